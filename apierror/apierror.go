@@ -0,0 +1,203 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/logging"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Code es el código estable de un error, pensado para que los clientes decidan
+// qué hacer sin interpretar el mensaje.
+type Code string
+
+const (
+	// CodeInvalidRequest indica que la petición está mal formada (JSON, parámetros, IDs).
+	CodeInvalidRequest Code = "invalid_request"
+	// CodeValidationFailed indica que algún campo no cumple las validaciones.
+	CodeValidationFailed Code = "validation_failed"
+	// CodeUnsupportedFileType indica que el tipo de archivo no está permitido.
+	CodeUnsupportedFileType Code = "unsupported_file_type"
+	// CodeUserNotFound indica que el usuario no existe.
+	CodeUserNotFound Code = "user_not_found"
+	// CodeTaskNotFound indica que la tarea no existe.
+	CodeTaskNotFound Code = "task_not_found"
+	// CodeTaskLegalHold indica que la tarea tiene retención legal y no se puede eliminar.
+	CodeTaskLegalHold Code = "task_legal_hold"
+	// CodeEmailTaken indica que ya existe un usuario con ese email.
+	CodeEmailTaken Code = "email_taken"
+	// CodeRateLimited indica que se superó el límite de peticiones.
+	CodeRateLimited Code = "rate_limited"
+	// CodeQuotaExceeded indica que se agotó la cuota diaria o mensual del usuario.
+	CodeQuotaExceeded Code = "quota_exceeded"
+	// CodeInvalidSchema indica que el esquema de respuesta enviado no es un JSON Schema válido.
+	CodeInvalidSchema Code = "invalid_schema"
+	// CodeSchemaNotFound indica que no hay un esquema registrado con ese nombre.
+	CodeSchemaNotFound Code = "schema_not_found"
+	// CodeToolNotFound indica que se pidió una herramienta que no está registrada.
+	CodeToolNotFound Code = "tool_not_found"
+	// CodeCollectionNotFound indica que la colección no existe.
+	CodeCollectionNotFound Code = "collection_not_found"
+	// CodeCollectionNameTaken indica que ya existe una colección con ese nombre.
+	CodeCollectionNameTaken Code = "collection_name_taken"
+	// CodeCollectionEmpty indica que la colección no tiene documentos indexados para responder.
+	CodeCollectionEmpty Code = "collection_empty"
+	// CodeDocumentNotFound indica que el documento no existe en la colección.
+	CodeDocumentNotFound Code = "document_not_found"
+	// CodeTemplateNotFound indica que la plantilla o la versión pedida no existe.
+	CodeTemplateNotFound Code = "template_not_found"
+	// CodeTemplateNameTaken indica que ya existe una plantilla con ese nombre.
+	CodeTemplateNameTaken Code = "template_name_taken"
+	// CodeInvalidTemplate indica que el cuerpo de la plantilla no tiene una sintaxis válida.
+	CodeInvalidTemplate Code = "invalid_template"
+	// CodeTemplateRenderFailed indica que la plantilla no se pudo generar con las variables enviadas.
+	CodeTemplateRenderFailed Code = "template_render_failed"
+	// CodeBatchNotFound indica que el lote no existe.
+	CodeBatchNotFound Code = "batch_not_found"
+	// CodeBatchNotFinished indica que el lote aún tiene tareas sin terminar.
+	CodeBatchNotFinished Code = "batch_not_finished"
+	// CodeBatchTooLarge indica que el lote supera la cantidad máxima de tareas.
+	CodeBatchTooLarge Code = "batch_too_large"
+	// CodeInvalidCSV indica que el archivo CSV no se pudo leer.
+	CodeInvalidCSV Code = "invalid_csv"
+	// CodeAPIKeyNotFound indica que la API key no existe o no es del usuario.
+	CodeAPIKeyNotFound Code = "api_key_not_found"
+	// CodeAPIKeyRevoked indica que la API key está revocada y no se puede rotar.
+	CodeAPIKeyRevoked Code = "api_key_revoked"
+	// CodeInvalidToken indica que el token enviado por correo no existe, ya se usó o venció.
+	CodeInvalidToken Code = "invalid_token"
+	// CodeEmailNotVerified indica que el usuario aún no confirmó su email.
+	CodeEmailNotVerified Code = "email_not_verified"
+	// CodeOIDCNotConfigured indica que el inicio de sesión con OIDC no está configurado.
+	CodeOIDCNotConfigured Code = "oidc_not_configured"
+	// CodeOIDCLoginFailed indica que el proveedor OIDC rechazó el inicio de sesión o su respuesta no es válida.
+	CodeOIDCLoginFailed Code = "oidc_login_failed"
+	// CodeOrgNotFound indica que la organización no existe.
+	CodeOrgNotFound Code = "org_not_found"
+	// CodeOrgSlugTaken indica que ya existe una organización con ese slug.
+	CodeOrgSlugTaken Code = "org_slug_taken"
+	// CodeOrgMemberNotFound indica que el usuario no es miembro de la organización.
+	CodeOrgMemberNotFound Code = "org_member_not_found"
+	// CodeOrgMemberExists indica que el usuario ya es miembro de la organización.
+	CodeOrgMemberExists Code = "org_member_exists"
+	// CodeOrgLastOwner indica que la operación dejaría a la organización sin owners.
+	CodeOrgLastOwner Code = "org_last_owner"
+	// CodeUnauthorized indica que la operación requiere identificar al usuario.
+	CodeUnauthorized Code = "unauthorized"
+	// CodeForbidden indica que el rol del usuario no tiene permiso para la operación.
+	CodeForbidden Code = "forbidden"
+	// CodeServerBusy indica que el servidor no puede aceptar más trabajo por ahora; se puede reintentar.
+	CodeServerBusy Code = "server_busy"
+	// CodeInternal indica un error inesperado del servidor.
+	CodeInternal Code = "internal_error"
+
+	// CodeSchemaValidationFailed indica que la respuesta de Gemini no cumple el esquema pedido.
+	CodeSchemaValidationFailed Code = "schema_validation_failed"
+	// CodeGeminiToolStepsExceeded indica que el modelo superó el máximo de pasos con herramientas.
+	CodeGeminiToolStepsExceeded Code = "gemini_tool_steps_exceeded"
+	// CodeDocumentExtractionFailed indica que no se pudo leer el texto de un documento.
+	CodeDocumentExtractionFailed Code = "document_extraction_failed"
+	// CodeDocumentEmpty indica que el documento no contiene texto extraíble.
+	CodeDocumentEmpty Code = "document_empty"
+	// CodeGeminiNotConfigured indica que falta la configuración de la API de Gemini.
+	CodeGeminiNotConfigured Code = "gemini_not_configured"
+	// CodeGeminiInvalidRequest indica que Gemini rechazó la petición (prompt o archivo inválido).
+	CodeGeminiInvalidRequest Code = "gemini_invalid_request"
+	// CodeGeminiPermissionDenied indica que la credencial de Gemini no es válida o no tiene permisos.
+	CodeGeminiPermissionDenied Code = "gemini_permission_denied"
+	// CodeGeminiRateLimited indica que se agotó la cuota de la API de Gemini.
+	CodeGeminiRateLimited Code = "gemini_rate_limited"
+	// CodeGeminiTimeout indica que Gemini no respondió a tiempo.
+	CodeGeminiTimeout Code = "gemini_timeout"
+	// CodeGeminiUnavailable indica que la API de Gemini no está disponible.
+	CodeGeminiUnavailable Code = "gemini_unavailable"
+	// CodeGeminiUploadFailed indica que no se pudo subir el archivo a Gemini.
+	CodeGeminiUploadFailed Code = "gemini_upload_failed"
+	// CodeGeminiError indica cualquier otro error al llamar a Gemini.
+	CodeGeminiError Code = "gemini_error"
+)
+
+// Error es un error de la API con su código HTTP y su código estable. El mensaje es
+// una clave del catálogo de i18n que se traduce al idioma de cada petición.
+type Error struct {
+	Status  int
+	Code    Code
+	Key     i18n.Key
+	Args    []interface{}
+	Details interface{}
+	// Err es la causa original; se registra en los logs pero nunca se envía al cliente.
+	Err error
+}
+
+// New crea un error de la API con el mensaje de la clave indicada.
+func New(status int, code Code, key i18n.Key, args ...interface{}) *Error {
+	return &Error{Status: status, Code: code, Key: key, Args: args}
+}
+
+// Wrap crea un error de la API conservando la causa original.
+func Wrap(err error, status int, code Code, key i18n.Key, args ...interface{}) *Error {
+	return &Error{Status: status, Code: code, Key: key, Args: args, Err: err}
+}
+
+// Message devuelve el mensaje del error en el idioma indicado.
+func (e *Error) Message(lang i18n.Lang) string {
+	return i18n.T(lang, e.Key, e.Args...)
+}
+
+// WithDetails devuelve una copia del error con información adicional para el cliente.
+func (e *Error) WithDetails(details interface{}) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message(i18n.Default), e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message(i18n.Default))
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Internal crea un error 500 a partir de una causa inesperada.
+func Internal(err error) *Error {
+	return Wrap(err, http.StatusInternalServerError, CodeInternal, i18n.ErrInternal)
+}
+
+// From convierte cualquier error en un *Error; los desconocidos se tratan como internos.
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return Internal(err)
+}
+
+// Response construye el cuerpo JSON del error en el idioma de la petición actual.
+func Response(c *gin.Context, err *Error) models.ErrorResponse {
+	return models.ErrorResponse{
+		Code:      string(err.Code),
+		Message:   err.Message(i18n.FromContext(c.Request.Context())),
+		Details:   err.Details,
+		RequestID: logging.RequestID(c.Request.Context()),
+	}
+}
+
+// Abort responde el error con el sobre estándar y detiene la cadena de handlers.
+// Los errores 5xx se registran con su causa original.
+func Abort(c *gin.Context, err error) {
+	apiErr := From(err)
+	if apiErr.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(c.Request.Context(), apiErr.Message(i18n.Default), "code", apiErr.Code, "error", apiErr.Err)
+	}
+	_ = c.Error(apiErr)
+	c.AbortWithStatusJSON(apiErr.Status, Response(c, apiErr))
+}
@@ -0,0 +1,83 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/logging"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/gin-gonic/gin"
+)
+
+// abortWith responde err con Abort en una petición con ID req-1 y devuelve la respuesta.
+func abortWith(t *testing.T, err error) (int, models.ErrorResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), "req-1"))
+		Abort(c, err)
+	})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	var body models.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("la respuesta no es el sobre de error: %v: %s", err, rec.Body.String())
+	}
+	return rec.Code, body
+}
+
+func TestAbortWritesTheEnvelope(t *testing.T) {
+	status, body := abortWith(t, New(http.StatusNotFound, CodeTaskNotFound, i18n.ErrTaskNotFound).WithDetails(gin.H{"id": "x"}))
+	if status != http.StatusNotFound || body.Code != "task_not_found" || body.RequestID != "req-1" {
+		t.Errorf("status=%d code=%q request_id=%q", status, body.Code, body.RequestID)
+	}
+	if body.Message != i18n.T(i18n.Default, i18n.ErrTaskNotFound) {
+		t.Errorf("message = %q", body.Message)
+	}
+	if details, _ := body.Details.(map[string]interface{}); details["id"] != "x" {
+		t.Errorf("details = %v", body.Details)
+	}
+}
+
+func TestUnknownErrorsAreInternalAndHideTheCause(t *testing.T) {
+	status, body := abortWith(t, errors.New("pq: contraseña de la base incorrecta"))
+	if status != http.StatusInternalServerError || body.Code != string(CodeInternal) {
+		t.Errorf("status=%d code=%q, se esperaba 500 internal_error", status, body.Code)
+	}
+	if strings.Contains(body.Message, "pq:") || body.Details != nil {
+		t.Errorf("la respuesta expone la causa: %+v", body)
+	}
+}
+
+func TestBindingListsTheInvalidFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var input models.CreateUserInput
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"full_name": "Ana", "email": "no-es-un-email"}`))
+	ctx.Request.Header.Set("Content-Type", "application/json")
+
+	err := Binding(ctx.ShouldBindJSON(&input))
+	if err.Status != http.StatusBadRequest || err.Code != CodeValidationFailed {
+		t.Fatalf("Binding = %d %s, se esperaba 400 validation_failed", err.Status, err.Code)
+	}
+	fields, _ := err.Details.([]FieldError)
+	got := map[string]string{}
+	for _, f := range fields {
+		got[f.Field] = f.Rule
+	}
+	if got["Email"] != "email" || got["Password"] != "required" || len(got) != 2 {
+		t.Errorf("campos inválidos = %+v", fields)
+	}
+
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"full_name": 3}`))
+	ctx.Request.Header.Set("Content-Type", "application/json")
+	if err := Binding(ctx.ShouldBindJSON(&input)); err.Code != CodeInvalidRequest {
+		t.Errorf("tipo inválido: código %s, se esperaba invalid_request", err.Code)
+	}
+}
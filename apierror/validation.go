@@ -0,0 +1,37 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describe un campo que no pasó la validación.
+type FieldError struct {
+	Field string `json:"field" example:"email"`
+	Rule  string `json:"rule" example:"email"`
+	Param string `json:"param,omitempty"`
+}
+
+// Binding traduce el error de ShouldBind* a un error de la API sin exponer la salida
+// del validador: los campos inválidos se listan en Details.
+func Binding(err error) *Error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, FieldError{Field: fe.Field(), Rule: fe.Tag(), Param: fe.Param()})
+		}
+		return Wrap(err, http.StatusBadRequest, CodeValidationFailed, i18n.ErrValidationFailed).WithDetails(fields)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return Wrap(err, http.StatusBadRequest, CodeInvalidRequest, i18n.ErrInvalidRequest).
+			WithDetails([]FieldError{{Field: typeErr.Field, Rule: "type", Param: typeErr.Type.String()}})
+	}
+	return Wrap(err, http.StatusBadRequest, CodeInvalidRequest, i18n.ErrInvalidRequest)
+}
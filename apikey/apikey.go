@@ -0,0 +1,41 @@
+// Package apikey genera las API keys y calcula el hash con el que se guardan. Una key tiene
+// la forma gak_<prefijo>_<secreto>: el prefijo es visible para reconocerla en los listados
+// y el secreto solo se muestra al crearla.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// Marker es el inicio de todas las API keys; permite distinguirlas de otros tokens Bearer.
+const Marker = "gak_"
+
+// Generate crea una key nueva y devuelve la key completa, su prefijo visible y su hash.
+func Generate() (key string, prefix string, hash string, err error) {
+	id := make([]byte, 4)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+	prefix = Marker + hex.EncodeToString(id)
+	key = prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return key, prefix, Hash(key), nil
+}
+
+// Hash devuelve el SHA-256 en hexadecimal de la key, que es lo que se guarda y se busca.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsKey indica si el valor tiene el formato de una API key.
+func IsKey(value string) bool {
+	return strings.HasPrefix(value, Marker)
+}
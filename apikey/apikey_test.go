@@ -0,0 +1,26 @@
+package apikey
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	key, prefix, hash, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if !IsKey(key) || !strings.HasPrefix(key, prefix+"_") || hash != Hash(key) {
+		t.Fatalf("key %q con prefijo %q y hash %q", key, prefix, hash)
+	}
+	if strings.Contains(hash, key) || len(hash) != 64 {
+		t.Errorf("el hash no es un SHA-256: %q", hash)
+	}
+	other, otherPrefix, _, _ := Generate()
+	if other == key || otherPrefix == prefix {
+		t.Error("dos keys generadas son iguales")
+	}
+	if IsKey("eyJhbGciOiJIUzI1NiJ9.e30.x") {
+		t.Error("IsKey aceptó un token que no es API key")
+	}
+}
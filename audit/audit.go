@@ -0,0 +1,124 @@
+// Package audit guarda el registro de auditoría: un evento por cada petición a una ruta
+// auditada (ver middleware.Audit), con los cambios que hizo en las tablas auditadas, que se
+// obtienen de los callbacks de GORM registrados con RegisterHooks.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"gorm.io/gorm"
+)
+
+type entryKey struct{}
+
+// Entry acumula los datos del evento de una petición mientras se atiende. Sus métodos
+// aceptan un Entry nil, para que los handlers puedan usarlos sin comprobar si la ruta se
+// audita.
+type Entry struct {
+	mu           sync.Mutex
+	resourceType string
+	resourceID   string
+	actorUserID  *uint
+	changes      []models.AuditChange
+}
+
+// NewEntry crea el evento de una operación sobre el recurso indicado; resourceID puede ser ""
+// si el recurso se crea en la petición.
+func NewEntry(resourceType, resourceID string) *Entry {
+	return &Entry{resourceType: resourceType, resourceID: resourceID}
+}
+
+// WithEntry guarda el evento en el contexto; los cambios hechos con ese contexto se añaden a él.
+func WithEntry(ctx context.Context, entry *Entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, entry)
+}
+
+// FromContext devuelve el evento guardado en el contexto, o nil si la petición no se audita.
+func FromContext(ctx context.Context) *Entry {
+	entry, _ := ctx.Value(entryKey{}).(*Entry)
+	return entry
+}
+
+// SetResource define el recurso del evento cuando la ruta no lo indica.
+func (e *Entry) SetResource(id string) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.resourceID = id
+}
+
+// SetActor define el usuario que actúa cuando la petición no está identificada, como en el
+// inicio de sesión o el restablecimiento de la contraseña.
+func (e *Entry) SetActor(userID uint) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.actorUserID = &userID
+}
+
+// Resource devuelve el tipo y el ID del recurso del evento.
+func (e *Entry) Resource() (string, string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.resourceType, e.resourceID
+}
+
+// Actor devuelve el usuario definido con SetActor, o nil.
+func (e *Entry) Actor() *uint {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.actorUserID
+}
+
+// Changes devuelve los cambios registrados, codificados para models.AuditEventDB.Changes.
+func (e *Entry) Changes() models.JSON {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.changes) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(e.changes)
+	if err != nil {
+		slog.Error("error al codificar los cambios del evento de auditoría", "error", err)
+		return nil
+	}
+	return data
+}
+
+// addChange añade un cambio a los del evento.
+func (e *Entry) addChange(resourceType string, change models.AuditChange) {
+	if change.Operation == OperationCreate {
+		e.setCreated(resourceType, change.ID)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.changes = append(e.changes, change)
+}
+
+// setCreated toma el ID de la fila creada como recurso del evento si es del tipo del evento y
+// la ruta no lo indicaba.
+func (e *Entry) setCreated(resourceType, id string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if resourceType == e.resourceType && e.resourceID == "" {
+		e.resourceID = id
+	}
+}
+
+// Record guarda un evento. Los errores solo se registran en el log: la auditoría no debe
+// hacer fallar una petición que ya se atendió.
+func Record(ctx context.Context, conn *gorm.DB, event models.AuditEventDB) {
+	// El evento se guarda aunque el cliente ya haya cerrado la conexión.
+	ctx = context.WithoutCancel(ctx)
+	if err := conn.WithContext(ctx).Create(&event).Error; err != nil {
+		slog.ErrorContext(ctx, "error al guardar el evento de auditoría", "action", event.Action, "error", err)
+	}
+}
@@ -0,0 +1,321 @@
+package audit
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Operaciones de models.AuditChange.
+const (
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+// auditedTables son las tablas cuyos cambios se registran, con el tipo de recurso de sus filas.
+var auditedTables = map[string]string{
+	models.UserDB{}.TableName():           "user",
+	models.APIKeyDB{}.TableName():         "api_key",
+	models.OrganizationDB{}.TableName():   "org",
+	models.OrgMemberDB{}.TableName():      "org_member",
+	models.CollectionDB{}.TableName():     "collection",
+	models.DocumentDB{}.TableName():       "document",
+	models.PromptTemplateDB{}.TableName(): "template",
+}
+
+// createdTables guardan prompts y resultados, por lo que sus cambios no se registran; de su
+// creación solo se toma el ID del recurso del evento.
+var createdTables = map[string]string{
+	models.GeminiProcessingDB{}.TableName():     "task",
+	models.GeminiProcessingFileDB{}.TableName(): "file_task",
+	models.BatchDB{}.TableName():                "batch",
+}
+
+// redactedColumns son las columnas con secretos: se registra que cambiaron, no su valor.
+var redactedColumns = map[string]bool{
+	"password": true,
+	"hash":     true,
+}
+
+// redacted reemplaza el valor de las columnas de redactedColumns.
+const redacted = "[REDACTED]"
+
+// ignoredColumns cambian en cada actualización y no aportan al registro.
+var ignoredColumns = map[string]bool{
+	"updated_at": true,
+}
+
+// maxRows limita las filas de una actualización o eliminación cuyo estado anterior se guarda.
+const maxRows = 100
+
+// beforeKey es la clave de la sesión de GORM donde se guardan las filas antes del cambio.
+const beforeKey = "audit:before"
+
+// RegisterHooks registra los callbacks de GORM que añaden al evento de la petición (ver
+// WithEntry) los cambios en las tablas auditadas. Sin evento en el contexto, como en los
+// workers, no hacen nada.
+func RegisterHooks(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().After("gorm:create").Register("audit:after_create", afterCreate); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("audit:before_update", loadBefore); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("audit:after_update", afterUpdate); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("audit:before_delete", loadBefore); err != nil {
+		return err
+	}
+	return callbacks.Delete().After("gorm:delete").Register("audit:after_delete", afterDelete)
+}
+
+// audited devuelve el evento de la petición y el tipo de recurso de la tabla, o nil si el
+// cambio no se registra.
+func audited(tx *gorm.DB) (*Entry, string) {
+	stmt := tx.Statement
+	if stmt.Schema == nil || stmt.Context == nil {
+		return nil, ""
+	}
+	resourceType, ok := auditedTables[stmt.Schema.Table]
+	if !ok {
+		return nil, ""
+	}
+	return FromContext(stmt.Context), resourceType
+}
+
+// row es una fila de una tabla auditada: su clave primaria y el valor de cada columna.
+type row struct {
+	id     string
+	values map[string]interface{}
+}
+
+func afterCreate(tx *gorm.DB) {
+	stmt := tx.Statement
+	if tx.Error != nil || stmt.Schema == nil || stmt.Context == nil {
+		return
+	}
+	if resourceType, ok := createdTables[stmt.Schema.Table]; ok {
+		if entry := FromContext(stmt.Context); entry != nil && stmt.ReflectValue.Kind() == reflect.Struct {
+			if id, ok := primaryKey(stmt, stmt.ReflectValue); ok {
+				entry.setCreated(resourceType, id)
+			}
+		}
+		return
+	}
+	entry, resourceType := audited(tx)
+	if entry == nil {
+		return
+	}
+	for _, r := range rowsOf(tx.Statement, tx.Statement.ReflectValue) {
+		entry.addChange(resourceType, models.AuditChange{
+			Table:     tx.Statement.Schema.Table,
+			ID:        r.id,
+			Operation: OperationCreate,
+			After:     redact(r.values),
+		})
+	}
+}
+
+// loadBefore lee de la base de datos las filas que va a modificar o eliminar la sentencia.
+func loadBefore(tx *gorm.DB) {
+	entry, _ := audited(tx)
+	if entry == nil || tx.Error != nil {
+		return
+	}
+	stmt := tx.Statement
+	query := reload(tx)
+	if where, ok := stmt.Clauses["WHERE"]; ok {
+		if expr, ok := where.Expression.(clause.Where); ok {
+			query = query.Clauses(expr)
+		}
+	}
+	if stmt.ReflectValue.Kind() == reflect.Struct {
+		if id, ok := primaryKey(stmt, stmt.ReflectValue); ok {
+			query = query.Where(clause.Eq{Column: clause.Column{Name: stmt.Schema.PrioritizedPrimaryField.DBName}, Value: id})
+		} else if _, ok := stmt.Clauses["WHERE"]; !ok {
+			// Sin condiciones GORM rechaza la sentencia (ErrMissingWhereClause).
+			return
+		}
+	}
+	rows, err := find(stmt, query.Limit(maxRows))
+	if err != nil {
+		slog.ErrorContext(stmt.Context, "error al leer las filas auditadas", "table", stmt.Schema.Table, "error", err)
+		return
+	}
+	tx.InstanceSet(beforeKey, rows)
+}
+
+func afterUpdate(tx *gorm.DB) {
+	entry, resourceType := audited(tx)
+	if entry == nil || tx.Error != nil || tx.RowsAffected == 0 {
+		return
+	}
+	stmt := tx.Statement
+	before := beforeRows(tx)
+	if len(before) == 0 {
+		// Sin el estado anterior solo se conocen los valores enviados en la actualización.
+		if values, ok := stmt.Dest.(map[string]interface{}); ok {
+			entry.addChange(resourceType, models.AuditChange{
+				Table:     stmt.Schema.Table,
+				Operation: OperationUpdate,
+				After:     redact(values),
+			})
+		}
+		return
+	}
+	ids := make([]interface{}, 0, len(before))
+	for _, r := range before {
+		ids = append(ids, r.id)
+	}
+	after, err := find(stmt, reload(tx).Where(clause.IN{Column: clause.Column{Name: stmt.Schema.PrioritizedPrimaryField.DBName}, Values: ids}))
+	if err != nil {
+		slog.ErrorContext(stmt.Context, "error al leer las filas auditadas", "table", stmt.Schema.Table, "error", err)
+		return
+	}
+	current := make(map[string]map[string]interface{}, len(after))
+	for _, r := range after {
+		current[r.id] = r.values
+	}
+	for _, r := range before {
+		old, updated := diff(r.values, current[r.id])
+		if len(updated) == 0 {
+			continue
+		}
+		entry.addChange(resourceType, models.AuditChange{
+			Table:     stmt.Schema.Table,
+			ID:        r.id,
+			Operation: OperationUpdate,
+			Before:    old,
+			After:     updated,
+		})
+	}
+}
+
+func afterDelete(tx *gorm.DB) {
+	entry, resourceType := audited(tx)
+	if entry == nil || tx.Error != nil || tx.RowsAffected == 0 {
+		return
+	}
+	for _, r := range beforeRows(tx) {
+		entry.addChange(resourceType, models.AuditChange{
+			Table:     tx.Statement.Schema.Table,
+			ID:        r.id,
+			Operation: OperationDelete,
+			Before:    redact(r.values),
+		})
+	}
+}
+
+// reload devuelve una consulta nueva en la misma transacción que la sentencia, incluyendo las
+// filas eliminadas con soft delete (para registrar las restauraciones).
+func reload(tx *gorm.DB) *gorm.DB {
+	return tx.Session(&gorm.Session{NewDB: true}).Unscoped()
+}
+
+// find ejecuta la consulta y devuelve las filas leídas.
+func find(stmt *gorm.Statement, query *gorm.DB) ([]row, error) {
+	dest := reflect.New(reflect.SliceOf(stmt.Schema.ModelType))
+	if err := query.Find(dest.Interface()).Error; err != nil {
+		return nil, err
+	}
+	return rowsOf(stmt, dest.Elem()), nil
+}
+
+func beforeRows(tx *gorm.DB) []row {
+	value, ok := tx.InstanceGet(beforeKey)
+	if !ok {
+		return nil
+	}
+	rows, _ := value.([]row)
+	return rows
+}
+
+// rowsOf devuelve las filas de un struct o de un slice de structs del modelo de la sentencia.
+func rowsOf(stmt *gorm.Statement, value reflect.Value) []row {
+	value = reflect.Indirect(value)
+	switch value.Kind() {
+	case reflect.Struct:
+		return []row{snapshot(stmt, value)}
+	case reflect.Slice, reflect.Array:
+		rows := make([]row, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			if item := reflect.Indirect(value.Index(i)); item.Kind() == reflect.Struct {
+				rows = append(rows, snapshot(stmt, item))
+			}
+		}
+		return rows
+	}
+	return nil
+}
+
+func snapshot(stmt *gorm.Statement, value reflect.Value) row {
+	values := make(map[string]interface{}, len(stmt.Schema.DBNames))
+	for _, name := range stmt.Schema.DBNames {
+		field := stmt.Schema.FieldsByDBName[name]
+		v, _ := field.ValueOf(stmt.Context, value)
+		values[name] = jsonValue(v)
+	}
+	id, _ := primaryKey(stmt, value)
+	return row{id: id, values: values}
+}
+
+// primaryKey devuelve la clave primaria de la fila, o false si no tiene (aún no se creó).
+func primaryKey(stmt *gorm.Statement, value reflect.Value) (string, bool) {
+	field := stmt.Schema.PrioritizedPrimaryField
+	if field == nil {
+		return "", false
+	}
+	v, zero := field.ValueOf(stmt.Context, value)
+	if zero {
+		return "", false
+	}
+	return fmt.Sprint(v), true
+}
+
+// jsonValue adapta los valores de las columnas para guardarlos en el JSON del evento.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case models.JSON:
+		if len(v) == 0 {
+			return nil
+		}
+		return v
+	case gorm.DeletedAt:
+		if !v.Valid {
+			return nil
+		}
+		return v.Time
+	}
+	return v
+}
+
+// diff devuelve los valores anteriores y nuevos de las columnas que cambiaron.
+func diff(before, after map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	old, updated := map[string]interface{}{}, map[string]interface{}{}
+	for name, value := range after {
+		if ignoredColumns[name] || reflect.DeepEqual(before[name], value) {
+			continue
+		}
+		old[name], updated[name] = before[name], value
+	}
+	return redact(old), redact(updated)
+}
+
+// redact devuelve una copia de los valores sin los de las columnas de redactedColumns.
+func redact(values map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		if redactedColumns[name] {
+			value = redacted
+		}
+		copied[name] = value
+	}
+	return copied
+}
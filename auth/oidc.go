@@ -0,0 +1,457 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"golang.org/x/oauth2"
+)
+
+// Margen de tolerancia con el reloj del proveedor al validar exp e iat.
+const clockSkew = time.Minute
+
+// jwksRefreshInterval es el tiempo mínimo entre dos descargas de las claves del proveedor
+// cuando llega un ID token firmado con una clave desconocida.
+const jwksRefreshInterval = time.Minute
+
+// rolePrecedence es el orden en que se elige el rol cuando varios grupos del usuario
+// tienen un rol asignado: gana el de más permisos.
+var rolePrecedence = []models.Role{models.RoleAdmin, models.RoleMember, models.RoleService, models.RoleViewer}
+
+// OIDCConfig es la configuración del inicio de sesión con un proveedor OpenID Connect.
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// GroupsClaim es el claim del ID token con los grupos del usuario.
+	GroupsClaim string
+	// GroupRoles asigna un rol a cada grupo del proveedor.
+	GroupRoles map[string]models.Role
+	// DefaultRole es el rol de los usuarios nuevos que no pertenecen a ningún grupo de GroupRoles.
+	DefaultRole models.Role
+}
+
+// LoadOIDCConfig lee la configuración de OIDC_ISSUER, OIDC_CLIENT_ID, OIDC_CLIENT_SECRET,
+// OIDC_REDIRECT_URL, OIDC_SCOPES (por defecto "openid email profile"), OIDC_GROUPS_CLAIM
+// (por defecto groups), OIDC_ROLE_MAPPING ("grupo=rol,grupo=rol") y OIDC_DEFAULT_ROLE (por
+// defecto member). Sin OIDC_ISSUER devuelve nil: el inicio de sesión con OIDC queda desactivado.
+func LoadOIDCConfig() (*OIDCConfig, error) {
+	issuer := strings.TrimRight(os.Getenv("OIDC_ISSUER"), "/")
+	if issuer == "" {
+		return nil, nil
+	}
+	cfg := &OIDCConfig{
+		Issuer:       issuer,
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		Scopes:       strings.Fields(os.Getenv("OIDC_SCOPES")),
+		GroupsClaim:  os.Getenv("OIDC_GROUPS_CLAIM"),
+		GroupRoles:   map[string]models.Role{},
+		DefaultRole:  models.Role(os.Getenv("OIDC_DEFAULT_ROLE")),
+	}
+	if cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, errors.New("OIDC_ISSUER requiere OIDC_CLIENT_ID y OIDC_REDIRECT_URL")
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	if !slices.Contains(cfg.Scopes, "openid") {
+		cfg.Scopes = append([]string{"openid"}, cfg.Scopes...)
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	if cfg.DefaultRole == "" {
+		cfg.DefaultRole = models.RoleMember
+	}
+	if !cfg.DefaultRole.Valid() {
+		return nil, fmt.Errorf("OIDC_DEFAULT_ROLE inválido: %q", cfg.DefaultRole)
+	}
+	for _, pair := range strings.Split(os.Getenv("OIDC_ROLE_MAPPING"), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		group, role, ok := strings.Cut(pair, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !ok || group == "" || !models.Role(role).Valid() {
+			return nil, fmt.Errorf("OIDC_ROLE_MAPPING inválido: %q", pair)
+		}
+		cfg.GroupRoles[group] = models.Role(role)
+	}
+	return cfg, nil
+}
+
+// OIDCClaims son los datos del usuario que se toman del ID token.
+type OIDCClaims struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Groups        []string
+}
+
+// OIDCProvider inicia sesión con el flujo authorization code + PKCE y valida los ID tokens
+// con las claves publicadas por el proveedor. La configuración del proveedor se descubre en
+// el primer uso, para que la API arranque aunque el proveedor aún no esté disponible.
+type OIDCProvider struct {
+	config OIDCConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]crypto.PublicKey
+	keysAt    time.Time
+}
+
+// oidcDiscovery es la parte usada de /.well-known/openid-configuration.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// NewOIDCProvider crea el proveedor con la configuración indicada.
+func NewOIDCProvider(config OIDCConfig) *OIDCProvider {
+	return &OIDCProvider{config: config, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Role devuelve el rol asignado a los grupos del usuario; ok=false si ningún grupo tiene rol.
+func (p *OIDCProvider) Role(groups []string) (models.Role, bool) {
+	for _, role := range rolePrecedence {
+		for _, group := range groups {
+			if p.config.GroupRoles[group] == role {
+				return role, true
+			}
+		}
+	}
+	return "", false
+}
+
+// DefaultRole es el rol de los usuarios nuevos sin un grupo con rol asignado.
+func (p *OIDCProvider) DefaultRole() models.Role {
+	return p.config.DefaultRole
+}
+
+// SecureCookies indica si las cookies del inicio de sesión deben ser Secure: cuando el
+// callback se sirve por HTTPS.
+func (p *OIDCProvider) SecureCookies() bool {
+	return strings.HasPrefix(p.config.RedirectURL, "https://")
+}
+
+// OIDCLogin es un inicio de sesión recién empezado. State, Nonce y Verifier se guardan hasta
+// el callback; URL es la página del proveedor a la que se redirige al usuario.
+type OIDCLogin struct {
+	State    string
+	Nonce    string
+	Verifier string
+	URL      string
+}
+
+// StartLogin genera el state, el nonce y el code_verifier de PKCE de un inicio de sesión y la
+// URL del proveedor. Al proveedor solo se envía el SHA-256 del verifier (PKCE S256).
+func (p *OIDCProvider) StartLogin(ctx context.Context) (OIDCLogin, error) {
+	oauth, err := p.oauthConfig(ctx)
+	if err != nil {
+		return OIDCLogin{}, err
+	}
+	state, _, err := NewToken()
+	if err != nil {
+		return OIDCLogin{}, err
+	}
+	nonce, _, err := NewToken()
+	if err != nil {
+		return OIDCLogin{}, err
+	}
+	login := OIDCLogin{State: state, Nonce: nonce, Verifier: oauth2.GenerateVerifier()}
+	login.URL = oauth.AuthCodeURL(state, oauth2.S256ChallengeOption(login.Verifier), oauth2.SetAuthURLParam("nonce", nonce))
+	return login, nil
+}
+
+// Exchange canjea el código del callback por los tokens del usuario y devuelve los datos del
+// ID token, después de validar su firma, emisor, audiencia, vigencia y nonce.
+func (p *OIDCProvider) Exchange(ctx context.Context, code string, verifier string, nonce string) (OIDCClaims, error) {
+	oauth, err := p.oauthConfig(ctx)
+	if err != nil {
+		return OIDCClaims{}, err
+	}
+	token, err := oauth.Exchange(context.WithValue(ctx, oauth2.HTTPClient, p.client), code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return OIDCClaims{}, fmt.Errorf("canje del código: %w", err)
+	}
+	rawIDToken, _ := token.Extra("id_token").(string)
+	if rawIDToken == "" {
+		return OIDCClaims{}, errors.New("la respuesta del proveedor no incluye id_token")
+	}
+	return p.verifyIDToken(ctx, rawIDToken, nonce)
+}
+
+// oauthConfig arma la configuración de OAuth2 con los endpoints descubiertos.
+func (p *OIDCProvider) oauthConfig(ctx context.Context) (oauth2.Config, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return oauth2.Config{}, err
+	}
+	return oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Scopes:       p.config.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  discovery.AuthorizationEndpoint,
+			TokenURL: discovery.TokenEndpoint,
+		},
+	}, nil
+}
+
+// discover descarga la configuración del proveedor la primera vez y la conserva.
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+	var discovery oidcDiscovery
+	if err := p.getJSON(ctx, p.config.Issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("configuración del proveedor OIDC: %w", err)
+	}
+	if strings.TrimRight(discovery.Issuer, "/") != p.config.Issuer {
+		return nil, fmt.Errorf("el proveedor OIDC informa el emisor %q en lugar de %q", discovery.Issuer, p.config.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("la configuración del proveedor OIDC está incompleta")
+	}
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// getJSON hace un GET y decodifica la respuesta JSON.
+func (p *OIDCProvider) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: estado %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// idTokenHeader es la cabecera de un JWT.
+type idTokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// idTokenPayload son los claims estándar del ID token; los grupos se leen aparte porque el
+// nombre del claim es configurable.
+type idTokenPayload struct {
+	Issuer        string          `json:"iss"`
+	Subject       string          `json:"sub"`
+	Audience      json.RawMessage `json:"aud"`
+	Expiry        int64           `json:"exp"`
+	IssuedAt      int64           `json:"iat"`
+	Nonce         string          `json:"nonce"`
+	Email         string          `json:"email"`
+	EmailVerified any             `json:"email_verified"`
+	Name          string          `json:"name"`
+}
+
+// verifyIDToken valida el ID token y devuelve sus claims.
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, raw string, nonce string) (OIDCClaims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return OIDCClaims{}, errors.New("el ID token no es un JWT")
+	}
+	var header idTokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return OIDCClaims{}, fmt.Errorf("cabecera del ID token: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return OIDCClaims{}, fmt.Errorf("firma del ID token: %w", err)
+	}
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return OIDCClaims{}, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return OIDCClaims{}, err
+	}
+
+	var payload idTokenPayload
+	if err := decodeSegment(parts[1], &payload); err != nil {
+		return OIDCClaims{}, fmt.Errorf("claims del ID token: %w", err)
+	}
+	now := time.Now()
+	switch {
+	case strings.TrimRight(payload.Issuer, "/") != p.config.Issuer:
+		return OIDCClaims{}, fmt.Errorf("emisor del ID token inesperado: %q", payload.Issuer)
+	case !audienceContains(payload.Audience, p.config.ClientID):
+		return OIDCClaims{}, errors.New("el ID token no está dirigido a este cliente")
+	case now.After(time.Unix(payload.Expiry, 0).Add(clockSkew)):
+		return OIDCClaims{}, errors.New("el ID token venció")
+	case time.Unix(payload.IssuedAt, 0).After(now.Add(clockSkew)):
+		return OIDCClaims{}, errors.New("el ID token se emitió en el futuro")
+	case payload.Nonce != nonce:
+		return OIDCClaims{}, errors.New("el nonce del ID token no coincide")
+	case payload.Subject == "":
+		return OIDCClaims{}, errors.New("el ID token no tiene sub")
+	}
+
+	var extra map[string]json.RawMessage
+	if err := decodeSegment(parts[1], &extra); err != nil {
+		return OIDCClaims{}, fmt.Errorf("claims del ID token: %w", err)
+	}
+	return OIDCClaims{
+		Issuer:        p.config.Issuer,
+		Subject:       payload.Subject,
+		Email:         models.NormalizeEmail(payload.Email),
+		EmailVerified: payload.EmailVerified == true || payload.EmailVerified == "true",
+		Name:          payload.Name,
+		Groups:        stringList(extra[p.config.GroupsClaim]),
+	}, nil
+}
+
+// key devuelve la clave pública kid del proveedor. Si no la conoce vuelve a descargar las
+// claves, como mucho una vez por jwksRefreshInterval, para seguir al proveedor cuando las rota.
+func (p *OIDCProvider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("clave de firma desconocida: %q", kid)
+	}
+	var set jwkSet
+	if err := p.getJSON(ctx, discovery.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("claves del proveedor OIDC: %w", err)
+	}
+	p.keys, p.keysAt = set.publicKeys(), time.Now()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("clave de firma desconocida: %q", kid)
+}
+
+// jwkSet es el documento de jwks_uri.
+type jwkSet struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	} `json:"keys"`
+}
+
+// publicKeys devuelve las claves de firma RSA y EC P-256 por kid; omite las demás.
+func (s jwkSet) publicKeys() map[string]crypto.PublicKey {
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range s.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(k.N)
+			e, errE := base64.RawURLEncoding.DecodeString(k.E)
+			if errN != nil || errE != nil {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			x, errX := base64.RawURLEncoding.DecodeString(k.X)
+			y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+			if k.Crv != "P-256" || errX != nil || errY != nil {
+				continue
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+	return keys
+}
+
+// verifySignature valida la firma RS256 o ES256 del JWT.
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], signature) != nil {
+			return errors.New("firma del ID token inválida")
+		}
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return errors.New("firma del ID token inválida")
+		}
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecKey, digest[:], r, s) {
+			return errors.New("firma del ID token inválida")
+		}
+	default:
+		return fmt.Errorf("algoritmo de firma no soportado: %q", alg)
+	}
+	return nil
+}
+
+// decodeSegment decodifica una parte base64url de un JWT.
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// audienceContains indica si aud (un string o una lista) incluye al cliente.
+func audienceContains(aud json.RawMessage, clientID string) bool {
+	return slices.Contains(stringList(aud), clientID)
+}
+
+// stringList lee un claim que puede ser un string o una lista de strings.
+func stringList(raw json.RawMessage) []string {
+	var list []string
+	if json.Unmarshal(raw, &list) == nil {
+		return list
+	}
+	var single string
+	if json.Unmarshal(raw, &single) == nil && single != "" {
+		return []string{single}
+	}
+	return nil
+}
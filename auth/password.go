@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// MaxPasswordLength es el largo máximo de una contraseña en bytes: bcrypt no admite más.
+const MaxPasswordLength = 72
+
+// HashPassword devuelve el hash bcrypt de la contraseña, que es lo único que se guarda.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword indica si la contraseña corresponde al hash guardado.
+func CheckPassword(hash string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// HashStoredPasswords reemplaza por su hash las contraseñas que las versiones anteriores
+// guardaban en texto plano, incluidas las de usuarios eliminados. Devuelve cuántas cambió.
+// Las vacías (usuarios creados con OIDC) se dejan así: ninguna contraseña coincide con ellas.
+func HashStoredPasswords(ctx context.Context, conn *gorm.DB) (int, error) {
+	var users []models.UserDB
+	err := conn.WithContext(ctx).Unscoped().Select("id", "password").
+		Where("password <> '' AND password NOT LIKE ?", "$2%").
+		Find(&users).Error
+	if err != nil {
+		return 0, err
+	}
+	changed := 0
+	for _, user := range users {
+		hash, err := HashPassword(user.Password)
+		if err != nil {
+			// bcrypt no admite más de 72 bytes: la contraseña queda inválida y el usuario
+			// tendrá que recuperarla.
+			hash = "!"
+		}
+		err = conn.WithContext(ctx).Unscoped().Model(&models.UserDB{}).
+			Where("id = ? AND password = ?", user.ID, user.Password).
+			Update("password", hash).Error
+		if err != nil {
+			return changed, err
+		}
+		changed++
+	}
+	return changed, nil
+}
@@ -0,0 +1,27 @@
+// Package auth reúne lo necesario para identificar a los usuarios: los tokens de un solo
+// uso que se envían por correo (verificación de email y recuperación de contraseña) y el
+// inicio de sesión con un proveedor OpenID Connect.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewToken genera un token aleatorio para enviar al usuario y el hash con el que se guarda.
+func NewToken() (token string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// HashToken devuelve el SHA-256 en hexadecimal del token, que es lo que se guarda y se busca.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,101 @@
+// Package cache guarda las respuestas de Gemini para reutilizarlas cuando llega de nuevo
+// el mismo prompt con los mismos parámetros de generación. Está desactivado por defecto.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Valores por defecto de la configuración.
+const (
+	defaultTTL        = time.Hour
+	defaultMaxEntries = 1000
+)
+
+// Entry es una respuesta guardada.
+type Entry struct {
+	Text  string
+	JSON  json.RawMessage
+	Model string
+}
+
+// Store guarda las respuestas por clave hasta que vencen o se alcanza el máximo de entradas.
+type Store interface {
+	// Get devuelve la respuesta guardada con la clave; ok es false si no existe o ya venció.
+	Get(ctx context.Context, key string) (entry Entry, ok bool, err error)
+	// Set guarda la respuesta con la clave, reemplazando la anterior.
+	Set(ctx context.Context, key string, entry Entry) error
+}
+
+// KeyParts son los datos que determinan la respuesta de Gemini y forman la clave del caché.
+type KeyParts struct {
+	// OrgID separa el caché de cada organización: una respuesta nunca se comparte con otra.
+	OrgID             *uint           `json:"org_id,omitempty"`
+	Model             string          `json:"model"`
+	Prompt            string          `json:"prompt"`
+	SystemInstruction string          `json:"system_instruction,omitempty"`
+	ResponseSchema    json.RawMessage `json:"response_schema,omitempty"`
+	// FileSHA256 y FileType identifican el archivo de las tareas con archivo.
+	FileSHA256 string `json:"file_sha256,omitempty"`
+	FileType   string `json:"file_type,omitempty"`
+}
+
+// Key devuelve la clave del caché: el SHA-256 de las partes con el prompt normalizado
+// (sin espacios al inicio o al final y con los espacios consecutivos reducidos a uno).
+func Key(parts KeyParts) string {
+	parts.Prompt = strings.Join(strings.Fields(parts.Prompt), " ")
+	parts.SystemInstruction = strings.Join(strings.Fields(parts.SystemInstruction), " ")
+	encoded, _ := json.Marshal(parts)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// FileSHA256 devuelve el SHA-256 en hexadecimal del contenido de un archivo.
+func FileSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// New crea el Store configurado en RESPONSE_CACHE_STORE ("memory" o "postgres"), con la
+// duración de RESPONSE_CACHE_TTL (por defecto 1h) y el máximo de entradas de
+// RESPONSE_CACHE_MAX_ENTRIES (por defecto 1000). Sin RESPONSE_CACHE_STORE devuelve nil:
+// el caché está desactivado.
+func New(db *gorm.DB) (Store, error) {
+	ttl := defaultTTL
+	if v := os.Getenv("RESPONSE_CACHE_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("RESPONSE_CACHE_TTL inválido: %q", v)
+		}
+		ttl = d
+	}
+	maxEntries := defaultMaxEntries
+	if v := os.Getenv("RESPONSE_CACHE_MAX_ENTRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("RESPONSE_CACHE_MAX_ENTRIES inválido: %q", v)
+		}
+		maxEntries = n
+	}
+
+	switch store := os.Getenv("RESPONSE_CACHE_STORE"); store {
+	case "":
+		return nil, nil
+	case "memory":
+		return NewMemoryStore(maxEntries, ttl), nil
+	case "postgres":
+		return NewPostgresStore(db, maxEntries, ttl), nil
+	default:
+		return nil, fmt.Errorf("RESPONSE_CACHE_STORE no soportado: %s", store)
+	}
+}
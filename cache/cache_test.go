@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/db/dbtest"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+)
+
+func TestKeyNormalizesWhitespaceAndSeparatesOrganizations(t *testing.T) {
+	orgA, orgB := uint(1), uint(2)
+	base := Key(KeyParts{OrgID: &orgA, Model: "m", Prompt: "Resume el texto"})
+	if got := Key(KeyParts{OrgID: &orgA, Model: "m", Prompt: "  Resume   el\ttexto\n"}); got != base {
+		t.Error("los espacios del prompt cambiaron la clave")
+	}
+	for name, parts := range map[string]KeyParts{
+		"organización":     {OrgID: &orgB, Model: "m", Prompt: "Resume el texto"},
+		"modelo":           {OrgID: &orgA, Model: "otro", Prompt: "Resume el texto"},
+		"prompt":           {OrgID: &orgA, Model: "m", Prompt: "Resume el libro"},
+		"instrucción":      {OrgID: &orgA, Model: "m", Prompt: "Resume el texto", SystemInstruction: "En inglés"},
+		"esquema":          {OrgID: &orgA, Model: "m", Prompt: "Resume el texto", ResponseSchema: []byte(`{"type":"object"}`)},
+		"archivo":          {OrgID: &orgA, Model: "m", Prompt: "Resume el texto", FileSHA256: FileSHA256([]byte("a"))},
+		"sin organización": {Model: "m", Prompt: "Resume el texto"},
+	} {
+		if Key(parts) == base {
+			t.Errorf("cambiar %s no cambió la clave", name)
+		}
+	}
+}
+
+func TestMemoryStoreExpiresAndEvictsTheLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	s := NewMemoryStore(2, time.Minute)
+	s.now = func() time.Time { return now }
+
+	s.Set(ctx, "a", Entry{Text: "A"})
+	s.Set(ctx, "b", Entry{Text: "B"})
+	if entry, ok, _ := s.Get(ctx, "a"); !ok || entry.Text != "A" {
+		t.Fatalf("Get(a) = %+v, %v", entry, ok)
+	}
+	// "b" es la menos usada: se descarta al superar el máximo.
+	s.Set(ctx, "c", Entry{Text: "C"})
+	if _, ok, _ := s.Get(ctx, "b"); ok {
+		t.Error("la entrada menos usada no se descartó")
+	}
+	if _, ok, _ := s.Get(ctx, "a"); !ok {
+		t.Error("se descartó una entrada usada recientemente")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok, _ := s.Get(ctx, "c"); ok {
+		t.Error("la entrada vencida se siguió devolviendo")
+	}
+}
+
+func TestNewRejectsInvalidConfiguration(t *testing.T) {
+	t.Setenv("RESPONSE_CACHE_STORE", "")
+	if s, err := New(nil); s != nil || err != nil {
+		t.Errorf("sin RESPONSE_CACHE_STORE: %v, %v; el caché debe quedar desactivado", s, err)
+	}
+	t.Setenv("RESPONSE_CACHE_STORE", "memory")
+	if s, err := New(nil); err != nil || s == nil {
+		t.Errorf("RESPONSE_CACHE_STORE=memory: %v, %v", s, err)
+	}
+	for env, value := range map[string]string{
+		"RESPONSE_CACHE_STORE":       "redis",
+		"RESPONSE_CACHE_TTL":         "-1h",
+		"RESPONSE_CACHE_MAX_ENTRIES": "cero",
+	} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv(env, value)
+			if _, err := New(nil); err == nil {
+				t.Errorf("%s=%s no devolvió error", env, value)
+			}
+		})
+	}
+}
+
+func TestPostgresStore(t *testing.T) {
+	conn := dbtest.Postgres(t, &models.ResponseCacheDB{})
+	ctx := context.Background()
+	s := NewPostgresStore(conn, 10, time.Minute)
+
+	if _, ok, err := s.Get(ctx, "k"); ok || err != nil {
+		t.Fatalf("Get de una clave inexistente: %v, %v", ok, err)
+	}
+	if err := s.Set(ctx, "k", Entry{Text: "uno", Model: "m"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := s.Set(ctx, "k", Entry{Text: "dos", JSON: []byte(`{"a":1}`), Model: "m"}); err != nil {
+		t.Fatalf("Set de una clave existente: %v", err)
+	}
+	entry, ok, err := s.Get(ctx, "k")
+	if err != nil || !ok || entry.Text != "dos" || string(entry.JSON) != `{"a": 1}` && string(entry.JSON) != `{"a":1}` {
+		t.Fatalf("Get = %+v, %v, %v", entry, ok, err)
+	}
+
+	conn.Model(&models.ResponseCacheDB{}).Where("key = ?", "k").Update("expires_at", time.Now().Add(-time.Second))
+	if _, ok, _ := s.Get(ctx, "k"); ok {
+		t.Error("la entrada vencida se siguió devolviendo")
+	}
+}
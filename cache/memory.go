@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	key       string
+	entry     Entry
+	expiresAt time.Time
+}
+
+// MemoryStore guarda las respuestas en memoria del proceso. Al superar el máximo de
+// entradas se descarta la que lleva más tiempo sin usarse.
+type MemoryStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	order      *list.List // de la más a la menos usada
+	entries    map[string]*list.Element
+	now        func() time.Time
+}
+
+// NewMemoryStore crea un Store en memoria.
+func NewMemoryStore(maxEntries int, ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// Get devuelve la respuesta guardada con la clave.
+func (s *MemoryStore) Get(_ context.Context, key string) (Entry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return Entry{}, false, nil
+	}
+	e := elem.Value.(*memoryEntry)
+	if s.now().After(e.expiresAt) {
+		s.order.Remove(elem)
+		delete(s.entries, key)
+		return Entry{}, false, nil
+	}
+	s.order.MoveToFront(elem)
+	return e.entry, true, nil
+}
+
+// Set guarda la respuesta con la clave.
+func (s *MemoryStore) Set(_ context.Context, key string, entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt := s.now().Add(s.ttl)
+	if elem, ok := s.entries[key]; ok {
+		elem.Value = &memoryEntry{key: key, entry: entry, expiresAt: expiresAt}
+		s.order.MoveToFront(elem)
+		return nil
+	}
+	s.entries[key] = s.order.PushFront(&memoryEntry{key: key, entry: entry, expiresAt: expiresAt})
+	for s.order.Len() > s.maxEntries {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*memoryEntry).key)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pruneEvery es cada cuántas escrituras se eliminan las entradas vencidas y las que
+// superan el máximo.
+const pruneEvery = 100
+
+// PostgresStore guarda las respuestas en PostgreSQL para compartirlas entre réplicas.
+// Al superar el máximo de entradas se eliminan las que llevan más tiempo sin usarse.
+type PostgresStore struct {
+	db         *gorm.DB
+	maxEntries int
+	ttl        time.Duration
+	writes     atomic.Int64
+}
+
+// NewPostgresStore crea un Store respaldado por la tabla gemini.response_cache.
+func NewPostgresStore(db *gorm.DB, maxEntries int, ttl time.Duration) *PostgresStore {
+	return &PostgresStore{db: db, maxEntries: maxEntries, ttl: ttl}
+}
+
+// Get devuelve la respuesta guardada con la clave y registra el uso.
+func (s *PostgresStore) Get(ctx context.Context, key string) (Entry, bool, error) {
+	var row models.ResponseCacheDB
+	err := s.db.WithContext(ctx).
+		Where("key = ? AND expires_at > now()", key).
+		First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Entry{}, false, nil
+	}
+	if err != nil {
+		return Entry{}, false, err
+	}
+	if err := s.db.WithContext(ctx).Model(&row).Update("last_hit_at", gorm.Expr("now()")).Error; err != nil {
+		return Entry{}, false, err
+	}
+	return Entry{Text: row.Result, JSON: []byte(row.ResultJSON), Model: row.Model}, true, nil
+}
+
+// Set guarda la respuesta con la clave.
+func (s *PostgresStore) Set(ctx context.Context, key string, entry Entry) error {
+	now := time.Now()
+	row := models.ResponseCacheDB{
+		Key:        key,
+		Result:     entry.Text,
+		ResultJSON: models.JSON(entry.JSON),
+		Model:      entry.Model,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(s.ttl),
+		LastHitAt:  now,
+	}
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"result", "result_json", "model", "created_at", "expires_at", "last_hit_at"}),
+	}).Create(&row).Error
+	if err != nil {
+		return err
+	}
+	if s.writes.Add(1)%pruneEvery == 0 {
+		return s.prune(ctx)
+	}
+	return nil
+}
+
+// prune elimina las entradas vencidas y las menos usadas que superan el máximo.
+func (s *PostgresStore) prune(ctx context.Context) error {
+	db := s.db.WithContext(ctx)
+	if err := db.Where("expires_at <= now()").Delete(&models.ResponseCacheDB{}).Error; err != nil {
+		return err
+	}
+	return db.Exec(`DELETE FROM `+models.ResponseCacheDB{}.TableName()+` WHERE key IN (
+		SELECT key FROM `+models.ResponseCacheDB{}.TableName()+` ORDER BY last_hit_at DESC OFFSET ?)`, s.maxEntries).Error
+}
@@ -0,0 +1,110 @@
+// Command bootstrap-admin crea el primer administrador de la API, o convierte en
+// administrador a un usuario existente con ese email.
+//
+//	go run ./cmd/bootstrap-admin -email admin@example.com -name "Admin" -password "..."
+//
+// La contraseña también se puede pasar en ADMIN_PASSWORD para no dejarla en el historial.
+// Si ya hay un administrador no hace nada, salvo con -force.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/auth"
+	"github.com/Efren-Garza-Z/go-api-gemini/db"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/tenant"
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+)
+
+func main() {
+	_ = godotenv.Load() // carga .env si existe
+
+	email := flag.String("email", "", "email del administrador (obligatorio)")
+	name := flag.String("name", "Administrador", "nombre del administrador si hay que crearlo")
+	password := flag.String("password", os.Getenv("ADMIN_PASSWORD"), "contraseña si hay que crearlo (o ADMIN_PASSWORD)")
+	force := flag.Bool("force", false, "continuar aunque ya exista un administrador")
+	flag.Parse()
+
+	if *email == "" {
+		fmt.Fprintln(os.Stderr, "falta -email")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	db.Connect()
+	if err := db.DB.AutoMigrate(&models.UserDB{}, &models.OrganizationDB{}, &models.OrgMemberDB{}); err != nil {
+		fail("error al migrar las tablas de usuarios y organizaciones", err)
+	}
+
+	user, created, err := bootstrapAdmin(db.DB, models.NormalizeEmail(*email), *name, *password, *force)
+	if err != nil {
+		fail("no se pudo crear el administrador", err)
+	}
+	if created {
+		fmt.Printf("administrador creado: id=%d email=%s\n", user.ID, user.Email)
+	} else {
+		fmt.Printf("usuario convertido en administrador: id=%d email=%s\n", user.ID, user.Email)
+	}
+}
+
+// errAdminExists indica que ya hay un administrador y no se pidió -force.
+var errAdminExists = errors.New("ya existe un administrador; usa -force para agregar otro")
+
+// bootstrapAdmin da el rol de administrador al usuario con el email (restaurándolo si estaba
+// eliminado) o lo crea, con su organización personal, si no existe. Devuelve created=true si
+// el usuario es nuevo. El email del administrador se da por confirmado, para que
+// REQUIRE_EMAIL_VERIFICATION no lo bloquee.
+func bootstrapAdmin(conn *gorm.DB, email string, name string, password string, force bool) (models.UserDB, bool, error) {
+	var user models.UserDB
+	created := false
+	err := conn.Transaction(func(tx *gorm.DB) error {
+		if !force {
+			var admins int64
+			if err := tx.Model(&models.UserDB{}).Where("role = ?", models.RoleAdmin).Count(&admins).Error; err != nil {
+				return err
+			}
+			if admins > 0 {
+				return errAdminExists
+			}
+		}
+
+		err := tx.Unscoped().Where("lower(email) = ?", email).First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if password == "" {
+				return errors.New("el usuario no existe: indica -password o ADMIN_PASSWORD para crearlo")
+			}
+			hash, err := auth.HashPassword(password)
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			user = models.UserDB{FullName: name, Email: email, Password: hash, Role: models.RoleAdmin, EmailVerifiedAt: &now}
+			created = true
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+			return tenant.CreatePersonalOrg(tx, user)
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&user).Updates(map[string]interface{}{
+			"role":              models.RoleAdmin,
+			"deleted_at":        nil,
+			"email_verified_at": gorm.Expr("COALESCE(email_verified_at, ?)", time.Now()),
+		}).Error
+	})
+	return user, created, err
+}
+
+// fail escribe el error y termina con código 1.
+func fail(message string, err error) {
+	fmt.Fprintf(os.Stderr, "%s: %v\n", message, err)
+	os.Exit(1)
+}
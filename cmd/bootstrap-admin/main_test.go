@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/auth"
+	"github.com/Efren-Garza-Z/go-api-gemini/db/dbtest"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+)
+
+func TestBootstrapAdmin(t *testing.T) {
+	conn := dbtest.Open(t, &models.UserDB{}, &models.OrganizationDB{}, &models.OrgMemberDB{})
+
+	if _, _, err := bootstrapAdmin(conn, "admin@example.com", "Admin", "", false); err == nil {
+		t.Fatal("crear un administrador sin contraseña no devolvió error")
+	}
+	user, created, err := bootstrapAdmin(conn, "admin@example.com", "Admin", "contraseña1", false)
+	if err != nil || !created || user.Role != models.RoleAdmin || user.EmailVerifiedAt == nil || !auth.CheckPassword(user.Password, "contraseña1") {
+		t.Fatalf("crear el administrador: %+v, created = %v, err = %v", user, created, err)
+	}
+	var members int64
+	conn.Model(&models.OrgMemberDB{}).Where("user_id = ?", user.ID).Count(&members)
+	if members != 1 {
+		t.Errorf("el administrador tiene %d organizaciones, se esperaba su organización personal", members)
+	}
+
+	// Con un administrador existente solo se agrega otro con -force; un usuario eliminado se
+	// restaura con el rol.
+	other := models.UserDB{FullName: "Ana", Email: "ana@example.com", Role: models.RoleViewer}
+	if err := conn.Create(&other).Error; err != nil {
+		t.Fatalf("crear el usuario: %v", err)
+	}
+	conn.Delete(&other)
+	if _, _, err := bootstrapAdmin(conn, "ana@example.com", "", "", false); !errors.Is(err, errAdminExists) {
+		t.Fatalf("segundo administrador sin -force: err = %v, se esperaba errAdminExists", err)
+	}
+	if _, created, err := bootstrapAdmin(conn, "ana@example.com", "", "", true); err != nil || created {
+		t.Fatalf("promover un usuario existente: created = %v, err = %v", created, err)
+	}
+	var promoted models.UserDB
+	if err := conn.First(&promoted, other.ID).Error; err != nil || promoted.Role != models.RoleAdmin || promoted.EmailVerifiedAt == nil {
+		t.Errorf("el usuario no quedó restaurado como administrador: %+v, err = %v", promoted, err)
+	}
+}
@@ -0,0 +1,198 @@
+// Command encryption-keys administra las claves maestras con que se cifran los prompts,
+// resultados y archivos de las tareas (ver el paquete encryption):
+//
+//	go run ./cmd/encryption-keys -new           # agrega una clave y la deja como actual
+//	go run ./cmd/encryption-keys -rotate        # vuelve a cifrar los datos con la clave actual
+//	go run ./cmd/encryption-keys -remove k2025… # quita una clave que ya no se usa
+//	go run ./cmd/encryption-keys                # cuántos valores usa cada clave
+//
+// El archivo de claves es el de ENCRYPTION_KEY_FILE, o el indicado con -file. Para rotar la
+// clave maestra: -new, reiniciar la API para que cifre con la clave nueva, -rotate y, por
+// último, -remove de la clave anterior. -rotate también cifra los datos guardados antes de
+// configurar el cifrado.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/db"
+	"github.com/Efren-Garza-Z/go-api-gemini/encryption"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// encryptedModels son los modelos con columnas cifradas.
+var encryptedModels = []interface{}{&models.GeminiProcessingDB{}, &models.GeminiProcessingFileDB{}, &models.ResponseCacheDB{}}
+
+// pageSize es la cantidad de filas que se leen por consulta.
+const pageSize = 500
+
+// plaintextKey agrupa en el conteo los valores sin cifrar.
+const plaintextKey = "(sin cifrar)"
+
+func main() {
+	_ = godotenv.Load() // carga .env si existe
+
+	path := flag.String("file", os.Getenv("ENCRYPTION_KEY_FILE"), "archivo de claves maestras (o ENCRYPTION_KEY_FILE)")
+	newKey := flag.Bool("new", false, "agregar una clave maestra nueva y dejarla como actual")
+	rotate := flag.Bool("rotate", false, "volver a cifrar las claves de datos con la clave actual y cifrar los datos sin cifrar")
+	remove := flag.String("remove", "", "quitar del archivo una clave que ya no usa ningún dato")
+	flag.Parse()
+
+	if *path == "" {
+		fmt.Fprintln(os.Stderr, "falta -file o ENCRYPTION_KEY_FILE")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *newKey {
+		id, err := encryption.AddKey(*path)
+		if err != nil {
+			fail("no se pudo agregar la clave", err)
+		}
+		fmt.Printf("clave %s agregada como actual en %s; reinicia la API y ejecuta -rotate\n", id, *path)
+		return
+	}
+
+	keys, err := encryption.LoadKeyFile(*path)
+	if err != nil {
+		fail("no se pudo cargar el archivo de claves", err)
+	}
+	encryption.SetKeyProvider(keys)
+	db.Connect()
+	ctx := context.Background()
+
+	current := ""
+	if *rotate {
+		current = keys.KeyID()
+	}
+	counts, err := walk(ctx, db.DB, current)
+	if err != nil {
+		fail("error al recorrer los datos cifrados", err)
+	}
+	if *remove != "" {
+		if counts[*remove] > 0 {
+			fail("no se puede quitar la clave", fmt.Errorf("%d valores siguen cifrados con %s; ejecuta -rotate", counts[*remove], *remove))
+		}
+		if err := encryption.RemoveKey(*path, *remove); err != nil {
+			fail("no se pudo quitar la clave", err)
+		}
+		fmt.Printf("clave %s quitada de %s\n", *remove, *path)
+		return
+	}
+
+	ids := make([]string, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	fmt.Printf("clave actual: %s\n", keys.KeyID())
+	for _, id := range ids {
+		fmt.Printf("%s: %d valores\n", id, counts[id])
+	}
+	for _, id := range keys.KeyIDs() {
+		if id != keys.KeyID() && counts[id] == 0 {
+			fmt.Printf("la clave %s ya no se usa y se puede quitar con -remove %s\n", id, id)
+		}
+	}
+}
+
+// walk recorre las columnas cifradas y cuenta los valores por clave maestra. Si se indica la
+// clave actual (current), antes vuelve a cifrar con ella los que usan otra o están sin cifrar.
+func walk(ctx context.Context, conn *gorm.DB, current string) (map[string]int, error) {
+	counts := map[string]int{}
+	for _, model := range encryptedModels {
+		stmt := &gorm.Statement{DB: conn}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		for _, field := range stmt.Schema.Fields {
+			if !encryption.IsEncrypted(field) {
+				continue
+			}
+			if err := walkColumn(ctx, conn, stmt.Schema, field, current, counts); err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", stmt.Schema.Table, field.DBName, err)
+			}
+		}
+	}
+	return counts, nil
+}
+
+// walkColumn recorre una columna por orden de clave primaria, de a pageSize filas. Se usa
+// Table en lugar del modelo para leer y escribir el valor guardado, sin el serializer.
+func walkColumn(ctx context.Context, conn *gorm.DB, s *schema.Schema, field *schema.Field, current string, counts map[string]int) error {
+	pk := s.PrioritizedPrimaryField.DBName
+	last := ""
+	for {
+		rows, err := conn.WithContext(ctx).Table(s.Table).
+			Select(pk, field.DBName).
+			Where(field.DBName+" IS NOT NULL AND "+pk+" > ?", last).
+			Order(pk).
+			Limit(pageSize).
+			Rows()
+		if err != nil {
+			return err
+		}
+		type row struct {
+			id     string
+			stored []byte
+		}
+		var page []row
+		for rows.Next() {
+			var r row
+			if err := rows.Scan(&r.id, &r.stored); err != nil {
+				rows.Close()
+				return err
+			}
+			page = append(page, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, r := range page {
+			keyID, encrypted := encryption.KeyIDOf(field, r.stored)
+			if !encrypted {
+				if len(r.stored) == 0 {
+					continue
+				}
+				keyID = plaintextKey
+			}
+			if current != "" {
+				value, _, changed, err := encryption.Rewrap(ctx, field, r.id, r.stored)
+				if err != nil {
+					return fmt.Errorf("fila %s: %w", r.id, err)
+				}
+				if changed {
+					// Solo si el valor no cambió mientras tanto; si la API lo reemplazó, ya
+					// está cifrado con la clave actual.
+					result := conn.WithContext(ctx).Table(s.Table).
+						Where(pk+" = ? AND "+field.DBName+" = ?", r.id, encryption.StoredValue(field, r.stored)).
+						UpdateColumn(field.DBName, value)
+					if result.Error != nil {
+						return fmt.Errorf("fila %s: %w", r.id, result.Error)
+					}
+					keyID = current
+				}
+			}
+			counts[keyID]++
+		}
+		if len(page) < pageSize {
+			return nil
+		}
+		last = page[len(page)-1].id
+	}
+}
+
+// fail escribe el error y termina con código 1.
+func fail(message string, err error) {
+	fmt.Fprintf(os.Stderr, "%s: %v\n", message, err)
+	os.Exit(1)
+}
@@ -0,0 +1,50 @@
+// Command mock-oidc es un proveedor OpenID Connect mínimo para probar el inicio de sesión
+// con OIDC en local, sin un proveedor real. Aprueba cada inicio de sesión sin pedir
+// credenciales, con el usuario indicado en los flags (o el email de ?login_hint=).
+//
+//	go run ./cmd/mock-oidc -addr :9090 -email dev@example.com -groups gemini-admins
+//
+// y en la API: OIDC_ISSUER=http://localhost:9090 OIDC_CLIENT_ID=gemini-api
+// OIDC_REDIRECT_URL=http://localhost:8080/v1/auth/oidc/callback.
+//
+// Exige PKCE S256 y firma los ID tokens con una clave RSA que se genera al arrancar. No
+// sirve para producción.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/auth/oidctest"
+)
+
+func main() {
+	addr := flag.String("addr", ":9090", "dirección en la que escucha")
+	issuer := flag.String("issuer", "", "emisor de los tokens (por defecto http://localhost<addr>)")
+	email := flag.String("email", "dev@example.com", "email del usuario")
+	name := flag.String("name", "Dev Local", "nombre del usuario")
+	groups := flag.String("groups", "", "grupos del usuario separados por coma")
+	emailVerified := flag.Bool("email-verified", true, "informar el email como verificado")
+	flag.Parse()
+
+	if *issuer == "" {
+		*issuer = "http://localhost" + *addr
+	}
+	p, err := oidctest.NewProvider(*issuer)
+	if err != nil {
+		log.Fatalf("no se pudo generar la clave: %v", err)
+	}
+	p.Name = *name
+	p.Email = *email
+	p.EmailVerified = *emailVerified
+	for _, g := range strings.Split(*groups, ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			p.Groups = append(p.Groups, g)
+		}
+	}
+
+	log.Printf("proveedor OIDC de prueba en %s (emisor %s)", *addr, p.Issuer)
+	log.Fatal(http.ListenAndServe(*addr, p))
+}
@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/middleware"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/gin-gonic/gin"
+)
+
+// taskCursor es la posición de la siguiente página del listado de tareas: la fecha de
+// creación y el ID de la última tarea devuelta.
+type taskCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// adminTaskColumns son las columnas de models.AdminTask; no incluyen el prompt ni el resultado.
+const adminTaskColumns = "id, status, org_id, user_id, model, total_tokens, estimated_cost, cached, legal_hold, error_code, created_at, updated_at"
+
+// adminTaskKind devuelve el tipo de tarea de ?kind= (por defecto task) y su modelo. Si el tipo
+// no es válido responde el error y devuelve ok=false.
+func adminTaskKind(c *gin.Context) (string, interface{}, bool) {
+	kind := c.DefaultQuery("kind", models.TaskKindPrompt)
+	switch kind {
+	case models.TaskKindPrompt:
+		return kind, &models.GeminiProcessingDB{}, true
+	case models.TaskKindFile:
+		return kind, &models.GeminiProcessingFileDB{}, true
+	}
+	apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, "kind"))
+	return "", nil, false
+}
+
+// ListAllTasks @Summary Listar las tareas de todos los usuarios
+// @Description Devuelve las tareas de texto (kind=task) o con archivo (kind=file_task) de todos los usuarios y
+// @Description organizaciones, de la más reciente a la más antigua, sin el prompt ni el resultado. La respuesta trae
+// @Description next_cursor, que se envía en ?cursor= con los mismos filtros para pedir la página siguiente. Solo para
+// @Description administradores.
+// @Tags admin
+// @Produce json
+// @Param kind query string false "Tipo de tarea" Enums(task, file_task) default(task)
+// @Param status query string false "Estado de la tarea" Enums(pending, processing, completed, error)
+// @Param user_id query int false "ID del usuario que originó la tarea"
+// @Param org_id query int false "ID de la organización de la tarea"
+// @Param limit query int false "Tareas por página (máximo 100)" default(20)
+// @Param cursor query string false "Cursor de la página siguiente"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.AdminTaskList
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/admin/tasks [get]
+func ListAllTasks(c *gin.Context) {
+	limit, ok := pageLimit(c)
+	if !ok {
+		return
+	}
+
+	kind, model, ok := adminTaskKind(c)
+	if !ok {
+		return
+	}
+	query := DB.WithContext(c.Request.Context()).Model(model)
+
+	if status := models.GeminiProcessingStatus(c.Query("status")); status != "" {
+		switch status {
+		case models.StatusPending, models.StatusProcessing, models.StatusCompleted, models.StatusError:
+			query = query.Where("status = ?", status)
+		default:
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, "status"))
+			return
+		}
+	}
+	if value := c.Query("user_id"); value != "" {
+		userID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, "user_id"))
+			return
+		}
+		query = query.Where("user_id = ?", userID)
+	}
+	if value := c.Query("org_id"); value != "" {
+		orgID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, "org_id"))
+			return
+		}
+		query = query.Where("org_id = ?", orgID)
+	}
+	if value := c.Query("cursor"); value != "" {
+		var cursor taskCursor
+		decoded, err := base64.RawURLEncoding.DecodeString(value)
+		if err == nil {
+			err = json.Unmarshal(decoded, &cursor)
+		}
+		if err != nil {
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, "cursor"))
+			return
+		}
+		query = query.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+
+	// Se pide una tarea de más para saber si hay otra página.
+	var tasks []models.AdminTask
+	err := query.
+		Select(adminTaskColumns).
+		Order("created_at DESC, id DESC").
+		Limit(limit + 1).
+		Scan(&tasks).Error
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+
+	lang := i18n.FromContext(c.Request.Context())
+	response := models.AdminTaskList{Items: tasks}
+	if response.Items == nil {
+		response.Items = []models.AdminTask{}
+	}
+	if len(tasks) > limit {
+		response.Items = tasks[:limit]
+		last := response.Items[limit-1]
+		encoded, _ := json.Marshal(taskCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		response.NextCursor = base64.RawURLEncoding.EncodeToString(encoded)
+	}
+	for i := range response.Items {
+		response.Items[i].Kind = kind
+		response.Items[i].StatusLabel = response.Items[i].Status.Label(lang)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SetTaskLegalHold @Summary Poner o quitar la retención legal de una tarea
+// @Description Con legal_hold=true la tarea queda excluida de la purga por la política de retención y su usuario no
+// @Description la puede eliminar, hasta que se quite la retención. Aplica a tareas de cualquier organización. Solo
+// @Description para administradores.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "ID de la tarea"
+// @Param kind query string false "Tipo de tarea" Enums(task, file_task) default(task)
+// @Param input body models.LegalHoldInput true "Retención legal"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.AdminTask
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/admin/tasks/{id}/legal-hold [put]
+func SetTaskLegalHold(c *gin.Context) {
+	kind, model, ok := adminTaskKind(c)
+	if !ok {
+		return
+	}
+	var input models.LegalHoldInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	result := DB.WithContext(ctx).Model(model).Where("id = ?", c.Param("id")).UpdateColumn("legal_hold", *input.LegalHold)
+	if result.Error != nil {
+		apierror.Abort(c, apierror.Wrap(result.Error, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	if result.RowsAffected == 0 {
+		apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeTaskNotFound, i18n.ErrTaskNotFound))
+		return
+	}
+
+	var task models.AdminTask
+	if err := DB.WithContext(ctx).Model(model).Select(adminTaskColumns).Where("id = ?", c.Param("id")).Scan(&task).Error; err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	task.Kind = kind
+	task.StatusLabel = task.Status.Label(i18n.FromContext(ctx))
+	c.JSON(http.StatusOK, task)
+}
+
+// UpdateUserRole @Summary Cambiar el rol de un usuario
+// @Description Asigna el rol admin, member, viewer o service a un usuario. Un administrador no puede quitarse su
+// @Description propio rol, para no dejar la API sin administradores por error. Solo para administradores.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param input body models.UpdateUserRoleInput true "Nuevo rol"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/admin/users/{id}/role [put]
+func UpdateUserRole(c *gin.Context) {
+	user, ok := findUser(c, false)
+	if !ok {
+		return
+	}
+	var input models.UpdateUserRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+	if current, ok := middleware.User(c); ok && current.ID == user.ID && input.Role != models.RoleAdmin {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeValidationFailed, i18n.ErrOwnAdminRole).
+			WithDetails([]apierror.FieldError{{Field: "role", Rule: "oneof", Param: string(models.RoleAdmin)}}))
+		return
+	}
+
+	if err := DB.WithContext(c.Request.Context()).Model(&user).Update("role", input.Role).Error; err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	c.JSON(http.StatusOK, user.ToSwagger())
+}
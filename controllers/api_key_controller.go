@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/apikey"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// findAPIKey busca la key id del usuario; si no existe o es de otro usuario responde el
+// error y devuelve ok=false.
+func findAPIKey(c *gin.Context, userID uint, id string) (models.APIKeyDB, bool) {
+	var key models.APIKeyDB
+	keyID, err := strconv.Atoi(id)
+	if err != nil {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidID))
+		return key, false
+	}
+	if err := DB.WithContext(c.Request.Context()).Where("user_id = ?", userID).First(&key, keyID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeAPIKeyNotFound, i18n.ErrAPIKeyNotFound))
+			return key, false
+		}
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return key, false
+	}
+	return key, true
+}
+
+// CreateAPIKey @Summary Crear una API key
+// @Description Crea una API key para que servicios del usuario llamen a la API sin iniciar sesión, enviándola en
+// @Description Authorization: Bearer o en X-API-Key. La key completa solo se devuelve en esta respuesta; se guarda su hash.
+// @Description Con scopes la key solo puede usar esos permisos (y siempre dentro de los del rol del usuario).
+// @Description La key actúa solo en la organización de la petición (X-Org-ID), de la que el usuario debe ser miembro.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param input body models.CreateAPIKeyInput true "Datos de la key"
+// @Param X-Org-ID header int false "Organización de la key; por defecto la primera del usuario que hace la petición"
+// @Success 201 {object} models.APIKeyCreated
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/users/{id}/api-keys [post]
+func CreateAPIKey(c *gin.Context) {
+	user, ok := findUser(c, false)
+	if !ok {
+		return
+	}
+	var input models.CreateAPIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+	for _, scope := range input.Scopes {
+		if !scope.Valid() {
+			names := make([]string, len(models.Permissions))
+			for i, p := range models.Permissions {
+				names[i] = string(p)
+			}
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeValidationFailed, i18n.ErrInvalidParam, "scopes").
+				WithDetails([]apierror.FieldError{{Field: "scopes", Rule: "oneof", Param: strings.Join(names, " ")}}))
+			return
+		}
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeValidationFailed, i18n.ErrExpiresInPast).
+			WithDetails([]apierror.FieldError{{Field: "expires_at", Rule: "gt", Param: "now"}}))
+		return
+	}
+
+	org := orgRef(c)
+	if _, ok := findOrgMember(c, *org, user.ID); !ok {
+		return
+	}
+
+	secret, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		apierror.Abort(c, apierror.Internal(err))
+		return
+	}
+	key := models.APIKeyDB{
+		OrgID:     org,
+		UserID:    user.ID,
+		Name:      input.Name,
+		Prefix:    prefix,
+		Hash:      hash,
+		ExpiresAt: input.ExpiresAt,
+	}
+	if len(input.Scopes) > 0 {
+		scopes, _ := json.Marshal(input.Scopes)
+		key.Scopes = models.JSON(scopes)
+	}
+	if err := DB.WithContext(c.Request.Context()).Create(&key).Error; err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	c.JSON(http.StatusCreated, models.APIKeyCreated{APIKey: key.ToResponse(), Key: secret})
+}
+
+// ListAPIKeys @Summary Listar las API keys de un usuario
+// @Description Devuelve las keys del usuario (incluidas las revocadas y vencidas) con su prefijo visible, la fecha
+// @Description del último uso y la cantidad de peticiones. El consumo de tokens por key está en /v1/users/{id}/usage?api_key_id=.
+// @Tags api-keys
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Success 200 {array} models.APIKey
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/users/{id}/api-keys [get]
+func ListAPIKeys(c *gin.Context) {
+	user, ok := findUser(c, false)
+	if !ok {
+		return
+	}
+	var keys []models.APIKeyDB
+	if err := DB.WithContext(c.Request.Context()).Where("user_id = ?", user.ID).Order("id").Find(&keys).Error; err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+
+	response := make([]models.APIKey, len(keys))
+	for i, key := range keys {
+		response[i] = key.ToResponse()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetAPIKey @Summary Obtener una API key
+// @Tags api-keys
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param key_id path int true "ID de la key"
+// @Success 200 {object} models.APIKey
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /v1/users/{id}/api-keys/{key_id} [get]
+func GetAPIKey(c *gin.Context) {
+	user, ok := findUser(c, false)
+	if !ok {
+		return
+	}
+	key, ok := findAPIKey(c, user.ID, c.Param("key_id"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, key.ToResponse())
+}
+
+// RevokeAPIKey @Summary Revocar una API key
+// @Description La key deja de funcionar de inmediato; se conserva en el listado con revoked_at y su consumo.
+// @Tags api-keys
+// @Param id path int true "ID del usuario"
+// @Param key_id path int true "ID de la key"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/users/{id}/api-keys/{key_id} [delete]
+func RevokeAPIKey(c *gin.Context) {
+	user, ok := findUser(c, false)
+	if !ok {
+		return
+	}
+	key, ok := findAPIKey(c, user.ID, c.Param("key_id"))
+	if !ok {
+		return
+	}
+	if key.RevokedAt == nil {
+		if err := DB.WithContext(c.Request.Context()).Model(&key).Update("revoked_at", time.Now()).Error; err != nil {
+			apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+			return
+		}
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RotateAPIKey @Summary Rotar una API key
+// @Description Genera un secreto nuevo para la key, con el mismo nombre, scopes, vencimiento y consumo acumulado.
+// @Description El secreto anterior deja de funcionar de inmediato. La key nueva solo se devuelve en esta respuesta.
+// @Tags api-keys
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param key_id path int true "ID de la key"
+// @Success 200 {object} models.APIKeyCreated
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "La key está revocada"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/users/{id}/api-keys/{key_id}/rotate [post]
+func RotateAPIKey(c *gin.Context) {
+	user, ok := findUser(c, false)
+	if !ok {
+		return
+	}
+	key, ok := findAPIKey(c, user.ID, c.Param("key_id"))
+	if !ok {
+		return
+	}
+	if key.RevokedAt != nil {
+		apierror.Abort(c, apierror.New(http.StatusConflict, apierror.CodeAPIKeyRevoked, i18n.ErrAPIKeyRevoked))
+		return
+	}
+
+	secret, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		apierror.Abort(c, apierror.Internal(err))
+		return
+	}
+	err = DB.WithContext(c.Request.Context()).Model(&key).Updates(map[string]interface{}{
+		"prefix":     prefix,
+		"hash":       hash,
+		"rotated_at": time.Now(),
+	}).Error
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	c.JSON(http.StatusOK, models.APIKeyCreated{APIKey: key.ToResponse(), Key: secret})
+}
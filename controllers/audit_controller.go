@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// auditExportPageSize es la cantidad de eventos que se leen por consulta al exportar.
+const auditExportPageSize = 500
+
+// auditCursor es la posición de la siguiente página del registro de auditoría: la fecha y el
+// ID del último evento devuelto.
+type auditCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uint      `json:"id"`
+}
+
+// auditQuery arma la consulta de eventos con los filtros de la petición. Si un filtro no es
+// válido responde 400 y devuelve false.
+func auditQuery(c *gin.Context) (*gorm.DB, bool) {
+	query := DB.WithContext(c.Request.Context()).Model(&models.AuditEventDB{})
+	for _, param := range []string{"actor_user_id", "org_id"} {
+		if value := c.Query(param); value != "" {
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, param))
+				return nil, false
+			}
+			query = query.Where(param+" = ?", id)
+		}
+	}
+	for _, param := range []string{"action", "resource_type", "resource_id", "request_id"} {
+		if value := c.Query(param); value != "" {
+			query = query.Where(param+" = ?", value)
+		}
+	}
+	if value := c.Query("from"); value != "" {
+		from, err := parseUsageTime(value)
+		if err != nil {
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, "from"))
+			return nil, false
+		}
+		query = query.Where("created_at >= ?", from)
+	}
+	if value := c.Query("to"); value != "" {
+		to, err := parseUsageTime(value)
+		if err != nil {
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, "to"))
+			return nil, false
+		}
+		query = query.Where("created_at < ?", to)
+	}
+	return query, true
+}
+
+// ListAuditEvents @Summary Consultar el registro de auditoría
+// @Description Devuelve los eventos de auditoría (accesos a datos y operaciones de seguridad, incluidas las
+// @Description rechazadas), del más reciente al más antiguo. La respuesta trae next_cursor, que se envía en ?cursor=
+// @Description con los mismos filtros para pedir la página siguiente. Solo para administradores.
+// @Tags admin
+// @Produce json
+// @Param actor_user_id query int false "ID del usuario que hizo la operación"
+// @Param org_id query int false "ID de la organización en la que se hizo"
+// @Param action query string false "Acción, por ejemplo user.update"
+// @Param resource_type query string false "Tipo de recurso, por ejemplo user"
+// @Param resource_id query string false "ID del recurso"
+// @Param request_id query string false "ID de la petición (X-Request-ID)"
+// @Param from query string false "Desde (YYYY-MM-DD o RFC3339, incluido)"
+// @Param to query string false "Hasta (YYYY-MM-DD o RFC3339, excluido)"
+// @Param limit query int false "Eventos por página (máximo 100)" default(20)
+// @Param cursor query string false "Cursor de la página siguiente"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.AuditEventList
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/admin/audit-events [get]
+func ListAuditEvents(c *gin.Context) {
+	limit, ok := pageLimit(c)
+	if !ok {
+		return
+	}
+	query, ok := auditQuery(c)
+	if !ok {
+		return
+	}
+	if value := c.Query("cursor"); value != "" {
+		var cursor auditCursor
+		decoded, err := base64.RawURLEncoding.DecodeString(value)
+		if err == nil {
+			err = json.Unmarshal(decoded, &cursor)
+		}
+		if err != nil {
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, "cursor"))
+			return
+		}
+		query = query.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+
+	// Se pide un evento de más para saber si hay otra página.
+	var events []models.AuditEventDB
+	if err := query.Order("created_at DESC, id DESC").Limit(limit + 1).Find(&events).Error; err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+
+	response := models.AuditEventList{Items: []models.AuditEvent{}}
+	if len(events) > limit {
+		events = events[:limit]
+		last := events[limit-1]
+		encoded, _ := json.Marshal(auditCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		response.NextCursor = base64.RawURLEncoding.EncodeToString(encoded)
+	}
+	for _, event := range events {
+		response.Items = append(response.Items, event.ToResponse())
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ExportAuditEvents @Summary Exportar el registro de auditoría
+// @Description Descarga los eventos de auditoría que cumplen los filtros en formato JSONL, del más antiguo al más
+// @Description reciente; cada línea es un models.AuditEvent. Los eventos se leen por partes, por lo que la exportación
+// @Description no tiene límite de tamaño. Solo para administradores.
+// @Tags admin
+// @Produce application/x-ndjson
+// @Param actor_user_id query int false "ID del usuario que hizo la operación"
+// @Param org_id query int false "ID de la organización en la que se hizo"
+// @Param action query string false "Acción, por ejemplo user.update"
+// @Param resource_type query string false "Tipo de recurso, por ejemplo user"
+// @Param resource_id query string false "ID del recurso"
+// @Param request_id query string false "ID de la petición (X-Request-ID)"
+// @Param from query string false "Desde (YYYY-MM-DD o RFC3339, incluido)"
+// @Param to query string false "Hasta (YYYY-MM-DD o RFC3339, excluido)"
+// @Security ApiKeyAuth
+// @Success 200 {file} file "Eventos de auditoría"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /v1/admin/audit-events/export [get]
+func ExportAuditEvents(c *gin.Context) {
+	if _, ok := auditQuery(c); !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="audit-events-`+time.Now().UTC().Format("20060102T150405Z")+`.jsonl"`)
+	c.Status(http.StatusOK)
+
+	// La respuesta ya empezó: un error al leer o escribir solo se registra.
+	encoder := json.NewEncoder(c.Writer)
+	var last auditCursor
+	for {
+		// auditQuery ya validó los filtros; se arma de nuevo porque cada consulta la consume.
+		query, _ := auditQuery(c)
+		if last.ID != 0 {
+			query = query.Where("(created_at, id) > (?, ?)", last.CreatedAt, last.ID)
+		}
+		var events []models.AuditEventDB
+		if err := query.Order("created_at, id").Limit(auditExportPageSize).Find(&events).Error; err != nil {
+			slog.ErrorContext(ctx, "error al leer los eventos de auditoría", "error", err)
+			return
+		}
+		for _, event := range events {
+			if err := encoder.Encode(event.ToResponse()); err != nil {
+				slog.WarnContext(ctx, "error al escribir los eventos de auditoría", "error", err)
+				return
+			}
+		}
+		c.Writer.Flush()
+		if len(events) < auditExportPageSize {
+			return
+		}
+		last = auditCursor{CreatedAt: events[len(events)-1].CreatedAt, ID: events[len(events)-1].ID}
+	}
+}
@@ -0,0 +1,435 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/apikey"
+	"github.com/Efren-Garza-Z/go-api-gemini/audit"
+	"github.com/Efren-Garza-Z/go-api-gemini/auth"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/mail"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/worker"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Vigencia por defecto de los tokens enviados por correo y de las API keys de sesión.
+const (
+	defaultEmailVerificationTTL = 48 * time.Hour
+	defaultPasswordResetTTL     = time.Hour
+	defaultSessionTTL           = 12 * time.Hour
+)
+
+// errInvalidToken indica que el token no existe, ya se usó, venció o no corresponde al usuario.
+var errInvalidToken = errors.New("token inválido")
+
+// mailer envía los correos de verificación y recuperación de contraseña.
+var mailer mail.Mailer = mail.LogMailer{}
+
+// SetMailer configura el transporte de los correos.
+func SetMailer(m mail.Mailer) {
+	mailer = m
+}
+
+// mailJobs envía los correos en segundo plano, aparte del pool de las tareas para que una
+// cola de tareas llena no demore los correos ni las peticiones que los piden. Con nil cada
+// correo usa su propia goroutine.
+var mailJobs *worker.Pool
+
+// SetMailPool configura el pool que envía los correos.
+func SetMailPool(p *worker.Pool) {
+	mailJobs = p
+}
+
+// queueMail ejecuta job en la cola de correos con un contexto que sigue vigente al terminar
+// la petición. Nunca espera: si la cola está llena el correo se descarta (el usuario puede
+// pedir otro), así la respuesta tarda lo mismo en cualquier caso.
+func queueMail(ctx context.Context, purpose models.TokenPurpose, job func(ctx context.Context) error) {
+	mailCtx := context.WithoutCancel(ctx)
+	queued := mailJobs.TrySubmit(func() {
+		if err := job(mailCtx); err != nil {
+			slog.ErrorContext(mailCtx, "error al enviar el correo", "purpose", purpose, "error", err)
+		}
+	})
+	if !queued {
+		slog.WarnContext(ctx, "cola de correos llena: correo descartado", "purpose", purpose)
+	}
+}
+
+// tokenTTL devuelve la vigencia de los tokens de cada tipo, configurable con
+// EMAIL_VERIFICATION_TTL y PASSWORD_RESET_TTL.
+func tokenTTL(purpose models.TokenPurpose) time.Duration {
+	name, ttl := "EMAIL_VERIFICATION_TTL", defaultEmailVerificationTTL
+	if purpose == models.TokenPasswordReset {
+		name, ttl = "PASSWORD_RESET_TTL", defaultPasswordResetTTL
+	}
+	if v, err := time.ParseDuration(os.Getenv(name)); err == nil && v > 0 {
+		return v
+	}
+	return ttl
+}
+
+// sessionTTL devuelve la vigencia de la API key de sesión del inicio de sesión con
+// contraseña o con OIDC, configurable con SESSION_TTL.
+func sessionTTL() time.Duration {
+	if v, err := time.ParseDuration(os.Getenv("SESSION_TTL")); err == nil && v > 0 {
+		return v
+	}
+	return defaultSessionTTL
+}
+
+// createSessionKey crea la API key de sesión que devuelve un inicio de sesión, con el nombre
+// del método usado y vigencia ttl.
+func createSessionKey(ctx context.Context, userID uint, name string, ttl time.Duration) (models.APIKeyCreated, error) {
+	secret, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		return models.APIKeyCreated{}, err
+	}
+	expiresAt := time.Now().Add(ttl)
+	key := models.APIKeyDB{UserID: userID, Name: name, Prefix: prefix, Hash: hash, ExpiresAt: &expiresAt}
+	if err := DB.WithContext(ctx).Create(&key).Error; err != nil {
+		return models.APIKeyCreated{}, err
+	}
+	return models.APIKeyCreated{APIKey: key.ToResponse(), Key: secret}, nil
+}
+
+// unknownUserHash es el hash con el que se compara la contraseña cuando el email no existe,
+// para que el inicio de sesión tarde lo mismo y no revele qué emails están registrados.
+var unknownUserHash = sync.OnceValue(func() string {
+	hash, _ := auth.HashPassword("usuario-inexistente")
+	return hash
+})
+
+// Login @Summary Iniciar sesión con email y contraseña
+// @Description Valida la contraseña y devuelve el usuario y una API key de sesión que vence a las SESSION_TTL (por
+// @Description defecto 12 horas), para enviar en Authorization: Bearer. Los usuarios creados con OIDC no tienen
+// @Description contraseña: inician sesión con su proveedor o eligen una con /v1/auth/password/forgot.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.LoginInput true "Email y contraseña"
+// @Success 200 {object} models.LoginResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse "Email o contraseña incorrectos"
+// @Failure 403 {object} models.ErrorResponse "El email no está confirmado y REQUIRE_EMAIL_VERIFICATION=true"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/auth/login [post]
+func Login(c *gin.Context) {
+	var input models.LoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+	ctx := c.Request.Context()
+	user, found, err := findUserByEmail(ctx, input.Email)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	// Sin usuario, o sin contraseña si se creó con OIDC, igual se compara un hash para que la
+	// respuesta tarde lo mismo.
+	hash := user.Password
+	if !found || hash == "" {
+		hash = unknownUserHash()
+	}
+	if !auth.CheckPassword(hash, input.Password) || hash == unknownUserHash() {
+		slog.WarnContext(ctx, "inicio de sesión rechazado", "user_found", found)
+		apierror.Abort(c, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, i18n.ErrInvalidCredentials))
+		return
+	}
+	auditUser(c, user.ID)
+	if os.Getenv("REQUIRE_EMAIL_VERIFICATION") == "true" && user.EmailVerifiedAt == nil {
+		apierror.Abort(c, apierror.New(http.StatusForbidden, apierror.CodeEmailNotVerified, i18n.ErrEmailNotVerified))
+		return
+	}
+
+	key, err := createSessionKey(ctx, user.ID, "session", sessionTTL())
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	slog.InfoContext(ctx, "inicio de sesión con contraseña", "user_id", user.ID)
+	c.JSON(http.StatusOK, models.LoginResponse{User: user.ToSwagger(), APIKey: key})
+}
+
+// tokenLink arma el enlace del correo: APP_BASE_URL (por defecto http://localhost:8080)
+// con la ruta de la página que recibe el token.
+func tokenLink(purpose models.TokenPurpose, token string) string {
+	base := strings.TrimRight(os.Getenv("APP_BASE_URL"), "/")
+	if base == "" {
+		base = "http://localhost:8080"
+	}
+	path := "/verify-email"
+	if purpose == models.TokenPasswordReset {
+		path = "/reset-password"
+	}
+	return base + path + "?token=" + url.QueryEscape(token)
+}
+
+// sendUserToken genera un token para el usuario, invalida los anteriores del mismo tipo y
+// envía el correo en el idioma de la petición. Se ejecuta en la cola de correos (queueMail).
+func sendUserToken(ctx context.Context, user models.UserDB, purpose models.TokenPurpose) error {
+	token, hash, err := auth.NewToken()
+	if err != nil {
+		return err
+	}
+	ttl := tokenTTL(purpose)
+	err = DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.UserTokenDB{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, purpose).
+			Update("used_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&models.UserTokenDB{
+			UserID:    user.ID,
+			Purpose:   purpose,
+			Hash:      hash,
+			Email:     user.Email,
+			ExpiresAt: time.Now().Add(ttl),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	lang := i18n.FromContext(ctx)
+	subject, body := i18n.MailVerifySubject, i18n.MailVerifyBody
+	if purpose == models.TokenPasswordReset {
+		subject, body = i18n.MailResetSubject, i18n.MailResetBody
+	}
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: i18n.T(lang, subject),
+		Body:    i18n.T(lang, body, user.FullName, tokenLink(purpose, token), ttl),
+	}
+	if err := mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("usuario %d: %w", user.ID, err)
+	}
+	return nil
+}
+
+// sendVerificationEmail encola el correo de verificación; un error solo se registra, porque
+// el usuario puede pedir otro con POST /v1/auth/email/verification.
+func sendVerificationEmail(ctx context.Context, user models.UserDB) {
+	queueMail(ctx, models.TokenEmailVerification, func(ctx context.Context) error {
+		return sendUserToken(ctx, user, models.TokenEmailVerification)
+	})
+}
+
+// sendTokenByEmail encola buscar al usuario del email y enviarle un token. La búsqueda
+// también se hace en la cola, para que la respuesta no tarde distinto según el email esté
+// registrado o no. Con needsUnverified solo se envía si el email aún no está confirmado.
+func sendTokenByEmail(ctx context.Context, email string, purpose models.TokenPurpose, needsUnverified bool) {
+	queueMail(ctx, purpose, func(ctx context.Context) error {
+		user, found, err := findUserByEmail(ctx, email)
+		if err != nil || !found || (needsUnverified && user.EmailVerifiedAt != nil) {
+			return err
+		}
+		return sendUserToken(ctx, user, purpose)
+	})
+}
+
+// auditUser registra al usuario como actor y recurso del evento de auditoría de la petición:
+// las rutas de auth no están identificadas y el usuario se conoce por el token.
+func auditUser(c *gin.Context, userID uint) {
+	entry := audit.FromContext(c.Request.Context())
+	entry.SetActor(userID)
+	entry.SetResource(strconv.FormatUint(uint64(userID), 10))
+}
+
+// consumeToken marca el token como usado si es válido y del tipo indicado, y lo devuelve.
+// La actualización condicional garantiza que un token solo se use una vez.
+func consumeToken(tx *gorm.DB, purpose models.TokenPurpose, token string) (models.UserTokenDB, error) {
+	var stored models.UserTokenDB
+	result := tx.Model(&stored).
+		Clauses(clause.Returning{}).
+		Where("hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", auth.HashToken(token), purpose, time.Now()).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return stored, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return stored, errInvalidToken
+	}
+	return stored, nil
+}
+
+// abortTokenError responde el error de usar un token.
+func abortTokenError(c *gin.Context, err error) {
+	if errors.Is(err, errInvalidToken) {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidToken, i18n.ErrInvalidToken))
+		return
+	}
+	apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+}
+
+// respondEmailSent responde 202 con el mismo mensaje exista o no el email, para no revelar
+// qué direcciones están registradas.
+func respondEmailSent(c *gin.Context) {
+	c.JSON(http.StatusAccepted, models.AcceptedResponse{Message: i18n.Tc(c.Request.Context(), i18n.MsgEmailSent)})
+}
+
+// findUserByEmail busca un usuario activo por email; devuelve ok=false si no existe.
+func findUserByEmail(ctx context.Context, email string) (models.UserDB, bool, error) {
+	var user models.UserDB
+	err := DB.WithContext(ctx).Where("lower(email) = ?", models.NormalizeEmail(email)).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, false, nil
+	}
+	return user, err == nil, err
+}
+
+// VerifyEmail @Summary Confirmar el email
+// @Description Confirma el email del usuario con el token recibido por correo al registrarse o al cambiarlo. El token
+// @Description es de un solo uso y no vale si el usuario cambió su email después de recibirlo.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.VerifyEmailInput true "Token recibido por correo"
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/auth/email/verify [post]
+func VerifyEmail(c *gin.Context) {
+	var input models.VerifyEmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+
+	var user models.UserDB
+	err := DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		token, err := consumeToken(tx, models.TokenEmailVerification, input.Token)
+		if err != nil {
+			return err
+		}
+		if err := tx.First(&user, token.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errInvalidToken
+			}
+			return err
+		}
+		if user.Email != token.Email {
+			return errInvalidToken
+		}
+		auditUser(c, user.ID)
+		if user.EmailVerifiedAt == nil {
+			return tx.Model(&user).Update("email_verified_at", time.Now()).Error
+		}
+		return nil
+	})
+	if err != nil {
+		abortTokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user.ToSwagger())
+}
+
+// ResendVerification @Summary Reenviar el correo de verificación
+// @Description Envía un correo de verificación nuevo si el email está registrado y sin confirmar; los enlaces anteriores
+// @Description dejan de valer. Siempre responde 202, sin esperar a buscar el email, para no revelar qué emails están
+// @Description registrados.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.EmailInput true "Email del usuario"
+// @Success 202 {object} models.AcceptedResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /v1/auth/email/verification [post]
+func ResendVerification(c *gin.Context) {
+	var input models.EmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+	sendTokenByEmail(c.Request.Context(), input.Email, models.TokenEmailVerification, true)
+	respondEmailSent(c)
+}
+
+// ForgotPassword @Summary Pedir la recuperación de la contraseña
+// @Description Envía un correo con un enlace para elegir una contraseña nueva, válido una sola vez y por
+// @Description PASSWORD_RESET_TTL (por defecto 1 hora); los enlaces anteriores dejan de valer. Siempre responde 202,
+// @Description sin esperar a buscar el email, para no revelar qué emails están registrados.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.EmailInput true "Email del usuario"
+// @Success 202 {object} models.AcceptedResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /v1/auth/password/forgot [post]
+func ForgotPassword(c *gin.Context) {
+	var input models.EmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+	sendTokenByEmail(c.Request.Context(), input.Email, models.TokenPasswordReset, false)
+	respondEmailSent(c)
+}
+
+// ResetPassword @Summary Elegir una contraseña nueva
+// @Description Cambia la contraseña con el token recibido por correo. El token es de un solo uso. Revoca todas las
+// @Description API keys del usuario, las de sesión y las creadas con nombre, porque quien conocía la contraseña
+// @Description anterior pudo crearlas.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.ResetPasswordInput true "Token y contraseña nueva"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/auth/password/reset [post]
+func ResetPassword(c *gin.Context) {
+	var input models.ResetPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+
+	hash, err := auth.HashPassword(input.Password)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrInternal))
+		return
+	}
+
+	err = DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		token, err := consumeToken(tx, models.TokenPasswordReset, input.Token)
+		if err != nil {
+			return err
+		}
+		auditUser(c, token.UserID)
+		result := tx.Model(&models.UserDB{}).
+			Where("id = ? AND email = ?", token.UserID, token.Email).
+			Update("password", hash)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvalidToken
+		}
+		// Quien conocía la contraseña anterior pudo iniciar sesión o crear keys con nombre:
+		// se revocan todas, no solo las de sesión.
+		return tx.Model(&models.APIKeyDB{}).
+			Where("user_id = ? AND revoked_at IS NULL", token.UserID).
+			Update("revoked_at", time.Now()).Error
+	})
+	if err != nil {
+		abortTokenError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
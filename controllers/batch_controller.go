@@ -0,0 +1,488 @@
+package controllers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/db"
+	"github.com/Efren-Garza-Z/go-api-gemini/gemini"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/logging"
+	"github.com/Efren-Garza-Z/go-api-gemini/middleware"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/telemetry"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Valores por defecto de los lotes.
+const (
+	defaultBatchMaxItems = 10000
+	// batchPageSize es la cantidad de tareas que se leen por consulta al encolar o exportar un lote.
+	batchPageSize = 500
+)
+
+// batchMaxItems devuelve la cantidad máxima de tareas por lote configurada en BATCH_MAX_ITEMS.
+func batchMaxItems() int {
+	if v, err := strconv.Atoi(os.Getenv("BATCH_MAX_ITEMS")); err == nil && v > 0 {
+		return v
+	}
+	return defaultBatchMaxItems
+}
+
+// abortBatchTooLarge responde que el lote supera la cantidad máxima de tareas.
+func abortBatchTooLarge(c *gin.Context, maxItems int) {
+	apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeBatchTooLarge, i18n.ErrBatchTooLarge, maxItems).
+		WithDetails(gin.H{"max": maxItems}))
+}
+
+// CreateBatch @Summary Crear un lote de tareas
+// @Description Crea una tarea por cada prompt y las ejecuta en el pool de workers. Acepta JSON con "prompts"
+// @Description o multipart con un archivo CSV ("file") y una plantilla ("template", "version"): la primera fila
+// @Description del CSV son los nombres de las variables y cada fila siguiente genera una tarea.
+// @Tags gemini
+// @Accept json,mpfd
+// @Produce json
+// @Param requestBody body models.BatchRequest false "Prompts (JSON)"
+// @Param file formData file false "Filas con las variables de la plantilla (CSV)"
+// @Param template formData string false "Plantilla para generar el prompt de cada fila (CSV)"
+// @Param version formData int false "Versión de la plantilla; por defecto la más reciente (CSV)"
+// @Param schema formData string false "Nombre de un esquema de respuesta registrado (CSV)"
+// @Security ApiKeyAuth
+// @Param Cache-Control header string false "no-cache para generar las respuestas sin usar el caché"
+// @Success 202 {object} models.BatchCreatedResponse "Lote en cola"
+// @Header  202 {string} Location "URL para consultar el progreso del lote"
+// @Failure 400 {object} models.ErrorResponse "Solicitud inválida"
+// @Failure 404 {object} models.ErrorResponse "Plantilla no encontrada"
+// @Failure 429 {object} models.ErrorResponse "Límite de peticiones o cuota agotada"
+// @Failure 500 {object} models.ErrorResponse "Error interno"
+// @Router /v1/gemini/batches [post]
+func CreateBatch(c *gin.Context) {
+	batch := models.BatchDB{ID: uuid.New().String(), OrgID: orgRef(c), UserID: middleware.UserID(c)}
+
+	var tasks []models.GeminiProcessingDB
+	var ok bool
+	if c.ContentType() == gin.MIMEMultipartPOSTForm {
+		batch.Source = "csv"
+		tasks, ok = batchTasksFromCSV(c, &batch)
+	} else {
+		batch.Source = "json"
+		tasks, ok = batchTasksFromJSON(c)
+	}
+	if !ok {
+		return
+	}
+	// Cada tarea del lote cuenta en la cuota de peticiones de la organización.
+	if !middleware.ChargeQuota(c, int64(len(tasks))) {
+		return
+	}
+	batch.Total = len(tasks)
+
+	ctx := c.Request.Context()
+	traceParent, traceState := telemetry.InjectTraceContext(ctx)
+	bypass := cacheBypass(c)
+	apiKeyID := middleware.APIKeyID(c)
+	for i := range tasks {
+		index := i + 1
+		tasks[i].ID = uuid.New().String()
+		tasks[i].Status = models.StatusPending
+		tasks[i].OrgID = batch.OrgID
+		tasks[i].UserID = batch.UserID
+		tasks[i].APIKeyID = apiKeyID
+		tasks[i].Model = gemini.DefaultModel
+		tasks[i].BatchID = &batch.ID
+		tasks[i].BatchIndex = &index
+		tasks[i].RequestID = logging.RequestID(ctx)
+		tasks[i].TraceParent = traceParent
+		tasks[i].TraceState = traceState
+		tasks[i].CacheBypass = bypass
+	}
+
+	err := db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&batch).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(&tasks, batchPageSize).Error
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrTaskCreateFailed))
+		return
+	}
+
+	slog.InfoContext(ctx, "lote creado", "batch_id", batch.ID, "source", batch.Source, "total", batch.Total)
+	go dispatchBatch(batch.ID)
+
+	statusURL := "/v1/gemini/batches/" + batch.ID
+	c.Header("Location", statusURL)
+	c.JSON(http.StatusAccepted, models.BatchCreatedResponse{ID: batch.ID, Total: batch.Total, StatusURL: statusURL})
+}
+
+// batchTasksFromJSON arma una tarea por cada prompt de la petición JSON.
+// Si la petición no es válida responde el error y devuelve ok=false.
+func batchTasksFromJSON(c *gin.Context) ([]models.GeminiProcessingDB, bool) {
+	var request models.BatchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return nil, false
+	}
+	schemaName, responseSchema, ok := resolveResponseSchema(c, request.Schema, request.ResponseSchema)
+	if !ok {
+		return nil, false
+	}
+
+	if maxItems := batchMaxItems(); len(request.Prompts) > maxItems {
+		abortBatchTooLarge(c, maxItems)
+		return nil, false
+	}
+
+	tasks := make([]models.GeminiProcessingDB, len(request.Prompts))
+	for i, prompt := range request.Prompts {
+		tasks[i] = models.GeminiProcessingDB{
+			Prompt:         prompt,
+			SchemaName:     schemaName,
+			ResponseSchema: responseSchema,
+		}
+	}
+	return tasks, true
+}
+
+// batchTasksFromCSV arma una tarea por cada fila del CSV generando su prompt con la plantilla.
+// Si el archivo o la plantilla no son válidos responde el error y devuelve ok=false.
+func batchTasksFromCSV(c *gin.Context, batch *models.BatchDB) ([]models.GeminiProcessingDB, bool) {
+	name := c.PostForm("template")
+	if name == "" {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeValidationFailed, i18n.ErrTemplateRequired).
+			WithDetails([]apierror.FieldError{{Field: "template", Rule: "required"}}))
+		return nil, false
+	}
+	version := 0
+	if v := c.PostForm("version"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, "version"))
+			return nil, false
+		}
+		version = n
+	}
+	schemaName, responseSchema, ok := resolveResponseSchema(c, c.PostForm("schema"), nil)
+	if !ok {
+		return nil, false
+	}
+	// Todas las filas usan la misma versión aunque se publique otra mientras se lee el archivo.
+	template, err := findTemplate(c.Request.Context(), middleware.OrgID(c), name, version)
+	if err != nil {
+		abortTemplateLookup(c, err)
+		return nil, false
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeValidationFailed, i18n.ErrFileRequired).
+			WithDetails([]apierror.FieldError{{Field: "file", Rule: "required"}}))
+		return nil, false
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrFileOpenFailed))
+		return nil, false
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusBadRequest, apierror.CodeInvalidCSV, i18n.ErrInvalidCSV).
+			WithDetails(gin.H{"reason": err.Error()}))
+		return nil, false
+	}
+
+	var tasks []models.GeminiProcessingDB
+	maxItems := batchMaxItems()
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			apierror.Abort(c, apierror.Wrap(err, http.StatusBadRequest, apierror.CodeInvalidCSV, i18n.ErrInvalidCSV).
+				WithDetails(gin.H{"reason": err.Error()}))
+			return nil, false
+		}
+		// No se sigue leyendo un archivo que ya superó el máximo.
+		if row > maxItems {
+			abortBatchTooLarge(c, maxItems)
+			return nil, false
+		}
+
+		variables := make(map[string]interface{}, len(header))
+		for i, column := range header {
+			variables[column] = record[i]
+		}
+		task := models.GeminiProcessingDB{SchemaName: schemaName, ResponseSchema: responseSchema}
+		if err := renderTemplate(&task, template, variables); err != nil {
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeTemplateRenderFailed, i18n.ErrTemplateRender).
+				WithDetails(gin.H{"row": row, "reason": err.Error()}))
+			return nil, false
+		}
+		tasks = append(tasks, task)
+	}
+	if len(tasks) == 0 {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidCSV, i18n.ErrInvalidCSV).
+			WithDetails(gin.H{"rows": 0}))
+		return nil, false
+	}
+
+	batch.TemplateName = template.Name
+	batch.TemplateVersion = &template.Version
+	return tasks, true
+}
+
+// dispatchBatch encola en el pool de workers las tareas pendientes del lote, en orden.
+func dispatchBatch(batchID string) {
+	ctx := context.Background()
+	last := 0
+	for {
+		var tasks []models.GeminiProcessingDB
+		err := db.DB.WithContext(ctx).
+			Where("batch_id = ? AND status = ? AND batch_index > ?", batchID, models.StatusPending, last).
+			Order("batch_index").
+			Limit(batchPageSize).
+			Find(&tasks).Error
+		if err != nil {
+			slog.ErrorContext(ctx, "error al encolar las tareas del lote", "batch_id", batchID, "error", err)
+			return
+		}
+		for _, task := range tasks {
+			workers.Submit(func() { processPromptTask(task) })
+		}
+		if len(tasks) < batchPageSize {
+			return
+		}
+		last = *tasks[len(tasks)-1].BatchIndex
+	}
+}
+
+// ResumeBatches vuelve a encolar las tareas pendientes de los lotes que quedaron a medias,
+// por ejemplo al reiniciar el servidor. Las tareas que otro worker ya tomó no se repiten.
+func ResumeBatches(ctx context.Context) error {
+	var batchIDs []string
+	err := db.DB.WithContext(ctx).Model(&models.GeminiProcessingDB{}).
+		Distinct("batch_id").
+		Where("batch_id IS NOT NULL AND status = ?", models.StatusPending).
+		Pluck("batch_id", &batchIDs).Error
+	if err != nil {
+		return err
+	}
+	for _, id := range batchIDs {
+		slog.InfoContext(ctx, "reanudando lote", "batch_id", id)
+		go dispatchBatch(id)
+	}
+	return nil
+}
+
+// findBatch busca el lote del parámetro :id en la organización de la petición; si no existe
+// responde el error y devuelve ok=false.
+func findBatch(c *gin.Context) (models.BatchDB, bool) {
+	var batch models.BatchDB
+	err := db.DB.WithContext(c.Request.Context()).
+		Where("org_id = ?", middleware.OrgID(c)).
+		First(&batch, "id = ?", c.Param("id")).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeBatchNotFound, i18n.ErrBatchNotFound))
+			return batch, false
+		}
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return batch, false
+	}
+	return batch, true
+}
+
+// batchProgress cuenta las tareas del lote por estado y suma su consumo, incluidas las
+// eliminadas, que conservan su estado y su consumo.
+func batchProgress(ctx context.Context, batch models.BatchDB) (models.Batch, error) {
+	var rows []struct {
+		Status        models.GeminiProcessingStatus
+		Count         int
+		TotalTokens   int64
+		EstimatedCost float64
+	}
+	err := db.DB.WithContext(ctx).Unscoped().Model(&models.GeminiProcessingDB{}).
+		Select("status, COUNT(*) AS count, COALESCE(SUM(total_tokens), 0) AS total_tokens, COALESCE(SUM(estimated_cost), 0) AS estimated_cost").
+		Where("batch_id = ?", batch.ID).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return models.Batch{}, err
+	}
+
+	progress := models.Batch{
+		ID:        batch.ID,
+		Total:     batch.Total,
+		Template:  models.NewTaskTemplate(batch.TemplateName, batch.TemplateVersion),
+		CreatedAt: batch.CreatedAt,
+	}
+	for _, row := range rows {
+		switch row.Status {
+		case models.StatusPending:
+			progress.Pending = row.Count
+		case models.StatusProcessing:
+			progress.Processing = row.Count
+		case models.StatusCompleted:
+			progress.Completed = row.Count
+		case models.StatusError:
+			progress.Failed = row.Count
+		}
+		progress.TotalTokens += row.TotalTokens
+		progress.EstimatedCost += row.EstimatedCost
+	}
+
+	// Las tareas eliminadas (por su usuario o por la política de retención) cuentan como terminadas.
+	finished := batch.Total - progress.Pending - progress.Processing
+	switch {
+	case finished == batch.Total:
+		progress.Status = models.StatusCompleted
+		progress.ResultsURL = "/v1/gemini/batches/" + batch.ID + "/results"
+	case finished == 0 && progress.Processing == 0:
+		progress.Status = models.StatusPending
+	default:
+		progress.Status = models.StatusProcessing
+	}
+	if batch.Total > 0 {
+		progress.Progress = float64(finished) / float64(batch.Total)
+	}
+	return progress, nil
+}
+
+// GetBatch @Summary Obtener el progreso de un lote
+// @Description Devuelve cuántas tareas del lote están pendientes, en proceso, completadas o con error y el consumo total.
+// @Description Cuando todas terminaron, results_url indica dónde descargar los resultados.
+// @Tags gemini
+// @Produce json
+// @Param id path string true "ID del lote"
+// @Success 200 {object} models.Batch "Progreso del lote"
+// @Failure 404 {object} models.ErrorResponse "Lote no encontrado"
+// @Failure 500 {object} models.ErrorResponse "Error interno"
+// @Router /v1/gemini/batches/{id} [get]
+func GetBatch(c *gin.Context) {
+	batch, ok := findBatch(c)
+	if !ok {
+		return
+	}
+	progress, err := batchProgress(c.Request.Context(), batch)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	progress.StatusLabel = progress.Status.Label(i18n.FromContext(c.Request.Context()))
+	c.JSON(http.StatusOK, progress)
+}
+
+// GetBatchResults @Summary Descargar los resultados de un lote
+// @Description Descarga el resultado de cada tarea del lote, en el orden de los prompts o de las filas del CSV.
+// @Description Con format=jsonl (por defecto) cada línea es un models.BatchResult; con format=csv las columnas son
+// @Description index, task_id, status, result, result_json, error_code y error.
+// @Tags gemini
+// @Produce json,text/csv
+// @Param id path string true "ID del lote"
+// @Param format query string false "Formato de descarga" Enums(jsonl, csv)
+// @Success 200 {file} file "Resultados del lote"
+// @Failure 400 {object} models.ErrorResponse "Formato inválido"
+// @Failure 404 {object} models.ErrorResponse "Lote no encontrado"
+// @Failure 409 {object} models.ErrorResponse "El lote aún no termina"
+// @Failure 500 {object} models.ErrorResponse "Error interno"
+// @Router /v1/gemini/batches/{id}/results [get]
+func GetBatchResults(c *gin.Context) {
+	format := c.DefaultQuery("format", models.BatchFormatJSONL)
+	if format != models.BatchFormatJSONL && format != models.BatchFormatCSV {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, "format"))
+		return
+	}
+	batch, ok := findBatch(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	progress, err := batchProgress(ctx, batch)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	if progress.Status != models.StatusCompleted {
+		apierror.Abort(c, apierror.New(http.StatusConflict, apierror.CodeBatchNotFinished, i18n.ErrBatchNotFinished).
+			WithDetails(gin.H{"progress": progress.Progress}))
+		return
+	}
+
+	contentType := "application/x-ndjson"
+	if format == models.BatchFormatCSV {
+		contentType = "text/csv; charset=utf-8"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="batch-`+batch.ID+"."+format+`"`)
+	c.Status(http.StatusOK)
+
+	lang := i18n.FromContext(ctx)
+	var write func(models.BatchResult) error
+	var csvWriter *csv.Writer
+	if format == models.BatchFormatCSV {
+		csvWriter = csv.NewWriter(c.Writer)
+		_ = csvWriter.Write([]string{"index", "task_id", "status", "result", "result_json", "error_code", "error"})
+		write = func(r models.BatchResult) error {
+			var code, message string
+			if r.Error != nil {
+				code, message = r.Error.Code, r.Error.Message
+			}
+			return csvWriter.Write([]string{strconv.Itoa(r.Index), r.TaskID, string(r.Status), r.Result, string(r.ResultJSON), code, message})
+		}
+	} else {
+		encoder := json.NewEncoder(c.Writer)
+		write = func(r models.BatchResult) error { return encoder.Encode(r) }
+	}
+
+	// La respuesta ya empezó: un error al leer o escribir solo se registra.
+	last := 0
+	for {
+		var tasks []models.GeminiProcessingDB
+		err := db.DB.WithContext(ctx).
+			Select("id, batch_index, status, result, result_json, error_code, error, error_details").
+			Where("batch_id = ? AND batch_index > ?", batch.ID, last).
+			Order("batch_index").
+			Limit(batchPageSize).
+			Find(&tasks).Error
+		if err != nil {
+			slog.ErrorContext(ctx, "error al leer los resultados del lote", "batch_id", batch.ID, "error", err)
+			return
+		}
+		for _, task := range tasks {
+			err := write(models.BatchResult{
+				Index:      *task.BatchIndex,
+				TaskID:     task.ID,
+				Status:     task.Status,
+				Result:     task.Result,
+				ResultJSON: task.ResultJSON,
+				Error:      localizedTaskError(lang, task.ErrorCode, task.Error, task.ErrorDetails),
+			})
+			if err != nil {
+				slog.WarnContext(ctx, "error al escribir los resultados del lote", "batch_id", batch.ID, "error", err)
+				return
+			}
+		}
+		if csvWriter != nil {
+			csvWriter.Flush()
+		}
+		if len(tasks) < batchPageSize {
+			return
+		}
+		last = *tasks[len(tasks)-1].BatchIndex
+	}
+}
@@ -0,0 +1,442 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/db"
+	"github.com/Efren-Garza-Z/go-api-gemini/gemini"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/logging"
+	"github.com/Efren-Garza-Z/go-api-gemini/middleware"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/rag"
+	"github.com/Efren-Garza-Z/go-api-gemini/telemetry"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultQueryTopK es la cantidad de fragmentos que se incluyen en el prompt si no se indica top_k.
+const defaultQueryTopK = 5
+
+// findCollection busca la colección del parámetro :id en la organización de la petición; si no
+// existe responde el error y devuelve ok=false. Los documentos se buscan siempre dentro de ella.
+func findCollection(c *gin.Context) (models.CollectionDB, bool) {
+	var collection models.CollectionDB
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidID))
+		return collection, false
+	}
+	err = db.DB.WithContext(c.Request.Context()).Where("org_id = ?", middleware.OrgID(c)).First(&collection, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeCollectionNotFound, i18n.ErrCollectionNotFound))
+			return collection, false
+		}
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return collection, false
+	}
+	return collection, true
+}
+
+// documentResponse convierte DocumentDB en la respuesta con el estado y el error traducidos.
+func documentResponse(lang i18n.Lang, document models.DocumentDB) models.Document {
+	return models.Document{
+		ID:           document.ID,
+		CollectionID: document.CollectionID,
+		Filename:     document.Filename,
+		MIMEType:     document.MIMEType,
+		Status:       document.Status,
+		StatusLabel:  document.Status.Label(lang),
+		Pages:        document.Pages,
+		Chunks:       document.Chunks,
+		Error:        localizedTaskError(lang, document.ErrorCode, document.Error, nil),
+		CreatedAt:    document.CreatedAt,
+	}
+}
+
+// CreateCollection @Summary Crear una colección de documentos
+// @Description Crea una colección donde se suben documentos para hacerles preguntas.
+// @Tags collections
+// @Accept json
+// @Produce json
+// @Param input body models.CreateCollectionInput true "Datos de la colección"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.Collection
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/collections [post]
+func CreateCollection(c *gin.Context) {
+	var input models.CreateCollectionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+
+	collection := models.CollectionDB{
+		Name:        input.Name,
+		Description: input.Description,
+		OrgID:       orgRef(c),
+		UserID:      middleware.UserID(c),
+	}
+	if err := db.DB.WithContext(c.Request.Context()).Create(&collection).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			apierror.Abort(c, apierror.Wrap(err, http.StatusConflict, apierror.CodeCollectionNameTaken, i18n.ErrCollectionTaken))
+			return
+		}
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+
+	c.JSON(http.StatusCreated, collection.ToResponse())
+}
+
+// ListCollections @Summary Listar colecciones
+// @Description Devuelve las colecciones de documentos de la organización.
+// @Tags collections
+// @Produce json
+// @Success 200 {array} models.Collection
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/collections [get]
+func ListCollections(c *gin.Context) {
+	var collections []models.CollectionDB
+	err := db.DB.WithContext(c.Request.Context()).
+		Where("org_id = ?", middleware.OrgID(c)).
+		Order("name").
+		Find(&collections).Error
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+
+	response := make([]models.Collection, len(collections))
+	for i, collection := range collections {
+		response[i] = collection.ToResponse()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetCollection @Summary Obtener una colección
+// @Tags collections
+// @Produce json
+// @Param id path int true "ID de la colección"
+// @Success 200 {object} models.Collection
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /v1/collections/{id} [get]
+func GetCollection(c *gin.Context) {
+	collection, ok := findCollection(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, collection.ToResponse())
+}
+
+// DeleteCollection @Summary Eliminar una colección
+// @Description Elimina la colección junto con sus documentos y fragmentos indexados.
+// @Tags collections
+// @Param id path int true "ID de la colección"
+// @Success 204 "Colección eliminada"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/collections/{id} [delete]
+func DeleteCollection(c *gin.Context) {
+	collection, ok := findCollection(c)
+	if !ok {
+		return
+	}
+	if err := db.DB.WithContext(c.Request.Context()).Delete(&collection).Error; err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// UploadDocument @Summary Subir un documento a una colección
+// @Description Sube un documento (PDF, texto o Markdown) que se indexa en segundo plano: se extrae su texto,
+// @Description se divide en fragmentos y se guardan sus embeddings. El estado se consulta en status_url.
+// @Tags collections
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "ID de la colección"
+// @Security ApiKeyAuth
+// @Param file formData file true "Documento (PDF, TXT, MD)"
+// @Success 202 {object} models.DocumentCreatedResponse "Documento en cola"
+// @Header  202 {string} Location "URL para consultar el documento"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse "Cola de procesamiento llena; reintentar después de Retry-After"
+// @Router /v1/collections/{id}/documents [post]
+func UploadDocument(c *gin.Context) {
+	collection, ok := findCollection(c)
+	if !ok {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeValidationFailed, i18n.ErrFileRequired).
+			WithDetails([]apierror.FieldError{{Field: "file", Rule: "required"}}))
+		return
+	}
+	fileType := fileHeader.Header.Get("Content-Type")
+	if !slices.Contains(rag.SupportedTypes, fileType) {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeUnsupportedFileType, i18n.ErrUnsupportedFile, fileType).
+			WithDetails(gin.H{"allowed": rag.SupportedTypes}))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrFileOpenFailed))
+		return
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrFileReadFailed))
+		return
+	}
+
+	ctx := c.Request.Context()
+	traceParent, traceState := telemetry.InjectTraceContext(ctx)
+	document := models.DocumentDB{
+		CollectionID: collection.ID,
+		Filename:     fileHeader.Filename,
+		MIMEType:     fileType,
+		Status:       models.StatusPending,
+		UserID:       middleware.UserID(c),
+		RequestID:    logging.RequestID(ctx),
+		TraceParent:  traceParent,
+		TraceState:   traceState,
+	}
+	if err := db.DB.WithContext(ctx).Omit("Collection").Create(&document).Error; err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+
+	slog.InfoContext(ctx, "documento recibido",
+		"document_id", document.ID,
+		"collection_id", collection.ID,
+		"file_type", fileType,
+		"file_size", len(data))
+	// El contenido del documento no se guarda, así que si la cola está llena no puede quedar
+	// pendiente: se descarta y se pide al cliente que reintente.
+	if !workers.TrySubmit(func() { processDocument(document, data) }) {
+		slog.WarnContext(ctx, "cola de workers llena: documento rechazado", "document_id", document.ID)
+		db.DB.WithContext(ctx).Delete(&models.DocumentDB{}, document.ID)
+		c.Header("Retry-After", "5")
+		apierror.Abort(c, apierror.New(http.StatusServiceUnavailable, apierror.CodeServerBusy, i18n.ErrServerBusy))
+		return
+	}
+
+	statusURL := "/v1/collections/" + strconv.Itoa(int(collection.ID)) + "/documents/" + strconv.Itoa(int(document.ID))
+	c.Header("Location", statusURL)
+	c.JSON(http.StatusAccepted, models.DocumentCreatedResponse{ID: document.ID, StatusURL: statusURL})
+}
+
+// ListDocuments @Summary Listar los documentos de una colección
+// @Tags collections
+// @Produce json
+// @Param id path int true "ID de la colección"
+// @Success 200 {array} models.Document
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/collections/{id}/documents [get]
+func ListDocuments(c *gin.Context) {
+	collection, ok := findCollection(c)
+	if !ok {
+		return
+	}
+
+	var documents []models.DocumentDB
+	err := db.DB.WithContext(c.Request.Context()).
+		Where("collection_id = ?", collection.ID).
+		Order("id").
+		Find(&documents).Error
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+
+	lang := i18n.FromContext(c.Request.Context())
+	response := make([]models.Document, len(documents))
+	for i, document := range documents {
+		response[i] = documentResponse(lang, document)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// findDocument busca el documento :document_id dentro de la colección; si no existe responde el error.
+func findDocument(c *gin.Context, collection models.CollectionDB) (models.DocumentDB, bool) {
+	var document models.DocumentDB
+	id, err := strconv.Atoi(c.Param("document_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidID))
+		return document, false
+	}
+	err = db.DB.WithContext(c.Request.Context()).
+		Where("collection_id = ?", collection.ID).
+		First(&document, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeDocumentNotFound, i18n.ErrDocumentNotFound))
+			return document, false
+		}
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return document, false
+	}
+	return document, true
+}
+
+// GetDocument @Summary Obtener el estado de un documento
+// @Tags collections
+// @Produce json
+// @Param id path int true "ID de la colección"
+// @Param document_id path int true "ID del documento"
+// @Success 200 {object} models.Document
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /v1/collections/{id}/documents/{document_id} [get]
+func GetDocument(c *gin.Context) {
+	collection, ok := findCollection(c)
+	if !ok {
+		return
+	}
+	document, ok := findDocument(c, collection)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, documentResponse(i18n.FromContext(c.Request.Context()), document))
+}
+
+// DeleteDocument @Summary Eliminar un documento
+// @Description Elimina el documento y sus fragmentos indexados.
+// @Tags collections
+// @Param id path int true "ID de la colección"
+// @Param document_id path int true "ID del documento"
+// @Success 204 "Documento eliminado"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/collections/{id}/documents/{document_id} [delete]
+func DeleteDocument(c *gin.Context) {
+	collection, ok := findCollection(c)
+	if !ok {
+		return
+	}
+	document, ok := findDocument(c, collection)
+	if !ok {
+		return
+	}
+	if err := db.DB.WithContext(c.Request.Context()).Delete(&document).Error; err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// QueryCollection @Summary Preguntar a una colección
+// @Description Busca los fragmentos más relevantes de los documentos de la colección, los incluye como fuentes en el prompt
+// @Description y crea una tarea de Gemini. La respuesta de la tarea cita las fuentes como [n] y trae en citations
+// @Description el documento, la página y el fragmento de cada una.
+// @Tags collections
+// @Accept json
+// @Produce json
+// @Param id path int true "ID de la colección"
+// @Security ApiKeyAuth
+// @Param input body models.CollectionQueryRequest true "Pregunta"
+// @Success 202 {object} models.TaskCreatedResponse "Tarea creada"
+// @Header  202 {string} Location "URL para consultar la tarea"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "La colección no tiene documentos indexados"
+// @Failure 429 {object} models.ErrorResponse "Límite de peticiones o cuota agotada"
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 502 {object} models.ErrorResponse "Error de Gemini"
+// @Router /v1/collections/{id}/query [post]
+func QueryCollection(c *gin.Context) {
+	collection, ok := findCollection(c)
+	if !ok {
+		return
+	}
+	var request models.CollectionQueryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+	topK := request.TopK
+	if topK == 0 {
+		topK = defaultQueryTopK
+	}
+
+	ctx := c.Request.Context()
+	vectors, err := embed(ctx, []string{request.Question}, gemini.EmbedQuery)
+	if err != nil {
+		apierror.Abort(c, gemini.TaskError(err))
+		return
+	}
+	citations, err := searchChunks(ctx, collection.ID, models.Vector(vectors[0]), topK)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	if len(citations) == 0 {
+		apierror.Abort(c, apierror.New(http.StatusConflict, apierror.CodeCollectionEmpty, i18n.ErrCollectionEmpty))
+		return
+	}
+
+	sources := make([]rag.Source, len(citations))
+	for i, citation := range citations {
+		sources[i] = rag.Source{Filename: citation.Filename, Page: citation.Page, Text: citation.Text}
+	}
+	encoded, err := json.Marshal(citations)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal(err))
+		return
+	}
+
+	task := models.GeminiProcessingDB{
+		Prompt:       rag.BuildPrompt(request.Question, sources),
+		CollectionID: &collection.ID,
+		Citations:    models.JSON(encoded),
+	}
+	if !enqueuePromptTask(c, &task) {
+		return
+	}
+	respondTaskCreated(c, "/v1/gemini/tasks/"+task.ID, task.ID)
+}
+
+// searchChunks busca los topK fragmentos de la colección más cercanos al vector y los
+// devuelve como citas numeradas desde 1, en orden de relevancia.
+func searchChunks(ctx context.Context, collectionID uint, vector models.Vector, topK int) ([]models.Citation, error) {
+	citations := []models.Citation{}
+	err := db.DB.WithContext(ctx).
+		Table(models.DocumentChunkDB{}.TableName()+" AS chunk").
+		Select("chunk.document_id, document.filename, chunk.page, chunk.chunk_index, chunk.text, 1 - (chunk.embedding <=> ?) AS score", vector).
+		Joins("JOIN "+models.DocumentDB{}.TableName()+" AS document ON document.id = chunk.document_id").
+		Where("chunk.collection_id = ? AND document.status = ?", collectionID, models.StatusCompleted).
+		Order(clause.Expr{SQL: "chunk.embedding <=> ?", Vars: []interface{}{vector}}).
+		Limit(topK).
+		Scan(&citations).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range citations {
+		citations[i].Index = i + 1
+	}
+	return citations, nil
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/db/dbtest"
+	"github.com/Efren-Garza-Z/go-api-gemini/gemini"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+)
+
+// La búsqueda usa el operador de distancia de pgvector, así que necesita Postgres.
+func TestSearchChunksCitesTheClosestChunks(t *testing.T) {
+	conn := dbtest.Postgres(t)
+	if err := models.EnableVector(conn); err != nil {
+		t.Fatalf("EnableVector: %v", err)
+	}
+	if err := conn.AutoMigrate(&models.CollectionDB{}, &models.DocumentDB{}, &models.DocumentChunkDB{}); err != nil {
+		t.Fatalf("crear las tablas: %v", err)
+	}
+	SetEmbedder(gemini.FakeEmbedder{})
+	t.Cleanup(func() { SetEmbedder(&gemini.Service{}) })
+
+	ctx := context.Background()
+	collection := models.CollectionDB{Name: "becas " + t.Name()}
+	other := models.CollectionDB{Name: "otra " + t.Name()}
+	for _, c := range []*models.CollectionDB{&collection, &other} {
+		if err := conn.Create(c).Error; err != nil {
+			t.Fatalf("crear la colección: %v", err)
+		}
+	}
+	t.Cleanup(func() { conn.Delete(&models.CollectionDB{}, []uint{collection.ID, other.ID}) })
+
+	const answer = "El promedio mínimo para renovar la beca es 8.5."
+	documents := []struct {
+		collection uint
+		filename   string
+		status     models.GeminiProcessingStatus
+		text       string
+	}{
+		{collection.ID, "reglamento.txt", models.StatusCompleted, answer},
+		{collection.ID, "cafeteria.txt", models.StatusCompleted, "La cafetería abre a las siete de la mañana."},
+		// Ni los documentos que se están indexando ni los de otra colección se citan.
+		{collection.ID, "borrador.txt", models.StatusProcessing, answer},
+		{other.ID, "reglamento.txt", models.StatusCompleted, answer},
+	}
+	ids := make([]uint, len(documents))
+	for i, d := range documents {
+		document := models.DocumentDB{CollectionID: d.collection, Filename: d.filename, MIMEType: "text/plain", Status: d.status}
+		if err := conn.Omit("Collection").Create(&document).Error; err != nil {
+			t.Fatalf("crear %s: %v", d.filename, err)
+		}
+		if _, _, err := indexDocument(ctx, document, []byte(d.text)); err != nil {
+			t.Fatalf("indexar %s: %v", d.filename, err)
+		}
+		ids[i] = document.ID
+	}
+
+	vectors, err := embed(ctx, []string{"¿Qué promedio necesito para renovar la beca?"}, gemini.EmbedQuery)
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	citations, err := searchChunks(ctx, collection.ID, models.Vector(vectors[0]), 5)
+	if err != nil {
+		t.Fatalf("searchChunks: %v", err)
+	}
+	if len(citations) != 2 {
+		t.Fatalf("citas = %+v, se esperaban los 2 documentos completados de la colección", citations)
+	}
+	first, second := citations[0], citations[1]
+	if first.Index != 1 || first.DocumentID != ids[0] || first.Filename != "reglamento.txt" || first.Page != 1 || first.Text != answer {
+		t.Errorf("primera cita = %+v, se esperaba el reglamento", first)
+	}
+	if second.Index != 2 || second.DocumentID != ids[1] || second.Score >= first.Score {
+		t.Errorf("segunda cita = %+v, se esperaba la cafetería con menor puntaje que %v", second, first.Score)
+	}
+
+	if citations, err := searchChunks(ctx, collection.ID, models.Vector(vectors[0]), 1); err != nil || len(citations) != 1 {
+		t.Errorf("searchChunks con topK 1: %d citas, err = %v", len(citations), err)
+	}
+}
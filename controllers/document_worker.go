@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/db"
+	"github.com/Efren-Garza-Z/go-api-gemini/gemini"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/rag"
+	"go.opentelemetry.io/otel/codes"
+	"gorm.io/gorm"
+)
+
+// documentError convierte un error de indexación en el error que se guarda en el documento.
+func documentError(err error) *apierror.Error {
+	switch {
+	case errors.Is(err, rag.ErrNoText):
+		return apierror.Wrap(err, http.StatusUnprocessableEntity, apierror.CodeDocumentEmpty, i18n.ErrDocumentEmpty)
+	case errors.Is(err, rag.ErrUnsupportedType):
+		return apierror.Wrap(err, http.StatusUnprocessableEntity, apierror.CodeDocumentExtractionFailed, i18n.ErrDocumentExtraction)
+	default:
+		return gemini.TaskError(err)
+	}
+}
+
+// updateDocument actualiza las columnas de un documento y registra el error si la actualización falla.
+func updateDocument(ctx context.Context, id uint, values map[string]interface{}) {
+	err := db.DB.WithContext(ctx).Model(&models.DocumentDB{}).
+		Where("id = ?", id).
+		Updates(values).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "error al actualizar el documento", "status", values["status"], "error", err)
+	}
+}
+
+// processDocument indexa un documento en segundo plano: extrae su texto, lo divide en
+// fragmentos, genera sus embeddings y los guarda reemplazando los anteriores.
+func processDocument(document models.DocumentDB, data []byte) {
+	id := strconv.Itoa(int(document.ID))
+	ctx, span := startTaskSpan("rag.document.process", id, document.RequestID, document.TraceParent, document.TraceState)
+	defer span.End()
+
+	start := time.Now()
+	slog.InfoContext(ctx, "indexando documento", "file_type", document.MIMEType)
+	updateDocument(ctx, document.ID, map[string]interface{}{"status": models.StatusProcessing})
+
+	pages, chunks, err := indexDocument(ctx, document, data)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		slog.ErrorContext(ctx, "error indexando documento", "error", err, "duration", time.Since(start))
+		docErr := documentError(err)
+		updateDocument(ctx, document.ID, map[string]interface{}{
+			"status":     models.StatusError,
+			"error_code": string(docErr.Code),
+			"error":      docErr.Message(i18n.Default),
+		})
+		return
+	}
+
+	slog.InfoContext(ctx, "documento indexado", "duration", time.Since(start), "pages", pages, "chunks", chunks)
+	updateDocument(ctx, document.ID, map[string]interface{}{
+		"status": models.StatusCompleted,
+		"pages":  pages,
+		"chunks": chunks,
+	})
+}
+
+// indexDocument guarda los fragmentos del documento con sus embeddings y devuelve
+// cuántas páginas y fragmentos se indexaron.
+func indexDocument(ctx context.Context, document models.DocumentDB, data []byte) (int, int, error) {
+	pages, err := rag.Extract(data, document.MIMEType)
+	if err != nil {
+		return 0, 0, err
+	}
+	chunks := rag.Split(pages, rag.ChunkSize(), rag.ChunkOverlap())
+	if len(chunks) == 0 {
+		return 0, 0, rag.ErrNoText
+	}
+
+	rows := make([]models.DocumentChunkDB, 0, len(chunks))
+	for start := 0; start < len(chunks); start += gemini.MaxEmbeddingBatch {
+		batch := chunks[start:min(start+gemini.MaxEmbeddingBatch, len(chunks))]
+		texts := make([]string, len(batch))
+		for i, chunk := range batch {
+			texts[i] = chunk.Text
+		}
+		vectors, err := embed(ctx, texts, gemini.EmbedDocument)
+		if err != nil {
+			return 0, 0, err
+		}
+		for i, chunk := range batch {
+			rows = append(rows, models.DocumentChunkDB{
+				DocumentID:   document.ID,
+				CollectionID: document.CollectionID,
+				ChunkIndex:   chunk.Index,
+				Page:         chunk.Page,
+				Text:         chunk.Text,
+				Model:        embedder.Model(),
+				Embedding:    vectors[i],
+			})
+		}
+	}
+
+	err = db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("document_id = ?", document.ID).Delete(&models.DocumentChunkDB{}).Error; err != nil {
+			return err
+		}
+		return tx.Omit("Document").CreateInBatches(&rows, 500).Error
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(pages), len(rows), nil
+}
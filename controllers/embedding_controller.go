@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/db"
+	"github.com/Efren-Garza-Z/go-api-gemini/gemini"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/middleware"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// defaultTopK es la cantidad de resultados de una búsqueda si no se indica top_k.
+const defaultTopK = 5
+
+// embedder genera los embeddings de los endpoints de embeddings y búsqueda.
+var embedder gemini.Embedder = &gemini.Service{}
+
+// SetEmbedder configura el generador de embeddings (por ejemplo gemini.FakeEmbedder en pruebas).
+func SetEmbedder(e gemini.Embedder) {
+	embedder = e
+}
+
+// namespaceOrDefault devuelve el namespace indicado o el namespace por defecto.
+func namespaceOrDefault(namespace string) string {
+	if namespace == "" {
+		return models.DefaultNamespace
+	}
+	return namespace
+}
+
+// CreateEmbeddings @Summary Generar embeddings
+// @Description Genera los embeddings de un texto ("text") o de hasta 100 textos ("items") y, salvo que store sea false,
+// @Description los guarda en el namespace indicado para usarlos en búsquedas. Los items con external_id reemplazan al existente.
+// @Tags gemini
+// @Accept  json
+// @Produce  json
+// @Param   requestBody body models.EmbeddingRequest true "Textos a convertir"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.EmbeddingResponse "Embeddings generados"
+// @Failure 400 {object} models.ErrorResponse "Solicitud inválida"
+// @Failure 429 {object} models.ErrorResponse "Límite de peticiones o cuota agotada"
+// @Failure 500 {object} models.ErrorResponse "Error interno"
+// @Failure 502 {object} models.ErrorResponse "Error de Gemini"
+// @Router /v1/gemini/embeddings [post]
+func CreateEmbeddings(c *gin.Context) {
+	var request models.EmbeddingRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+
+	items := request.Items
+	switch {
+	case request.Text != "" && len(items) > 0, request.Text == "" && len(items) == 0:
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeValidationFailed, i18n.ErrEmbeddingInput).
+			WithDetails([]apierror.FieldError{{Field: "text", Rule: "required_without", Param: "items"}}))
+		return
+	case request.Text != "":
+		items = []models.EmbeddingInput{{Text: request.Text}}
+	}
+
+	ctx := c.Request.Context()
+	texts := make([]string, len(items))
+	for i, item := range items {
+		texts[i] = item.Text
+	}
+	vectors, err := embed(ctx, texts, gemini.EmbedDocument)
+	if err != nil {
+		apierror.Abort(c, gemini.TaskError(err))
+		return
+	}
+
+	namespace := namespaceOrDefault(request.Namespace)
+	response := models.EmbeddingResponse{
+		Model:      embedder.Model(),
+		Namespace:  namespace,
+		Dimensions: gemini.EmbeddingDimensions,
+		Data:       make([]models.EmbeddingResult, len(items)),
+	}
+	for i, item := range items {
+		response.Data[i] = models.EmbeddingResult{ExternalID: item.ExternalID, Embedding: vectors[i]}
+	}
+
+	if request.Store == nil || *request.Store {
+		userID, org := middleware.UserID(c), orgRef(c)
+		rows := make([]models.EmbeddingDB, len(items))
+		for i, item := range items {
+			rows[i] = models.EmbeddingDB{
+				Namespace:  namespace,
+				ExternalID: item.ExternalID,
+				Text:       item.Text,
+				Metadata:   item.Metadata,
+				Model:      embedder.Model(),
+				Embedding:  vectors[i],
+				OrgID:      org,
+				UserID:     userID,
+			}
+		}
+		// Los items con external_id reemplazan al embedding existente del namespace.
+		err := db.DB.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "org_id"}, {Name: "namespace"}, {Name: "external_id"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "external_id <> ''"}}},
+			DoUpdates:   clause.AssignmentColumns([]string{"text", "metadata", "model", "embedding", "user_id", "updated_at"}),
+		}).Create(&rows).Error
+		if err != nil {
+			apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+			return
+		}
+		for i := range rows {
+			response.Data[i].ID = rows[i].ID
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SearchEmbeddings @Summary Búsqueda semántica
+// @Description Devuelve los top_k textos guardados del namespace de la organización más parecidos a la consulta (similitud coseno).
+// @Description Con "filter" solo se consideran los textos cuya metadata contiene ese objeto.
+// @Tags gemini
+// @Accept  json
+// @Produce  json
+// @Param   requestBody body models.SearchRequest true "Consulta"
+// @Success 200 {object} models.SearchResponse "Resultados ordenados por similitud"
+// @Failure 400 {object} models.ErrorResponse "Solicitud inválida"
+// @Failure 429 {object} models.ErrorResponse "Límite de peticiones"
+// @Failure 500 {object} models.ErrorResponse "Error interno"
+// @Failure 502 {object} models.ErrorResponse "Error de Gemini"
+// @Router /v1/gemini/search [post]
+func SearchEmbeddings(c *gin.Context) {
+	var request models.SearchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+	topK := request.TopK
+	if topK == 0 {
+		topK = defaultTopK
+	}
+
+	ctx := c.Request.Context()
+	vectors, err := embed(ctx, []string{request.Query}, gemini.EmbedQuery)
+	if err != nil {
+		apierror.Abort(c, gemini.TaskError(err))
+		return
+	}
+
+	namespace := namespaceOrDefault(request.Namespace)
+	matches, err := searchEmbeddings(ctx, middleware.OrgID(c), namespace, models.Vector(vectors[0]), request.Filter, topK)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SearchResponse{
+		Model:     embedder.Model(),
+		Namespace: namespace,
+		Matches:   matches,
+	})
+}
+
+// searchEmbeddings busca los topK embeddings del namespace de la organización más cercanos al
+// vector por distancia coseno, opcionalmente filtrando por metadata.
+func searchEmbeddings(ctx context.Context, orgID uint, namespace string, vector models.Vector, filter models.JSON, topK int) ([]models.SearchMatch, error) {
+	query := db.DB.WithContext(ctx).
+		Model(&models.EmbeddingDB{}).
+		Select("id, external_id, text, metadata, 1 - (embedding <=> ?) AS score", vector).
+		Where("org_id = ? AND namespace = ?", orgID, namespace)
+	if len(filter) > 0 {
+		query = query.Where("metadata @> ?", filter)
+	}
+
+	matches := []models.SearchMatch{}
+	err := query.Order(clause.Expr{SQL: "embedding <=> ?", Vars: []interface{}{vector}}).
+		Limit(topK).
+		Scan(&matches).Error
+	return matches, err
+}
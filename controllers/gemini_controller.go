@@ -1,132 +1,246 @@
 package controllers
 
 import (
-	"bytes"
-	"fmt"
+	"encoding/json"
+	"errors"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
 	"github.com/Efren-Garza-Z/go-api-gemini/db"
 	"github.com/Efren-Garza-Z/go-api-gemini/gemini"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/logging"
+	"github.com/Efren-Garza-Z/go-api-gemini/middleware"
 	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/telemetry"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	_ "google.golang.org/api/option"
+	"gorm.io/gorm"
 )
 
+// abortTaskLookup responde el error de buscar una tarea por su ID.
+func abortTaskLookup(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeTaskNotFound, i18n.ErrTaskNotFound))
+		return
+	}
+	apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+}
+
+// localizedTaskError traduce el error guardado en una tarea según su código; si el código
+// no está en el catálogo se usa el mensaje guardado.
+func localizedTaskError(lang i18n.Lang, code string, stored string, details models.JSON) *models.TaskError {
+	if message, ok := i18n.Lookup(lang, i18n.CodeKey(code)); ok {
+		stored = message
+	}
+	return models.NewTaskError(code, stored, details)
+}
+
 // In-memory store para las tareas. En producción, usarías una base de datos.
 //var GeminiProcessing = make(map[string]models.GeminiProcessingResponse)
 //var mu sync.Mutex // Mutex para evitar race conditions en el map
 
-// ProcessPrompt @Summary Iniciar tarea asíncrona de Gemini
+// CreateTask @Summary Iniciar tarea asíncrona de Gemini
 // @Description Inicia una tarea en segundo plano para procesar un prompt con la API de Gemini.
+// @Description Con "schema" o "response_schema" la respuesta se pide en JSON, se valida contra el esquema y se devuelve en result_json.
+// @Description Con "tools" el modelo puede llamar herramientas internas; cada llamada y su resultado se devuelven en tool_calls.
+// @Description Con "template", "version" y "variables" el prompt se genera con una plantilla registrada en lugar de enviarse en "prompt".
+// @Description Si el caché de respuestas está activo, un prompt idéntico se responde desde el caché y la tarea indica cached=true.
 // @Tags gemini
 // @Accept  json
 // @Produce  json
 // @Param   requestBody body models.PromptRequest true "Prompt a procesar"
-// @Success 202 {object} models.GeminiProcessingIDResponse "Solicitud aceptada y procesando"
-// @Failure 400 {object} map[string]string "JSON de solicitud inválido"
-// @Router /gemini/process [post]
-func ProcessPrompt(c *gin.Context) {
-	var requestBody models.PromptRequest
-	if err := c.ShouldBindJSON(&requestBody); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "JSON de solicitud inválido"})
+// @Security ApiKeyAuth
+// @Param   Cache-Control header string false "no-cache para generar una respuesta nueva sin usar el caché"
+// @Success 202 {object} models.TaskCreatedResponse "Solicitud aceptada y procesando"
+// @Header  202 {string} Location "URL para consultar la tarea"
+// @Failure 400 {object} models.ErrorResponse "JSON de solicitud inválido"
+// @Failure 429 {object} models.ErrorResponse "Límite de peticiones o cuota agotada"
+// @Failure 500 {object} models.ErrorResponse "Error interno"
+// @Router /v1/gemini/tasks [post]
+func CreateTask(c *gin.Context) {
+	id, ok := createPromptTask(c)
+	if !ok {
 		return
 	}
+	respondTaskCreated(c, "/v1/gemini/tasks/"+id, id)
+}
 
-	GeminiProcessingID := uuid.New().String()
+// respondTaskCreated responde 202 con el ID de la tarea y la URL para consultarla.
+func respondTaskCreated(c *gin.Context, statusURL string, id string) {
+	c.Header("Location", statusURL)
+	c.JSON(http.StatusAccepted, models.TaskCreatedResponse{ID: id, StatusURL: statusURL})
+}
 
-	// Crear registro inicial en DB
-	newGeminiProcessing := models.GeminiProcessingDB{
-		ID:     GeminiProcessingID,
-		Status: models.StatusPending,
-		Prompt: requestBody.Prompt,
+// createPromptTask valida la petición, guarda la tarea de texto y la procesa en segundo plano.
+// Si algo falla responde el error y devuelve ok=false.
+func createPromptTask(c *gin.Context) (string, bool) {
+	var requestBody models.PromptRequest
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return "", false
 	}
-	if err := db.DB.Create(&newGeminiProcessing).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo crear la tarea"})
-		return
+	schemaName, responseSchema, ok := resolveResponseSchema(c, requestBody.Schema, requestBody.ResponseSchema)
+	if !ok {
+		return "", false
+	}
+	tools, ok := resolveTools(c, requestBody.Tools, responseSchema)
+	if !ok {
+		return "", false
 	}
 
-	// Procesar en segundo plano
-	go func(id string, prompt string) {
-		service := &gemini.Service{}
-
-		// Actualizar estado a en_proceso
-		db.DB.Model(&models.GeminiProcessingDB{}).
-			Where("id = ?", id).
-			Update("status", models.StatusProcessing)
-
-		result, err := service.GenerateContent(prompt)
-		if err != nil {
-			db.DB.Model(&models.GeminiProcessingDB{}).
-				Where("id = ?", id).
-				Updates(map[string]interface{}{
-					"status": models.StatusError,
-					"error":  err.Error(),
-				})
-		} else {
-			db.DB.Model(&models.GeminiProcessingDB{}).
-				Where("id = ?", id).
-				Updates(map[string]interface{}{
-					"status": models.StatusCompleted,
-					"result": result,
-				})
+	task := models.GeminiProcessingDB{
+		Prompt:         requestBody.Prompt,
+		SchemaName:     schemaName,
+		ResponseSchema: responseSchema,
+		Tools:          tools,
+	}
+	if requestBody.Template != "" {
+		if requestBody.Prompt != "" {
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrTemplateConflict))
+			return "", false
 		}
-	}(GeminiProcessingID, requestBody.Prompt)
+		if !applyTemplate(c, &task, requestBody.Template, requestBody.Version, requestBody.Variables) {
+			return "", false
+		}
+	}
+	if !enqueuePromptTask(c, &task) {
+		return "", false
+	}
+	return task.ID, true
+}
+
+// enqueuePromptTask completa los datos comunes de una tarea de texto (ID, organización,
+// usuario, modelo, petición y traza), la guarda y la procesa en segundo plano. Si algo falla responde el
+// error y devuelve false.
+func enqueuePromptTask(c *gin.Context, task *models.GeminiProcessingDB) bool {
+	ctx := c.Request.Context()
+	traceParent, traceState := telemetry.InjectTraceContext(ctx)
+
+	task.ID = uuid.New().String()
+	task.Status = models.StatusPending
+	task.OrgID = orgRef(c)
+	task.UserID = middleware.UserID(c)
+	task.APIKeyID = middleware.APIKeyID(c)
+	task.Model = gemini.DefaultModel
+	task.RequestID = logging.RequestID(ctx)
+	task.TraceParent = traceParent
+	task.TraceState = traceState
+	task.CacheBypass = cacheBypass(c)
+
+	// Crear registro inicial en DB
+	if err := db.DB.WithContext(ctx).Create(task).Error; err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrTaskCreateFailed))
+		return false
+	}
 
-	c.JSON(http.StatusAccepted, models.GeminiProcessingIDResponse{GeminiProcessingID: GeminiProcessingID})
+	// Procesar en segundo plano; con la cola llena la tarea queda pendiente y la encola
+	// RequeuePendingTasks, para no bloquear la petición.
+	slog.InfoContext(ctx, "tarea creada", "task_id", task.ID, logging.Prompt(task.Prompt))
+	queued := *task
+	if !workers.TrySubmit(func() { processPromptTask(queued) }) {
+		slog.WarnContext(ctx, "cola de workers llena: la tarea queda pendiente", "task_id", task.ID)
+	}
+
+	return true
 }
 
-// GetTaskStatus @Summary Obtener estado de la tarea de Gemini
-// @Description Consulta el estado y el resultado de una tarea por su ID.
+// GetTask @Summary Obtener estado de la tarea de Gemini
+// @Description Consulta el estado y el resultado de una tarea por su ID. Con ?wait=30s, si la tarea no ha terminado,
+// @Description la respuesta espera hasta que cambie de estado o se cumpla el tiempo (máximo LONG_POLL_MAX_WAIT).
 // @Tags gemini
 // @Accept  json
 // @Produce  json
-// @Param   gemini_processing_id path string true "ID del proceso"
+// @Param   id path string true "ID de la tarea"
+// @Param   wait query string false "Tiempo máximo de espera a un cambio de estado (por ejemplo 30s)"
 // @Success 200 {object} models.GeminiProcessingResponse "Estado del proceso y resultado"
-// @Failure 400 {object} map[string]string "ID de proceso inválido"
-// @Router /gemini/status/{gemini_processing_id} [get]
-func GetTaskStatus(c *gin.Context) {
-	geminiProcessingID := c.Param("gemini_processing_id")
+// @Failure 404 {object} models.ErrorResponse "ID de proceso no encontrado"
+// @Router /v1/gemini/tasks/{id} [get]
+func GetTask(c *gin.Context) {
+	geminiProcessingID := c.Param("id")
 	var geminiProcessing models.GeminiProcessingDB
 
-	if err := db.DB.First(&geminiProcessing, "id = ?", geminiProcessingID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "ID de proceso no encontrado"})
+	if !findTask(c, geminiProcessingID, &geminiProcessing, func() models.GeminiProcessingStatus { return geminiProcessing.Status }) {
 		return
 	}
 
 	// Convertir TaskDB → TaskResponse para no exponer Prompt ni timestamps
+	lang := i18n.FromContext(c.Request.Context())
 	response := models.GeminiProcessingResponse{
-		ID:     geminiProcessing.ID,
-		Status: geminiProcessing.Status,
-		Result: geminiProcessing.Result,
-		Error:  geminiProcessing.Error,
+		ID:          geminiProcessing.ID,
+		Status:      geminiProcessing.Status,
+		StatusLabel: geminiProcessing.Status.Label(lang),
+		Result:      geminiProcessing.Result,
+		ResultJSON:  geminiProcessing.ResultJSON,
+		ToolCalls:   geminiProcessing.ToolCalls,
+		Redactions:  geminiProcessing.Redactions,
+		Citations:   geminiProcessing.Citations,
+		Template:    models.NewTaskTemplate(geminiProcessing.TemplateName, geminiProcessing.TemplateVersion),
+		Cached:      geminiProcessing.Cached,
+		Error:       localizedTaskError(lang, geminiProcessing.ErrorCode, geminiProcessing.Error, geminiProcessing.ErrorDetails),
+		Usage: models.NewTaskUsage(geminiProcessing.Model, geminiProcessing.PromptTokens,
+			geminiProcessing.CandidateTokens, geminiProcessing.TotalTokens, geminiProcessing.EstimatedCost),
+		ResultPurgedAt: geminiProcessing.ResultPurgedAt,
 	}
 	c.JSON(http.StatusOK, response)
 }
 
-// GenerateWithFileController @Summary Generar contenido de Gemini con un archivo (asíncrono)
+// CreateFileTask @Summary Generar contenido de Gemini con un archivo (asíncrono)
 // @Description Procesa un prompt y un archivo de forma asíncrona, guarda los datos y retorna un ID de proceso.
 // @Tags gemini
 // @Accept  multipart/form-data
 // @Produce  json
+// @Security ApiKeyAuth
+// @Param   Cache-Control header string false "no-cache para generar una respuesta nueva sin usar el caché"
 // @Param   prompt formData string true "Texto del prompt"
 // @Param   file formData file true "Archivo (PDF, PNG, JPEG)"
-// @Success 202 {object} models.GeminiProcessingFileIDResponse "Proceso en cola"
-// @Failure 400 {object} map[string]string "Solicitud inválida"
-// @Router /gemini/process/file [post]
-func GenerateWithFileController(c *gin.Context) {
+// @Param   schema formData string false "Nombre de un esquema de respuesta registrado"
+// @Param   response_schema formData string false "JSON Schema que debe cumplir la respuesta"
+// @Param   tools formData []string false "Herramientas que el modelo puede llamar" collectionFormat(multi)
+// @Success 202 {object} models.TaskCreatedResponse "Proceso en cola"
+// @Header  202 {string} Location "URL para consultar la tarea"
+// @Failure 400 {object} models.ErrorResponse "Solicitud inválida"
+// @Failure 429 {object} models.ErrorResponse "Límite de peticiones o cuota agotada"
+// @Failure 500 {object} models.ErrorResponse "Error interno"
+// @Router /v1/gemini/file-tasks [post]
+func CreateFileTask(c *gin.Context) {
+	id, ok := createFileTask(c)
+	if !ok {
+		return
+	}
+	respondTaskCreated(c, "/v1/gemini/file-tasks/"+id, id)
+}
+
+// createFileTask valida el prompt y el archivo, guarda la tarea y la procesa en segundo plano.
+// Si algo falla responde el error y devuelve ok=false.
+func createFileTask(c *gin.Context) (string, bool) {
+	userID := middleware.UserID(c)
+
 	prompt := c.PostForm("prompt")
 	if prompt == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "El prompt es obligatorio"})
-		return
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeValidationFailed, i18n.ErrPromptRequired).
+			WithDetails([]apierror.FieldError{{Field: "prompt", Rule: "required"}}))
+		return "", false
+	}
+
+	schemaName, responseSchema, ok := resolveResponseSchema(c, c.PostForm("schema"), json.RawMessage(c.PostForm("response_schema")))
+	if !ok {
+		return "", false
+	}
+	tools, ok := resolveTools(c, c.PostFormArray("tools"), responseSchema)
+	if !ok {
+		return "", false
 	}
 
 	fileHeader, err := c.FormFile("file")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Se requiere un archivo"})
-		return
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeValidationFailed, i18n.ErrFileRequired).
+			WithDetails([]apierror.FieldError{{Field: "file", Rule: "required"}}))
+		return "", false
 	}
 
 	fileType := fileHeader.Header.Get("Content-Type")
@@ -134,94 +248,178 @@ func GenerateWithFileController(c *gin.Context) {
 	case "image/jpeg", "image/png", "application/pdf":
 		// Tipo de archivo válido
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Tipo de archivo no soportado: %s", fileType)})
-		return
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeUnsupportedFileType, i18n.ErrUnsupportedFile, fileType).
+			WithDetails(gin.H{"allowed": []string{"image/jpeg", "image/png", "application/pdf"}}))
+		return "", false
 	}
 
 	// Leer el contenido del archivo de forma segura en memoria
 	file, err := fileHeader.Open()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo abrir el archivo"})
-		return
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrFileOpenFailed))
+		return "", false
 	}
 	fileContent, err := io.ReadAll(file)
 	file.Close() // Cerrar el archivo después de leerlo
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo leer el archivo"})
-		return
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrFileReadFailed))
+		return "", false
 	}
 
 	// 1. Crear un ID único y guardar el registro inicial con el archivo
 	processID := uuid.New().String()
+	ctx := c.Request.Context()
+	traceParent, traceState := telemetry.InjectTraceContext(ctx)
 	geminiProcess := models.GeminiProcessingFileDB{
-		ID:     processID,
-		Status: models.StatusProcessing,
-		Prompt: prompt,
-		File:   fileContent, // Guardamos el contenido del archivo
+		ID:             processID,
+		Status:         models.StatusPending,
+		Prompt:         prompt,
+		File:           fileContent, // Guardamos el contenido del archivo
+		FileName:       fileHeader.Filename,
+		FileType:       fileType,
+		OrgID:          orgRef(c),
+		UserID:         userID,
+		APIKeyID:       middleware.APIKeyID(c),
+		SchemaName:     schemaName,
+		ResponseSchema: responseSchema,
+		Tools:          tools,
+		Model:          gemini.DefaultModel,
+		RequestID:      logging.RequestID(ctx),
+		TraceParent:    traceParent,
+		TraceState:     traceState,
+		CacheBypass:    cacheBypass(c),
 	}
 
-	if err := db.DB.Create(&geminiProcess).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al guardar el registro en la base de datos"})
-		return
+	if err := db.DB.WithContext(ctx).Create(&geminiProcess).Error; err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrTaskCreateFailed))
+		return "", false
 	}
 
-	// 2. Responder inmediatamente con el ID de la tarea
-	c.JSON(http.StatusAccepted, models.GeminiProcessingFileIDResponse{
-		GeminiProcessingFileID: processID,
-	})
-
-	// 3. Iniciar el procesamiento de Gemini en una goroutine
-	go func() {
-		service := &gemini.Service{}
-		// Convertimos el slice de bytes a un io.Reader para la función GenerateWithFile.
-		fileReader := bytes.NewReader(fileContent)
-
-		result, err := service.GenerateWithFile(fileReader, fileHeader.Filename, fileType, prompt)
-
-		// 4. Actualizar el registro en la base de datos
-		if err != nil {
-			log.Printf("Error procesando tarea %s con Gemini: %v", processID, err)
-			db.DB.Model(&models.GeminiProcessingFileDB{}).
-				Where("id = ?", processID).
-				Updates(map[string]interface{}{
-					"status": models.StatusError,
-					"error":  err.Error(),
-				})
-		} else {
-			db.DB.Model(&models.GeminiProcessingFileDB{}).
-				Where("id = ?", processID).
-				Updates(map[string]interface{}{
-					"status": models.StatusCompleted,
-					"result": result,
-				})
-		}
-	}()
+	slog.InfoContext(ctx, "tarea con archivo creada",
+		"task_id", processID,
+		"file_type", fileType,
+		"file_size", len(fileContent),
+		logging.Prompt(prompt))
+
+	// 2. Encolar el procesamiento de Gemini en el pool de workers; el llamador responde con el ID.
+	// Con la cola llena la tarea queda pendiente y la encola RequeuePendingTasks.
+	if !workers.TrySubmit(func() { processFileTask(geminiProcess) }) {
+		slog.WarnContext(ctx, "cola de workers llena: la tarea queda pendiente", "task_id", processID)
+	}
+
+	return processID, true
 }
 
-// GetGeminiProcessStatus @Summary Obtener estado de la tarea de Gemini
-// @Description Consulta el estado y el resultado de una tarea por su ID.
+// GetFileTask @Summary Obtener estado de la tarea de Gemini con archivo
+// @Description Consulta el estado y el resultado de una tarea con archivo por su ID. Con ?wait=30s, si la tarea no ha
+// @Description terminado, la respuesta espera hasta que cambie de estado o se cumpla el tiempo (máximo LONG_POLL_MAX_WAIT).
 // @Tags gemini
 // @Accept  json
 // @Produce  json
-// @Param   gemini_processing_id path string true "ID del proceso"
+// @Param   id path string true "ID de la tarea"
+// @Param   wait query string false "Tiempo máximo de espera a un cambio de estado (por ejemplo 30s)"
 // @Success 200 {object} models.GeminiProcessingFileResponse "Estado del proceso y resultado"
-// @Failure 400 {object} map[string]string "ID de proceso inválido"
-// @Router /gemini/status-file/{gemini_processing_id} [get]
-func GetGeminiProcessStatus(c *gin.Context) {
-	geminiProcessingFileID := c.Param("gemini_processing_id")
+// @Failure 404 {object} models.ErrorResponse "ID de proceso no encontrado"
+// @Router /v1/gemini/file-tasks/{id} [get]
+func GetFileTask(c *gin.Context) {
+	geminiProcessingFileID := c.Param("id")
 	var geminiProcessingFile models.GeminiProcessingFileDB
 
-	if err := db.DB.First(&geminiProcessingFile, "id = ?", geminiProcessingFileID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "ID de proceso no encontrado"})
+	if !findTask(c, geminiProcessingFileID, &geminiProcessingFile, func() models.GeminiProcessingStatus { return geminiProcessingFile.Status }) {
 		return
 	}
 
 	// Convertir TaskDB → TaskResponse para no exponer Prompt ni timestamps
+	lang := i18n.FromContext(c.Request.Context())
 	response := models.GeminiProcessingFileResponse{
-		ID:     geminiProcessingFile.ID,
-		Status: geminiProcessingFile.Status,
-		Result: geminiProcessingFile.Result,
-		Error:  geminiProcessingFile.Error,
+		ID:          geminiProcessingFile.ID,
+		Status:      geminiProcessingFile.Status,
+		StatusLabel: geminiProcessingFile.Status.Label(lang),
+		Result:      geminiProcessingFile.Result,
+		ResultJSON:  geminiProcessingFile.ResultJSON,
+		ToolCalls:   geminiProcessingFile.ToolCalls,
+		Redactions:  geminiProcessingFile.Redactions,
+		Cached:      geminiProcessingFile.Cached,
+		Error:       localizedTaskError(lang, geminiProcessingFile.ErrorCode, geminiProcessingFile.Error, geminiProcessingFile.ErrorDetails),
+		Usage: models.NewTaskUsage(geminiProcessingFile.Model, geminiProcessingFile.PromptTokens,
+			geminiProcessingFile.CandidateTokens, geminiProcessingFile.TotalTokens, geminiProcessingFile.EstimatedCost),
+		ResultPurgedAt: geminiProcessingFile.ResultPurgedAt,
 	}
 	c.JSON(http.StatusOK, response)
 }
+
+// DeleteTask @Summary Eliminar una tarea
+// @Description Elimina la tarea con su prompt y su resultado. Solo la puede eliminar el usuario que la creó o un
+// @Description administrador; las tareas con retención legal no se pueden eliminar.
+// @Tags gemini
+// @Param   id path string true "ID de la tarea"
+// @Success 204 "Tarea eliminada"
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse "ID de proceso no encontrado"
+// @Failure 409 {object} models.ErrorResponse "La tarea tiene retención legal"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/gemini/tasks/{id} [delete]
+func DeleteTask(c *gin.Context) {
+	deleteTask(c, &models.GeminiProcessingDB{})
+}
+
+// DeleteFileTask @Summary Eliminar una tarea con archivo
+// @Description Elimina la tarea con su prompt, su archivo y su resultado. Solo la puede eliminar el usuario que la
+// @Description creó o un administrador; las tareas con retención legal no se pueden eliminar.
+// @Tags gemini
+// @Param   id path string true "ID de la tarea"
+// @Success 204 "Tarea eliminada"
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse "ID de proceso no encontrado"
+// @Failure 409 {object} models.ErrorResponse "La tarea tiene retención legal"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/gemini/file-tasks/{id} [delete]
+func DeleteFileTask(c *gin.Context) {
+	deleteTask(c, &models.GeminiProcessingFileDB{})
+}
+
+// deleteTask elimina la tarea de la organización de la petición si la creó el usuario de la
+// petición (o tiene tasks:delete_all) y no tiene retención legal. Se borran su contenido y sus
+// archivos, pero la fila queda marcada como eliminada con su consumo (ver
+// models.DeletedTaskColumns).
+func deleteTask(c *gin.Context, model interface{}) {
+	id := c.Param("id")
+	ctx := c.Request.Context()
+	query := db.DB.WithContext(ctx).Model(model).Where("org_id = ? AND id = ?", middleware.OrgID(c), id)
+
+	// Solo se leen el dueño y la retención, sin descifrar el prompt ni el archivo.
+	var task struct {
+		UserID    *uint
+		LegalHold bool
+	}
+	if err := query.Session(&gorm.Session{}).Select("user_id, legal_hold").Take(&task).Error; err != nil {
+		abortTaskLookup(c, err)
+		return
+	}
+	if !middleware.AllowOwnerOr(c, task.UserID, models.PermTasksCreate, models.PermTasksDeleteAll) {
+		return
+	}
+	if task.LegalHold {
+		apierror.Abort(c, apierror.New(http.StatusConflict, apierror.CodeTaskLegalHold, i18n.ErrTaskLegalHold))
+		return
+	}
+
+	// La condición se repite al eliminar por si la retención se puso mientras tanto.
+	result := query.Session(&gorm.Session{}).Where("NOT legal_hold").UpdateColumns(models.DeletedTaskColumns(model, time.Now()))
+	if result.Error != nil {
+		apierror.Abort(c, apierror.Wrap(result.Error, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	if result.RowsAffected == 0 {
+		// Otra petición la eliminó o le puso la retención legal mientras tanto.
+		if err := query.Session(&gorm.Session{}).Select("user_id, legal_hold").Take(&task).Error; err == nil && task.LegalHold {
+			apierror.Abort(c, apierror.New(http.StatusConflict, apierror.CodeTaskLegalHold, i18n.ErrTaskLegalHold))
+			return
+		}
+		apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeTaskNotFound, i18n.ErrTaskNotFound))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,442 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/cache"
+	"github.com/Efren-Garza-Z/go-api-gemini/db"
+	"github.com/Efren-Garza-Z/go-api-gemini/gemini"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/logging"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/telemetry"
+	"github.com/Efren-Garza-Z/go-api-gemini/tools"
+	"github.com/Efren-Garza-Z/go-api-gemini/worker"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// workers ejecuta las tareas en segundo plano. Con nil cada tarea usa su propia goroutine.
+var workers *worker.Pool
+
+// SetWorkerPool configura el pool de workers que ejecuta las tareas, lotes y documentos.
+func SetWorkerPool(p *worker.Pool) {
+	workers = p
+}
+
+// startTaskSpan prepara el contexto del worker: continúa la traza guardada en la tarea
+// y agrega los IDs de petición y de tarea para los logs.
+func startTaskSpan(name string, id string, requestID string, traceParent string, traceState string) (context.Context, trace.Span) {
+	ctx := telemetry.ExtractTraceContext(context.Background(), traceParent, traceState)
+	ctx = logging.WithTaskID(logging.WithRequestID(ctx, requestID), id)
+	return telemetry.Tracer().Start(ctx, name, trace.WithAttributes(attribute.String("gemini.task.id", id)))
+}
+
+// updateTask actualiza las columnas de una tarea y registra el error si la actualización falla.
+// model lleva el ID de la tarea, que GORM usa como condición y el cifrado como dato asociado.
+func updateTask(ctx context.Context, model interface{}, values map[string]interface{}) {
+	err := db.DB.WithContext(ctx).Model(model).
+		Updates(values).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "error al actualizar la tarea", "status", values["status"], "error", err)
+	}
+}
+
+// claimTask pasa la tarea de pendiente a en proceso y guarda cuándo; devuelve false si ya no
+// estaba pendiente.
+func claimTask(ctx context.Context, model interface{}, id string) (bool, error) {
+	result := db.DB.WithContext(ctx).Model(model).
+		Where("id = ? AND status = ?", id, models.StatusPending).
+		Updates(map[string]interface{}{"status": models.StatusProcessing, "claimed_at": time.Now()})
+	return result.RowsAffected > 0, result.Error
+}
+
+// usageColumns agrega a values el consumo de tokens, su costo estimado y las llamadas
+// a herramientas hechas durante la generación.
+func usageColumns(values map[string]interface{}, result *gemini.Result) map[string]interface{} {
+	if len(result.ToolCalls) > 0 {
+		if calls, err := json.Marshal(result.ToolCalls); err == nil {
+			values["tool_calls"] = models.JSON(calls)
+		}
+	}
+	values["model"] = result.Usage.Model
+	values["prompt_tokens"] = result.Usage.PromptTokens
+	values["candidate_tokens"] = result.Usage.CandidateTokens
+	values["total_tokens"] = result.Usage.TotalTokens
+	values["estimated_cost"] = gemini.EstimateCost(result.Usage)
+	return values
+}
+
+// completedColumns devuelve las columnas a guardar al finalizar una tarea con éxito,
+// incluyendo la respuesta JSON validada (si se pidió un esquema) y el consumo de tokens.
+func completedColumns(result *gemini.Result) map[string]interface{} {
+	return usageColumns(map[string]interface{}{
+		"status":      models.StatusCompleted,
+		"result":      result.Text,
+		"result_json": models.JSON(result.JSON),
+	}, result)
+}
+
+// failedColumns devuelve las columnas a guardar cuando una tarea falla: un código estable,
+// un mensaje seguro para el cliente y los detalles del error si los hay. El error original
+// solo se registra en los logs. Si Gemini llegó a responder (result no es nil), se guarda
+// también la respuesta y el consumo de tokens.
+func failedColumns(err error, result *gemini.Result) map[string]interface{} {
+	taskErr := gemini.TaskError(err)
+	values := map[string]interface{}{
+		"status":     models.StatusError,
+		"error_code": string(taskErr.Code),
+		"error":      taskErr.Message(i18n.Default),
+	}
+	if taskErr.Details != nil {
+		if details, err := json.Marshal(taskErr.Details); err == nil {
+			values["error_details"] = models.JSON(details)
+		}
+	}
+	if result != nil {
+		values["result"] = result.Text
+		usageColumns(values, result)
+	}
+	return values
+}
+
+// generateOptions reconstruye las opciones de generación guardadas en la tarea.
+func generateOptions(responseSchema models.JSON, tools models.JSON) (gemini.Options, error) {
+	var opts gemini.Options
+	if len(responseSchema) > 0 {
+		schema, err := gemini.CompileSchema(json.RawMessage(responseSchema))
+		if err != nil {
+			return opts, err
+		}
+		opts.Schema = schema
+	}
+	if len(tools) > 0 {
+		var names []string
+		if err := json.Unmarshal(tools, &names); err != nil {
+			return opts, err
+		}
+		selected, err := gemini.DefaultTools.Select(names)
+		if err != nil {
+			return opts, err
+		}
+		opts.Tools = selected
+	}
+	return opts, nil
+}
+
+// processPromptTask ejecuta una tarea de texto en segundo plano y guarda su resultado.
+func processPromptTask(task models.GeminiProcessingDB) {
+	ctx, span := startTaskSpan("gemini.task.process", task.ID, task.RequestID, task.TraceParent, task.TraceState)
+	defer span.End()
+	// Las herramientas solo ven los datos que puede consultar quien creó la tarea.
+	ctx = tools.WithCaller(ctx, tools.Caller{OrgID: task.OrgID, UserID: task.UserID})
+
+	service := &gemini.Service{}
+	start := time.Now()
+	slog.InfoContext(ctx, "procesando tarea")
+
+	// Tomar la tarea: si otro worker ya la tomó (por ejemplo, al reanudar un lote) no se procesa de nuevo.
+	claimed, err := claimTask(ctx, &models.GeminiProcessingDB{}, task.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "error al tomar la tarea", "error", err)
+		return
+	}
+	if !claimed {
+		slog.InfoContext(ctx, "tarea ya tomada por otro worker")
+		return
+	}
+
+	opts, err := generateOptions(task.ResponseSchema, task.Tools)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		slog.ErrorContext(ctx, "opciones de generación inválidas", "error", err)
+		updateTask(ctx, &models.GeminiProcessingDB{ID: task.ID}, failedColumns(err, nil))
+		return
+	}
+	opts.SystemInstruction = task.SystemInstruction
+
+	cacheKey := responseCacheKey(task.CacheBypass, task.Tools, cache.KeyParts{
+		OrgID:             task.OrgID,
+		Prompt:            task.Prompt,
+		SystemInstruction: task.SystemInstruction,
+		ResponseSchema:    json.RawMessage(task.ResponseSchema),
+	})
+	if values := cachedColumns(ctx, cacheKey); values != nil {
+		slog.InfoContext(ctx, "tarea finalizada desde el caché", "duration", time.Since(start))
+		updateTask(ctx, &models.GeminiProcessingDB{ID: task.ID}, values)
+		return
+	}
+
+	// Los datos personales se reemplazan por marcadores antes de enviar el prompt y se
+	// restauran en la respuesta.
+	redaction := redactor.Start()
+	prompt := redaction.Redact(task.Prompt)
+	opts.SystemInstruction = redaction.Redact(opts.SystemInstruction)
+	opts.RedactToolResponse = redaction.RedactMap
+
+	result, err := service.GenerateContent(ctx, prompt, opts)
+	restoreResult(result, redaction)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		slog.ErrorContext(ctx, "error procesando tarea con Gemini", "error", err, "duration", time.Since(start))
+		updateTask(ctx, &models.GeminiProcessingDB{ID: task.ID}, redactionColumns(failedColumns(err, result), redaction))
+		return
+	}
+
+	slog.InfoContext(ctx, "tarea finalizada",
+		"duration", time.Since(start),
+		"result_length", len(result.Text),
+		"total_tokens", result.Usage.TotalTokens,
+		"tool_calls", len(result.ToolCalls),
+		"redactions", len(redaction.Findings()))
+	updateTask(ctx, &models.GeminiProcessingDB{ID: task.ID}, redactionColumns(completedColumns(result), redaction))
+	storeCachedResult(ctx, cacheKey, result)
+}
+
+// processFileTask ejecuta una tarea con archivo en segundo plano y guarda su resultado.
+func processFileTask(task models.GeminiProcessingFileDB) {
+	ctx, span := startTaskSpan("gemini.task.process_file", task.ID, task.RequestID, task.TraceParent, task.TraceState)
+	defer span.End()
+	// Las herramientas solo ven los datos que puede consultar quien creó la tarea.
+	ctx = tools.WithCaller(ctx, tools.Caller{OrgID: task.OrgID, UserID: task.UserID})
+
+	service := &gemini.Service{}
+	start := time.Now()
+	// Las tareas anteriores a FileType no guardaban el tipo: se deduce del contenido.
+	filename, fileType := task.FileName, task.FileType
+	if fileType == "" {
+		fileType = http.DetectContentType(task.File)
+	}
+	slog.InfoContext(ctx, "procesando tarea con archivo", "file_type", fileType)
+
+	claimed, err := claimTask(ctx, &models.GeminiProcessingFileDB{}, task.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "error al tomar la tarea", "error", err)
+		return
+	}
+	if !claimed {
+		slog.InfoContext(ctx, "tarea ya tomada por otro worker")
+		return
+	}
+
+	// Convertimos el slice de bytes a un io.Reader para la función GenerateWithFile.
+	fileReader := bytes.NewReader(task.File)
+
+	opts, err := generateOptions(task.ResponseSchema, task.Tools)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		slog.ErrorContext(ctx, "opciones de generación inválidas", "error", err)
+		updateTask(ctx, &models.GeminiProcessingFileDB{ID: task.ID}, failedColumns(err, nil))
+		return
+	}
+
+	cacheKey := responseCacheKey(task.CacheBypass, task.Tools, cache.KeyParts{
+		OrgID:          task.OrgID,
+		Prompt:         task.Prompt,
+		ResponseSchema: json.RawMessage(task.ResponseSchema),
+		FileSHA256:     cache.FileSHA256(task.File),
+		FileType:       fileType,
+	})
+	if values := cachedColumns(ctx, cacheKey); values != nil {
+		slog.InfoContext(ctx, "tarea finalizada desde el caché", "duration", time.Since(start))
+		updateTask(ctx, &models.GeminiProcessingFileDB{ID: task.ID}, values)
+		return
+	}
+
+	// Se redactan el prompt y los resultados de las herramientas: el archivo (imagen o PDF) se
+	// envía sin cambios.
+	redaction := redactor.Start()
+	prompt := redaction.Redact(task.Prompt)
+	opts.RedactToolResponse = redaction.RedactMap
+	result, err := service.GenerateWithFile(ctx, fileReader, filename, fileType, prompt, opts)
+	restoreResult(result, redaction)
+
+	// Actualizar el registro en la base de datos
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		slog.ErrorContext(ctx, "error procesando tarea con Gemini", "error", err, "duration", time.Since(start))
+		updateTask(ctx, &models.GeminiProcessingFileDB{ID: task.ID}, redactionColumns(failedColumns(err, result), redaction))
+		return
+	}
+
+	slog.InfoContext(ctx, "tarea finalizada",
+		"duration", time.Since(start),
+		"result_length", len(result.Text),
+		"total_tokens", result.Usage.TotalTokens,
+		"tool_calls", len(result.ToolCalls),
+		"redactions", len(redaction.Findings()))
+	updateTask(ctx, &models.GeminiProcessingFileDB{ID: task.ID}, redactionColumns(completedColumns(result), redaction))
+	storeCachedResult(ctx, cacheKey, result)
+}
+
+// defaultRequeueInterval es cada cuánto se buscan tareas pendientes sin encolar si no se define TASK_REQUEUE_INTERVAL.
+const defaultRequeueInterval = time.Minute
+
+// defaultClaimTimeout es el tiempo por defecto que una tarea puede seguir en proceso.
+const defaultClaimTimeout = 15 * time.Minute
+
+// TaskClaimTimeout devuelve cuánto puede seguir en proceso una tarea antes de darla por
+// abandonada, configurado en TASK_CLAIM_TIMEOUT. Debe superar lo que tarda la llamada más larga
+// a Gemini: una tarea liberada mientras su worker sigue trabajando se procesa dos veces.
+func TaskClaimTimeout() time.Duration {
+	if v, err := time.ParseDuration(os.Getenv("TASK_CLAIM_TIMEOUT")); err == nil && v > 0 {
+		return v
+	}
+	return defaultClaimTimeout
+}
+
+// TaskRequeueInterval devuelve cada cuánto RequeuePendingTasks busca tareas pendientes,
+// configurado en TASK_REQUEUE_INTERVAL (por ejemplo 30s).
+func TaskRequeueInterval() time.Duration {
+	if v, err := time.ParseDuration(os.Getenv("TASK_REQUEUE_INTERVAL")); err == nil && v > 0 {
+		return v
+	}
+	return defaultRequeueInterval
+}
+
+// RequeuePendingTasks encola las tareas de texto y con archivo que quedaron pendientes fuera
+// de un lote: al iniciar, las que dejó un reinicio del servidor, y luego cada interval, las que
+// no se encolaron porque la cola estaba llena (ver enqueuePromptTask). Solo se toman las
+// creadas hace más de interval, para no repetir las que aún esperan en la cola; si una se
+// encola dos veces, claimTask evita procesarla otra vez. Los lotes se reanudan con ResumeBatches.
+//
+// Antes devuelve a pendientes las tareas que siguen en proceso después de claimTimeout, que dejó
+// un worker detenido a mitad de la llamada a Gemini, y reanuda sus lotes.
+func RequeuePendingTasks(ctx context.Context, interval time.Duration, claimTimeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	cutoff := time.Now()
+	for {
+		releaseStuckTasks(ctx, time.Now().Add(-claimTimeout))
+		n, err := requeuePendingTasks(ctx, &models.GeminiProcessingDB{}, cutoff)
+		if err == nil {
+			var files int
+			files, err = requeuePendingTasks(ctx, &models.GeminiProcessingFileDB{}, cutoff)
+			n += files
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "error al encolar las tareas pendientes", "error", err)
+		} else if n > 0 {
+			slog.InfoContext(ctx, "tareas pendientes encoladas", "tasks", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			cutoff = now.Add(-interval)
+		}
+	}
+}
+
+// releaseStuckTasks devuelve a pendientes las tareas de texto y con archivo que siguen en
+// proceso desde antes de claimedBefore y vuelve a encolar los lotes de las de texto.
+func releaseStuckTasks(ctx context.Context, claimedBefore time.Time) {
+	n, batchIDs, err := releaseClaims(ctx, &models.GeminiProcessingDB{}, claimedBefore)
+	if err == nil {
+		var files int
+		files, _, err = releaseClaims(ctx, &models.GeminiProcessingFileDB{}, claimedBefore)
+		n += files
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "error al liberar las tareas abandonadas", "error", err)
+	} else if n > 0 {
+		slog.WarnContext(ctx, "tareas abandonadas en proceso devueltas a pendientes", "tasks", n)
+	}
+	for _, id := range batchIDs {
+		slog.InfoContext(ctx, "reanudando lote", "batch_id", id)
+		go dispatchBatch(id)
+	}
+}
+
+// releaseClaims devuelve a pendientes las tareas de la tabla de model que siguen en proceso
+// desde antes de claimedBefore, incluidas las tomadas antes de guardar claimed_at. Devuelve
+// cuántas liberó y los lotes a los que pertenecen.
+func releaseClaims(ctx context.Context, model interface{}, claimedBefore time.Time) (int, []string, error) {
+	_, prompt := model.(*models.GeminiProcessingDB)
+	columns := "id"
+	if prompt {
+		columns = "id, batch_id"
+	}
+	var stuck []struct {
+		ID      string
+		BatchID *string
+	}
+	err := db.DB.WithContext(ctx).Model(model).
+		Select(columns).
+		Where("status = ? AND (claimed_at IS NULL OR claimed_at < ?)", models.StatusProcessing, claimedBefore).
+		Scan(&stuck).Error
+	if err != nil || len(stuck) == 0 {
+		return 0, nil, err
+	}
+
+	ids := make([]string, len(stuck))
+	var batchIDs []string
+	for i, task := range stuck {
+		ids[i] = task.ID
+		if task.BatchID != nil && !slices.Contains(batchIDs, *task.BatchID) {
+			batchIDs = append(batchIDs, *task.BatchID)
+		}
+	}
+	result := db.DB.WithContext(ctx).Model(model).
+		Where("id IN ? AND status = ?", ids, models.StatusProcessing).
+		Updates(map[string]interface{}{"status": models.StatusPending, "claimed_at": nil})
+	return int(result.RowsAffected), batchIDs, result.Error
+}
+
+// requeuePendingTasks encola por páginas las tareas pendientes sin lote de la tabla de model
+// creadas antes de cutoff y devuelve cuántas encoló. Solo lee los IDs: cada trabajo carga su
+// tarea al ejecutarse, para no tener en memoria los archivos de toda la página.
+func requeuePendingTasks(ctx context.Context, model interface{}, cutoff time.Time) (int, error) {
+	_, prompt := model.(*models.GeminiProcessingDB)
+	total, last := 0, ""
+	for {
+		query := db.DB.WithContext(ctx).Model(model).
+			Where("status = ? AND created_at < ? AND id > ?", models.StatusPending, cutoff, last)
+		if prompt {
+			query = query.Where("batch_id IS NULL")
+		}
+		var ids []string
+		if err := query.Order("id").Limit(batchPageSize).Pluck("id", &ids).Error; err != nil {
+			return total, err
+		}
+		for _, id := range ids {
+			if prompt {
+				workers.Submit(func() { runPendingPromptTask(id) })
+			} else {
+				workers.Submit(func() { runPendingFileTask(id) })
+			}
+		}
+		total += len(ids)
+		if len(ids) < batchPageSize {
+			return total, nil
+		}
+		last = ids[len(ids)-1]
+	}
+}
+
+// runPendingPromptTask carga y procesa una tarea de texto encolada por RequeuePendingTasks.
+func runPendingPromptTask(id string) {
+	var task models.GeminiProcessingDB
+	if err := db.DB.First(&task, "id = ? AND status = ?", id, models.StatusPending).Error; err != nil {
+		return
+	}
+	processPromptTask(task)
+}
+
+// runPendingFileTask carga y procesa una tarea con archivo encolada por RequeuePendingTasks.
+func runPendingFileTask(id string) {
+	var task models.GeminiProcessingFileDB
+	if err := db.DB.First(&task, "id = ? AND status = ?", id, models.StatusPending).Error; err != nil {
+		return
+	}
+	processFileTask(task)
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/db/dbtest"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+)
+
+func TestStuckTasksGoBackToPending(t *testing.T) {
+	conn := dbtest.Open(t, &models.GeminiProcessingDB{}, &models.GeminiProcessingFileDB{})
+	ctx := context.Background()
+	batchID := "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"
+	old, recent := time.Now().Add(-time.Hour), time.Now()
+	tasks := []models.GeminiProcessingDB{
+		{ID: "abandonada", Status: models.StatusProcessing, Prompt: "a", ClaimedAt: &old},
+		{ID: "del-lote", Status: models.StatusProcessing, Prompt: "b", ClaimedAt: &old, BatchID: &batchID},
+		// Tomada antes de guardar claimed_at: solo pudo dejarla una versión anterior del servidor.
+		{ID: "sin-fecha", Status: models.StatusProcessing, Prompt: "c"},
+		{ID: "en-curso", Status: models.StatusProcessing, Prompt: "d", ClaimedAt: &recent},
+		{ID: "terminada", Status: models.StatusCompleted, Prompt: "e", ClaimedAt: &old},
+	}
+	if err := conn.Create(&tasks).Error; err != nil {
+		t.Fatalf("crear tareas: %v", err)
+	}
+	file := models.GeminiProcessingFileDB{ID: "archivo", Status: models.StatusProcessing, Prompt: "f", ClaimedAt: &old}
+	if err := conn.Create(&file).Error; err != nil {
+		t.Fatalf("crear la tarea con archivo: %v", err)
+	}
+
+	before := time.Now().Add(-15 * time.Minute)
+	n, batchIDs, err := releaseClaims(ctx, &models.GeminiProcessingDB{}, before)
+	if err != nil || n != 3 {
+		t.Fatalf("releaseClaims: %d tareas, err = %v; se esperaban 3", n, err)
+	}
+	if len(batchIDs) != 1 || batchIDs[0] != batchID {
+		t.Errorf("lotes a reanudar = %v, se esperaba %s", batchIDs, batchID)
+	}
+	if n, _, err := releaseClaims(ctx, &models.GeminiProcessingFileDB{}, before); err != nil || n != 1 {
+		t.Errorf("releaseClaims de archivos: %d tareas, err = %v; se esperaba 1", n, err)
+	}
+
+	want := map[string]models.GeminiProcessingStatus{
+		"abandonada": models.StatusPending,
+		"del-lote":   models.StatusPending,
+		"sin-fecha":  models.StatusPending,
+		"en-curso":   models.StatusProcessing,
+		"terminada":  models.StatusCompleted,
+	}
+	var stored []models.GeminiProcessingDB
+	conn.Select("id", "status", "claimed_at").Find(&stored)
+	for _, task := range stored {
+		if task.Status != want[task.ID] {
+			t.Errorf("tarea %s: estado %s, se esperaba %s", task.ID, task.Status, want[task.ID])
+		}
+		if task.Status == models.StatusPending && task.ClaimedAt != nil {
+			t.Errorf("tarea %s liberada con claimed_at", task.ID)
+		}
+	}
+
+	// Al tomarla otra vez se guarda cuándo, para liberarla si el worker se vuelve a detener.
+	claimed, err := claimTask(ctx, &models.GeminiProcessingDB{}, "abandonada")
+	if err != nil || !claimed {
+		t.Fatalf("claimTask: claimed=%v err=%v", claimed, err)
+	}
+	var task models.GeminiProcessingDB
+	conn.Select("id", "status", "claimed_at").First(&task, "id = ?", "abandonada")
+	if task.Status != models.StatusProcessing || task.ClaimedAt == nil || task.ClaimedAt.Before(before) {
+		t.Errorf("tarea tomada: estado %s, claimed_at %v", task.Status, task.ClaimedAt)
+	}
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers de la API sin versión. Se mantienen como alias obsoletos de /v1 con la forma
+// de respuesta original (task_id) y se documentan aparte en Swagger (instancia "legacy").
+
+// ProcessPrompt @Summary Iniciar tarea asíncrona de Gemini
+// @Description Obsoleto: usar POST /v1/gemini/tasks.
+// @Tags legacy
+// @Accept  json
+// @Produce  json
+// @Param   requestBody body models.PromptRequest true "Prompt a procesar"
+// @Security ApiKeyAuth
+// @Success 202 {object} models.GeminiProcessingIDResponse "Solicitud aceptada y procesando"
+// @Failure 400 {object} models.ErrorResponse "JSON de solicitud inválido"
+// @Failure 429 {object} models.ErrorResponse "Límite de peticiones o cuota agotada"
+// @Failure 500 {object} models.ErrorResponse "Error interno"
+// @Deprecated
+// @Router /gemini/process [post]
+func ProcessPrompt(c *gin.Context) {
+	id, ok := createPromptTask(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusAccepted, models.GeminiProcessingIDResponse{GeminiProcessingID: id})
+}
+
+// GetTaskStatus @Summary Obtener estado de la tarea de Gemini
+// @Description Obsoleto: usar GET /v1/gemini/tasks/{id}.
+// @Tags legacy
+// @Accept  json
+// @Produce  json
+// @Param   gemini_processing_id path string true "ID del proceso"
+// @Param   wait query string false "Tiempo máximo de espera a un cambio de estado (por ejemplo 30s)"
+// @Success 200 {object} models.GeminiProcessingResponse "Estado del proceso y resultado"
+// @Failure 404 {object} models.ErrorResponse "ID de proceso no encontrado"
+// @Deprecated
+// @Router /gemini/status/{gemini_processing_id} [get]
+func GetTaskStatus(c *gin.Context) {
+	GetTask(c)
+}
+
+// GenerateWithFileController @Summary Generar contenido de Gemini con un archivo (asíncrono)
+// @Description Obsoleto: usar POST /v1/gemini/file-tasks.
+// @Tags legacy
+// @Accept  multipart/form-data
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param   prompt formData string true "Texto del prompt"
+// @Param   file formData file true "Archivo (PDF, PNG, JPEG)"
+// @Success 202 {object} models.GeminiProcessingFileIDResponse "Proceso en cola"
+// @Failure 400 {object} models.ErrorResponse "Solicitud inválida"
+// @Failure 429 {object} models.ErrorResponse "Límite de peticiones o cuota agotada"
+// @Failure 500 {object} models.ErrorResponse "Error interno"
+// @Deprecated
+// @Router /gemini/process/file [post]
+func GenerateWithFileController(c *gin.Context) {
+	id, ok := createFileTask(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusAccepted, models.GeminiProcessingFileIDResponse{GeminiProcessingFileID: id})
+}
+
+// GetGeminiProcessStatus @Summary Obtener estado de la tarea de Gemini
+// @Description Obsoleto: usar GET /v1/gemini/file-tasks/{id}.
+// @Tags legacy
+// @Accept  json
+// @Produce  json
+// @Param   gemini_processing_id path string true "ID del proceso"
+// @Param   wait query string false "Tiempo máximo de espera a un cambio de estado (por ejemplo 30s)"
+// @Success 200 {object} models.GeminiProcessingFileResponse "Estado del proceso y resultado"
+// @Failure 404 {object} models.ErrorResponse "ID de proceso no encontrado"
+// @Deprecated
+// @Router /gemini/status-file/{gemini_processing_id} [get]
+func GetGeminiProcessStatus(c *gin.Context) {
+	GetFileTask(c)
+}
+
+// GetUserByIDLegacy GET /users/:id
+// @Summary Obtener un usuario por ID
+// @Description Obsoleto: usar GET /v1/users/{id}.
+// @Tags legacy
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Deprecated
+// @Router /users/{id} [get]
+func GetUserByIDLegacy(c *gin.Context) {
+	GetUserByID(c)
+}
+
+// CreateUserLegacy POST /users
+// @Summary Crear un usuario
+// @Description Obsoleto: usar POST /v1/users.
+// @Tags legacy
+// @Accept json
+// @Produce json
+// @Param input body models.CreateUserInput true "Datos para crear usuario"
+// @Success 201 {object} models.User
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Deprecated
+// @Router /users [post]
+func CreateUserLegacy(c *gin.Context) {
+	CreateUser(c)
+}
+
+// GetUserUsageLegacy GET /users/:id/usage
+// @Summary Consumo de tokens de un usuario
+// @Description Obsoleto: usar GET /v1/users/{id}/usage.
+// @Tags legacy
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param from query string false "Inicio del periodo (YYYY-MM-DD o RFC3339, incluido)"
+// @Param to query string false "Fin del periodo (YYYY-MM-DD o RFC3339, excluido)"
+// @Param group_by query string false "Agrupación" Enums(day, model) default(day)
+// @Success 200 {object} models.UsageReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Deprecated
+// @Router /users/{id}/usage [get]
+func GetUserUsageLegacy(c *gin.Context) {
+	GetUserUsage(c)
+}
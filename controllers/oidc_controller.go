@@ -0,0 +1,283 @@
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/auth"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/tenant"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// oidcStateCookie guarda el state en el navegador que inició sesión, para que el callback
+// solo se acepte en ese navegador.
+const (
+	oidcStateCookie = "oidc_state"
+	oidcCookiePath  = "/v1/auth/oidc"
+)
+
+// oidcLoginTTL es el tiempo que tiene el usuario para completar el inicio de sesión en el proveedor.
+const oidcLoginTTL = 10 * time.Minute
+
+var (
+	errOIDCUserDeleted       = errors.New("el usuario vinculado está eliminado")
+	errOIDCEmailMissing      = errors.New("el ID token no tiene email")
+	errOIDCEmailUnverified   = errors.New("el email del ID token no está verificado")
+	errOIDCAccountUnverified = errors.New("la cuenta con el email del ID token no está verificada")
+)
+
+// oidcProvider es el proveedor OIDC configurado; nil si el inicio de sesión con OIDC está desactivado.
+var oidcProvider *auth.OIDCProvider
+
+// SetOIDCProvider configura el proveedor OIDC.
+func SetOIDCProvider(p *auth.OIDCProvider) {
+	oidcProvider = p
+}
+
+// requireOIDC responde 404 y devuelve false si OIDC no está configurado.
+func requireOIDC(c *gin.Context) bool {
+	if oidcProvider == nil {
+		apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeOIDCNotConfigured, i18n.ErrOIDCNotConfigured))
+		return false
+	}
+	return true
+}
+
+// setOIDCStateCookie guarda el state en el navegador; con maxAge < 0 lo borra.
+func setOIDCStateCookie(c *gin.Context, state string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, maxAge, oidcCookiePath, "", oidcProvider.SecureCookies(), true)
+}
+
+// OIDCLogin @Summary Iniciar sesión con OIDC
+// @Description Redirige al proveedor OpenID Connect configurado (flujo authorization code con PKCE). Al terminar, el
+// @Description proveedor vuelve a /v1/auth/oidc/callback. Se abre en el navegador, no con fetch.
+// @Tags auth
+// @Success 302
+// @Failure 404 {object} models.ErrorResponse "OIDC no está configurado"
+// @Failure 502 {object} models.ErrorResponse "El proveedor no está disponible"
+// @Router /v1/auth/oidc/login [get]
+func OIDCLogin(c *gin.Context) {
+	if !requireOIDC(c) {
+		return
+	}
+	ctx := c.Request.Context()
+	login, err := oidcProvider.StartLogin(ctx)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusBadGateway, apierror.CodeOIDCLoginFailed, i18n.ErrOIDCLoginFailed))
+		return
+	}
+
+	now := time.Now()
+	err = DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Se aprovecha para borrar los inicios de sesión que nunca se completaron.
+		if err := tx.Where("expires_at < ?", now).Delete(&models.OIDCLoginDB{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.OIDCLoginDB{
+			StateHash: auth.HashToken(login.State),
+			Nonce:     login.Nonce,
+			Verifier:  login.Verifier,
+			ExpiresAt: now.Add(oidcLoginTTL),
+		}).Error
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	setOIDCStateCookie(c, login.State, int(oidcLoginTTL.Seconds()))
+	c.Redirect(http.StatusFound, login.URL)
+}
+
+// OIDCCallback @Summary Completar el inicio de sesión con OIDC
+// @Description Recibe al usuario de vuelta del proveedor, valida el ID token y vincula la cuenta con un usuario: el
+// @Description que ya tiene esa identidad, el que tiene el mismo email (si el proveedor y la cuenta lo verificaron) o
+// @Description uno nuevo. Los grupos del usuario asignan su rol según OIDC_ROLE_MAPPING. Devuelve una API key de
+// @Description sesión que vence a las SESSION_TTL (por defecto 12 horas), como la del inicio de sesión con
+// @Description contraseña, para enviar en Authorization: Bearer.
+// @Tags auth
+// @Produce json
+// @Param code query string true "Código de autorización"
+// @Param state query string true "State del inicio de sesión"
+// @Success 200 {object} models.OIDCLoginResponse
+// @Failure 400 {object} models.ErrorResponse "El inicio de sesión no es válido o venció"
+// @Failure 401 {object} models.ErrorResponse "El proveedor rechazó el inicio de sesión"
+// @Failure 403 {object} models.ErrorResponse "El usuario está eliminado"
+// @Failure 404 {object} models.ErrorResponse "OIDC no está configurado"
+// @Failure 409 {object} models.ErrorResponse "Ya existe una cuenta con ese email sin confirmar"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/auth/oidc/callback [get]
+func OIDCCallback(c *gin.Context) {
+	if !requireOIDC(c) {
+		return
+	}
+	ctx := c.Request.Context()
+	state := c.Query("state")
+	cookie, _ := c.Cookie(oidcStateCookie)
+	setOIDCStateCookie(c, "", -1)
+
+	if providerError := c.Query("error"); providerError != "" {
+		slog.WarnContext(ctx, "el proveedor OIDC rechazó el inicio de sesión",
+			"error", providerError, "description", c.Query("error_description"))
+		apierror.Abort(c, apierror.New(http.StatusUnauthorized, apierror.CodeOIDCLoginFailed, i18n.ErrOIDCLoginFailed).
+			WithDetails(gin.H{"error": providerError}))
+		return
+	}
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookie)) != 1 {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidToken, i18n.ErrOIDCState))
+		return
+	}
+
+	// El inicio de sesión se borra al usarse: el mismo state no se acepta dos veces.
+	var login models.OIDCLoginDB
+	result := DB.WithContext(ctx).Clauses(clause.Returning{}).
+		Where("state_hash = ? AND expires_at > ?", auth.HashToken(state), time.Now()).
+		Delete(&login)
+	if result.Error != nil {
+		apierror.Abort(c, apierror.Wrap(result.Error, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	if result.RowsAffected == 0 {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidToken, i18n.ErrOIDCState))
+		return
+	}
+
+	claims, err := oidcProvider.Exchange(ctx, c.Query("code"), login.Verifier, login.Nonce)
+	if err != nil {
+		slog.WarnContext(ctx, "inicio de sesión con OIDC inválido", "error", err)
+		apierror.Abort(c, apierror.New(http.StatusUnauthorized, apierror.CodeOIDCLoginFailed, i18n.ErrOIDCLoginFailed))
+		return
+	}
+
+	user, created, err := linkOIDCUser(ctx, claims)
+	switch {
+	case errors.Is(err, errOIDCUserDeleted):
+		apierror.Abort(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, i18n.ErrUserDeleted))
+		return
+	case errors.Is(err, errOIDCEmailMissing):
+		apierror.Abort(c, apierror.New(http.StatusUnauthorized, apierror.CodeOIDCLoginFailed, i18n.ErrOIDCEmailMissing))
+		return
+	case errors.Is(err, errOIDCEmailUnverified):
+		apierror.Abort(c, apierror.New(http.StatusUnauthorized, apierror.CodeOIDCLoginFailed, i18n.ErrOIDCEmailUnverified))
+		return
+	case errors.Is(err, errOIDCAccountUnverified):
+		apierror.Abort(c, apierror.New(http.StatusConflict, apierror.CodeEmailNotVerified, i18n.ErrOIDCAccountUnverified))
+		return
+	case err != nil:
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+
+	key, err := createSessionKey(ctx, user.ID, "sso", sessionTTL())
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	slog.InfoContext(ctx, "inicio de sesión con OIDC", "user_id", user.ID, "created", created, "role", user.Role)
+	auditUser(c, user.ID)
+	c.JSON(http.StatusOK, models.OIDCLoginResponse{
+		User:    user.ToSwagger(),
+		APIKey:  key,
+		Created: created,
+	})
+}
+
+// linkOIDCUser devuelve el usuario de la identidad OIDC, vinculándola la primera vez con el
+// usuario del mismo email, si el proveedor y la cuenta lo verificaron, o con uno nuevo. Si los grupos del usuario tienen un rol asignado,
+// su rol se actualiza en cada inicio de sesión. Devuelve created=true si el usuario es nuevo.
+func linkOIDCUser(ctx context.Context, claims auth.OIDCClaims) (models.UserDB, bool, error) {
+	var user models.UserDB
+	created := false
+	err := DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var identity models.UserIdentityDB
+		err := tx.Where("issuer = ? AND subject = ?", claims.Issuer, claims.Subject).First(&identity).Error
+		switch {
+		case err == nil:
+			if err := tx.Unscoped().First(&user, identity.UserID).Error; err != nil {
+				return err
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if claims.Email == "" {
+				return errOIDCEmailMissing
+			}
+			err := tx.Unscoped().Where("lower(email) = ?", claims.Email).First(&user).Error
+			switch {
+			case err == nil:
+				// Solo se vincula una cuenta existente si el proveedor garantiza que el email es
+				// del usuario; si no, cualquiera podría tomar la cuenta registrándose con ese email.
+				if !claims.EmailVerified {
+					return errOIDCEmailUnverified
+				}
+				// Tampoco si la cuenta local no confirmó su email: cualquiera pudo registrarla con el
+				// email de otro y una contraseña propia, y seguiría entrando después de vincularla.
+				if user.EmailVerifiedAt == nil {
+					return errOIDCAccountUnverified
+				}
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				user, err = provisionOIDCUser(tx, claims)
+				if err != nil {
+					return err
+				}
+				created = true
+			default:
+				return err
+			}
+			identity = models.UserIdentityDB{UserID: user.ID, Issuer: claims.Issuer, Subject: claims.Subject}
+		default:
+			return err
+		}
+		if user.DeletedAt.Valid {
+			return errOIDCUserDeleted
+		}
+
+		identity.LastLoginAt = now
+		if err := tx.Save(&identity).Error; err != nil {
+			return err
+		}
+		values := map[string]interface{}{}
+		if role, ok := oidcProvider.Role(claims.Groups); ok && role != user.Role {
+			values["role"] = role
+		}
+		if claims.EmailVerified && user.EmailVerifiedAt == nil && models.NormalizeEmail(user.Email) == claims.Email {
+			values["email_verified_at"] = now
+		}
+		if len(values) > 0 {
+			return tx.Model(&user).Updates(values).Error
+		}
+		return nil
+	})
+	return user, created, err
+}
+
+// provisionOIDCUser crea el usuario de una identidad OIDC nueva, sin contraseña (puede elegir
+// una con la recuperación de contraseña), con el rol de sus grupos o OIDC_DEFAULT_ROLE y con su
+// organización personal.
+func provisionOIDCUser(tx *gorm.DB, claims auth.OIDCClaims) (models.UserDB, error) {
+	role, ok := oidcProvider.Role(claims.Groups)
+	if !ok {
+		role = oidcProvider.DefaultRole()
+	}
+	name := claims.Name
+	if name == "" {
+		name = claims.Email
+	}
+	user := models.UserDB{FullName: name, Email: claims.Email, Role: role}
+	if claims.EmailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		return user, err
+	}
+	return user, tenant.CreatePersonalOrg(tx, user)
+}
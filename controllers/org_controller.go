@@ -0,0 +1,469 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/middleware"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/tenant"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// orgSlugPattern son los slugs válidos: palabras en minúsculas y números separadas por guiones.
+var orgSlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// orgRef devuelve el ID de la organización de la petición para guardarlo en las filas que crea.
+func orgRef(c *gin.Context) *uint {
+	id := middleware.OrgID(c)
+	return &id
+}
+
+// requireUser devuelve el usuario identificado; si la petición es anónima responde 401 y
+// devuelve ok=false.
+func requireUser(c *gin.Context) (models.UserDB, bool) {
+	user, ok := middleware.User(c)
+	if !ok {
+		apierror.Abort(c, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, i18n.ErrUnauthorized))
+	}
+	return user, ok
+}
+
+// findOrg busca la organización del parámetro :org_id y el rol del usuario de la petición en
+// ella. Solo los miembros y los administradores la ven; si no, responde el error y devuelve
+// ok=false.
+func findOrg(c *gin.Context) (models.OrganizationDB, models.OrgRole, bool) {
+	var org models.OrganizationDB
+	user, ok := requireUser(c)
+	if !ok {
+		return org, "", false
+	}
+	id, err := strconv.Atoi(c.Param("org_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidID))
+		return org, "", false
+	}
+
+	ctx := c.Request.Context()
+	if err := DB.WithContext(ctx).First(&org, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeOrgNotFound, i18n.ErrOrgNotFound))
+			return org, "", false
+		}
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return org, "", false
+	}
+	var member models.OrgMemberDB
+	err = DB.WithContext(ctx).Where("org_id = ? AND user_id = ?", org.ID, user.ID).First(&member).Error
+	switch {
+	case err == nil:
+		return org, member.Role, true
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return org, "", false
+	case user.Role == models.RoleAdmin:
+		return org, "", true
+	}
+	apierror.Abort(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, i18n.ErrNotOrgMember))
+	return org, "", false
+}
+
+// requireOrgOwner responde 403 y devuelve false si el usuario no es owner de la organización
+// ni administrador.
+func requireOrgOwner(c *gin.Context, role models.OrgRole) bool {
+	if role == models.OrgRoleOwner || middleware.Role(c) == models.RoleAdmin {
+		return true
+	}
+	apierror.Abort(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, i18n.ErrForbidden))
+	return false
+}
+
+// findOrgMember busca la pertenencia del usuario a la organización; si no es miembro responde
+// 404 y devuelve ok=false.
+func findOrgMember(c *gin.Context, orgID uint, userID uint) (models.OrgMemberDB, bool) {
+	var member models.OrgMemberDB
+	err := DB.WithContext(c.Request.Context()).Where("org_id = ? AND user_id = ?", orgID, userID).First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeOrgMemberNotFound, i18n.ErrOrgMemberNotFound))
+			return member, false
+		}
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return member, false
+	}
+	return member, true
+}
+
+// errLastOwner indica que un cambio de miembros dejaría a la organización sin owners.
+var errLastOwner = errors.New("la organización se quedaría sin owners")
+
+// changeOrgMember aplica change a la pertenencia de userID dentro de una transacción que
+// bloquea la organización, y falla con errLastOwner si después no queda ningún owner.
+func changeOrgMember(tx *gorm.DB, orgID uint, userID uint, change func(tx *gorm.DB, member *models.OrgMemberDB) error) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.OrganizationDB{}, orgID).Error; err != nil {
+		return err
+	}
+	var member models.OrgMemberDB
+	if err := tx.Where("org_id = ? AND user_id = ?", orgID, userID).First(&member).Error; err != nil {
+		return err
+	}
+	if err := change(tx, &member); err != nil {
+		return err
+	}
+	var owners int64
+	if err := tx.Model(&models.OrgMemberDB{}).Where("org_id = ? AND role = ?", orgID, models.OrgRoleOwner).Count(&owners).Error; err != nil {
+		return err
+	}
+	if owners == 0 {
+		return errLastOwner
+	}
+	return nil
+}
+
+// abortOrgMemberChange responde el error de modificar o quitar un miembro.
+func abortOrgMemberChange(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeOrgMemberNotFound, i18n.ErrOrgMemberNotFound))
+	case errors.Is(err, errLastOwner):
+		apierror.Abort(c, apierror.New(http.StatusConflict, apierror.CodeOrgLastOwner, i18n.ErrOrgLastOwner))
+	default:
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+	}
+}
+
+// CreateOrganization @Summary Crear una organización
+// @Description Crea una organización con el usuario de la petición como owner. Los slugs user-{id} están reservados
+// @Description para las organizaciones personales que se crean al registrarse.
+// @Tags orgs
+// @Accept json
+// @Produce json
+// @Param input body models.CreateOrganizationInput true "Datos de la organización"
+// @Success 201 {object} models.Organization
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "Slug en uso"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/orgs [post]
+func CreateOrganization(c *gin.Context) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	var input models.CreateOrganizationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+	if !orgSlugPattern.MatchString(input.Slug) || tenant.IsPersonalSlug(input.Slug) {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeValidationFailed, i18n.ErrInvalidOrgSlug).
+			WithDetails([]apierror.FieldError{{Field: "slug", Rule: "slug"}}))
+		return
+	}
+
+	org := models.OrganizationDB{Name: input.Name, Slug: input.Slug}
+	err := DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&org).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.OrgMemberDB{OrgID: org.ID, UserID: user.ID, Role: models.OrgRoleOwner}).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			apierror.Abort(c, apierror.Wrap(err, http.StatusConflict, apierror.CodeOrgSlugTaken, i18n.ErrOrgSlugTaken))
+			return
+		}
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	c.JSON(http.StatusCreated, org.ToResponse(models.OrgRoleOwner))
+}
+
+// ListOrganizations @Summary Listar mis organizaciones
+// @Description Devuelve las organizaciones de las que el usuario de la petición es miembro, con su rol en cada una.
+// @Tags orgs
+// @Produce json
+// @Success 200 {array} models.Organization
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/orgs [get]
+func ListOrganizations(c *gin.Context) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	var rows []struct {
+		models.OrganizationDB
+		Role models.OrgRole
+	}
+	err := DB.WithContext(c.Request.Context()).
+		Table(models.OrganizationDB{}.TableName()+" AS o").
+		Select("o.*, m.role").
+		Joins("JOIN "+models.OrgMemberDB{}.TableName()+" AS m ON m.org_id = o.id").
+		Where("m.user_id = ?", user.ID).
+		Order("m.created_at, m.id").
+		Scan(&rows).Error
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+
+	response := make([]models.Organization, len(rows))
+	for i, row := range rows {
+		response[i] = row.OrganizationDB.ToResponse(row.Role)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetOrganization @Summary Obtener una organización
+// @Tags orgs
+// @Produce json
+// @Param org_id path int true "ID de la organización"
+// @Success 200 {object} models.Organization
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /v1/orgs/{org_id} [get]
+func GetOrganization(c *gin.Context) {
+	org, role, ok := findOrg(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, org.ToResponse(role))
+}
+
+// UpdateOrganization @Summary Modificar una organización
+// @Description Cambia el nombre y la retención de datos (owners y administradores) o las cuotas de uso (solo
+// @Description administradores). quotas reemplaza las cuatro cuotas y retention los tres plazos: los que no se envían
+// @Description vuelven al valor por defecto de la configuración.
+// @Tags orgs
+// @Accept json
+// @Produce json
+// @Param org_id path int true "ID de la organización"
+// @Param input body models.UpdateOrganizationInput true "Cambios"
+// @Success 200 {object} models.Organization
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/orgs/{org_id} [patch]
+func UpdateOrganization(c *gin.Context) {
+	org, role, ok := findOrg(c)
+	if !ok || !requireOrgOwner(c, role) {
+		return
+	}
+	var input models.UpdateOrganizationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if input.Name != nil {
+		updates["name"] = *input.Name
+	}
+	// Las cuotas limitan el consumo de la organización: no las puede cambiar su owner.
+	if input.Quotas != nil {
+		if middleware.Role(c) != models.RoleAdmin {
+			apierror.Abort(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, i18n.ErrForbidden).
+				WithDetails(gin.H{"field": "quotas"}))
+			return
+		}
+		updates["daily_request_quota"] = input.Quotas.DailyRequests
+		updates["monthly_request_quota"] = input.Quotas.MonthlyRequests
+		updates["daily_token_quota"] = input.Quotas.DailyTokens
+		updates["monthly_token_quota"] = input.Quotas.MonthlyTokens
+	}
+	// La retención es una decisión sobre los datos propios de la organización: la define su owner.
+	if input.Retention != nil {
+		updates["file_retention_days"] = input.Retention.FileDays
+		updates["result_retention_days"] = input.Retention.ResultDays
+		updates["task_retention_days"] = input.Retention.TaskDays
+	}
+	if len(updates) > 0 {
+		if err := DB.WithContext(c.Request.Context()).Model(&org).Updates(updates).Error; err != nil {
+			apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+			return
+		}
+	}
+	c.JSON(http.StatusOK, org.ToResponse(role))
+}
+
+// ListOrgMembers @Summary Listar los miembros de una organización
+// @Tags orgs
+// @Produce json
+// @Param org_id path int true "ID de la organización"
+// @Success 200 {array} models.OrgMember
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/orgs/{org_id}/members [get]
+func ListOrgMembers(c *gin.Context) {
+	org, _, ok := findOrg(c)
+	if !ok {
+		return
+	}
+	members := []models.OrgMember{}
+	err := DB.WithContext(c.Request.Context()).
+		Table(models.OrgMemberDB{}.TableName()+" AS m").
+		Select("m.user_id, u.full_name, u.email, m.role, m.created_at").
+		Joins("JOIN "+models.UserDB{}.TableName()+" AS u ON u.id = m.user_id AND u.deleted_at IS NULL").
+		Where("m.org_id = ?", org.ID).
+		Order("m.created_at, m.id").
+		Scan(&members).Error
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+// AddOrgMember @Summary Agregar un miembro a una organización
+// @Description Agrega un usuario existente a la organización, como member salvo que se indique owner. Solo para
+// @Description owners y administradores.
+// @Tags orgs
+// @Accept json
+// @Produce json
+// @Param org_id path int true "ID de la organización"
+// @Param input body models.AddOrgMemberInput true "Usuario y rol"
+// @Success 201 {object} models.OrgMember
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "El usuario ya es miembro"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/orgs/{org_id}/members [post]
+func AddOrgMember(c *gin.Context) {
+	org, role, ok := findOrg(c)
+	if !ok || !requireOrgOwner(c, role) {
+		return
+	}
+	var input models.AddOrgMemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+	if input.Role == "" {
+		input.Role = models.OrgRoleMember
+	}
+
+	ctx := c.Request.Context()
+	var user models.UserDB
+	if err := DB.WithContext(ctx).First(&user, input.UserID).Error; err != nil {
+		abortUserLookup(c, err)
+		return
+	}
+	member := models.OrgMemberDB{OrgID: org.ID, UserID: user.ID, Role: input.Role}
+	if err := DB.WithContext(ctx).Create(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			apierror.Abort(c, apierror.Wrap(err, http.StatusConflict, apierror.CodeOrgMemberExists, i18n.ErrOrgMemberExists))
+			return
+		}
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	c.JSON(http.StatusCreated, models.OrgMember{
+		UserID:    user.ID,
+		FullName:  user.FullName,
+		Email:     user.Email,
+		Role:      member.Role,
+		CreatedAt: member.CreatedAt,
+	})
+}
+
+// UpdateOrgMember @Summary Cambiar el rol de un miembro
+// @Description Cambia el rol de un miembro a owner o member. La organización debe conservar al menos un owner. Solo
+// @Description para owners y administradores.
+// @Tags orgs
+// @Accept json
+// @Produce json
+// @Param org_id path int true "ID de la organización"
+// @Param user_id path int true "ID del usuario"
+// @Param input body models.UpdateOrgMemberInput true "Nuevo rol"
+// @Success 204 "Rol actualizado"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "La organización se quedaría sin owners"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/orgs/{org_id}/members/{user_id} [patch]
+func UpdateOrgMember(c *gin.Context) {
+	org, role, ok := findOrg(c)
+	if !ok || !requireOrgOwner(c, role) {
+		return
+	}
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidID))
+		return
+	}
+	var input models.UpdateOrgMemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+
+	err = DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		return changeOrgMember(tx, org.ID, uint(userID), func(tx *gorm.DB, member *models.OrgMemberDB) error {
+			return tx.Model(member).Update("role", input.Role).Error
+		})
+	})
+	if err != nil {
+		abortOrgMemberChange(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveOrgMember @Summary Quitar un miembro de una organización
+// @Description Quita al usuario de la organización; sus tareas y contenidos siguen en ella. Los owners y
+// @Description administradores pueden quitar a cualquiera y cada miembro puede salir. La organización debe
+// @Description conservar al menos un owner.
+// @Tags orgs
+// @Param org_id path int true "ID de la organización"
+// @Param user_id path int true "ID del usuario"
+// @Success 204 "Miembro quitado"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "La organización se quedaría sin owners"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/orgs/{org_id}/members/{user_id} [delete]
+func RemoveOrgMember(c *gin.Context) {
+	org, role, ok := findOrg(c)
+	if !ok {
+		return
+	}
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidID))
+		return
+	}
+	if current, _ := middleware.User(c); current.ID != uint(userID) && !requireOrgOwner(c, role) {
+		return
+	}
+
+	err = DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		return changeOrgMember(tx, org.ID, uint(userID), func(tx *gorm.DB, member *models.OrgMemberDB) error {
+			return tx.Delete(member).Error
+		})
+	})
+	if err != nil {
+		abortOrgMemberChange(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
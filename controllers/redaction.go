@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/gemini"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/pii"
+)
+
+// redactor reemplaza los datos personales de los prompts antes de enviarlos a Gemini. Con
+// nil la redacción está desactivada.
+var redactor *pii.Redactor
+
+// SetRedactor configura la redacción de datos personales.
+func SetRedactor(r *pii.Redactor) {
+	redactor = r
+}
+
+// restoreResult devuelve los valores originales en lugar de los marcadores en el texto y el
+// JSON de la respuesta. Las llamadas a herramientas, cuyos resultados también se redactan antes
+// de devolverlos al modelo, conservan los marcadores.
+func restoreResult(result *gemini.Result, redaction *pii.Redaction) {
+	if result == nil {
+		return
+	}
+	result.Text = redaction.Restore(result.Text)
+	result.JSON = redaction.RestoreJSON(result.JSON)
+}
+
+// redactTexts reemplaza los datos personales de los textos que se envían a Gemini para calcular
+// sus embeddings. Los marcadores no se restauran: en la base se guarda el texto original.
+func redactTexts(texts []string) []string {
+	if redactor == nil {
+		return texts
+	}
+	redacted := make([]string, len(texts))
+	for i, text := range texts {
+		redacted[i] = redactor.Start().Redact(text)
+	}
+	return redacted
+}
+
+// embed calcula los embeddings de los textos con los datos personales reemplazados.
+func embed(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	return embedder.Embed(ctx, redactTexts(texts), taskType)
+}
+
+// redactionColumns agrega a values los datos personales reemplazados en la tarea, si los hubo.
+func redactionColumns(values map[string]interface{}, redaction *pii.Redaction) map[string]interface{} {
+	if findings := redaction.Findings(); len(findings) > 0 {
+		if data, err := json.Marshal(findings); err == nil {
+			values["redactions"] = models.JSON(data)
+		}
+	}
+	return values
+}
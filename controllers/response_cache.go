@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/cache"
+	"github.com/Efren-Garza-Z/go-api-gemini/gemini"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/gin-gonic/gin"
+)
+
+// responseCache guarda las respuestas de Gemini para reutilizarlas. Con nil el caché está desactivado.
+var responseCache cache.Store
+
+// SetResponseCache configura el caché de respuestas de Gemini.
+func SetResponseCache(s cache.Store) {
+	responseCache = s
+}
+
+// cacheBypass indica si el cliente pidió una respuesta nueva con Cache-Control: no-cache o no-store.
+func cacheBypass(c *gin.Context) bool {
+	for _, directive := range strings.Split(c.GetHeader("Cache-Control"), ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "no-cache", "no-store":
+			return true
+		}
+	}
+	return false
+}
+
+// responseCacheKey devuelve la clave del caché de una tarea, o "" si la tarea no se puede
+// cachear: el caché está desactivado, el cliente lo omitió o la tarea usa herramientas
+// (cuyos resultados pueden cambiar entre llamadas).
+func responseCacheKey(bypass bool, tools models.JSON, parts cache.KeyParts) string {
+	if responseCache == nil || bypass || len(tools) > 0 {
+		return ""
+	}
+	parts.Model = gemini.DefaultModel
+	return cache.Key(parts)
+}
+
+// cachedColumns busca la respuesta en el caché y devuelve las columnas a guardar para
+// finalizar la tarea con ella, o nil si no está. Un error del caché no detiene la tarea.
+func cachedColumns(ctx context.Context, key string) map[string]interface{} {
+	if key == "" {
+		return nil
+	}
+	entry, ok, err := responseCache.Get(ctx, key)
+	if err != nil {
+		slog.WarnContext(ctx, "error al leer el caché de respuestas", "error", err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	return map[string]interface{}{
+		"status":           models.StatusCompleted,
+		"result":           entry.Text,
+		"result_json":      models.JSON(entry.JSON),
+		"cached":           true,
+		"model":            entry.Model,
+		"prompt_tokens":    0,
+		"candidate_tokens": 0,
+		"total_tokens":     0,
+		"estimated_cost":   0,
+	}
+}
+
+// storeCachedResult guarda en el caché la respuesta de Gemini de una tarea.
+func storeCachedResult(ctx context.Context, key string, result *gemini.Result) {
+	if key == "" {
+		return
+	}
+	entry := cache.Entry{Text: result.Text, JSON: result.JSON, Model: result.Usage.Model}
+	if err := responseCache.Set(ctx, key, entry); err != nil {
+		slog.WarnContext(ctx, "error al guardar en el caché de respuestas", "error", err)
+	}
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/gemini"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/gin-gonic/gin"
+)
+
+// resolveResponseSchema obtiene el esquema de respuesta de una petición, ya sea por nombre
+// (registrado en el servidor) o enviado directamente. Devuelve el nombre y el esquema a
+// guardar en la tarea; ambos vacíos si la petición no pide respuesta estructurada.
+// Si el esquema no es válido responde el error y devuelve ok=false.
+func resolveResponseSchema(c *gin.Context, name string, raw json.RawMessage) (string, models.JSON, bool) {
+	switch {
+	case name != "" && len(raw) > 0:
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrSchemaConflict))
+		return "", nil, false
+	case name != "":
+		schema, found := gemini.LookupSchema(name)
+		if !found {
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeSchemaNotFound, i18n.ErrSchemaNotFound, name).
+				WithDetails(gin.H{"available": gemini.SchemaNames()}))
+			return "", nil, false
+		}
+		return name, models.JSON(schema.Raw), true
+	case len(raw) > 0:
+		schema, err := gemini.CompileSchema(raw)
+		if err != nil {
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidSchema, i18n.ErrInvalidSchema).
+				WithDetails(gin.H{"reason": err.Error()}))
+			return "", nil, false
+		}
+		return "", models.JSON(schema.Raw), true
+	}
+	return "", nil, true
+}
+
+// ListSchemas @Summary Listar esquemas de respuesta registrados
+// @Description Devuelve los esquemas con nombre que se pueden usar en el campo "schema" al crear una tarea.
+// @Tags gemini
+// @Produce  json
+// @Success 200 {array} models.ResponseSchema "Esquemas registrados"
+// @Router /v1/gemini/schemas [get]
+func ListSchemas(c *gin.Context) {
+	names := gemini.SchemaNames()
+	list := make([]models.ResponseSchema, 0, len(names))
+	for _, name := range names {
+		if schema, ok := gemini.LookupSchema(name); ok {
+			list = append(list, models.ResponseSchema{Name: name, Schema: models.JSON(schema.Raw)})
+		}
+	}
+	c.JSON(http.StatusOK, list)
+}
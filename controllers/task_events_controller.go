@@ -0,0 +1,280 @@
+package controllers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/db"
+	"github.com/Efren-Garza-Z/go-api-gemini/events"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/middleware"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// Valores por defecto de long-polling y WebSocket.
+const (
+	defaultMaxWait = 60 * time.Second
+	// pingInterval es cada cuánto se envía un ping por el WebSocket; si no llega el pong
+	// antes de pongWait la conexión se da por perdida.
+	pingInterval = 30 * time.Second
+	pongWait     = 60 * time.Second
+	writeWait    = 10 * time.Second
+)
+
+// taskEvents reparte los cambios de estado de las tareas. Con nil, ?wait no espera y el
+// WebSocket solo envía el estado inicial.
+var taskEvents *events.Hub
+
+// SetTaskEvents configura el Hub de cambios de estado de las tareas.
+func SetTaskEvents(h *events.Hub) {
+	taskEvents = h
+}
+
+// maxWait devuelve la espera máxima de long-polling configurada en LONG_POLL_MAX_WAIT.
+func maxWait() time.Duration {
+	if v, err := time.ParseDuration(os.Getenv("LONG_POLL_MAX_WAIT")); err == nil && v > 0 {
+		return v
+	}
+	return defaultMaxWait
+}
+
+// waitParam lee ?wait= como duración ("30s") o en segundos ("30"), limitado a maxWait.
+// Si el valor no es válido responde el error y devuelve ok=false.
+func waitParam(c *gin.Context) (time.Duration, bool) {
+	value := c.Query("wait")
+	if value == "" {
+		return 0, true
+	}
+	wait, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(value)
+		wait, err = time.Duration(seconds)*time.Second, convErr
+	}
+	if err != nil || wait < 0 {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, "wait"))
+		return 0, false
+	}
+	return min(wait, maxWait()), true
+}
+
+// findTask carga en dest la tarea id de la organización de la petición. Con ?wait= y la tarea sin terminar, espera hasta que
+// cambie de estado, se cumpla el tiempo o el cliente se desconecte, y la vuelve a cargar.
+// Si la tarea no existe o el parámetro no es válido responde el error y devuelve false.
+func findTask(c *gin.Context, id string, dest interface{}, status func() models.GeminiProcessingStatus) bool {
+	wait, ok := waitParam(c)
+	if !ok {
+		return false
+	}
+
+	// La suscripción se crea antes de leer la tarea para no perder un cambio entre ambas cosas.
+	ctx := c.Request.Context()
+	orgID := middleware.OrgID(c)
+	var sub *events.Subscription
+	if wait > 0 && taskEvents != nil {
+		sub = taskEvents.Subscribe(id)
+		defer sub.Close()
+	}
+	if err := db.DB.WithContext(ctx).Where("org_id = ?", orgID).First(dest, "id = ?", id).Error; err != nil {
+		abortTaskLookup(c, err)
+		return false
+	}
+	if sub == nil || status().Finished() {
+		return true
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-sub.C:
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return true
+	}
+	if err := db.DB.WithContext(ctx).Where("org_id = ?", orgID).First(dest, "id = ?", id).Error; err != nil {
+		abortTaskLookup(c, err)
+		return false
+	}
+	return true
+}
+
+// upgrader acepta las conexiones WebSocket del mismo origen y de los orígenes de WEBSOCKET_ALLOWED_ORIGINS.
+var upgrader = websocket.Upgrader{CheckOrigin: checkOrigin}
+
+// checkOrigin permite las peticiones sin Origin, las del mismo host y las de los orígenes
+// configurados en WEBSOCKET_ALLOWED_ORIGINS (separados por comas, o "*" para todos).
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || strings.HasSuffix(origin, "://"+r.Host) {
+		return true
+	}
+	allowed := strings.Split(os.Getenv("WEBSOCKET_ALLOWED_ORIGINS"), ",")
+	for i := range allowed {
+		allowed[i] = strings.TrimSpace(allowed[i])
+	}
+	return slices.Contains(allowed, "*") || slices.Contains(allowed, origin)
+}
+
+// taskSubscriptionMessage es un mensaje del cliente por el WebSocket de eventos de tareas.
+type taskSubscriptionMessage struct {
+	Subscribe   []string `json:"subscribe"`
+	Unsubscribe []string `json:"unsubscribe"`
+}
+
+// currentTaskStatuses busca el estado actual de las tareas (de texto o con archivo) de la
+// organización. Los IDs que no existen o son de otra organización se omiten.
+func currentTaskStatuses(ctx context.Context, orgID uint, ids []string) ([]models.TaskStatusEvent, error) {
+	var found []models.TaskStatusEvent
+	sources := []struct {
+		model interface{}
+		kind  string
+	}{
+		{&models.GeminiProcessingDB{}, models.TaskKindPrompt},
+		{&models.GeminiProcessingFileDB{}, models.TaskKindFile},
+	}
+	for _, source := range sources {
+		var rows []models.TaskStatusEvent
+		err := db.DB.WithContext(ctx).Model(source.model).
+			Select("id, status").
+			Where("org_id = ? AND id IN ?", orgID, ids).
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			row.Kind = source.kind
+			found = append(found, row)
+		}
+	}
+	return found, nil
+}
+
+// TaskEvents @Summary Recibir cambios de estado de tareas por WebSocket
+// @Description Abre un WebSocket que envía un mensaje models.TaskStatusEvent por cada cambio de estado de las tareas
+// @Description suscritas (de texto o con archivo), empezando por su estado actual. Las tareas iniciales se indican en
+// @Description ?ids=a,b y después se pueden enviar mensajes {"subscribe": [...]} o {"unsubscribe": [...]}.
+// @Description Los IDs que no existen o son de otra organización se ignoran. Los cambios llegan de cualquier réplica (PostgreSQL LISTEN/NOTIFY).
+// @Tags gemini
+// @Param ids query string false "IDs de tareas separados por comas"
+// @Success 101 {object} models.TaskStatusEvent "Conexión WebSocket; cada mensaje es un evento"
+// @Failure 400 {object} models.ErrorResponse "La petición no es un WebSocket"
+// @Router /v1/gemini/tasks/events [get]
+func TaskEvents(c *gin.Context) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade ya respondió el error al cliente.
+		slog.WarnContext(c.Request.Context(), "no se pudo abrir el WebSocket", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	lang := i18n.FromContext(ctx)
+	orgID := middleware.OrgID(c)
+
+	hub := taskEvents
+	if hub == nil {
+		hub = events.NewHub()
+	}
+	sub := hub.Subscribe()
+	defer sub.Close()
+
+	// Los mensajes del cliente se leen en otra goroutine; las escrituras son todas de esta.
+	requests := make(chan taskSubscriptionMessage)
+	go func() {
+		defer cancel()
+		conn.SetReadLimit(64 * 1024)
+		_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+		conn.SetPongHandler(func(string) error { return conn.SetReadDeadline(time.Now().Add(pongWait)) })
+		for {
+			var message taskSubscriptionMessage
+			if err := conn.ReadJSON(&message); err != nil {
+				return
+			}
+			select {
+			case requests <- message:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	send := func(event models.TaskStatusEvent) bool {
+		event.StatusLabel = event.Status.Label(lang)
+		_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+		return conn.WriteJSON(event) == nil
+	}
+	allowed := map[string]bool{}
+	subscribe := func(ids []string) bool {
+		if len(ids) == 0 {
+			return true
+		}
+		// Como en findTask, la suscripción va antes de la lectura; después se quitan los IDs
+		// que no son de la organización. Los eventos se leen en esta misma goroutine, así que
+		// los que llegaron entre medio se filtran con allowed.
+		sub.Add(ids...)
+		current, err := currentTaskStatuses(ctx, orgID, ids)
+		if err != nil {
+			slog.ErrorContext(ctx, "error al leer el estado de las tareas", "error", err)
+			return false
+		}
+		for _, event := range current {
+			allowed[event.ID] = true
+		}
+		sub.Remove(slices.DeleteFunc(slices.Clone(ids), func(id string) bool { return allowed[id] })...)
+		for _, event := range current {
+			if !send(event) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var initial []string
+	for _, id := range strings.Split(c.Query("ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			initial = append(initial, id)
+		}
+	}
+	if !subscribe(initial) {
+		return
+	}
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-sub.C:
+			if !allowed[event.ID] {
+				continue
+			}
+			if !send(event) {
+				return
+			}
+		case message := <-requests:
+			sub.Remove(message.Unsubscribe...)
+			for _, id := range message.Unsubscribe {
+				delete(allowed, id)
+			}
+			if !subscribe(message.Subscribe) {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/db"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/middleware"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/prompts"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxVersionAttempts es la cantidad de intentos para crear una versión si otra petición
+// creó la misma versión al mismo tiempo.
+const maxVersionAttempts = 3
+
+// templateResponse convierte PromptTemplateDB en la respuesta con las variables que usa el cuerpo.
+func templateResponse(t models.PromptTemplateDB) models.PromptTemplate {
+	variables := []string{}
+	if tmpl, err := prompts.Parse(t.Name, t.Body); err == nil {
+		variables = prompts.Variables(tmpl)
+	}
+	return models.PromptTemplate{
+		Name:              t.Name,
+		Version:           t.Version,
+		Description:       t.Description,
+		Body:              t.Body,
+		SystemInstruction: t.SystemInstruction,
+		Defaults:          t.Defaults,
+		Variables:         variables,
+		CreatedAt:         t.CreatedAt,
+	}
+}
+
+// newTemplateVersion valida el cuerpo de la plantilla y arma la versión a guardar.
+// Si la plantilla no es válida responde el error y devuelve ok=false.
+func newTemplateVersion(c *gin.Context, name string, input models.PromptTemplateVersionInput) (models.PromptTemplateDB, bool) {
+	if _, err := prompts.Parse(name, input.Body); err != nil {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidTemplate, i18n.ErrInvalidTemplate).
+			WithDetails(gin.H{"reason": err.Error()}))
+		return models.PromptTemplateDB{}, false
+	}
+	var defaults models.JSON
+	if len(input.Defaults) > 0 {
+		encoded, err := json.Marshal(input.Defaults)
+		if err != nil {
+			apierror.Abort(c, apierror.Wrap(err, http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidRequest))
+			return models.PromptTemplateDB{}, false
+		}
+		defaults = models.JSON(encoded)
+	}
+	return models.PromptTemplateDB{
+		Name:              name,
+		Description:       input.Description,
+		Body:              input.Body,
+		SystemInstruction: input.SystemInstruction,
+		Defaults:          defaults,
+		OrgID:             orgRef(c),
+		UserID:            middleware.UserID(c),
+	}, true
+}
+
+// findTemplate busca la versión indicada de una plantilla de la organización; con version 0
+// devuelve la más reciente.
+func findTemplate(ctx context.Context, orgID uint, name string, version int) (models.PromptTemplateDB, error) {
+	var template models.PromptTemplateDB
+	query := db.DB.WithContext(ctx).Where("org_id = ? AND name = ?", orgID, name)
+	if version > 0 {
+		query = query.Where("version = ?", version)
+	}
+	err := query.Order("version DESC").First(&template).Error
+	return template, err
+}
+
+// abortTemplateLookup responde el error de buscar una plantilla.
+func abortTemplateLookup(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeTemplateNotFound, i18n.ErrTemplateNotFound))
+		return
+	}
+	apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+}
+
+// applyTemplate genera el prompt de la tarea con la plantilla y las variables indicadas.
+// Si algo falla responde el error y devuelve false.
+func applyTemplate(c *gin.Context, task *models.GeminiProcessingDB, name string, version int, variables map[string]interface{}) bool {
+	template, err := findTemplate(c.Request.Context(), middleware.OrgID(c), name, version)
+	if err != nil {
+		abortTemplateLookup(c, err)
+		return false
+	}
+	if err := renderTemplate(task, template, variables); err != nil {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeTemplateRenderFailed, i18n.ErrTemplateRender).
+			WithDetails(gin.H{"reason": err.Error()}))
+		return false
+	}
+	return true
+}
+
+// renderTemplate genera el prompt de la tarea con la plantilla y registra en la tarea la
+// plantilla, su versión y su instrucción de sistema.
+func renderTemplate(task *models.GeminiProcessingDB, template models.PromptTemplateDB, variables map[string]interface{}) error {
+	var defaults map[string]interface{}
+	if len(template.Defaults) > 0 {
+		if err := json.Unmarshal(template.Defaults, &defaults); err != nil {
+			return err
+		}
+	}
+	prompt, err := prompts.Render(template.Name, template.Body, defaults, variables)
+	if err != nil {
+		return err
+	}
+
+	task.Prompt = prompt
+	task.TemplateName = template.Name
+	task.TemplateVersion = &template.Version
+	task.SystemInstruction = template.SystemInstruction
+	return nil
+}
+
+// CreatePromptTemplate @Summary Crear una plantilla de prompt
+// @Description Crea la versión 1 de una plantilla. El cuerpo usa la sintaxis de text/template ({{.variable}}).
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param input body models.CreatePromptTemplateInput true "Plantilla"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.PromptTemplate
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/templates [post]
+func CreatePromptTemplate(c *gin.Context) {
+	var input models.CreatePromptTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+	template, ok := newTemplateVersion(c, input.Name, input.PromptTemplateVersionInput)
+	if !ok {
+		return
+	}
+	template.Version = 1
+
+	if err := db.DB.WithContext(c.Request.Context()).Create(&template).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			apierror.Abort(c, apierror.Wrap(err, http.StatusConflict, apierror.CodeTemplateNameTaken, i18n.ErrTemplateTaken))
+			return
+		}
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+
+	c.JSON(http.StatusCreated, templateResponse(template))
+}
+
+// ListPromptTemplates @Summary Listar plantillas de prompt
+// @Description Devuelve la versión más reciente de cada plantilla.
+// @Tags templates
+// @Produce json
+// @Success 200 {array} models.PromptTemplate
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/templates [get]
+func ListPromptTemplates(c *gin.Context) {
+	var templates []models.PromptTemplateDB
+	err := db.DB.WithContext(c.Request.Context()).
+		Where("org_id = ?", middleware.OrgID(c)).
+		Select("DISTINCT ON (name) *").
+		Order("name, version DESC").
+		Find(&templates).Error
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+
+	response := make([]models.PromptTemplate, len(templates))
+	for i, template := range templates {
+		response[i] = templateResponse(template)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetPromptTemplate @Summary Obtener una plantilla de prompt
+// @Description Devuelve la versión más reciente de la plantilla.
+// @Tags templates
+// @Produce json
+// @Param name path string true "Nombre de la plantilla"
+// @Success 200 {object} models.PromptTemplate
+// @Failure 404 {object} models.ErrorResponse
+// @Router /v1/templates/{name} [get]
+func GetPromptTemplate(c *gin.Context) {
+	template, err := findTemplate(c.Request.Context(), middleware.OrgID(c), c.Param("name"), 0)
+	if err != nil {
+		abortTemplateLookup(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, templateResponse(template))
+}
+
+// DeletePromptTemplate @Summary Eliminar una plantilla de prompt
+// @Description Elimina todas las versiones de la plantilla. Las tareas creadas con ella conservan el prompt generado.
+// @Tags templates
+// @Param name path string true "Nombre de la plantilla"
+// @Success 204 "Plantilla eliminada"
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/templates/{name} [delete]
+func DeletePromptTemplate(c *gin.Context) {
+	result := db.DB.WithContext(c.Request.Context()).
+		Where("org_id = ? AND name = ?", middleware.OrgID(c), c.Param("name")).
+		Delete(&models.PromptTemplateDB{})
+	if result.Error != nil {
+		apierror.Abort(c, apierror.Wrap(result.Error, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	if result.RowsAffected == 0 {
+		apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeTemplateNotFound, i18n.ErrTemplateNotFound))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// CreatePromptTemplateVersion @Summary Crear una versión de una plantilla de prompt
+// @Description Guarda una nueva versión de la plantilla; las versiones anteriores no cambian y se pueden seguir usando.
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param name path string true "Nombre de la plantilla"
+// @Param input body models.PromptTemplateVersionInput true "Nueva versión"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.PromptTemplate
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/templates/{name}/versions [post]
+func CreatePromptTemplateVersion(c *gin.Context) {
+	var input models.PromptTemplateVersionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+	template, ok := newTemplateVersion(c, c.Param("name"), input)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	for attempt := 1; ; attempt++ {
+		latest, err := findTemplate(ctx, middleware.OrgID(c), template.Name, 0)
+		if err != nil {
+			abortTemplateLookup(c, err)
+			return
+		}
+		template.ID = 0
+		template.Version = latest.Version + 1
+
+		err = db.DB.WithContext(ctx).Create(&template).Error
+		if err == nil {
+			break
+		}
+		// Otra petición creó la misma versión: se vuelve a calcular la siguiente.
+		if !errors.Is(err, gorm.ErrDuplicatedKey) || attempt == maxVersionAttempts {
+			apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, templateResponse(template))
+}
+
+// ListPromptTemplateVersions @Summary Listar las versiones de una plantilla de prompt
+// @Tags templates
+// @Produce json
+// @Param name path string true "Nombre de la plantilla"
+// @Success 200 {array} models.PromptTemplate
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/templates/{name}/versions [get]
+func ListPromptTemplateVersions(c *gin.Context) {
+	var templates []models.PromptTemplateDB
+	err := db.DB.WithContext(c.Request.Context()).
+		Where("org_id = ? AND name = ?", middleware.OrgID(c), c.Param("name")).
+		Order("version").
+		Find(&templates).Error
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	if len(templates) == 0 {
+		apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeTemplateNotFound, i18n.ErrTemplateNotFound))
+		return
+	}
+
+	response := make([]models.PromptTemplate, len(templates))
+	for i, template := range templates {
+		response[i] = templateResponse(template)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetPromptTemplateVersion @Summary Obtener una versión de una plantilla de prompt
+// @Tags templates
+// @Produce json
+// @Param name path string true "Nombre de la plantilla"
+// @Param version path int true "Versión"
+// @Success 200 {object} models.PromptTemplate
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /v1/templates/{name}/versions/{version} [get]
+func GetPromptTemplateVersion(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, "version"))
+		return
+	}
+	template, err := findTemplate(c.Request.Context(), middleware.OrgID(c), c.Param("name"), version)
+	if err != nil {
+		abortTemplateLookup(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, templateResponse(template))
+}
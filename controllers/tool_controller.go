@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/gemini"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/gin-gonic/gin"
+)
+
+// resolveTools valida los nombres de herramientas de una petición y devuelve la lista a
+// guardar en la tarea; nil si la petición no usa herramientas. Gemini no admite llamadas a
+// herramientas junto con respuesta JSON, por lo que no se pueden combinar con un esquema.
+// Si algo no es válido responde el error y devuelve ok=false.
+func resolveTools(c *gin.Context, names []string, responseSchema models.JSON) (models.JSON, bool) {
+	if len(names) == 0 {
+		return nil, true
+	}
+	if len(responseSchema) > 0 {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrToolsWithSchema))
+		return nil, false
+	}
+
+	if _, err := gemini.DefaultTools.Select(names); err != nil {
+		if errors.Is(err, gemini.ErrUnknownTool) {
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeToolNotFound, i18n.ErrToolNotFound).
+				WithDetails(gin.H{"available": gemini.DefaultTools.Names()}))
+			return nil, false
+		}
+		apierror.Abort(c, apierror.Internal(err))
+		return nil, false
+	}
+
+	data, err := json.Marshal(names)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal(err))
+		return nil, false
+	}
+	return models.JSON(data), true
+}
+
+// ListTools @Summary Listar herramientas disponibles
+// @Description Devuelve las herramientas que se pueden habilitar en el campo "tools" al crear una tarea.
+// @Tags gemini
+// @Produce  json
+// @Success 200 {array} models.ToolInfo "Herramientas registradas"
+// @Router /v1/gemini/tools [get]
+func ListTools(c *gin.Context) {
+	names := gemini.DefaultTools.Names()
+	list := make([]models.ToolInfo, 0, len(names))
+	for _, name := range names {
+		if tool, ok := gemini.DefaultTools.Lookup(name); ok {
+			list = append(list, models.ToolInfo{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  models.JSON(tool.ParametersSchema()),
+			})
+		}
+	}
+	c.JSON(http.StatusOK, list)
+}
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/gin-gonic/gin"
+)
+
+// usageDefaultRange es el periodo que se reporta si no se indica from.
+const usageDefaultRange = 30 * 24 * time.Hour
+
+// parseUsageTime acepta fechas YYYY-MM-DD o RFC3339.
+func parseUsageTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
+
+// GetUserUsage GET /v1/users/:id/usage
+// @Summary Consumo de tokens de un usuario
+// @Description Devuelve los tokens consumidos y el costo estimado de las tareas de un usuario, agrupados por día o por modelo.
+// @Description Con api_key_id se reportan solo las tareas creadas con esa API key.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param from query string false "Inicio del periodo (YYYY-MM-DD o RFC3339, incluido). Por defecto, 30 días atrás"
+// @Param to query string false "Fin del periodo (YYYY-MM-DD o RFC3339, excluido). Por defecto, ahora"
+// @Param group_by query string false "Agrupación" Enums(day, model) default(day)
+// @Param api_key_id query int false "Solo las tareas creadas con esta API key del usuario"
+// @Success 200 {object} models.UsageReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/users/{id}/usage [get]
+func GetUserUsage(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidID))
+		return
+	}
+
+	to := time.Now().UTC()
+	if v := c.Query("to"); v != "" {
+		if to, err = parseUsageTime(v); err != nil {
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, "to").
+				WithDetails([]apierror.FieldError{{Field: "to", Rule: "datetime", Param: "YYYY-MM-DD|RFC3339"}}))
+			return
+		}
+	}
+	from := to.Add(-usageDefaultRange)
+	if v := c.Query("from"); v != "" {
+		if from, err = parseUsageTime(v); err != nil {
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, "from").
+				WithDetails([]apierror.FieldError{{Field: "from", Rule: "datetime", Param: "YYYY-MM-DD|RFC3339"}}))
+			return
+		}
+	}
+	if !from.Before(to) {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrFromBeforeTo))
+		return
+	}
+
+	groupBy := models.UsageGroupBy(c.DefaultQuery("group_by", string(models.UsageGroupByDay)))
+	var keyExpr string
+	switch groupBy {
+	case models.UsageGroupByDay:
+		keyExpr = "to_char(date_trunc('day', created_at), 'YYYY-MM-DD')"
+	case models.UsageGroupByModel:
+		keyExpr = "COALESCE(NULLIF(model, ''), 'unknown')"
+	default:
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidGroupBy).
+			WithDetails([]apierror.FieldError{{Field: "group_by", Rule: "oneof", Param: "day model"}}))
+		return
+	}
+
+	var userDB models.UserDB
+	if err := DB.WithContext(ctx).First(&userDB, id).Error; err != nil {
+		abortUserLookup(c, err)
+		return
+	}
+
+	// Se suman las tareas de texto y las de archivo del periodo.
+	const columns = "created_at, model, prompt_tokens, candidate_tokens, total_tokens, estimated_cost"
+	filter := "user_id = @user AND created_at >= @from AND created_at < @to"
+	params := map[string]interface{}{"user": userDB.ID, "from": from, "to": to}
+	var apiKeyID *uint
+	if v := c.Query("api_key_id"); v != "" {
+		key, ok := findAPIKey(c, userDB.ID, v)
+		if !ok {
+			return
+		}
+		apiKeyID = &key.ID
+		filter += " AND api_key_id = @api_key"
+		params["api_key"] = key.ID
+	}
+	query := fmt.Sprintf(`
+		SELECT %[1]s AS key,
+			COUNT(*) AS requests,
+			COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens,
+			COALESCE(SUM(candidate_tokens), 0) AS candidate_tokens,
+			COALESCE(SUM(total_tokens), 0) AS total_tokens,
+			COALESCE(SUM(estimated_cost), 0) AS estimated_cost
+		FROM (
+			SELECT %[2]s FROM %[3]s WHERE %[5]s
+			UNION ALL
+			SELECT %[2]s FROM %[4]s WHERE %[5]s
+		) AS usage
+		GROUP BY key
+		ORDER BY key`,
+		keyExpr, columns,
+		models.GeminiProcessingDB{}.TableName(), models.GeminiProcessingFileDB{}.TableName(),
+		filter)
+
+	items := []models.UsageItem{}
+	if err := DB.WithContext(ctx).Raw(query, params).Scan(&items).Error; err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+
+	report := models.UsageReport{
+		UserID:   userDB.ID,
+		APIKeyID: apiKeyID,
+		From:     from,
+		To:       to,
+		GroupBy:  groupBy,
+		Items:    items,
+		Total:    models.UsageItem{Key: "total"},
+	}
+	for _, item := range items {
+		report.Total.Requests += item.Requests
+		report.Total.PromptTokens += item.PromptTokens
+		report.Total.CandidateTokens += item.CandidateTokens
+		report.Total.TotalTokens += item.TotalTokens
+		report.Total.EstimatedCost += item.EstimatedCost
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -1,9 +1,20 @@
 package controllers
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/auth"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/middleware"
 	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/tenant"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -15,7 +26,16 @@ func SetDB(db *gorm.DB) {
 	DB = db
 }
 
-// GET /users/:id
+// abortUserLookup responde el error de buscar un usuario por su ID.
+func abortUserLookup(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeUserNotFound, i18n.ErrUserNotFound))
+		return
+	}
+	apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+}
+
+// GET /v1/users/:id
 // @Summary Obtener un usuario por ID
 // @Description Devuelve la información de un usuario por su ID
 // @Tags users
@@ -23,58 +43,386 @@ func SetDB(db *gorm.DB) {
 // @Produce json
 // @Param id path int true "ID del usuario"
 // @Success 200 {object} models.User
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Router /users/{id} [get]
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/users/{id} [get]
 func GetUserByID(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
-		return
-	}
-
-	var userDB models.UserDB
-	if err := DB.First(&userDB, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error en la base de datos"})
-		}
+	userDB, ok := findUser(c, false)
+	if !ok {
 		return
 	}
 
 	c.JSON(http.StatusOK, userDB.ToSwagger())
 }
 
-// POST /users
+// POST /v1/users
 // @Summary Crear un usuario
-// @Description Crea un usuario nuevo con contraseña
+// @Description Crea un usuario nuevo con contraseña y su organización personal (slug user-{id}), de la que es owner, y le
+// @Description envía un correo para confirmar su email.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param input body models.CreateUserInput true "Datos para crear usuario"
 // @Success 201 {object} models.User
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
-// @Router /users [post]
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/users [post]
 func CreateUser(c *gin.Context) {
 	var input models.CreateUserInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+
+	// Cualquiera puede registrarse como member; los demás roles solo los asigna un administrador.
+	role := models.RoleMember
+	if input.Role != "" && input.Role != role {
+		if !middleware.Role(c).Can(models.PermUsersManage) {
+			apierror.Abort(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, i18n.ErrRoleNotAllowed, input.Role).
+				WithDetails(gin.H{"permission": models.PermUsersManage}))
+			return
+		}
+		role = input.Role
+	}
+
+	hash, err := auth.HashPassword(input.Password)
+	if err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrUserCreateFailed))
+		return
+	}
+	ctx := c.Request.Context()
+	email := models.NormalizeEmail(input.Email)
+	// El índice único distingue mayúsculas; los usuarios guardados antes de normalizar los emails
+	// solo se detectan comparando con lower(email).
+	var taken int64
+	if err := DB.WithContext(ctx).Unscoped().Model(&models.UserDB{}).Where("lower(email) = ?", email).Count(&taken).Error; err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrUserCreateFailed))
+		return
+	}
+	if taken > 0 {
+		apierror.Abort(c, apierror.New(http.StatusConflict, apierror.CodeEmailTaken, i18n.ErrEmailTaken))
 		return
 	}
 
 	userDB := models.UserDB{
 		FullName: input.FullName,
-		Email:    input.Email,
-		Password: input.Password, // Aquí la contraseña se guarda en DB (idealmente con hash)
+		Email:    email,
+		Password: hash,
+		Role:     role,
 	}
 
-	if err := DB.Create(&userDB).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo crear el usuario"})
+	err = DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&userDB).Error; err != nil {
+			return err
+		}
+		return tenant.CreatePersonalOrg(tx, userDB)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			apierror.Abort(c, apierror.New(http.StatusConflict, apierror.CodeEmailTaken, i18n.ErrEmailTaken))
+			return
+		}
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrUserCreateFailed))
 		return
 	}
 
+	sendVerificationEmail(ctx, userDB)
 	c.JSON(http.StatusCreated, userDB.ToSwagger())
 }
+
+// Paginación de los listados de usuarios y de tareas.
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// pageLimit lee ?limit= (por defecto defaultPageSize, máximo maxPageSize). Si el valor no es
+// válido responde el error y devuelve ok=false.
+func pageLimit(c *gin.Context) (int, bool) {
+	value := c.Query("limit")
+	if value == "" {
+		return defaultPageSize, true
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > maxPageSize {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, "limit"))
+		return 0, false
+	}
+	return limit, true
+}
+
+// userSortColumns son los campos por los que se puede ordenar el listado de usuarios.
+var userSortColumns = []string{"id", "full_name", "email", "created_at"}
+
+// userCursor es la posición de la siguiente página: el orden usado y los valores del último
+// usuario devuelto. Se envía al cliente en base64 y no debe interpretarse.
+type userCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"`
+	ID    uint   `json:"id"`
+}
+
+// encodeUserCursor arma el cursor que sigue al usuario indicado.
+func encodeUserCursor(sort string, column string, user models.UserDB) string {
+	cursor := userCursor{Sort: sort, ID: user.ID}
+	switch column {
+	case "full_name":
+		cursor.Value = user.FullName
+	case "email":
+		cursor.Value = user.Email
+	case "created_at":
+		cursor.Value = user.CreatedAt.Format(time.RFC3339Nano)
+	}
+	encoded, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// decodeUserCursor lee el cursor de ?cursor=; debe corresponder al mismo orden.
+func decodeUserCursor(value string, sort string) (userCursor, error) {
+	var cursor userCursor
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+	if err := json.Unmarshal(decoded, &cursor); err != nil {
+		return cursor, err
+	}
+	if cursor.Sort != sort {
+		return cursor, errors.New("el cursor corresponde a otro orden")
+	}
+	return cursor, nil
+}
+
+// likePattern arma el patrón de ILIKE que busca el texto en cualquier posición, escapando
+// los comodines que traiga.
+func likePattern(text string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text) + "%"
+}
+
+// findUser busca el usuario del parámetro :id (incluyendo los eliminados si unscoped es
+// true); si no existe responde el error y devuelve ok=false.
+func findUser(c *gin.Context, unscoped bool) (models.UserDB, bool) {
+	var user models.UserDB
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidID))
+		return user, false
+	}
+	query := DB.WithContext(c.Request.Context())
+	if unscoped {
+		query = query.Unscoped()
+	}
+	if err := query.First(&user, id).Error; err != nil {
+		abortUserLookup(c, err)
+		return user, false
+	}
+	return user, true
+}
+
+// ListUsers @Summary Listar usuarios
+// @Description Devuelve los usuarios por páginas. La respuesta trae next_cursor, que se envía en ?cursor= para
+// @Description pedir la página siguiente con los mismos q, sort y deleted. Con deleted=true se listan solo los
+// @Description usuarios eliminados, para restaurarlos.
+// @Tags users
+// @Produce json
+// @Param q query string false "Texto a buscar en el nombre o el email"
+// @Param sort query string false "Campo de orden (id, full_name, email, created_at); con '-' delante es descendente" default(id)
+// @Param limit query int false "Usuarios por página (máximo 100)" default(20)
+// @Param cursor query string false "Cursor de la página siguiente"
+// @Param deleted query bool false "Listar los usuarios eliminados"
+// @Success 200 {object} models.UserList
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/users [get]
+func ListUsers(c *gin.Context) {
+	limit, ok := pageLimit(c)
+	if !ok {
+		return
+	}
+	sort := c.DefaultQuery("sort", "id")
+	column, descending := strings.CutPrefix(sort, "-")
+	if !slices.Contains(userSortColumns, column) {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, "sort").
+			WithDetails(gin.H{"allowed": userSortColumns}))
+		return
+	}
+	deleted, err := strconv.ParseBool(c.DefaultQuery("deleted", "false"))
+	if err != nil {
+		apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, "deleted"))
+		return
+	}
+
+	query := DB.WithContext(c.Request.Context()).Model(&models.UserDB{})
+	if deleted {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		pattern := likePattern(q)
+		query = query.Where("(full_name ILIKE ? OR email ILIKE ?)", pattern, pattern)
+	}
+
+	// Paginación por cursor: se continúa después del último usuario de la página anterior,
+	// desempatando por ID para que el orden sea total.
+	operator, direction := ">", "ASC"
+	if descending {
+		operator, direction = "<", "DESC"
+	}
+	if value := c.Query("cursor"); value != "" {
+		cursor, err := decodeUserCursor(value, sort)
+		if err != nil {
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, "cursor"))
+			return
+		}
+		switch column {
+		case "id":
+			query = query.Where("id "+operator+" ?", cursor.ID)
+		case "created_at":
+			after, err := time.Parse(time.RFC3339Nano, cursor.Value)
+			if err != nil {
+				apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidParam, "cursor"))
+				return
+			}
+			query = query.Where("(created_at, id) "+operator+" (?, ?)", after, cursor.ID)
+		default:
+			query = query.Where("("+column+", id) "+operator+" (?, ?)", cursor.Value, cursor.ID)
+		}
+	}
+	query = query.Order(column + " " + direction)
+	if column != "id" {
+		query = query.Order("id " + direction)
+	}
+
+	// Se pide un usuario de más para saber si hay otra página.
+	var users []models.UserDB
+	if err := query.Limit(limit + 1).Find(&users).Error; err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+
+	response := models.UserList{Items: make([]models.User, 0, min(len(users), limit))}
+	if len(users) > limit {
+		users = users[:limit]
+		response.NextCursor = encodeUserCursor(sort, column, users[limit-1])
+	}
+	for _, user := range users {
+		response.Items = append(response.Items, user.ToSwagger())
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateUser @Summary Actualizar el perfil de un usuario
+// @Description Cambia el nombre o el email de un usuario; solo se modifican los campos enviados. El email no puede
+// @Description pertenecer a otro usuario, aunque esté eliminado. Al cambiar el email queda sin confirmar y se envía
+// @Description un correo de verificación a la dirección nueva.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param input body models.UpdateUserInput true "Campos a cambiar"
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/users/{id} [patch]
+func UpdateUser(c *gin.Context) {
+	user, ok := findUser(c, false)
+	if !ok {
+		return
+	}
+	var input models.UpdateUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierror.Abort(c, apierror.Binding(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	values := map[string]interface{}{}
+	if input.FullName != nil {
+		values["full_name"] = *input.FullName
+	}
+	if input.Email != nil && models.NormalizeEmail(*input.Email) != user.Email {
+		email := models.NormalizeEmail(*input.Email)
+		// Se revisa antes de actualizar para responder 409 también con los usuarios eliminados;
+		// el índice único cubre las actualizaciones simultáneas.
+		var taken int64
+		err := DB.WithContext(ctx).Unscoped().Model(&models.UserDB{}).
+			Where("lower(email) = ? AND id <> ?", email, user.ID).
+			Count(&taken).Error
+		if err != nil {
+			apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+			return
+		}
+		if taken > 0 {
+			apierror.Abort(c, apierror.New(http.StatusConflict, apierror.CodeEmailTaken, i18n.ErrEmailTaken))
+			return
+		}
+		// El email nuevo se debe confirmar otra vez.
+		values["email"] = email
+		values["email_verified_at"] = nil
+	}
+
+	if len(values) > 0 {
+		if err := DB.WithContext(ctx).Model(&user).Updates(values).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				apierror.Abort(c, apierror.New(http.StatusConflict, apierror.CodeEmailTaken, i18n.ErrEmailTaken))
+				return
+			}
+			apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+			return
+		}
+		if _, changed := values["email"]; changed {
+			sendVerificationEmail(ctx, user)
+		}
+	}
+	c.JSON(http.StatusOK, user.ToSwagger())
+}
+
+// DeleteUser @Summary Eliminar un usuario
+// @Description Marca el usuario como eliminado: deja de aparecer en las consultas y no puede originar peticiones,
+// @Description pero conserva sus datos y se puede restaurar.
+// @Tags users
+// @Param id path int true "ID del usuario"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/users/{id} [delete]
+func DeleteUser(c *gin.Context) {
+	user, ok := findUser(c, false)
+	if !ok {
+		return
+	}
+	if err := DB.WithContext(c.Request.Context()).Delete(&user).Error; err != nil {
+		apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RestoreUser @Summary Restaurar un usuario eliminado
+// @Description Quita la marca de eliminado de un usuario. Si el usuario no estaba eliminado lo devuelve sin cambios.
+// @Tags users
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v1/users/{id}/restore [post]
+func RestoreUser(c *gin.Context) {
+	user, ok := findUser(c, true)
+	if !ok {
+		return
+	}
+	if user.DeletedAt.Valid {
+		if err := DB.WithContext(c.Request.Context()).Unscoped().Model(&user).Update("deleted_at", nil).Error; err != nil {
+			apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+			return
+		}
+		user.DeletedAt = gorm.DeletedAt{}
+	}
+	c.JSON(http.StatusOK, user.ToSwagger())
+}
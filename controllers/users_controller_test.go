@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+)
+
+// La búsqueda usa ILIKE, que SQLite no tiene; aquí se prueba el patrón.
+func TestLikePatternEscapesWildcards(t *testing.T) {
+	for text, want := range map[string]string{
+		"ana":      "%ana%",
+		"100%":     `%100\%%`,
+		"a_b":      `%a\_b%`,
+		`c:\datos`: `%c:\\datos%`,
+	} {
+		if got := likePattern(text); got != want {
+			t.Errorf("likePattern(%q) = %q, se esperaba %q", text, got, want)
+		}
+	}
+}
+
+func TestUserCursorKeepsTheSortOrder(t *testing.T) {
+	user := models.UserDB{ID: 7, FullName: "Ana", Email: "ana@example.com", CreatedAt: time.Now()}
+	encoded := encodeUserCursor("-full_name", "full_name", user)
+	cursor, err := decodeUserCursor(encoded, "-full_name")
+	if err != nil || cursor.ID != 7 || cursor.Value != "Ana" {
+		t.Fatalf("decodeUserCursor = %+v, %v", cursor, err)
+	}
+	if _, err := decodeUserCursor(encoded, "full_name"); err == nil {
+		t.Error("el cursor de otro orden no devolvió error")
+	}
+	if _, err := decodeUserCursor("%%%", "id"); err == nil {
+		t.Error("un cursor inválido no devolvió error")
+	}
+}
@@ -2,51 +2,67 @@ package db
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/plugin/opentelemetry/tracing"
 )
 
 var DB *gorm.DB
 
-func Connect() {
-	host := os.Getenv("DB_HOST")
-	if host == "" {
-		host = "localhost"
-	}
-	user := os.Getenv("DB_USER")
-	if user == "" {
-		user = "edgz"
-	}
-	password := os.Getenv("DB_PASSWORD")
-	if password == "" {
-		password = "1234"
-	}
-	dbname := os.Getenv("DB_NAME")
-	if dbname == "" {
-		dbname = "edgz"
-	}
-	port := os.Getenv("DB_PORT")
-	if port == "" {
-		port = "5432"
+// envOr devuelve el valor de la variable de entorno o def si no está definida.
+func envOr(name string, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
 	}
+	return def
+}
 
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
-		host, user, password, dbname, port)
+// DSN arma la cadena de conexión a PostgreSQL a partir de DB_HOST, DB_USER, DB_PASSWORD,
+// DB_NAME y DB_PORT. También la usan las conexiones dedicadas, como la de LISTEN/NOTIFY.
+func DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
+		envOr("DB_HOST", "localhost"), envOr("DB_USER", "edgz"), envOr("DB_PASSWORD", "1234"),
+		envOr("DB_NAME", "edgz"), envOr("DB_PORT", "5432"))
+}
+
+func Connect() {
+	dsn := DSN()
 
 	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// Los logs de GORM van a slog con consultas parametrizadas para no registrar prompts ni archivos.
+	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.NewSlogLogger(slog.Default(), logger.Config{
+			SlowThreshold:             200 * time.Millisecond,
+			LogLevel:                  logger.Warn,
+			ParameterizedQueries:      true,
+			IgnoreRecordNotFoundError: true,
+		}),
+		// Traduce errores del driver (por ejemplo, claves duplicadas) a los errores de GORM.
+		TranslateError: true,
+	})
 	if err != nil {
-		log.Fatalf("Error al conectar a la base de datos: %v", err)
+		slog.Error("Error al conectar a la base de datos", "error", err)
+		os.Exit(1)
+	}
+
+	// Instrumentar las consultas con OpenTelemetry; se omiten los valores de las
+	// variables para no exportar prompts ni archivos en los spans.
+	if err := DB.Use(tracing.NewPlugin(tracing.WithoutQueryVariables(), tracing.WithoutMetrics())); err != nil {
+		slog.Error("Error al registrar el plugin de trazas", "error", err)
+		os.Exit(1)
 	}
 
 	// Aquí se crea el esquema si no existe.
 	// GORM no maneja la creación de esquemas, por lo que lo hacemos con un comando SQL.
 	if err := DB.Exec("CREATE SCHEMA IF NOT EXISTS gemini;").Error; err != nil {
-		log.Fatalf("Error al crear el esquema 'gemini': %v", err)
+		slog.Error("Error al crear el esquema 'gemini'", "error", err)
+		os.Exit(1)
 	}
 
-	log.Println("Conexión a la base de datos exitosa")
+	slog.Info("Conexión a la base de datos exitosa", "host", envOr("DB_HOST", "localhost"), "dbname", envOr("DB_NAME", "edgz"))
 }
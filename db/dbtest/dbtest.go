@@ -0,0 +1,120 @@
+// Package dbtest prepara bases de datos para las pruebas: SQLite en un directorio temporal,
+// con el esquema gemini adjunto para que funcionen los nombres de tabla de los modelos, o
+// la base Postgres de TEST_DATABASE_URL para lo que depende de Postgres (triggers, pgvector).
+package dbtest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/db"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// Open crea una base SQLite vacía con las tablas de los modelos, la asigna a db.DB mientras
+// dure la prueba y la devuelve.
+func Open(t testing.TB, models ...interface{}) *gorm.DB {
+	t.Helper()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.db")
+	// SQLite no tiene esquemas: "gemini.tabla" se resuelve en una segunda base adjunta con ese
+	// nombre. Es el mismo archivo, para que las consultas que unen tablas vean los mismos datos.
+	schema := filepath.Join(dir, "gemini.db")
+	if err := os.Symlink(file, schema); err != nil {
+		t.Fatalf("no se pudo preparar la base de prueba: %v", err)
+	}
+
+	conn, err := gorm.Open(sqliteDialector{sqlite.Open(file).(*sqlite.Dialector)}, &gorm.Config{
+		Logger:         logger.Discard,
+		TranslateError: true,
+		// SQLite no admite el esquema en REFERENCES: las claves foráneas solo se prueban en Postgres.
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("no se pudo abrir la base de prueba: %v", err)
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		t.Fatalf("no se pudo abrir la base de prueba: %v", err)
+	}
+	// Una sola conexión: el ATTACH vale por conexión.
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := conn.Exec("ATTACH DATABASE ? AS gemini", schema).Error; err != nil {
+		t.Fatalf("no se pudo adjuntar el esquema gemini: %v", err)
+	}
+	if err := conn.AutoMigrate(models...); err != nil {
+		t.Fatalf("no se pudieron crear las tablas: %v", err)
+	}
+	// El driver de SQLite escribe los INSERT sin el esquema. Como gemini es otro nombre del mismo
+	// archivo, una transacción que borra en gemini.tabla e inserta en tabla se bloquea a sí misma.
+	insert := conn.ClauseBuilders["INSERT"]
+	conn.ClauseBuilders["INSERT"] = func(c clause.Clause, builder clause.Builder) {
+		if stmt, ok := builder.(*gorm.Statement); ok && stmt.TableExpr != nil {
+			if expr, ok := c.Expression.(clause.Insert); ok && expr.Table.Name == "" {
+				expr.Table = clause.Table{Name: stmt.TableExpr.SQL, Raw: true}
+				c.Expression = expr
+			}
+		}
+		insert(c, builder)
+	}
+	use(t, conn)
+	return conn
+}
+
+// sqliteDialector traduce las violaciones de índices únicos a gorm.ErrDuplicatedKey, como hace
+// el driver de Postgres. El de SQLite solo reconoce *sqlite3.Error, pero go-sqlite3 devuelve
+// sqlite3.Error, así que sin esto las pruebas de los 409 responderían 500.
+type sqliteDialector struct {
+	*sqlite.Dialector
+}
+
+func (d sqliteDialector) Translate(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return gorm.ErrDuplicatedKey
+	}
+	return d.Dialector.Translate(err)
+}
+
+// Postgres se conecta a la base de TEST_DATABASE_URL, crea el esquema gemini y las tablas de
+// los modelos, y la asigna a db.DB mientras dure la prueba. Sin TEST_DATABASE_URL la prueba
+// se omite. Las tablas no se borran: conviene usar una base dedicada a las pruebas.
+func Postgres(t testing.TB, models ...interface{}) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL no está definida")
+	}
+	conn, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard, TranslateError: true})
+	if err != nil {
+		t.Fatalf("no se pudo conectar a TEST_DATABASE_URL: %v", err)
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		t.Fatalf("no se pudo conectar a TEST_DATABASE_URL: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := conn.Exec("CREATE SCHEMA IF NOT EXISTS gemini").Error; err != nil {
+		t.Fatalf("no se pudo crear el esquema gemini: %v", err)
+	}
+	if err := conn.AutoMigrate(models...); err != nil {
+		t.Fatalf("no se pudieron crear las tablas: %v", err)
+	}
+	use(t, conn)
+	return conn
+}
+
+// use asigna conn a db.DB y restaura el valor anterior al terminar la prueba.
+func use(t testing.TB, conn *gorm.DB) {
+	previous := db.DB
+	db.DB = conn
+	t.Cleanup(func() { db.DB = previous })
+}
@@ -1,439 +0,0 @@
-// Package docs Code generated by swaggo/swag. DO NOT EDIT
-package docs
-
-import "github.com/swaggo/swag"
-
-const docTemplate = `{
-    "schemes": {{ marshal .Schemes }},
-    "swagger": "2.0",
-    "info": {
-        "description": "{{escape .Description}}",
-        "title": "{{.Title}}",
-        "contact": {},
-        "version": "{{.Version}}"
-    },
-    "host": "{{.Host}}",
-    "basePath": "{{.BasePath}}",
-    "paths": {
-        "/gemini/process": {
-            "post": {
-                "description": "Inicia una tarea en segundo plano para procesar un prompt con la API de Gemini.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "gemini"
-                ],
-                "parameters": [
-                    {
-                        "description": "Prompt a procesar",
-                        "name": "requestBody",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.PromptRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "202": {
-                        "description": "Solicitud aceptada y procesando",
-                        "schema": {
-                            "$ref": "#/definitions/models.GeminiProcessingIDResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "JSON de solicitud inválido",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            }
-        },
-        "/gemini/process/file": {
-            "post": {
-                "description": "Procesa un prompt y un archivo de forma asíncrona, guarda los datos y retorna un ID de proceso.",
-                "consumes": [
-                    "multipart/form-data"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "gemini"
-                ],
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Texto del prompt",
-                        "name": "prompt",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "Archivo (PDF, PNG, JPEG)",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "202": {
-                        "description": "Proceso en cola",
-                        "schema": {
-                            "$ref": "#/definitions/models.GeminiProcessingFileIDResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Solicitud inválida",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            }
-        },
-        "/gemini/status-file/{gemini_processing_id}": {
-            "get": {
-                "description": "Consulta el estado y el resultado de una tarea por su ID.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "gemini"
-                ],
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "ID del proceso",
-                        "name": "gemini_processing_id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Estado del proceso y resultado",
-                        "schema": {
-                            "$ref": "#/definitions/models.GeminiProcessingFileResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "ID de proceso inválido",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            }
-        },
-        "/gemini/status/{gemini_processing_id}": {
-            "get": {
-                "description": "Consulta el estado y el resultado de una tarea por su ID.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "gemini"
-                ],
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "ID del proceso",
-                        "name": "gemini_processing_id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Estado del proceso y resultado",
-                        "schema": {
-                            "$ref": "#/definitions/models.GeminiProcessingResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "ID de proceso inválido",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            }
-        },
-        "/users": {
-            "post": {
-                "description": "Crea un usuario nuevo con contraseña",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "users"
-                ],
-                "summary": "Crear un usuario",
-                "parameters": [
-                    {
-                        "description": "Datos para crear usuario",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.CreateUserInput"
-                        }
-                    }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "Created",
-                        "schema": {
-                            "$ref": "#/definitions/models.User"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            }
-        },
-        "/users/{id}": {
-            "get": {
-                "description": "Devuelve la información de un usuario por su ID",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "users"
-                ],
-                "summary": "Obtener un usuario por ID",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "ID del usuario",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.User"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "models.CreateUserInput": {
-            "type": "object",
-            "required": [
-                "email",
-                "full_name",
-                "password"
-            ],
-            "properties": {
-                "email": {
-                    "type": "string",
-                    "example": "efren@example.com"
-                },
-                "full_name": {
-                    "type": "string",
-                    "example": "Efren David"
-                },
-                "password": {
-                    "type": "string",
-                    "example": "miPasswordSeguro123"
-                }
-            }
-        },
-        "models.GeminiProcessingFileIDResponse": {
-            "type": "object",
-            "properties": {
-                "task_id": {
-                    "type": "string",
-                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
-                }
-            }
-        },
-        "models.GeminiProcessingFileResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string",
-                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
-                },
-                "result": {
-                    "type": "string",
-                    "example": "Sí, existen varias becas..."
-                },
-                "status": {
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.GeminiProcessingStatus"
-                        }
-                    ],
-                    "example": "finalizado"
-                }
-            }
-        },
-        "models.GeminiProcessingIDResponse": {
-            "type": "object",
-            "properties": {
-                "task_id": {
-                    "type": "string",
-                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
-                }
-            }
-        },
-        "models.GeminiProcessingResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string",
-                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
-                },
-                "result": {
-                    "type": "string",
-                    "example": "Sí, existen varias becas..."
-                },
-                "status": {
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.GeminiProcessingStatus"
-                        }
-                    ],
-                    "example": "finalizado"
-                }
-            }
-        },
-        "models.GeminiProcessingStatus": {
-            "type": "string",
-            "enum": [
-                "pendiente",
-                "en_proceso",
-                "finalizado",
-                "error"
-            ],
-            "x-enum-varnames": [
-                "StatusPending",
-                "StatusProcessing",
-                "StatusCompleted",
-                "StatusError"
-            ]
-        },
-        "models.PromptRequest": {
-            "type": "object",
-            "properties": {
-                "prompt": {
-                    "type": "string",
-                    "example": "Conoces las becas para poder estudiar en finlandia o noruega?"
-                }
-            }
-        },
-        "models.User": {
-            "type": "object",
-            "properties": {
-                "email": {
-                    "type": "string",
-                    "example": "efren@example.com"
-                },
-                "full_name": {
-                    "type": "string",
-                    "example": "Efren David"
-                },
-                "id": {
-                    "type": "integer",
-                    "example": 1
-                }
-            }
-        }
-    }
-}`
-
-// SwaggerInfo holds exported Swagger Info so clients can modify it
-var SwaggerInfo = &swag.Spec{
-	Version:          "1.0",
-	Host:             "localhost:8080",
-	BasePath:         "/",
-	Schemes:          []string{},
-	Title:            "API GEMINI",
-	Description:      "API RESTful para gestión de usuarios",
-	InfoInstanceName: "swagger",
-	SwaggerTemplate:  docTemplate,
-	LeftDelim:        "{{",
-	RightDelim:       "}}",
-}
-
-func init() {
-	swag.Register(SwaggerInfo.InstanceName(), SwaggerInfo)
-}
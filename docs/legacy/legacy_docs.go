@@ -0,0 +1,935 @@
+// Package legacy Code generated by swaggo/swag. DO NOT EDIT
+package legacy
+
+import "github.com/swaggo/swag"
+
+const docTemplatelegacy = `{
+    "schemes": {{ marshal .Schemes }},
+    "swagger": "2.0",
+    "info": {
+        "description": "{{escape .Description}}",
+        "title": "{{.Title}}",
+        "contact": {},
+        "version": "{{.Version}}"
+    },
+    "host": "{{.Host}}",
+    "basePath": "{{.BasePath}}",
+    "paths": {
+        "/gemini/process": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Obsoleto: usar POST /v1/gemini/tasks.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "legacy"
+                ],
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "description": "Prompt a procesar",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PromptRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Solicitud aceptada y procesando",
+                        "schema": {
+                            "$ref": "#/definitions/models.GeminiProcessingIDResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "JSON de solicitud inválido",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Límite de peticiones o cuota agotada",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/gemini/process/file": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Obsoleto: usar POST /v1/gemini/file-tasks.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "legacy"
+                ],
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Texto del prompt",
+                        "name": "prompt",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Archivo (PDF, PNG, JPEG)",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Proceso en cola",
+                        "schema": {
+                            "$ref": "#/definitions/models.GeminiProcessingFileIDResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Solicitud inválida",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Límite de peticiones o cuota agotada",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/gemini/status-file/{gemini_processing_id}": {
+            "get": {
+                "description": "Obsoleto: usar GET /v1/gemini/file-tasks/{id}.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "legacy"
+                ],
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del proceso",
+                        "name": "gemini_processing_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tiempo máximo de espera a un cambio de estado (por ejemplo 30s)",
+                        "name": "wait",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Estado del proceso y resultado",
+                        "schema": {
+                            "$ref": "#/definitions/models.GeminiProcessingFileResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "ID de proceso no encontrado",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/gemini/status/{gemini_processing_id}": {
+            "get": {
+                "description": "Obsoleto: usar GET /v1/gemini/tasks/{id}.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "legacy"
+                ],
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del proceso",
+                        "name": "gemini_processing_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tiempo máximo de espera a un cambio de estado (por ejemplo 30s)",
+                        "name": "wait",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Estado del proceso y resultado",
+                        "schema": {
+                            "$ref": "#/definitions/models.GeminiProcessingResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "ID de proceso no encontrado",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "post": {
+                "description": "Obsoleto: usar POST /v1/users.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "legacy"
+                ],
+                "summary": "Crear un usuario",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "description": "Datos para crear usuario",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateUserInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "description": "Obsoleto: usar GET /v1/users/{id}.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "legacy"
+                ],
+                "summary": "Obtener un usuario por ID",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/usage": {
+            "get": {
+                "description": "Obsoleto: usar GET /v1/users/{id}/usage.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "legacy"
+                ],
+                "summary": "Consumo de tokens de un usuario",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Inicio del periodo (YYYY-MM-DD o RFC3339, incluido)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fin del periodo (YYYY-MM-DD o RFC3339, excluido)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "day",
+                            "model"
+                        ],
+                        "type": "string",
+                        "default": "day",
+                        "description": "Agrupación",
+                        "name": "group_by",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UsageReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.CreateUserInput": {
+            "type": "object",
+            "required": [
+                "email",
+                "full_name",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "efren@example.com"
+                },
+                "full_name": {
+                    "type": "string",
+                    "example": "Efren David"
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 8,
+                    "example": "miPasswordSeguro123"
+                },
+                "role": {
+                    "description": "Role solo lo puede indicar un administrador; por defecto es member.",
+                    "enum": [
+                        "admin",
+                        "member",
+                        "viewer",
+                        "service"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Role"
+                        }
+                    ],
+                    "example": "member"
+                }
+            }
+        },
+        "models.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "enum": [
+                        "invalid_request",
+                        "validation_failed",
+                        "unsupported_file_type",
+                        "user_not_found",
+                        "task_not_found",
+                        "email_taken",
+                        "invalid_schema",
+                        "schema_not_found",
+                        "tool_not_found",
+                        "collection_not_found",
+                        "collection_name_taken",
+                        "collection_empty",
+                        "document_not_found",
+                        "template_not_found",
+                        "template_name_taken",
+                        "invalid_template",
+                        "template_render_failed",
+                        "batch_not_found",
+                        "batch_not_finished",
+                        "batch_too_large",
+                        "invalid_csv",
+                        "api_key_not_found",
+                        "api_key_revoked",
+                        "invalid_token",
+                        "email_not_verified",
+                        "oidc_not_configured",
+                        "oidc_login_failed",
+                        "org_not_found",
+                        "org_slug_taken",
+                        "org_member_not_found",
+                        "org_member_exists",
+                        "org_last_owner",
+                        "unauthorized",
+                        "forbidden",
+                        "rate_limited",
+                        "quota_exceeded",
+                        "internal_error"
+                    ],
+                    "example": "validation_failed"
+                },
+                "details": {
+                    "type": "object"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Datos de entrada inválidos"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "0f8fad5b-d9cb-469f-a165-70867728950e"
+                }
+            }
+        },
+        "models.GeminiProcessingFileIDResponse": {
+            "type": "object",
+            "properties": {
+                "task_id": {
+                    "type": "string",
+                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                }
+            }
+        },
+        "models.GeminiProcessingFileResponse": {
+            "type": "object",
+            "properties": {
+                "cached": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "error": {
+                    "$ref": "#/definitions/models.TaskError"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                },
+                "redactions": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "result": {
+                    "type": "string",
+                    "example": "Sí, existen varias becas..."
+                },
+                "result_json": {
+                    "type": "object"
+                },
+                "result_purged_at": {
+                    "description": "ResultPurgedAt indica que el resultado se eliminó por la política de retención.",
+                    "type": "string",
+                    "example": "2025-04-15T03:00:00Z"
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GeminiProcessingStatus"
+                        }
+                    ],
+                    "example": "completed"
+                },
+                "status_label": {
+                    "type": "string",
+                    "example": "finalizado"
+                },
+                "tool_calls": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "usage": {
+                    "$ref": "#/definitions/models.TaskUsage"
+                }
+            }
+        },
+        "models.GeminiProcessingIDResponse": {
+            "type": "object",
+            "properties": {
+                "task_id": {
+                    "type": "string",
+                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                }
+            }
+        },
+        "models.GeminiProcessingResponse": {
+            "type": "object",
+            "properties": {
+                "cached": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "citations": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "error": {
+                    "$ref": "#/definitions/models.TaskError"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                },
+                "redactions": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "result": {
+                    "type": "string",
+                    "example": "Sí, existen varias becas..."
+                },
+                "result_json": {
+                    "type": "object"
+                },
+                "result_purged_at": {
+                    "description": "ResultPurgedAt indica que el resultado se eliminó por la política de retención.",
+                    "type": "string",
+                    "example": "2025-04-15T03:00:00Z"
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GeminiProcessingStatus"
+                        }
+                    ],
+                    "example": "completed"
+                },
+                "status_label": {
+                    "type": "string",
+                    "example": "finalizado"
+                },
+                "template": {
+                    "$ref": "#/definitions/models.TaskTemplate"
+                },
+                "tool_calls": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "usage": {
+                    "$ref": "#/definitions/models.TaskUsage"
+                }
+            }
+        },
+        "models.GeminiProcessingStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "processing",
+                "completed",
+                "error"
+            ],
+            "x-enum-varnames": [
+                "StatusPending",
+                "StatusProcessing",
+                "StatusCompleted",
+                "StatusError"
+            ]
+        },
+        "models.PromptRequest": {
+            "type": "object",
+            "properties": {
+                "prompt": {
+                    "type": "string",
+                    "example": "Conoces las becas para poder estudiar en finlandia o noruega?"
+                },
+                "response_schema": {
+                    "description": "ResponseSchema es un JSON Schema que debe cumplir la respuesta; no se puede usar junto con Schema.",
+                    "type": "object"
+                },
+                "schema": {
+                    "description": "Schema es el nombre de un esquema de respuesta registrado en el servidor.",
+                    "type": "string",
+                    "example": "invoice"
+                },
+                "template": {
+                    "description": "Template es el nombre de una plantilla de prompt; no se puede usar junto con Prompt.",
+                    "type": "string",
+                    "example": "invoice-summary"
+                },
+                "tools": {
+                    "description": "Tools son los nombres de las herramientas registradas que el modelo puede llamar.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "get_user"
+                    ]
+                },
+                "variables": {
+                    "description": "Variables son los valores de las variables de la plantilla; reemplazan a los valores por defecto.",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "version": {
+                    "description": "Version es la versión de la plantilla; si se omite se usa la más reciente.",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 3
+                }
+            }
+        },
+        "models.Role": {
+            "type": "string",
+            "enum": [
+                "admin",
+                "member",
+                "viewer",
+                "service"
+            ],
+            "x-enum-varnames": [
+                "RoleAdmin",
+                "RoleMember",
+                "RoleViewer",
+                "RoleService"
+            ]
+        },
+        "models.TaskError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "enum": [
+                        "schema_validation_failed",
+                        "gemini_tool_steps_exceeded",
+                        "document_extraction_failed",
+                        "document_empty",
+                        "gemini_not_configured",
+                        "gemini_invalid_request",
+                        "gemini_permission_denied",
+                        "gemini_rate_limited",
+                        "gemini_timeout",
+                        "gemini_unavailable",
+                        "gemini_upload_failed",
+                        "gemini_error"
+                    ],
+                    "example": "gemini_rate_limited"
+                },
+                "details": {
+                    "description": "Details es información adicional, por ejemplo los incumplimientos del esquema de respuesta.",
+                    "type": "object"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Se agotó la cuota de la API de Gemini"
+                }
+            }
+        },
+        "models.TaskTemplate": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "invoice-summary"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.TaskUsage": {
+            "type": "object",
+            "properties": {
+                "candidate_tokens": {
+                    "type": "integer",
+                    "example": 250
+                },
+                "estimated_cost_usd": {
+                    "type": "number",
+                    "example": 0.0001012
+                },
+                "model": {
+                    "type": "string",
+                    "example": "gemini-2.0-flash"
+                },
+                "prompt_tokens": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "total_tokens": {
+                    "type": "integer",
+                    "example": 262
+                }
+            }
+        },
+        "models.UsageGroupBy": {
+            "type": "string",
+            "enum": [
+                "day",
+                "model"
+            ],
+            "x-enum-varnames": [
+                "UsageGroupByDay",
+                "UsageGroupByModel"
+            ]
+        },
+        "models.UsageItem": {
+            "type": "object",
+            "properties": {
+                "candidate_tokens": {
+                    "type": "integer",
+                    "example": 8000
+                },
+                "estimated_cost_usd": {
+                    "type": "number",
+                    "example": 0.00332
+                },
+                "key": {
+                    "type": "string",
+                    "example": "2025-01-31"
+                },
+                "prompt_tokens": {
+                    "type": "integer",
+                    "example": 1200
+                },
+                "requests": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "total_tokens": {
+                    "type": "integer",
+                    "example": 9200
+                }
+            }
+        },
+        "models.UsageReport": {
+            "type": "object",
+            "properties": {
+                "api_key_id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "from": {
+                    "type": "string",
+                    "example": "2025-01-01T00:00:00Z"
+                },
+                "group_by": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UsageGroupBy"
+                        }
+                    ],
+                    "example": "day"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UsageItem"
+                    }
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2025-02-01T00:00:00Z"
+                },
+                "total": {
+                    "$ref": "#/definitions/models.UsageItem"
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-01-15T10:30:00Z"
+                },
+                "deleted_at": {
+                    "type": "string",
+                    "example": "2025-02-01T08:00:00Z"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "efren@example.com"
+                },
+                "email_verified": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "full_name": {
+                    "type": "string",
+                    "example": "Efren David"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "role": {
+                    "enum": [
+                        "admin",
+                        "member",
+                        "viewer",
+                        "service"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Role"
+                        }
+                    ],
+                    "example": "member"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2025-01-15T10:30:00Z"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "description": "API key del usuario o de sesión (POST /v1/auth/login); también se acepta en Authorization: Bearer.",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        }
+    }
+}`
+
+// SwaggerInfolegacy holds exported Swagger Info so clients can modify it
+var SwaggerInfolegacy = &swag.Spec{
+	Version:          "1.0",
+	Host:             "localhost:8080",
+	BasePath:         "/",
+	Schemes:          []string{},
+	Title:            "API GEMINI",
+	Description:      "API RESTful para gestión de usuarios",
+	InfoInstanceName: "legacy",
+	SwaggerTemplate:  docTemplatelegacy,
+	LeftDelim:        "{{",
+	RightDelim:       "}}",
+}
+
+func init() {
+	swag.Register(SwaggerInfolegacy.InstanceName(), SwaggerInfolegacy)
+}
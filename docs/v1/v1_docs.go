@@ -0,0 +1,5245 @@
+// Package v1 Code generated by swaggo/swag. DO NOT EDIT
+package v1
+
+import "github.com/swaggo/swag"
+
+const docTemplatev1 = `{
+    "schemes": {{ marshal .Schemes }},
+    "swagger": "2.0",
+    "info": {
+        "description": "{{escape .Description}}",
+        "title": "{{.Title}}",
+        "contact": {},
+        "version": "{{.Version}}"
+    },
+    "host": "{{.Host}}",
+    "basePath": "{{.BasePath}}",
+    "paths": {
+        "/v1/admin/audit-events": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Devuelve los eventos de auditoría (accesos a datos y operaciones de seguridad, incluidas las\nrechazadas), del más reciente al más antiguo. La respuesta trae next_cursor, que se envía en ?cursor=\ncon los mismos filtros para pedir la página siguiente. Solo para administradores.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario que hizo la operación",
+                        "name": "actor_user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID de la organización en la que se hizo",
+                        "name": "org_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Acción, por ejemplo user.update",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tipo de recurso, por ejemplo user",
+                        "name": "resource_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID del recurso",
+                        "name": "resource_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID de la petición (X-Request-ID)",
+                        "name": "request_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Desde (YYYY-MM-DD o RFC3339, incluido)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hasta (YYYY-MM-DD o RFC3339, excluido)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Eventos por página (máximo 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor de la página siguiente",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AuditEventList"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/admin/audit-events/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Descarga los eventos de auditoría que cumplen los filtros en formato JSONL, del más antiguo al más\nreciente; cada línea es un models.AuditEvent. Los eventos se leen por partes, por lo que la exportación\nno tiene límite de tamaño. Solo para administradores.",
+                "produces": [
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario que hizo la operación",
+                        "name": "actor_user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID de la organización en la que se hizo",
+                        "name": "org_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Acción, por ejemplo user.update",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tipo de recurso, por ejemplo user",
+                        "name": "resource_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID del recurso",
+                        "name": "resource_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID de la petición (X-Request-ID)",
+                        "name": "request_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Desde (YYYY-MM-DD o RFC3339, incluido)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hasta (YYYY-MM-DD o RFC3339, excluido)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Eventos de auditoría",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/admin/tasks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Devuelve las tareas de texto (kind=task) o con archivo (kind=file_task) de todos los usuarios y\norganizaciones, de la más reciente a la más antigua, sin el prompt ni el resultado. La respuesta trae\nnext_cursor, que se envía en ?cursor= con los mismos filtros para pedir la página siguiente. Solo para\nadministradores.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "parameters": [
+                    {
+                        "enum": [
+                            "task",
+                            "file_task"
+                        ],
+                        "type": "string",
+                        "default": "task",
+                        "description": "Tipo de tarea",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "processing",
+                            "completed",
+                            "error"
+                        ],
+                        "type": "string",
+                        "description": "Estado de la tarea",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario que originó la tarea",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID de la organización de la tarea",
+                        "name": "org_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Tareas por página (máximo 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor de la página siguiente",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AdminTaskList"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/admin/tasks/{id}/legal-hold": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Con legal_hold=true la tarea queda excluida de la purga por la política de retención y su usuario no\nla puede eliminar, hasta que se quite la retención. Aplica a tareas de cualquier organización. Solo\npara administradores.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la tarea",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "task",
+                            "file_task"
+                        ],
+                        "type": "string",
+                        "default": "task",
+                        "description": "Tipo de tarea",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Retención legal",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LegalHoldInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AdminTask"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/admin/users/{id}/role": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Asigna el rol admin, member, viewer o service a un usuario. Un administrador no puede quitarse su\npropio rol, para no dejar la API sin administradores por error. Solo para administradores.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Nuevo rol",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateUserRoleInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/auth/email/verification": {
+            "post": {
+                "description": "Envía un correo de verificación nuevo si el email está registrado y sin confirmar; los enlaces anteriores\ndejan de valer. Siempre responde 202, sin esperar a buscar el email, para no revelar qué emails están\nregistrados.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "parameters": [
+                    {
+                        "description": "Email del usuario",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.EmailInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.AcceptedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/auth/email/verify": {
+            "post": {
+                "description": "Confirma el email del usuario con el token recibido por correo al registrarse o al cambiarlo. El token\nes de un solo uso y no vale si el usuario cambió su email después de recibirlo.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "parameters": [
+                    {
+                        "description": "Token recibido por correo",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.VerifyEmailInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/auth/login": {
+            "post": {
+                "description": "Valida la contraseña y devuelve el usuario y una API key de sesión que vence a las SESSION_TTL (por\ndefecto 12 horas), para enviar en Authorization: Bearer. Los usuarios creados con OIDC no tienen\ncontraseña: inician sesión con su proveedor o eligen una con /v1/auth/password/forgot.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "parameters": [
+                    {
+                        "description": "Email y contraseña",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Email o contraseña incorrectos",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "El email no está confirmado y REQUIRE_EMAIL_VERIFICATION=true",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/auth/oidc/callback": {
+            "get": {
+                "description": "Recibe al usuario de vuelta del proveedor, valida el ID token y vincula la cuenta con un usuario: el\nque ya tiene esa identidad, el que tiene el mismo email (si el proveedor y la cuenta lo verificaron) o\nuno nuevo. Los grupos del usuario asignan su rol según OIDC_ROLE_MAPPING. Devuelve una API key de\nsesión que vence a las SESSION_TTL (por defecto 12 horas), como la del inicio de sesión con\ncontraseña, para enviar en Authorization: Bearer.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Código de autorización",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "State del inicio de sesión",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OIDCLoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "El inicio de sesión no es válido o venció",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "El proveedor rechazó el inicio de sesión",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "El usuario está eliminado",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "OIDC no está configurado",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Ya existe una cuenta con ese email sin confirmar",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/auth/oidc/login": {
+            "get": {
+                "description": "Redirige al proveedor OpenID Connect configurado (flujo authorization code con PKCE). Al terminar, el\nproveedor vuelve a /v1/auth/oidc/callback. Se abre en el navegador, no con fetch.",
+                "tags": [
+                    "auth"
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Found"
+                    },
+                    "404": {
+                        "description": "OIDC no está configurado",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "El proveedor no está disponible",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/auth/password/forgot": {
+            "post": {
+                "description": "Envía un correo con un enlace para elegir una contraseña nueva, válido una sola vez y por\nPASSWORD_RESET_TTL (por defecto 1 hora); los enlaces anteriores dejan de valer. Siempre responde 202,\nsin esperar a buscar el email, para no revelar qué emails están registrados.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "parameters": [
+                    {
+                        "description": "Email del usuario",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.EmailInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.AcceptedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/auth/password/reset": {
+            "post": {
+                "description": "Cambia la contraseña con el token recibido por correo. El token es de un solo uso. Revoca todas las\nAPI keys del usuario, las de sesión y las creadas con nombre, porque quien conocía la contraseña\nanterior pudo crearlas.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "parameters": [
+                    {
+                        "description": "Token y contraseña nueva",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ResetPasswordInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/collections": {
+            "get": {
+                "description": "Devuelve las colecciones de documentos de la organización.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collections"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Collection"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Crea una colección donde se suben documentos para hacerles preguntas.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collections"
+                ],
+                "parameters": [
+                    {
+                        "description": "Datos de la colección",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateCollectionInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Collection"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/collections/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collections"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la colección",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Collection"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Elimina la colección junto con sus documentos y fragmentos indexados.",
+                "tags": [
+                    "collections"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la colección",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Colección eliminada"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/collections/{id}/documents": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collections"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la colección",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Document"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Sube un documento (PDF, texto o Markdown) que se indexa en segundo plano: se extrae su texto,\nse divide en fragmentos y se guardan sus embeddings. El estado se consulta en status_url.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collections"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la colección",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Documento (PDF, TXT, MD)",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Documento en cola",
+                        "schema": {
+                            "$ref": "#/definitions/models.DocumentCreatedResponse"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL para consultar el documento"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Cola de procesamiento llena; reintentar después de Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/collections/{id}/documents/{document_id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collections"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la colección",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID del documento",
+                        "name": "document_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Document"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Elimina el documento y sus fragmentos indexados.",
+                "tags": [
+                    "collections"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la colección",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID del documento",
+                        "name": "document_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Documento eliminado"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/collections/{id}/query": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Busca los fragmentos más relevantes de los documentos de la colección, los incluye como fuentes en el prompt\ny crea una tarea de Gemini. La respuesta de la tarea cita las fuentes como [n] y trae en citations\nel documento, la página y el fragmento de cada una.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collections"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la colección",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Pregunta",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CollectionQueryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Tarea creada",
+                        "schema": {
+                            "$ref": "#/definitions/models.TaskCreatedResponse"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL para consultar la tarea"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "La colección no tiene documentos indexados",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Límite de peticiones o cuota agotada",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Error de Gemini",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/gemini/batches": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Crea una tarea por cada prompt y las ejecuta en el pool de workers. Acepta JSON con \"prompts\"\no multipart con un archivo CSV (\"file\") y una plantilla (\"template\", \"version\"): la primera fila\ndel CSV son los nombres de las variables y cada fila siguiente genera una tarea.",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gemini"
+                ],
+                "parameters": [
+                    {
+                        "description": "Prompts (JSON)",
+                        "name": "requestBody",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchRequest"
+                        }
+                    },
+                    {
+                        "type": "file",
+                        "description": "Filas con las variables de la plantilla (CSV)",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Plantilla para generar el prompt de cada fila (CSV)",
+                        "name": "template",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Versión de la plantilla; por defecto la más reciente (CSV)",
+                        "name": "version",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Nombre de un esquema de respuesta registrado (CSV)",
+                        "name": "schema",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "no-cache para generar las respuestas sin usar el caché",
+                        "name": "Cache-Control",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Lote en cola",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchCreatedResponse"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL para consultar el progreso del lote"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Solicitud inválida",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Plantilla no encontrada",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Límite de peticiones o cuota agotada",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/gemini/batches/{id}": {
+            "get": {
+                "description": "Devuelve cuántas tareas del lote están pendientes, en proceso, completadas o con error y el consumo total.\nCuando todas terminaron, results_url indica dónde descargar los resultados.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gemini"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del lote",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Progreso del lote",
+                        "schema": {
+                            "$ref": "#/definitions/models.Batch"
+                        }
+                    },
+                    "404": {
+                        "description": "Lote no encontrado",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/gemini/batches/{id}/results": {
+            "get": {
+                "description": "Descarga el resultado de cada tarea del lote, en el orden de los prompts o de las filas del CSV.\nCon format=jsonl (por defecto) cada línea es un models.BatchResult; con format=csv las columnas son\nindex, task_id, status, result, result_json, error_code y error.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "gemini"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del lote",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "jsonl",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Formato de descarga",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Resultados del lote",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Formato inválido",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Lote no encontrado",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "El lote aún no termina",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/gemini/embeddings": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Genera los embeddings de un texto (\"text\") o de hasta 100 textos (\"items\") y, salvo que store sea false,\nlos guarda en el namespace indicado para usarlos en búsquedas. Los items con external_id reemplazan al existente.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gemini"
+                ],
+                "parameters": [
+                    {
+                        "description": "Textos a convertir",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.EmbeddingRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Embeddings generados",
+                        "schema": {
+                            "$ref": "#/definitions/models.EmbeddingResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Solicitud inválida",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Límite de peticiones o cuota agotada",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Error de Gemini",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/gemini/file-tasks": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Procesa un prompt y un archivo de forma asíncrona, guarda los datos y retorna un ID de proceso.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gemini"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "no-cache para generar una respuesta nueva sin usar el caché",
+                        "name": "Cache-Control",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Texto del prompt",
+                        "name": "prompt",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Archivo (PDF, PNG, JPEG)",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Nombre de un esquema de respuesta registrado",
+                        "name": "schema",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON Schema que debe cumplir la respuesta",
+                        "name": "response_schema",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Herramientas que el modelo puede llamar",
+                        "name": "tools",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Proceso en cola",
+                        "schema": {
+                            "$ref": "#/definitions/models.TaskCreatedResponse"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL para consultar la tarea"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Solicitud inválida",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Límite de peticiones o cuota agotada",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/gemini/file-tasks/{id}": {
+            "get": {
+                "description": "Consulta el estado y el resultado de una tarea con archivo por su ID. Con ?wait=30s, si la tarea no ha\nterminado, la respuesta espera hasta que cambie de estado o se cumpla el tiempo (máximo LONG_POLL_MAX_WAIT).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gemini"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la tarea",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tiempo máximo de espera a un cambio de estado (por ejemplo 30s)",
+                        "name": "wait",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Estado del proceso y resultado",
+                        "schema": {
+                            "$ref": "#/definitions/models.GeminiProcessingFileResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "ID de proceso no encontrado",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Elimina la tarea con su prompt, su archivo y su resultado. Solo la puede eliminar el usuario que la\ncreó o un administrador; las tareas con retención legal no se pueden eliminar.",
+                "tags": [
+                    "gemini"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la tarea",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Tarea eliminada"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "ID de proceso no encontrado",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "La tarea tiene retención legal",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/gemini/schemas": {
+            "get": {
+                "description": "Devuelve los esquemas con nombre que se pueden usar en el campo \"schema\" al crear una tarea.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gemini"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Esquemas registrados",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ResponseSchema"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/gemini/search": {
+            "post": {
+                "description": "Devuelve los top_k textos guardados del namespace de la organización más parecidos a la consulta (similitud coseno).\nCon \"filter\" solo se consideran los textos cuya metadata contiene ese objeto.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gemini"
+                ],
+                "parameters": [
+                    {
+                        "description": "Consulta",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SearchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Resultados ordenados por similitud",
+                        "schema": {
+                            "$ref": "#/definitions/models.SearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Solicitud inválida",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Límite de peticiones",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Error de Gemini",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/gemini/tasks": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Inicia una tarea en segundo plano para procesar un prompt con la API de Gemini.\nCon \"schema\" o \"response_schema\" la respuesta se pide en JSON, se valida contra el esquema y se devuelve en result_json.\nCon \"tools\" el modelo puede llamar herramientas internas; cada llamada y su resultado se devuelven en tool_calls.\nCon \"template\", \"version\" y \"variables\" el prompt se genera con una plantilla registrada en lugar de enviarse en \"prompt\".\nSi el caché de respuestas está activo, un prompt idéntico se responde desde el caché y la tarea indica cached=true.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gemini"
+                ],
+                "parameters": [
+                    {
+                        "description": "Prompt a procesar",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PromptRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "no-cache para generar una respuesta nueva sin usar el caché",
+                        "name": "Cache-Control",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Solicitud aceptada y procesando",
+                        "schema": {
+                            "$ref": "#/definitions/models.TaskCreatedResponse"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL para consultar la tarea"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "JSON de solicitud inválido",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Límite de peticiones o cuota agotada",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/gemini/tasks/events": {
+            "get": {
+                "description": "Abre un WebSocket que envía un mensaje models.TaskStatusEvent por cada cambio de estado de las tareas\nsuscritas (de texto o con archivo), empezando por su estado actual. Las tareas iniciales se indican en\n?ids=a,b y después se pueden enviar mensajes {\"subscribe\": [...]} o {\"unsubscribe\": [...]}.\nLos IDs que no existen o son de otra organización se ignoran. Los cambios llegan de cualquier réplica (PostgreSQL LISTEN/NOTIFY).",
+                "tags": [
+                    "gemini"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "IDs de tareas separados por comas",
+                        "name": "ids",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Conexión WebSocket; cada mensaje es un evento",
+                        "schema": {
+                            "$ref": "#/definitions/models.TaskStatusEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "La petición no es un WebSocket",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/gemini/tasks/{id}": {
+            "get": {
+                "description": "Consulta el estado y el resultado de una tarea por su ID. Con ?wait=30s, si la tarea no ha terminado,\nla respuesta espera hasta que cambie de estado o se cumpla el tiempo (máximo LONG_POLL_MAX_WAIT).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gemini"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la tarea",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tiempo máximo de espera a un cambio de estado (por ejemplo 30s)",
+                        "name": "wait",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Estado del proceso y resultado",
+                        "schema": {
+                            "$ref": "#/definitions/models.GeminiProcessingResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "ID de proceso no encontrado",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Elimina la tarea con su prompt y su resultado. Solo la puede eliminar el usuario que la creó o un\nadministrador; las tareas con retención legal no se pueden eliminar.",
+                "tags": [
+                    "gemini"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la tarea",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Tarea eliminada"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "ID de proceso no encontrado",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "La tarea tiene retención legal",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/gemini/tools": {
+            "get": {
+                "description": "Devuelve las herramientas que se pueden habilitar en el campo \"tools\" al crear una tarea.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gemini"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Herramientas registradas",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ToolInfo"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/orgs": {
+            "get": {
+                "description": "Devuelve las organizaciones de las que el usuario de la petición es miembro, con su rol en cada una.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orgs"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Organization"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Crea una organización con el usuario de la petición como owner. Los slugs user-{id} están reservados\npara las organizaciones personales que se crean al registrarse.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orgs"
+                ],
+                "parameters": [
+                    {
+                        "description": "Datos de la organización",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateOrganizationInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Organization"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Slug en uso",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/orgs/{org_id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orgs"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la organización",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Organization"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Cambia el nombre y la retención de datos (owners y administradores) o las cuotas de uso (solo\nadministradores). quotas reemplaza las cuatro cuotas y retention los tres plazos: los que no se envían\nvuelven al valor por defecto de la configuración.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orgs"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la organización",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Cambios",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateOrganizationInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Organization"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/orgs/{org_id}/members": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orgs"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la organización",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OrgMember"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Agrega un usuario existente a la organización, como member salvo que se indique owner. Solo para\nowners y administradores.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orgs"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la organización",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Usuario y rol",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AddOrgMemberInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrgMember"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "El usuario ya es miembro",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/orgs/{org_id}/members/{user_id}": {
+            "delete": {
+                "description": "Quita al usuario de la organización; sus tareas y contenidos siguen en ella. Los owners y\nadministradores pueden quitar a cualquiera y cada miembro puede salir. La organización debe\nconservar al menos un owner.",
+                "tags": [
+                    "orgs"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la organización",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Miembro quitado"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "La organización se quedaría sin owners",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Cambia el rol de un miembro a owner o member. La organización debe conservar al menos un owner. Solo\npara owners y administradores.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orgs"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la organización",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Nuevo rol",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateOrgMemberInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Rol actualizado"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "La organización se quedaría sin owners",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/templates": {
+            "get": {
+                "description": "Devuelve la versión más reciente de cada plantilla.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PromptTemplate"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Crea la versión 1 de una plantilla. El cuerpo usa la sintaxis de text/template ({{.variable}}).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "parameters": [
+                    {
+                        "description": "Plantilla",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreatePromptTemplateInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.PromptTemplate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/templates/{name}": {
+            "get": {
+                "description": "Devuelve la versión más reciente de la plantilla.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Nombre de la plantilla",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PromptTemplate"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Elimina todas las versiones de la plantilla. Las tareas creadas con ella conservan el prompt generado.",
+                "tags": [
+                    "templates"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Nombre de la plantilla",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Plantilla eliminada"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/templates/{name}/versions": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Nombre de la plantilla",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PromptTemplate"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Guarda una nueva versión de la plantilla; las versiones anteriores no cambian y se pueden seguir usando.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Nombre de la plantilla",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Nueva versión",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PromptTemplateVersionInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.PromptTemplate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/templates/{name}/versions/{version}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Nombre de la plantilla",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Versión",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PromptTemplate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/users": {
+            "get": {
+                "description": "Devuelve los usuarios por páginas. La respuesta trae next_cursor, que se envía en ?cursor= para\npedir la página siguiente con los mismos q, sort y deleted. Con deleted=true se listan solo los\nusuarios eliminados, para restaurarlos.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Texto a buscar en el nombre o el email",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "id",
+                        "description": "Campo de orden (id, full_name, email, created_at); con '-' delante es descendente",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Usuarios por página (máximo 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor de la página siguiente",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Listar los usuarios eliminados",
+                        "name": "deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserList"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Crea un usuario nuevo con contraseña y su organización personal (slug user-{id}), de la que es owner, y le\nenvía un correo para confirmar su email.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Crear un usuario",
+                "parameters": [
+                    {
+                        "description": "Datos para crear usuario",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateUserInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/users/{id}": {
+            "get": {
+                "description": "Devuelve la información de un usuario por su ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Obtener un usuario por ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Marca el usuario como eliminado: deja de aparecer en las consultas y no puede originar peticiones,\npero conserva sus datos y se puede restaurar.",
+                "tags": [
+                    "users"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Cambia el nombre o el email de un usuario; solo se modifican los campos enviados. El email no puede\npertenecer a otro usuario, aunque esté eliminado. Al cambiar el email queda sin confirmar y se envía\nun correo de verificación a la dirección nueva.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Campos a cambiar",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateUserInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/users/{id}/api-keys": {
+            "get": {
+                "description": "Devuelve las keys del usuario (incluidas las revocadas y vencidas) con su prefijo visible, la fecha\ndel último uso y la cantidad de peticiones. El consumo de tokens por key está en /v1/users/{id}/usage?api_key_id=.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.APIKey"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Crea una API key para que servicios del usuario llamen a la API sin iniciar sesión, enviándola en\nAuthorization: Bearer o en X-API-Key. La key completa solo se devuelve en esta respuesta; se guarda su hash.\nCon scopes la key solo puede usar esos permisos (y siempre dentro de los del rol del usuario).\nLa key actúa solo en la organización de la petición (X-Org-ID), de la que el usuario debe ser miembro.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Datos de la key",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateAPIKeyInput"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Organización de la key; por defecto la primera del usuario que hace la petición",
+                        "name": "X-Org-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIKeyCreated"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/users/{id}/api-keys/{key_id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID de la key",
+                        "name": "key_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "La key deja de funcionar de inmediato; se conserva en el listado con revoked_at y su consumo.",
+                "tags": [
+                    "api-keys"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID de la key",
+                        "name": "key_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/users/{id}/api-keys/{key_id}/rotate": {
+            "post": {
+                "description": "Genera un secreto nuevo para la key, con el mismo nombre, scopes, vencimiento y consumo acumulado.\nEl secreto anterior deja de funcionar de inmediato. La key nueva solo se devuelve en esta respuesta.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID de la key",
+                        "name": "key_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIKeyCreated"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "La key está revocada",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/users/{id}/restore": {
+            "post": {
+                "description": "Quita la marca de eliminado de un usuario. Si el usuario no estaba eliminado lo devuelve sin cambios.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/users/{id}/usage": {
+            "get": {
+                "description": "Devuelve los tokens consumidos y el costo estimado de las tareas de un usuario, agrupados por día o por modelo.\nCon api_key_id se reportan solo las tareas creadas con esa API key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Consumo de tokens de un usuario",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Inicio del periodo (YYYY-MM-DD o RFC3339, incluido). Por defecto, 30 días atrás",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fin del periodo (YYYY-MM-DD o RFC3339, excluido). Por defecto, ahora",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "day",
+                            "model"
+                        ],
+                        "type": "string",
+                        "default": "day",
+                        "description": "Agrupación",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Solo las tareas creadas con esta API key del usuario",
+                        "name": "api_key_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UsageReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-01-01T09:00:00Z"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2026-12-31T00:00:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2025-01-15T10:30:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "jobs-nocturnos"
+                },
+                "org_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "gak_7f3a9c1e"
+                },
+                "request_count": {
+                    "type": "integer",
+                    "example": 1520
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "rotated_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Permission"
+                    },
+                    "example": [
+                        "tasks:create",
+                        "tasks:read"
+                    ]
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.APIKeyCreated": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-01-01T09:00:00Z"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2026-12-31T00:00:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "key": {
+                    "type": "string",
+                    "example": "gak_7f3a9c1e_Qm9vdHN0cmFwIGFuIEFQSSBrZXkgZm9yIGpvYnM"
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2025-01-15T10:30:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "jobs-nocturnos"
+                },
+                "org_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "gak_7f3a9c1e"
+                },
+                "request_count": {
+                    "type": "integer",
+                    "example": 1520
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "rotated_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Permission"
+                    },
+                    "example": [
+                        "tasks:create",
+                        "tasks:read"
+                    ]
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.AcceptedResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Si el email está registrado, recibirás un correo con instrucciones"
+                }
+            }
+        },
+        "models.AddOrgMemberInput": {
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "role": {
+                    "enum": [
+                        "owner",
+                        "member"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.OrgRole"
+                        }
+                    ],
+                    "example": "member"
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "models.AdminTask": {
+            "type": "object",
+            "properties": {
+                "cached": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-01-15T10:30:00Z"
+                },
+                "error_code": {
+                    "type": "string",
+                    "example": "gemini_rate_limited"
+                },
+                "estimated_cost": {
+                    "type": "number",
+                    "example": 0.000185
+                },
+                "id": {
+                    "type": "string",
+                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "task",
+                        "file_task"
+                    ],
+                    "example": "task"
+                },
+                "legal_hold": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "model": {
+                    "type": "string",
+                    "example": "gemini-2.0-flash"
+                },
+                "org_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GeminiProcessingStatus"
+                        }
+                    ],
+                    "example": "completed"
+                },
+                "status_label": {
+                    "type": "string",
+                    "example": "finalizado"
+                },
+                "total_tokens": {
+                    "type": "integer",
+                    "example": 1650
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2025-01-15T10:30:05Z"
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.AdminTaskList": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AdminTask"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "eyJ0IjoiMjAyNS0wMS0xNVQxMDozMDowMFoiLCJpZCI6IjhiOWExZDJlIn0"
+                }
+            }
+        },
+        "models.AuditChange": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "before": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "id": {
+                    "type": "string",
+                    "example": "2"
+                },
+                "operation": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete"
+                    ],
+                    "example": "update"
+                },
+                "table": {
+                    "type": "string",
+                    "example": "gemini.users"
+                }
+            }
+        },
+        "models.AuditEvent": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "user.update"
+                },
+                "actor_api_key_id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "actor_user_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditChange"
+                    }
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-01-15T10:30:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1542
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "org_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "0f8fad5b-d9cb-469f-a165-70867728950e"
+                },
+                "resource_id": {
+                    "type": "string",
+                    "example": "2"
+                },
+                "resource_type": {
+                    "type": "string",
+                    "example": "user"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "user_agent": {
+                    "type": "string",
+                    "example": "curl/8.5.0"
+                }
+            }
+        },
+        "models.AuditEventList": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditEvent"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "eyJ0IjoiMjAyNS0wMS0xNVQxMDozMDowMFoiLCJpZCI6MTU0Mn0"
+                }
+            }
+        },
+        "models.Batch": {
+            "type": "object",
+            "properties": {
+                "completed": {
+                    "type": "integer",
+                    "example": 390
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "estimated_cost_usd": {
+                    "type": "number",
+                    "example": 0.0412
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5f0c2a1e-7b3d-4c8a-9e6f-1a2b3c4d5e6f"
+                },
+                "pending": {
+                    "type": "integer",
+                    "example": 600
+                },
+                "processing": {
+                    "type": "integer",
+                    "example": 8
+                },
+                "progress": {
+                    "description": "Progress es la fracción de tareas terminadas (completadas o con error), de 0 a 1.",
+                    "type": "number",
+                    "example": 0.392
+                },
+                "results_url": {
+                    "description": "ResultsURL es la URL para descargar los resultados; solo está presente cuando el lote terminó.",
+                    "type": "string",
+                    "example": "/v1/gemini/batches/5f0c2a1e-7b3d-4c8a-9e6f-1a2b3c4d5e6f/results"
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GeminiProcessingStatus"
+                        }
+                    ],
+                    "example": "processing"
+                },
+                "status_label": {
+                    "type": "string",
+                    "example": "en proceso"
+                },
+                "template": {
+                    "$ref": "#/definitions/models.TaskTemplate"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 1000
+                },
+                "total_tokens": {
+                    "type": "integer",
+                    "example": 102400
+                }
+            }
+        },
+        "models.BatchCreatedResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "5f0c2a1e-7b3d-4c8a-9e6f-1a2b3c4d5e6f"
+                },
+                "status_url": {
+                    "type": "string",
+                    "example": "/v1/gemini/batches/5f0c2a1e-7b3d-4c8a-9e6f-1a2b3c4d5e6f"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 1000
+                }
+            }
+        },
+        "models.BatchRequest": {
+            "type": "object",
+            "required": [
+                "prompts"
+            ],
+            "properties": {
+                "prompts": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Resume: ...",
+                        "Traduce: ..."
+                    ]
+                },
+                "response_schema": {
+                    "description": "ResponseSchema es un JSON Schema que debe cumplir cada respuesta; no se puede usar junto con Schema.",
+                    "type": "object"
+                },
+                "schema": {
+                    "description": "Schema es el nombre de un esquema de respuesta registrado en el servidor.",
+                    "type": "string",
+                    "example": "invoice"
+                }
+            }
+        },
+        "models.Collection": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Reglamentos internos de becas"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "reglamentos"
+                }
+            }
+        },
+        "models.CollectionQueryRequest": {
+            "type": "object",
+            "required": [
+                "question"
+            ],
+            "properties": {
+                "question": {
+                    "type": "string",
+                    "example": "¿Cuál es el promedio mínimo para renovar la beca?"
+                },
+                "top_k": {
+                    "type": "integer",
+                    "maximum": 20,
+                    "minimum": 1,
+                    "example": 5
+                }
+            }
+        },
+        "models.CreateAPIKeyInput": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_at": {
+                    "description": "ExpiresAt es la fecha en que la key deja de funcionar; sin valor no vence.",
+                    "type": "string",
+                    "example": "2026-12-31T00:00:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "example": "jobs-nocturnos"
+                },
+                "scopes": {
+                    "description": "Scopes limita los permisos de la key a un subconjunto de los del rol del usuario; vacío usa todos.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Permission"
+                    },
+                    "example": [
+                        "tasks:create",
+                        "tasks:read"
+                    ]
+                }
+            }
+        },
+        "models.CreateCollectionInput": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000,
+                    "example": "Reglamentos internos de becas"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "example": "reglamentos"
+                }
+            }
+        },
+        "models.CreateOrganizationInput": {
+            "type": "object",
+            "required": [
+                "name",
+                "slug"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "example": "Acme"
+                },
+                "slug": {
+                    "description": "Slug identifica a la organización en URLs: minúsculas, números y guiones.",
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "acme"
+                }
+            }
+        },
+        "models.CreatePromptTemplateInput": {
+            "type": "object",
+            "required": [
+                "body",
+                "name"
+            ],
+            "properties": {
+                "body": {
+                    "description": "Body es el prompt con variables en sintaxis de text/template, por ejemplo {{.cliente}}.",
+                    "type": "string",
+                    "example": "Resume la factura de {{.cliente}} en {{.idioma}}:\n{{.factura}}"
+                },
+                "defaults": {
+                    "description": "Defaults son los valores por defecto de las variables.",
+                    "type": "object"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000,
+                    "example": "Resumen de facturas para contabilidad"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "example": "invoice-summary"
+                },
+                "system_instruction": {
+                    "description": "SystemInstruction es la instrucción de sistema que se envía a Gemini junto con el prompt.",
+                    "type": "string",
+                    "example": "Eres un asistente contable. Responde de forma concisa."
+                }
+            }
+        },
+        "models.CreateUserInput": {
+            "type": "object",
+            "required": [
+                "email",
+                "full_name",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "efren@example.com"
+                },
+                "full_name": {
+                    "type": "string",
+                    "example": "Efren David"
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 8,
+                    "example": "miPasswordSeguro123"
+                },
+                "role": {
+                    "description": "Role solo lo puede indicar un administrador; por defecto es member.",
+                    "enum": [
+                        "admin",
+                        "member",
+                        "viewer",
+                        "service"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Role"
+                        }
+                    ],
+                    "example": "member"
+                }
+            }
+        },
+        "models.Document": {
+            "type": "object",
+            "properties": {
+                "chunks": {
+                    "type": "integer",
+                    "example": 34
+                },
+                "collection_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.TaskError"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "reglamento.pdf"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "mime_type": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "pages": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GeminiProcessingStatus"
+                        }
+                    ],
+                    "example": "completed"
+                },
+                "status_label": {
+                    "type": "string",
+                    "example": "finalizado"
+                }
+            }
+        },
+        "models.DocumentCreatedResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "status_url": {
+                    "type": "string",
+                    "example": "/v1/collections/1/documents/1"
+                }
+            }
+        },
+        "models.EmailInput": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "efren@example.com"
+                }
+            }
+        },
+        "models.EmbeddingInput": {
+            "type": "object",
+            "required": [
+                "text"
+            ],
+            "properties": {
+                "external_id": {
+                    "description": "ExternalID identifica el texto en el sistema del cliente; si ya existe en el namespace se reemplaza.",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "doc-42"
+                },
+                "metadata": {
+                    "type": "object"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Becas para estudiar en Noruega"
+                }
+            }
+        },
+        "models.EmbeddingRequest": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "items": {
+                        "$ref": "#/definitions/models.EmbeddingInput"
+                    }
+                },
+                "namespace": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "example": "becas"
+                },
+                "store": {
+                    "description": "Store indica si los vectores se guardan para búsquedas; por defecto true.",
+                    "type": "boolean",
+                    "example": true
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Becas para estudiar en Noruega"
+                }
+            }
+        },
+        "models.EmbeddingResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.EmbeddingResult"
+                    }
+                },
+                "dimensions": {
+                    "type": "integer",
+                    "example": 768
+                },
+                "model": {
+                    "type": "string",
+                    "example": "gemini-embedding-001"
+                },
+                "namespace": {
+                    "type": "string",
+                    "example": "becas"
+                }
+            }
+        },
+        "models.EmbeddingResult": {
+            "type": "object",
+            "properties": {
+                "embedding": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "external_id": {
+                    "type": "string",
+                    "example": "doc-42"
+                },
+                "id": {
+                    "description": "ID es el identificador del embedding guardado; 0 si no se guardó.",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "enum": [
+                        "invalid_request",
+                        "validation_failed",
+                        "unsupported_file_type",
+                        "user_not_found",
+                        "task_not_found",
+                        "email_taken",
+                        "invalid_schema",
+                        "schema_not_found",
+                        "tool_not_found",
+                        "collection_not_found",
+                        "collection_name_taken",
+                        "collection_empty",
+                        "document_not_found",
+                        "template_not_found",
+                        "template_name_taken",
+                        "invalid_template",
+                        "template_render_failed",
+                        "batch_not_found",
+                        "batch_not_finished",
+                        "batch_too_large",
+                        "invalid_csv",
+                        "api_key_not_found",
+                        "api_key_revoked",
+                        "invalid_token",
+                        "email_not_verified",
+                        "oidc_not_configured",
+                        "oidc_login_failed",
+                        "org_not_found",
+                        "org_slug_taken",
+                        "org_member_not_found",
+                        "org_member_exists",
+                        "org_last_owner",
+                        "unauthorized",
+                        "forbidden",
+                        "rate_limited",
+                        "quota_exceeded",
+                        "internal_error"
+                    ],
+                    "example": "validation_failed"
+                },
+                "details": {
+                    "type": "object"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Datos de entrada inválidos"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "0f8fad5b-d9cb-469f-a165-70867728950e"
+                }
+            }
+        },
+        "models.GeminiProcessingFileResponse": {
+            "type": "object",
+            "properties": {
+                "cached": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "error": {
+                    "$ref": "#/definitions/models.TaskError"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                },
+                "redactions": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "result": {
+                    "type": "string",
+                    "example": "Sí, existen varias becas..."
+                },
+                "result_json": {
+                    "type": "object"
+                },
+                "result_purged_at": {
+                    "description": "ResultPurgedAt indica que el resultado se eliminó por la política de retención.",
+                    "type": "string",
+                    "example": "2025-04-15T03:00:00Z"
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GeminiProcessingStatus"
+                        }
+                    ],
+                    "example": "completed"
+                },
+                "status_label": {
+                    "type": "string",
+                    "example": "finalizado"
+                },
+                "tool_calls": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "usage": {
+                    "$ref": "#/definitions/models.TaskUsage"
+                }
+            }
+        },
+        "models.GeminiProcessingResponse": {
+            "type": "object",
+            "properties": {
+                "cached": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "citations": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "error": {
+                    "$ref": "#/definitions/models.TaskError"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                },
+                "redactions": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "result": {
+                    "type": "string",
+                    "example": "Sí, existen varias becas..."
+                },
+                "result_json": {
+                    "type": "object"
+                },
+                "result_purged_at": {
+                    "description": "ResultPurgedAt indica que el resultado se eliminó por la política de retención.",
+                    "type": "string",
+                    "example": "2025-04-15T03:00:00Z"
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GeminiProcessingStatus"
+                        }
+                    ],
+                    "example": "completed"
+                },
+                "status_label": {
+                    "type": "string",
+                    "example": "finalizado"
+                },
+                "template": {
+                    "$ref": "#/definitions/models.TaskTemplate"
+                },
+                "tool_calls": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "usage": {
+                    "$ref": "#/definitions/models.TaskUsage"
+                }
+            }
+        },
+        "models.GeminiProcessingStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "processing",
+                "completed",
+                "error"
+            ],
+            "x-enum-varnames": [
+                "StatusPending",
+                "StatusProcessing",
+                "StatusCompleted",
+                "StatusError"
+            ]
+        },
+        "models.LegalHoldInput": {
+            "type": "object",
+            "required": [
+                "legal_hold"
+            ],
+            "properties": {
+                "legal_hold": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.LoginInput": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "efren@example.com"
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "example": "miPasswordSeguro123"
+                }
+            }
+        },
+        "models.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "$ref": "#/definitions/models.APIKeyCreated"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                }
+            }
+        },
+        "models.OIDCLoginResponse": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "$ref": "#/definitions/models.APIKeyCreated"
+                },
+                "created": {
+                    "description": "Created indica si el usuario se creó en este inicio de sesión.",
+                    "type": "boolean",
+                    "example": false
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                }
+            }
+        },
+        "models.OrgMember": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-01-15T10:30:00Z"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "efren@example.com"
+                },
+                "full_name": {
+                    "type": "string",
+                    "example": "Efren David"
+                },
+                "role": {
+                    "enum": [
+                        "owner",
+                        "member"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.OrgRole"
+                        }
+                    ],
+                    "example": "member"
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.OrgQuotas": {
+            "type": "object",
+            "properties": {
+                "daily_requests": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 1000
+                },
+                "daily_tokens": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 2000000
+                },
+                "monthly_requests": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 20000
+                },
+                "monthly_tokens": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "models.OrgRetention": {
+            "type": "object",
+            "properties": {
+                "file_days": {
+                    "description": "FileDays son los días que se conserva el archivo de las tareas con archivo.",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 7
+                },
+                "result_days": {
+                    "description": "ResultDays son los días que se conservan el resultado y las llamadas a herramientas.",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 90
+                },
+                "task_days": {
+                    "description": "TaskDays son los días tras los que se elimina la tarea completa.",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 365
+                }
+            }
+        },
+        "models.OrgRole": {
+            "type": "string",
+            "enum": [
+                "owner",
+                "member"
+            ],
+            "x-enum-varnames": [
+                "OrgRoleOwner",
+                "OrgRoleMember"
+            ]
+        },
+        "models.Organization": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-01-15T10:30:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Acme"
+                },
+                "quotas": {
+                    "$ref": "#/definitions/models.OrgQuotas"
+                },
+                "retention": {
+                    "$ref": "#/definitions/models.OrgRetention"
+                },
+                "role": {
+                    "description": "Role es el rol del usuario de la petición en la organización, si es miembro.",
+                    "enum": [
+                        "owner",
+                        "member"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.OrgRole"
+                        }
+                    ],
+                    "example": "owner"
+                },
+                "slug": {
+                    "type": "string",
+                    "example": "acme"
+                }
+            }
+        },
+        "models.Permission": {
+            "type": "string",
+            "enum": [
+                "tasks:create",
+                "tasks:read",
+                "tasks:read_all",
+                "content:read",
+                "content:write",
+                "users:read",
+                "users:manage",
+                "orgs:manage",
+                "audit:read",
+                "tasks:legal_hold",
+                "tasks:delete_all"
+            ],
+            "x-enum-varnames": [
+                "PermTasksCreate",
+                "PermTasksRead",
+                "PermTasksReadAll",
+                "PermContentRead",
+                "PermContentWrite",
+                "PermUsersRead",
+                "PermUsersManage",
+                "PermOrgsManage",
+                "PermAuditRead",
+                "PermTasksLegalHold",
+                "PermTasksDeleteAll"
+            ]
+        },
+        "models.PromptRequest": {
+            "type": "object",
+            "properties": {
+                "prompt": {
+                    "type": "string",
+                    "example": "Conoces las becas para poder estudiar en finlandia o noruega?"
+                },
+                "response_schema": {
+                    "description": "ResponseSchema es un JSON Schema que debe cumplir la respuesta; no se puede usar junto con Schema.",
+                    "type": "object"
+                },
+                "schema": {
+                    "description": "Schema es el nombre de un esquema de respuesta registrado en el servidor.",
+                    "type": "string",
+                    "example": "invoice"
+                },
+                "template": {
+                    "description": "Template es el nombre de una plantilla de prompt; no se puede usar junto con Prompt.",
+                    "type": "string",
+                    "example": "invoice-summary"
+                },
+                "tools": {
+                    "description": "Tools son los nombres de las herramientas registradas que el modelo puede llamar.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "get_user"
+                    ]
+                },
+                "variables": {
+                    "description": "Variables son los valores de las variables de la plantilla; reemplazan a los valores por defecto.",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "version": {
+                    "description": "Version es la versión de la plantilla; si se omite se usa la más reciente.",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 3
+                }
+            }
+        },
+        "models.PromptTemplate": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string",
+                    "example": "Resume la factura de {{.cliente}} en {{.idioma}}:\n{{.factura}}"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "defaults": {
+                    "type": "object"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Resumen de facturas para contabilidad"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "invoice-summary"
+                },
+                "system_instruction": {
+                    "type": "string",
+                    "example": "Eres un asistente contable. Responde de forma concisa."
+                },
+                "variables": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "cliente",
+                        "factura",
+                        "idioma"
+                    ]
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.PromptTemplateVersionInput": {
+            "type": "object",
+            "required": [
+                "body"
+            ],
+            "properties": {
+                "body": {
+                    "description": "Body es el prompt con variables en sintaxis de text/template, por ejemplo {{.cliente}}.",
+                    "type": "string",
+                    "example": "Resume la factura de {{.cliente}} en {{.idioma}}:\n{{.factura}}"
+                },
+                "defaults": {
+                    "description": "Defaults son los valores por defecto de las variables.",
+                    "type": "object"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000,
+                    "example": "Resumen de facturas para contabilidad"
+                },
+                "system_instruction": {
+                    "description": "SystemInstruction es la instrucción de sistema que se envía a Gemini junto con el prompt.",
+                    "type": "string",
+                    "example": "Eres un asistente contable. Responde de forma concisa."
+                }
+            }
+        },
+        "models.ResetPasswordInput": {
+            "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 8,
+                    "example": "otraPasswordSegura456"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "q0v3Yb6m1N2kZ8xR4tH7aLw9cE5sJ2pD0fG3uI6oK1M"
+                }
+            }
+        },
+        "models.ResponseSchema": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "invoice"
+                },
+                "schema": {
+                    "type": "object"
+                }
+            }
+        },
+        "models.Role": {
+            "type": "string",
+            "enum": [
+                "admin",
+                "member",
+                "viewer",
+                "service"
+            ],
+            "x-enum-varnames": [
+                "RoleAdmin",
+                "RoleMember",
+                "RoleViewer",
+                "RoleService"
+            ]
+        },
+        "models.SearchMatch": {
+            "type": "object",
+            "properties": {
+                "external_id": {
+                    "type": "string",
+                    "example": "doc-42"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "metadata": {
+                    "type": "object"
+                },
+                "score": {
+                    "type": "number",
+                    "example": 0.87
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Becas para estudiar en Noruega"
+                }
+            }
+        },
+        "models.SearchRequest": {
+            "type": "object",
+            "required": [
+                "query"
+            ],
+            "properties": {
+                "filter": {
+                    "description": "Filter limita la búsqueda a los embeddings cuya metadata contiene este objeto.",
+                    "type": "object"
+                },
+                "namespace": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "example": "becas"
+                },
+                "query": {
+                    "type": "string",
+                    "example": "estudiar en el extranjero"
+                },
+                "top_k": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 1,
+                    "example": 5
+                }
+            }
+        },
+        "models.SearchResponse": {
+            "type": "object",
+            "properties": {
+                "matches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SearchMatch"
+                    }
+                },
+                "model": {
+                    "type": "string",
+                    "example": "gemini-embedding-001"
+                },
+                "namespace": {
+                    "type": "string",
+                    "example": "becas"
+                }
+            }
+        },
+        "models.TaskCreatedResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                },
+                "status_url": {
+                    "type": "string",
+                    "example": "/v1/gemini/tasks/8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                }
+            }
+        },
+        "models.TaskError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "enum": [
+                        "schema_validation_failed",
+                        "gemini_tool_steps_exceeded",
+                        "document_extraction_failed",
+                        "document_empty",
+                        "gemini_not_configured",
+                        "gemini_invalid_request",
+                        "gemini_permission_denied",
+                        "gemini_rate_limited",
+                        "gemini_timeout",
+                        "gemini_unavailable",
+                        "gemini_upload_failed",
+                        "gemini_error"
+                    ],
+                    "example": "gemini_rate_limited"
+                },
+                "details": {
+                    "description": "Details es información adicional, por ejemplo los incumplimientos del esquema de respuesta.",
+                    "type": "object"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Se agotó la cuota de la API de Gemini"
+                }
+            }
+        },
+        "models.TaskStatusEvent": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "task",
+                        "file_task"
+                    ],
+                    "example": "task"
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GeminiProcessingStatus"
+                        }
+                    ],
+                    "example": "completed"
+                },
+                "status_label": {
+                    "type": "string",
+                    "example": "finalizado"
+                }
+            }
+        },
+        "models.TaskTemplate": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "invoice-summary"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.TaskUsage": {
+            "type": "object",
+            "properties": {
+                "candidate_tokens": {
+                    "type": "integer",
+                    "example": 250
+                },
+                "estimated_cost_usd": {
+                    "type": "number",
+                    "example": 0.0001012
+                },
+                "model": {
+                    "type": "string",
+                    "example": "gemini-2.0-flash"
+                },
+                "prompt_tokens": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "total_tokens": {
+                    "type": "integer",
+                    "example": 262
+                }
+            }
+        },
+        "models.ToolInfo": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "Busca un usuario de la aplicación por su ID"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "get_user"
+                },
+                "parameters": {
+                    "type": "object"
+                }
+            }
+        },
+        "models.UpdateOrgMemberInput": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "enum": [
+                        "owner",
+                        "member"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.OrgRole"
+                        }
+                    ],
+                    "example": "owner"
+                }
+            }
+        },
+        "models.UpdateOrganizationInput": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 1,
+                    "example": "Acme S.A."
+                },
+                "quotas": {
+                    "$ref": "#/definitions/models.OrgQuotas"
+                },
+                "retention": {
+                    "$ref": "#/definitions/models.OrgRetention"
+                }
+            }
+        },
+        "models.UpdateUserInput": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "efren.garza@example.com"
+                },
+                "full_name": {
+                    "type": "string",
+                    "minLength": 1,
+                    "example": "Efren David Garza"
+                }
+            }
+        },
+        "models.UpdateUserRoleInput": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "enum": [
+                        "admin",
+                        "member",
+                        "viewer",
+                        "service"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Role"
+                        }
+                    ],
+                    "example": "viewer"
+                }
+            }
+        },
+        "models.UsageGroupBy": {
+            "type": "string",
+            "enum": [
+                "day",
+                "model"
+            ],
+            "x-enum-varnames": [
+                "UsageGroupByDay",
+                "UsageGroupByModel"
+            ]
+        },
+        "models.UsageItem": {
+            "type": "object",
+            "properties": {
+                "candidate_tokens": {
+                    "type": "integer",
+                    "example": 8000
+                },
+                "estimated_cost_usd": {
+                    "type": "number",
+                    "example": 0.00332
+                },
+                "key": {
+                    "type": "string",
+                    "example": "2025-01-31"
+                },
+                "prompt_tokens": {
+                    "type": "integer",
+                    "example": 1200
+                },
+                "requests": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "total_tokens": {
+                    "type": "integer",
+                    "example": 9200
+                }
+            }
+        },
+        "models.UsageReport": {
+            "type": "object",
+            "properties": {
+                "api_key_id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "from": {
+                    "type": "string",
+                    "example": "2025-01-01T00:00:00Z"
+                },
+                "group_by": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UsageGroupBy"
+                        }
+                    ],
+                    "example": "day"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UsageItem"
+                    }
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2025-02-01T00:00:00Z"
+                },
+                "total": {
+                    "$ref": "#/definitions/models.UsageItem"
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-01-15T10:30:00Z"
+                },
+                "deleted_at": {
+                    "type": "string",
+                    "example": "2025-02-01T08:00:00Z"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "efren@example.com"
+                },
+                "email_verified": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "full_name": {
+                    "type": "string",
+                    "example": "Efren David"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "role": {
+                    "enum": [
+                        "admin",
+                        "member",
+                        "viewer",
+                        "service"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Role"
+                        }
+                    ],
+                    "example": "member"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2025-01-15T10:30:00Z"
+                }
+            }
+        },
+        "models.UserList": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.User"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "eyJzIjoiaWQiLCJpZCI6MjB9"
+                }
+            }
+        },
+        "models.VerifyEmailInput": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string",
+                    "example": "q0v3Yb6m1N2kZ8xR4tH7aLw9cE5sJ2pD0fG3uI6oK1M"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "description": "API key del usuario o de sesión (POST /v1/auth/login); también se acepta en Authorization: Bearer.",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        }
+    }
+}`
+
+// SwaggerInfov1 holds exported Swagger Info so clients can modify it
+var SwaggerInfov1 = &swag.Spec{
+	Version:          "1.0",
+	Host:             "localhost:8080",
+	BasePath:         "/",
+	Schemes:          []string{},
+	Title:            "API GEMINI",
+	Description:      "API RESTful para gestión de usuarios",
+	InfoInstanceName: "v1",
+	SwaggerTemplate:  docTemplatev1,
+	LeftDelim:        "{{",
+	RightDelim:       "}}",
+}
+
+func init() {
+	swag.Register(SwaggerInfov1.InstanceName(), SwaggerInfov1)
+}
@@ -0,0 +1,185 @@
+// Package encryption cifra en la base de datos los prompts, resultados y archivos de las
+// tareas con cifrado de sobre (envelope encryption): cada valor se cifra con AES-256-GCM y una
+// clave de datos propia, generada al guardarlo, y esa clave se guarda junto al valor cifrada
+// con la clave maestra de un KeyProvider (un archivo local o un KMS). Rotar la clave maestra
+// solo requiere volver a cifrar las claves de datos, no los valores (ver Rewrap).
+//
+// Las columnas cifradas se declaran con la etiqueta gorm:"serializer:encrypted" y se
+// descifran al leerlas, sin cambios en los controladores. Cada valor se cifra con la tabla, la
+// columna y la clave primaria de su fila como datos asociados (AAD), así que un valor copiado a
+// otra fila u otra columna no se puede descifrar.
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// prefix identifica los valores cifrados; los que no lo tienen se guardaron antes de
+// configurar el cifrado y se leen tal cual. Los valores con prefixV1 se cifraron sin datos
+// asociados, antes de incluir la fila, y se siguen leyendo hasta que -rotate los vuelve a cifrar.
+// Los valores sin cifrar que empiezan con reservedPrefix se guardan detrás de prefixPlain,
+// para que nunca se confundan con un valor cifrado.
+const (
+	prefix         = "enc:v2:"
+	prefixV1       = "enc:v1:"
+	prefixPlain    = "enc:plain:"
+	reservedPrefix = "enc:"
+)
+
+// dataKeySize es el tamaño de las claves de datos (AES-256).
+const dataKeySize = 32
+
+// ErrNoKeyProvider indica que hay un valor cifrado pero no se configuró la clave maestra.
+var ErrNoKeyProvider = errors.New("hay datos cifrados pero no se configuró ENCRYPTION_KEY_FILE")
+
+// ErrNoRowID indica que se quiso cifrar o descifrar un valor sin conocer la clave primaria de su
+// fila, por ejemplo en un Updates con un map sobre un modelo sin ID o en un Select que no la
+// incluye antes de la columna cifrada.
+var ErrNoRowID = errors.New("se necesita la clave primaria de la fila para cifrar o descifrar el valor")
+
+// KeyProvider guarda las claves maestras con las que se cifran las claves de datos. Un KMS
+// puede implementarlo cifrando y descifrando las claves de datos con su API.
+type KeyProvider interface {
+	// KeyID es el ID de la clave maestra actual, con la que se cifran las claves nuevas.
+	KeyID() string
+	// WrapKey cifra una clave de datos con la clave maestra actual y devuelve su ID.
+	WrapKey(ctx context.Context, dataKey []byte) (keyID string, wrapped []byte, err error)
+	// UnwrapKey descifra una clave de datos cifrada con la clave maestra keyID.
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+var (
+	providerMu sync.RWMutex
+	provider   KeyProvider
+)
+
+// SetKeyProvider define la clave maestra. Sin ella los valores se guardan sin cifrar.
+func SetKeyProvider(p KeyProvider) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	provider = p
+}
+
+func getKeyProvider() KeyProvider {
+	providerMu.RLock()
+	defer providerMu.RUnlock()
+	return provider
+}
+
+// envelope es un valor cifrado: el ID de la clave maestra, la clave de datos cifrada con ella
+// y el nonce seguido del texto cifrado. Se guarda como enc:v2:<key_id>:<clave>:<datos>, en base64.
+type envelope struct {
+	keyID   string
+	wrapped []byte
+	data    []byte
+	// legacy indica un valor enc:v1, cifrado sin datos asociados.
+	legacy bool
+}
+
+func (e envelope) String() string {
+	p := prefix
+	if e.legacy {
+		p = prefixV1
+	}
+	return p + e.keyID + ":" + base64.RawStdEncoding.EncodeToString(e.wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(e.data)
+}
+
+// parseEnvelope interpreta un valor guardado; devuelve false si no está cifrado.
+func parseEnvelope(stored []byte) (envelope, bool) {
+	legacy := bytes.HasPrefix(stored, []byte(prefixV1))
+	if !legacy && !bytes.HasPrefix(stored, []byte(prefix)) {
+		return envelope{}, false
+	}
+	parts := strings.Split(string(stored[len(prefix):]), ":")
+	if len(parts) != 3 || parts[0] == "" {
+		return envelope{}, false
+	}
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return envelope{}, false
+	}
+	data, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return envelope{}, false
+	}
+	return envelope{keyID: parts[0], wrapped: wrapped, data: data, legacy: legacy}, true
+}
+
+// associatedData identifica dónde se guarda un valor: la tabla, la columna y la clave primaria
+// de la fila, separadas por un byte nulo.
+func associatedData(table, column, rowID string) []byte {
+	return []byte(table + "\x00" + column + "\x00" + rowID)
+}
+
+// encrypt cifra el valor con una clave de datos nueva y aad como datos asociados.
+func encrypt(ctx context.Context, p KeyProvider, plaintext, aad []byte) (envelope, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return envelope{}, err
+	}
+	data, err := seal(dataKey, plaintext, aad)
+	if err != nil {
+		return envelope{}, err
+	}
+	keyID, wrapped, err := p.WrapKey(ctx, dataKey)
+	if err != nil {
+		return envelope{}, fmt.Errorf("cifrar la clave de datos: %w", err)
+	}
+	return envelope{keyID: keyID, wrapped: wrapped, data: data}, nil
+}
+
+// decrypt descifra el valor con su clave de datos; aad se ignora en los valores enc:v1.
+func decrypt(ctx context.Context, p KeyProvider, e envelope, aad []byte) ([]byte, error) {
+	dataKey, err := p.UnwrapKey(ctx, e.keyID, e.wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("descifrar la clave de datos: %w", err)
+	}
+	if e.legacy {
+		aad = nil
+	}
+	return open(dataKey, e.data, aad)
+}
+
+// seal cifra con AES-GCM y devuelve el nonce seguido del texto cifrado.
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// open descifra lo que devolvió seal.
+func open(key, data, aad []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("valor cifrado incompleto")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,197 @@
+package encryption
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/db/dbtest"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// secret es una tabla de prueba con dos columnas cifradas.
+type secret struct {
+	ID    string `gorm:"primaryKey"`
+	Body  string `gorm:"serializer:encrypted;type:text"`
+	Notes string `gorm:"serializer:encrypted;type:text"`
+}
+
+func openEncrypted(t *testing.T) (*gorm.DB, *LocalKeyProvider) {
+	t.Helper()
+	schema.RegisterSerializer(SerializerName, Serializer{})
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if _, err := AddKey(path); err != nil {
+		t.Fatalf("AddKey: %v", err)
+	}
+	keys, err := LoadKeyFile(path)
+	if err != nil {
+		t.Fatalf("LoadKeyFile: %v", err)
+	}
+	SetKeyProvider(keys)
+	t.Cleanup(func() { SetKeyProvider(nil) })
+	conn := dbtest.Open(t, &secret{})
+	if err := RegisterCallbacks(conn); err != nil {
+		t.Fatalf("RegisterCallbacks: %v", err)
+	}
+	return conn, keys
+}
+
+// stored devuelve el valor guardado en la columna, sin descifrar.
+func stored(t *testing.T, conn *gorm.DB, id, column string) string {
+	t.Helper()
+	var value string
+	if err := conn.Table("secrets").Where("id = ?", id).Pluck(column, &value).Error; err != nil {
+		t.Fatalf("leer %s: %v", column, err)
+	}
+	return value
+}
+
+func TestValuesAreBoundToTheirRowAndColumn(t *testing.T) {
+	conn, _ := openEncrypted(t)
+	if err := conn.Create(&[]secret{{ID: "a", Body: "de A", Notes: "notas de A"}, {ID: "b", Body: "de B"}}).Error; err != nil {
+		t.Fatalf("crear: %v", err)
+	}
+	if value := stored(t, conn, "a", "body"); !strings.HasPrefix(value, prefix) {
+		t.Fatalf("body guardado = %q, se esperaba un valor %s", value, prefix)
+	}
+
+	var a secret
+	if err := conn.First(&a, "id = ?", "a").Error; err != nil {
+		t.Fatalf("leer a: %v", err)
+	}
+	if a.Body != "de A" || a.Notes != "notas de A" {
+		t.Fatalf("a = %+v", a)
+	}
+
+	// Copiar el valor cifrado de A a otra fila o a otra columna no permite leerlo ahí.
+	conn.Table("secrets").Where("id = ?", "b").UpdateColumn("body", stored(t, conn, "a", "body"))
+	if err := conn.First(&secret{}, "id = ?", "b").Error; err == nil {
+		t.Error("se descifró en la fila B el valor copiado de la fila A")
+	}
+	conn.Table("secrets").Where("id = ?", "a").UpdateColumn("notes", stored(t, conn, "a", "body"))
+	if err := conn.First(&secret{}, "id = ?", "a").Error; err == nil {
+		t.Error("se descifró en notes el valor copiado de body")
+	}
+}
+
+func TestMapUpdatesNeedTheRowID(t *testing.T) {
+	conn, _ := openEncrypted(t)
+	if err := conn.Create(&secret{ID: "a", Body: "antes"}).Error; err != nil {
+		t.Fatalf("crear: %v", err)
+	}
+
+	err := conn.Model(&secret{}).Where("id = ?", "a").Updates(map[string]interface{}{"body": "después"}).Error
+	if !errors.Is(err, ErrNoRowID) {
+		t.Fatalf("Updates sin ID en el modelo: error %v, se esperaba ErrNoRowID", err)
+	}
+	// Los valores vacíos no se cifran, así que se pueden borrar sin conocer la fila.
+	if err := conn.Model(&secret{}).Where("id = ?", "a").Updates(map[string]interface{}{"notes": ""}).Error; err != nil {
+		t.Fatalf("borrar notes: %v", err)
+	}
+
+	if err := conn.Model(&secret{ID: "a"}).Updates(map[string]interface{}{"body": "después"}).Error; err != nil {
+		t.Fatalf("Updates con ID en el modelo: %v", err)
+	}
+	var a secret
+	if err := conn.First(&a, "id = ?", "a").Error; err != nil || a.Body != "después" {
+		t.Fatalf("a = %+v, err = %v", a, err)
+	}
+}
+
+func TestLegacyValuesAreReadAndUpgradedByRewrap(t *testing.T) {
+	conn, keys := openEncrypted(t)
+	// Un valor enc:v1, cifrado sin datos asociados antes de incluir la fila.
+	legacy, err := encrypt(context.Background(), keys, []byte("antiguo"), nil)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	legacy.legacy = true
+	if err := conn.Table("secrets").Create(map[string]interface{}{"id": "a", "body": legacy.String()}).Error; err != nil {
+		t.Fatalf("crear: %v", err)
+	}
+
+	var a secret
+	if err := conn.First(&a, "id = ?", "a").Error; err != nil || a.Body != "antiguo" {
+		t.Fatalf("leer el valor enc:v1: a = %+v, err = %v", a, err)
+	}
+
+	stmt := &gorm.Statement{DB: conn}
+	if err := stmt.Parse(&secret{}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	field := stmt.Schema.LookUpField("body")
+	value, previous, changed, err := Rewrap(context.Background(), field, "a", []byte(legacy.String()))
+	if err != nil || !changed || previous != keys.KeyID() {
+		t.Fatalf("Rewrap: changed=%v previous=%q err=%v", changed, previous, err)
+	}
+	if !strings.HasPrefix(value.(string), prefix) {
+		t.Fatalf("Rewrap devolvió %q, se esperaba un valor %s", value, prefix)
+	}
+	conn.Table("secrets").Where("id = ?", "a").UpdateColumn("body", value)
+	if err := conn.First(&a, "id = ?", "a").Error; err != nil || a.Body != "antiguo" {
+		t.Fatalf("leer el valor actualizado: a = %+v, err = %v", a, err)
+	}
+}
+
+func TestSelectWithoutTheRowIDFailsClearly(t *testing.T) {
+	conn, _ := openEncrypted(t)
+	if err := conn.Create(&secret{ID: "a", Body: "de A"}).Error; err != nil {
+		t.Fatalf("crear: %v", err)
+	}
+	var a secret
+	err := conn.Select("body").First(&a, "id = ?", "a").Error
+	if !errors.Is(err, ErrNoRowID) || !strings.Contains(err.Error(), "antes de body") {
+		t.Fatalf("Select sin id: error %v, se esperaba ErrNoRowID indicando la columna", err)
+	}
+	if err := conn.Select("id", "body").First(&a, "id = ?", "a").Error; err != nil || a.Body != "de A" {
+		t.Fatalf("Select con id: a = %+v, err = %v", a, err)
+	}
+}
+
+func TestPlaintextThatLooksEncryptedIsKeptAsIs(t *testing.T) {
+	conn, keys := openEncrypted(t)
+	looksEncrypted := "enc:v2:" + keys.KeyID() + ":AAAA:AAAA"
+
+	// Sin clave maestra el valor se guarda sin cifrar, marcado para no leerlo como cifrado.
+	SetKeyProvider(nil)
+	if err := conn.Create(&secret{ID: "a", Body: looksEncrypted, Notes: "enc:plain:x"}).Error; err != nil {
+		t.Fatalf("crear: %v", err)
+	}
+	if value := stored(t, conn, "a", "body"); value != prefixPlain+looksEncrypted {
+		t.Fatalf("body guardado = %q, se esperaba marcado con %s", value, prefixPlain)
+	}
+	var a secret
+	if err := conn.First(&a, "id = ?", "a").Error; err != nil || a.Body != looksEncrypted || a.Notes != "enc:plain:x" {
+		t.Fatalf("leer sin clave maestra: a = %+v, err = %v", a, err)
+	}
+
+	// Con la clave maestra se sigue leyendo igual, y Rewrap lo cifra.
+	SetKeyProvider(keys)
+	if err := conn.First(&a, "id = ?", "a").Error; err != nil || a.Body != looksEncrypted {
+		t.Fatalf("leer con clave maestra: a = %+v, err = %v", a, err)
+	}
+	stmt := &gorm.Statement{DB: conn}
+	if err := stmt.Parse(&secret{}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	value, _, changed, err := Rewrap(context.Background(), stmt.Schema.LookUpField("body"), "a", []byte(stored(t, conn, "a", "body")))
+	if err != nil || !changed {
+		t.Fatalf("Rewrap: changed=%v err=%v", changed, err)
+	}
+	conn.Table("secrets").Where("id = ?", "a").UpdateColumn("body", value)
+	if err := conn.First(&a, "id = ?", "a").Error; err != nil || a.Body != looksEncrypted {
+		t.Fatalf("leer el valor cifrado por Rewrap: a = %+v, err = %v", a, err)
+	}
+
+	// En las columnas JSON se marca el string JSON.
+	escaped := plainValue(kindJSON, []byte(`"`+looksEncrypted+`"`)).(string)
+	if plain, ok := escapedPlain(kindJSON, []byte(escaped)); !ok || string(plain) != `"`+looksEncrypted+`"` {
+		t.Fatalf("JSON marcado %s: se leyó %s", escaped, plain)
+	}
+	if value := plainValue(kindJSON, []byte(`{"a": 1}`)); value != `{"a": 1}` {
+		t.Fatalf("JSON sin prefijo guardado como %v", value)
+	}
+}
@@ -0,0 +1,163 @@
+package encryption
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// keyIDPattern son los IDs de clave válidos; no pueden contener ":", el separador del formato
+// de los valores cifrados.
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// keyFile es el formato del archivo de claves maestras: la clave actual y todas las que aún
+// pueden descifrar datos, en base64 (32 bytes).
+type keyFile struct {
+	Current string            `json:"current"`
+	Keys    map[string]string `json:"keys"`
+}
+
+// LocalKeyProvider guarda las claves maestras en un archivo local (ENCRYPTION_KEY_FILE).
+type LocalKeyProvider struct {
+	current string
+	keys    map[string][]byte
+}
+
+// FromEnv carga las claves maestras de ENCRYPTION_KEY_FILE; devuelve nil si no está definida.
+func FromEnv() (KeyProvider, error) {
+	path := os.Getenv("ENCRYPTION_KEY_FILE")
+	if path == "" {
+		return nil, nil
+	}
+	return LoadKeyFile(path)
+}
+
+// LoadKeyFile carga un archivo de claves maestras.
+func LoadKeyFile(path string) (*LocalKeyProvider, error) {
+	file, err := readKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := &LocalKeyProvider{current: file.Current, keys: make(map[string][]byte, len(file.Keys))}
+	for id, encoded := range file.Keys {
+		if !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("%s: ID de clave inválido %q", path, id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != dataKeySize {
+			return nil, fmt.Errorf("%s: la clave %q debe ser de %d bytes en base64", path, id, dataKeySize)
+		}
+		p.keys[id] = key
+	}
+	if _, ok := p.keys[p.current]; !ok {
+		return nil, fmt.Errorf("%s: la clave actual %q no está en keys", path, p.current)
+	}
+	return p, nil
+}
+
+// KeyID implementa KeyProvider.
+func (p *LocalKeyProvider) KeyID() string {
+	return p.current
+}
+
+// KeyIDs devuelve los IDs de todas las claves del archivo, ordenados.
+func (p *LocalKeyProvider) KeyIDs() []string {
+	ids := make([]string, 0, len(p.keys))
+	for id := range p.keys {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// WrapKey implementa KeyProvider.
+func (p *LocalKeyProvider) WrapKey(_ context.Context, dataKey []byte) (string, []byte, error) {
+	wrapped, err := seal(p.keys[p.current], dataKey, nil)
+	return p.current, wrapped, err
+}
+
+// UnwrapKey implementa KeyProvider.
+func (p *LocalKeyProvider) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("la clave maestra %q no está en el archivo de claves", keyID)
+	}
+	return open(key, wrapped, nil)
+}
+
+// AddKey genera una clave maestra nueva, la agrega al archivo (que se crea si no existe) y la
+// deja como actual; las anteriores se conservan para descifrar los datos existentes.
+// Devuelve el ID de la clave nueva.
+func AddKey(path string) (string, error) {
+	file, err := readKeyFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		file, err = keyFile{Keys: map[string]string{}}, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	key := make([]byte, dataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	id := "k" + time.Now().UTC().Format("20060102150405")
+	if _, exists := file.Keys[id]; exists {
+		return "", fmt.Errorf("ya existe la clave %q", id)
+	}
+	file.Keys[id] = base64.StdEncoding.EncodeToString(key)
+	file.Current = id
+	return id, writeKeyFile(path, file)
+}
+
+// RemoveKey quita del archivo una clave maestra que ya no es la actual. Los datos cifrados
+// con ella dejan de poder leerse, por lo que antes hay que volver a cifrarlos (ver Rewrap).
+func RemoveKey(path, id string) error {
+	file, err := readKeyFile(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Keys[id]; !ok {
+		return fmt.Errorf("la clave %q no está en el archivo", id)
+	}
+	if id == file.Current {
+		return fmt.Errorf("la clave %q es la actual", id)
+	}
+	delete(file.Keys, id)
+	return writeKeyFile(path, file)
+}
+
+func readKeyFile(path string) (keyFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return keyFile{}, err
+	}
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return keyFile{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if file.Keys == nil {
+		file.Keys = map[string]string{}
+	}
+	return file, nil
+}
+
+// writeKeyFile guarda el archivo con permisos solo para el dueño, reemplazándolo de forma
+// atómica para no dejarlo a medias.
+func writeKeyFile(path string, file keyFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,322 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName es el nombre con el que se registra Serializer en GORM.
+const SerializerName = "encrypted"
+
+// Serializer cifra y descifra las columnas con la etiqueta gorm:"serializer:encrypted". Admite
+// campos string (columnas text), []byte (bytea) y JSON en columnas jsonb, donde el valor
+// cifrado se guarda como un string JSON. Los valores vacíos se guardan sin cifrar.
+//
+// Los datos asociados de cada valor incluyen la clave primaria de la fila, que tiene que estar
+// en el modelo al guardarlo (también en los Updates con un map) y leerse antes que la columna
+// cifrada; si no, se devuelve ErrNoRowID.
+//
+// Sin clave maestra los valores se guardan sin cifrar; los que empiezan como un valor cifrado
+// se marcan con prefixPlain.
+type Serializer struct{}
+
+// columnKind es la forma en que se guarda el valor según el tipo de la columna.
+type columnKind int
+
+const (
+	kindText columnKind = iota
+	kindBytes
+	kindJSON
+)
+
+func kindOf(field *schema.Field) columnKind {
+	switch {
+	case field.FieldType.Kind() == reflect.String:
+		return kindText
+	case strings.Contains(strings.ToLower(field.TagSettings["TYPE"]), "json"):
+		return kindJSON
+	default:
+		return kindBytes
+	}
+}
+
+// Scan implementa schema.SerializerInterface.
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored []byte
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		stored = v
+	case string:
+		stored = []byte(v)
+	default:
+		return fmt.Errorf("encryption: tipo no soportado para %s: %T", field.Name, dbValue)
+	}
+
+	plaintext := stored
+	if plain, ok := escapedPlain(kindOf(field), stored); ok {
+		plaintext = plain
+	} else if e, ok := storedEnvelope(kindOf(field), stored); ok {
+		p := getKeyProvider()
+		if p == nil {
+			return ErrNoKeyProvider
+		}
+		var aad []byte
+		if !e.legacy {
+			rowID, err := rowIDOf(ctx, field, dst)
+			if err != nil {
+				return fmt.Errorf("encryption: %s: %w; el Select debe incluir la clave primaria antes de %s",
+					field.Name, err, field.DBName)
+			}
+			aad = associatedData(field.Schema.Table, field.DBName, rowID)
+		}
+		var err error
+		if plaintext, err = decrypt(ctx, p, e, aad); err != nil {
+			return fmt.Errorf("encryption: %s: %w", field.Name, err)
+		}
+	}
+
+	value := reflect.New(field.FieldType).Elem()
+	if value.Kind() == reflect.String {
+		value.SetString(string(plaintext))
+	} else if plaintext != nil {
+		value.SetBytes(append([]byte(nil), plaintext...))
+	}
+	field.ReflectValueOf(ctx, dst).Set(value)
+	return nil
+}
+
+// Value implementa schema.SerializerValuerInterface.
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	if fieldValue == nil {
+		return nil, nil
+	}
+	value := reflect.ValueOf(fieldValue)
+	var plaintext []byte
+	switch {
+	case value.Kind() == reflect.String:
+		plaintext = []byte(value.String())
+	case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8:
+		plaintext = value.Bytes()
+	default:
+		return nil, fmt.Errorf("encryption: tipo no soportado para %s: %T", field.Name, fieldValue)
+	}
+
+	kind := kindOf(field)
+	p := getKeyProvider()
+	if len(plaintext) == 0 || p == nil {
+		return plainValue(kind, plaintext), nil
+	}
+	rowID, err := rowIDOf(ctx, field, dst)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: %s: %w", field.Name, err)
+	}
+	e, err := encrypt(ctx, p, plaintext, associatedData(field.Schema.Table, field.DBName, rowID))
+	if err != nil {
+		return nil, fmt.Errorf("encryption: %s: %w", field.Name, err)
+	}
+	return envelopeValue(kind, e), nil
+}
+
+// rowIDOf devuelve la clave primaria de la fila row como texto.
+func rowIDOf(ctx context.Context, field *schema.Field, row reflect.Value) (string, error) {
+	pk := field.Schema.PrioritizedPrimaryField
+	row = reflect.Indirect(row)
+	if pk == nil || !row.IsValid() || row.Kind() != reflect.Struct {
+		return "", ErrNoRowID
+	}
+	value, zero := pk.ValueOf(ctx, row)
+	if zero {
+		return "", ErrNoRowID
+	}
+	return fmt.Sprint(value), nil
+}
+
+// storedString devuelve el texto en que se busca el prefijo de un valor guardado: el valor
+// mismo o, en las columnas JSON, el string JSON que lo contiene. Devuelve false si el valor
+// JSON no es un string.
+func storedString(kind columnKind, stored []byte) ([]byte, bool) {
+	if kind != kindJSON {
+		return stored, true
+	}
+	var s string
+	if json.Unmarshal(stored, &s) != nil {
+		return nil, false
+	}
+	return []byte(s), true
+}
+
+// storedEnvelope interpreta el valor guardado en una columna; devuelve false si no está cifrado.
+func storedEnvelope(kind columnKind, stored []byte) (envelope, bool) {
+	s, ok := storedString(kind, stored)
+	if !ok {
+		return envelope{}, false
+	}
+	return parseEnvelope(s)
+}
+
+// escapedPlain devuelve el valor sin cifrar que se guardó detrás de prefixPlain, o false si el
+// valor guardado no lo tiene.
+func escapedPlain(kind columnKind, stored []byte) ([]byte, bool) {
+	s, ok := storedString(kind, stored)
+	if !ok || !bytes.HasPrefix(s, []byte(prefixPlain)) {
+		return nil, false
+	}
+	return s[len(prefixPlain):], true
+}
+
+// plainValue es el valor que se guarda sin cifrar: tal cual o, si empieza como un valor
+// cifrado, detrás de prefixPlain.
+func plainValue(kind columnKind, plaintext []byte) interface{} {
+	if s, ok := storedString(kind, plaintext); !ok || !bytes.HasPrefix(s, []byte(reservedPrefix)) {
+		return storedValue(kind, plaintext)
+	}
+	escaped := append([]byte(prefixPlain), plaintext...)
+	if kind == kindJSON {
+		escaped, _ = json.Marshal(string(escaped))
+	}
+	return storedValue(kind, escaped)
+}
+
+// storedValue es el valor sin cifrar que se guarda en la columna.
+func storedValue(kind columnKind, plaintext []byte) interface{} {
+	switch {
+	case kind == kindText:
+		return string(plaintext)
+	case len(plaintext) == 0:
+		return nil
+	case kind == kindJSON:
+		return string(plaintext)
+	default:
+		return plaintext
+	}
+}
+
+// envelopeValue es el valor cifrado que se guarda en la columna.
+func envelopeValue(kind columnKind, e envelope) interface{} {
+	switch kind {
+	case kindJSON:
+		encoded, _ := json.Marshal(e.String())
+		return string(encoded)
+	case kindBytes:
+		return []byte(e.String())
+	default:
+		return e.String()
+	}
+}
+
+// StoredValue devuelve un valor leído de la columna con el tipo con que se guarda, por
+// ejemplo para compararlo en una actualización condicional.
+func StoredValue(field *schema.Field, stored []byte) interface{} {
+	return storedValue(kindOf(field), stored)
+}
+
+// IsEncrypted indica si la columna se guarda cifrada.
+func IsEncrypted(field *schema.Field) bool {
+	_, ok := field.Serializer.(Serializer)
+	return ok
+}
+
+// RegisterCallbacks registra el callback de GORM que cifra las columnas de las
+// actualizaciones con un map (Updates(map[string]interface{}{...}) o Update(columna, valor)),
+// a las que GORM no aplica los serializers.
+func RegisterCallbacks(db *gorm.DB) error {
+	return db.Callback().Update().Before("gorm:update").Register("encryption:encrypt_map", encryptMap)
+}
+
+func encryptMap(tx *gorm.DB) {
+	stmt := tx.Statement
+	values, ok := stmt.Dest.(map[string]interface{})
+	if !ok || stmt.Schema == nil || tx.Error != nil {
+		return
+	}
+	var encrypted map[string]interface{}
+	for name, value := range values {
+		field := stmt.Schema.LookUpField(name)
+		if field == nil || !IsEncrypted(field) {
+			continue
+		}
+		stored, err := Serializer{}.Value(stmt.Context, field, stmt.ReflectValue, value)
+		if err != nil {
+			// Las expresiones SQL (gorm.Expr) no se pueden cifrar y se rechazan.
+			_ = tx.AddError(err)
+			return
+		}
+		if encrypted == nil {
+			// Se cifra una copia para no modificar el map del llamador.
+			encrypted = maps.Clone(values)
+		}
+		encrypted[name] = stored
+	}
+	if encrypted != nil {
+		stmt.Dest = encrypted
+	}
+}
+
+// KeyIDOf devuelve el ID de la clave maestra con que se cifró un valor guardado en la
+// columna, o false si no está cifrado.
+func KeyIDOf(field *schema.Field, stored []byte) (string, bool) {
+	e, ok := storedEnvelope(kindOf(field), stored)
+	return e.keyID, ok
+}
+
+// Rewrap vuelve a cifrar con la clave maestra actual la clave de datos de un valor guardado en
+// la columna de la fila rowID, sin descifrar el valor; si estaba sin cifrar o era un valor
+// enc:v1, sin datos asociados, lo cifra de nuevo. Devuelve el valor a guardar, el ID de la clave
+// maestra con que estaba cifrado ("" si no lo estaba) y false si ya usaba la clave actual o
+// está vacío.
+func Rewrap(ctx context.Context, field *schema.Field, rowID string, stored []byte) (interface{}, string, bool, error) {
+	p := getKeyProvider()
+	if p == nil {
+		return nil, "", false, ErrNoKeyProvider
+	}
+	kind := kindOf(field)
+	aad := associatedData(field.Schema.Table, field.DBName, rowID)
+	e, ok := storedEnvelope(kind, stored)
+	if !ok {
+		if len(stored) == 0 {
+			return nil, "", false, nil
+		}
+		if plain, ok := escapedPlain(kind, stored); ok {
+			stored = plain
+		}
+		e, err := encrypt(ctx, p, stored, aad)
+		if err != nil {
+			return nil, "", false, err
+		}
+		return envelopeValue(kind, e), "", true, nil
+	}
+	if e.legacy {
+		plaintext, err := decrypt(ctx, p, e, nil)
+		if err != nil {
+			return nil, e.keyID, false, err
+		}
+		upgraded, err := encrypt(ctx, p, plaintext, aad)
+		if err != nil {
+			return nil, e.keyID, false, err
+		}
+		return envelopeValue(kind, upgraded), e.keyID, true, nil
+	}
+	if e.keyID == p.KeyID() {
+		return nil, e.keyID, false, nil
+	}
+	dataKey, err := p.UnwrapKey(ctx, e.keyID, e.wrapped)
+	if err != nil {
+		return nil, e.keyID, false, err
+	}
+	keyID, wrapped, err := p.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, e.keyID, false, err
+	}
+	previous := e.keyID
+	e.keyID, e.wrapped = keyID, wrapped
+	return envelopeValue(kind, e), previous, true, nil
+}
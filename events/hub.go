@@ -0,0 +1,149 @@
+// Package events reparte los cambios de estado de las tareas a los clientes que esperan
+// por ellos (long-polling y WebSocket). Los cambios llegan de PostgreSQL con LISTEN/NOTIFY,
+// por lo que un cliente conectado a una réplica se entera de lo que procesa otra.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// subscriptionBuffer es la cantidad de eventos que una suscripción guarda sin leer; si
+// se llena, los eventos siguientes se descartan para no bloquear al resto.
+const subscriptionBuffer = 64
+
+// Hub entrega cada evento a las suscripciones interesadas en su tarea.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[string]map[*Subscription]struct{}
+}
+
+// NewHub crea un Hub sin suscripciones.
+func NewHub() *Hub {
+	return &Hub{subs: map[string]map[*Subscription]struct{}{}}
+}
+
+// Subscription recibe en C los eventos de las tareas a las que está suscrita.
+type Subscription struct {
+	C   chan models.TaskStatusEvent
+	hub *Hub
+	ids map[string]struct{}
+}
+
+// Subscribe crea una suscripción a los eventos de las tareas indicadas. Se debe cerrar con Close.
+func (h *Hub) Subscribe(ids ...string) *Subscription {
+	s := &Subscription{C: make(chan models.TaskStatusEvent, subscriptionBuffer), hub: h, ids: map[string]struct{}{}}
+	s.Add(ids...)
+	return s
+}
+
+// Add suscribe a los eventos de más tareas.
+func (s *Subscription) Add(ids ...string) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	for _, id := range ids {
+		s.ids[id] = struct{}{}
+		if s.hub.subs[id] == nil {
+			s.hub.subs[id] = map[*Subscription]struct{}{}
+		}
+		s.hub.subs[id][s] = struct{}{}
+	}
+}
+
+// Remove deja de recibir los eventos de las tareas indicadas.
+func (s *Subscription) Remove(ids ...string) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	for _, id := range ids {
+		s.hub.remove(s, id)
+	}
+}
+
+// Close cancela la suscripción a todas sus tareas.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	for id := range s.ids {
+		s.hub.remove(s, id)
+	}
+}
+
+// remove quita la suscripción de la tarea; se llama con mu tomado.
+func (h *Hub) remove(s *Subscription, id string) {
+	delete(s.ids, id)
+	delete(h.subs[id], s)
+	if len(h.subs[id]) == 0 {
+		delete(h.subs, id)
+	}
+}
+
+// Publish entrega el evento a las suscripciones de su tarea sin bloquearse.
+func (h *Hub) Publish(event models.TaskStatusEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs[event.ID] {
+		select {
+		case s.C <- event:
+		default:
+			slog.Warn("evento de tarea descartado: suscripción llena", "task_id", event.ID, "status", event.Status)
+		}
+	}
+}
+
+// Listen escucha models.TaskStatusChannel en una conexión dedicada y publica cada aviso
+// en el Hub hasta que se cancele ctx. Si la conexión se pierde, se reconecta con espera
+// creciente de hasta 30 segundos.
+func (h *Hub) Listen(ctx context.Context, dsn string) {
+	backoff := time.Second
+	for ctx.Err() == nil {
+		start := time.Now()
+		err := h.listen(ctx, dsn)
+		if ctx.Err() != nil {
+			return
+		}
+		// Una conexión que duró un rato no cuenta como fallo consecutivo.
+		if time.Since(start) > time.Minute {
+			backoff = time.Second
+		}
+		slog.Error("se perdió la conexión de LISTEN, reintentando", "error", err, "retry_in", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+// listen abre la conexión, ejecuta LISTEN y publica los avisos hasta que ocurra un error.
+func (h *Hub) listen(ctx context.Context, dsn string) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+models.TaskStatusChannel); err != nil {
+		return err
+	}
+	slog.Info("escuchando cambios de estado de las tareas", "channel", models.TaskStatusChannel)
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var event models.TaskStatusEvent
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			slog.Warn("aviso de tarea inválido", "payload", notification.Payload, "error", err)
+			continue
+		}
+		h.Publish(event)
+	}
+}
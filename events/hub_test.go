@@ -0,0 +1,124 @@
+package events
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/db/dbtest"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/google/uuid"
+)
+
+// receive espera un evento de s o falla la prueba.
+func receive(t *testing.T, s *Subscription) models.TaskStatusEvent {
+	t.Helper()
+	select {
+	case event := <-s.C:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no llegó el evento")
+		return models.TaskStatusEvent{}
+	}
+}
+
+// nothing comprueba que s no recibió ningún evento.
+func nothing(t *testing.T, s *Subscription, name string) {
+	t.Helper()
+	select {
+	case event := <-s.C:
+		t.Errorf("%s recibió %+v", name, event)
+	default:
+	}
+}
+
+func TestHubDeliversToSubscribedTasksOnly(t *testing.T) {
+	h := NewHub()
+	a := h.Subscribe("t1", "t2")
+	b := h.Subscribe("t2")
+	defer b.Close()
+
+	h.Publish(models.TaskStatusEvent{ID: "t1", Status: models.StatusProcessing})
+	if event := receive(t, a); event.ID != "t1" || event.Status != models.StatusProcessing {
+		t.Errorf("a recibió %+v", event)
+	}
+	nothing(t, b, "b")
+
+	h.Publish(models.TaskStatusEvent{ID: "t2", Status: models.StatusCompleted})
+	receive(t, a)
+	receive(t, b)
+
+	a.Remove("t2")
+	h.Publish(models.TaskStatusEvent{ID: "t2", Status: models.StatusError})
+	nothing(t, a, "a después de Remove")
+	receive(t, b)
+
+	a.Close()
+	h.Publish(models.TaskStatusEvent{ID: "t1", Status: models.StatusCompleted})
+	nothing(t, a, "a después de Close")
+	if len(h.subs) != 1 {
+		t.Errorf("quedaron suscripciones de tareas sin suscriptores: %v", h.subs)
+	}
+}
+
+func TestPublishDoesNotBlockOnAFullSubscription(t *testing.T) {
+	h := NewHub()
+	slow := h.Subscribe("t1")
+	defer slow.Close()
+	fast := h.Subscribe("t1")
+	defer fast.Close()
+
+	done := make(chan struct{})
+	go func() {
+		for range subscriptionBuffer + 10 {
+			h.Publish(models.TaskStatusEvent{ID: "t1", Status: models.StatusProcessing})
+			<-fast.C
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Publish se bloqueó con una suscripción llena")
+	}
+	if len(slow.C) != subscriptionBuffer {
+		t.Errorf("la suscripción lenta tiene %d eventos, se esperaban %d", len(slow.C), subscriptionBuffer)
+	}
+}
+
+func TestListenReceivesStatusChangesFromPostgres(t *testing.T) {
+	conn := dbtest.Postgres(t, &models.GeminiProcessingDB{})
+	if err := models.MigrateTaskStatusNotifications(conn); err != nil {
+		t.Fatalf("MigrateTaskStatusNotifications: %v", err)
+	}
+	task := models.GeminiProcessingDB{ID: uuid.NewString(), Prompt: "p", Status: models.StatusPending}
+	if err := conn.Create(&task).Error; err != nil {
+		t.Fatalf("crear tarea: %v", err)
+	}
+
+	h := NewHub()
+	sub := h.Subscribe(task.ID)
+	defer sub.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.Listen(ctx, os.Getenv("TEST_DATABASE_URL"))
+
+	// Hasta que la conexión ejecuta LISTEN los avisos se pierden: se repite el cambio.
+	deadline := time.Now().Add(10 * time.Second)
+	status := []models.GeminiProcessingStatus{models.StatusProcessing, models.StatusPending}
+	for i := 0; ; i++ {
+		conn.Model(&task).Update("status", status[i%2])
+		select {
+		case event := <-sub.C:
+			if event.Kind != models.TaskKindPrompt || event.Status != status[i%2] {
+				t.Fatalf("evento = %+v", event)
+			}
+			return
+		case <-time.After(200 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no llegó el aviso de LISTEN/NOTIFY")
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genai"
+)
+
+const (
+	// DefaultEmbeddingModel es el modelo de Gemini usado para generar embeddings.
+	DefaultEmbeddingModel = "gemini-embedding-001"
+	// EmbeddingDimensions es el tamaño de los vectores; debe coincidir con la columna vector de Postgres.
+	EmbeddingDimensions = 768
+	// MaxEmbeddingBatch es el máximo de textos por llamada de embeddings.
+	MaxEmbeddingBatch = 100
+)
+
+// Tipos de tarea de embeddings: los documentos y las consultas se codifican distinto
+// para mejorar la búsqueda.
+const (
+	EmbedDocument = "RETRIEVAL_DOCUMENT"
+	EmbedQuery    = "RETRIEVAL_QUERY"
+)
+
+// Embedder genera vectores normalizados de EmbeddingDimensions elementos para una lista de textos.
+type Embedder interface {
+	Model() string
+	Embed(ctx context.Context, texts []string, taskType string) ([][]float32, error)
+}
+
+// NewEmbedder devuelve el embedder configurado en GEMINI_EMBEDDER: "gemini" (por defecto)
+// o "fake", que no llama a la API y sirve para desarrollo y pruebas sin conexión.
+func NewEmbedder() (Embedder, error) {
+	switch os.Getenv("GEMINI_EMBEDDER") {
+	case "", "gemini":
+		return &Service{}, nil
+	case "fake":
+		return FakeEmbedder{}, nil
+	default:
+		return nil, fmt.Errorf("GEMINI_EMBEDDER no soportado: %s", os.Getenv("GEMINI_EMBEDDER"))
+	}
+}
+
+// Model devuelve el modelo de embeddings de Gemini.
+func (s *Service) Model() string {
+	return DefaultEmbeddingModel
+}
+
+// Embed genera los embeddings de los textos con la API de Gemini.
+func (s *Service) Embed(ctx context.Context, texts []string, taskType string) (_ [][]float32, err error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "gemini.EmbedContent", trace.WithAttributes(
+		attribute.String("gen_ai.request.model", DefaultEmbeddingModel),
+		attribute.Int("gemini.embeddings.count", len(texts)),
+		attribute.String("gemini.embeddings.task_type", taskType),
+	))
+	defer func() { endSpan(span, err) }()
+
+	client, err := newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make([]*genai.Content, len(texts))
+	for i, text := range texts {
+		contents[i] = genai.NewContentFromText(text, genai.RoleUser)
+	}
+	res, err := client.Models.EmbedContent(ctx, DefaultEmbeddingModel, contents, &genai.EmbedContentConfig{
+		TaskType:             taskType,
+		OutputDimensionality: genai.Ptr[int32](EmbeddingDimensions),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("gemini devolvió %d embeddings para %d textos", len(res.Embeddings), len(texts))
+	}
+
+	vectors := make([][]float32, len(res.Embeddings))
+	for i, embedding := range res.Embeddings {
+		if len(embedding.Values) != EmbeddingDimensions {
+			return nil, fmt.Errorf("embedding de %d dimensiones, se esperaban %d", len(embedding.Values), EmbeddingDimensions)
+		}
+		vectors[i] = normalize(embedding.Values)
+	}
+	return vectors, nil
+}
+
+// FakeEmbedder genera embeddings deterministas sin llamar a la API: cada palabra se
+// proyecta en una dimensión por hash, de modo que los textos con palabras en común
+// quedan cerca. No tiene calidad semántica; solo sirve para pruebas sin conexión.
+type FakeEmbedder struct{}
+
+// Model devuelve el nombre del modelo falso.
+func (FakeEmbedder) Model() string {
+	return "fake-embedding"
+}
+
+// Embed genera los embeddings de los textos.
+func (FakeEmbedder) Embed(_ context.Context, texts []string, _ string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, EmbeddingDimensions)
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+		for _, word := range words {
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(word))
+			sum := h.Sum32()
+			sign := float32(1)
+			if sum&1 == 1 {
+				sign = -1
+			}
+			vector[(sum>>1)%EmbeddingDimensions] += sign
+		}
+		vectors[i] = normalize(vector)
+	}
+	return vectors, nil
+}
+
+// normalize escala el vector a norma 1; un vector nulo se devuelve sin cambios.
+func normalize(vector []float32) []float32 {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return vector
+	}
+	norm := float32(math.Sqrt(sum))
+	out := make([]float32, len(vector))
+	for i, v := range vector {
+		out[i] = v / norm
+	}
+	return out
+}
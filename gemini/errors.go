@@ -0,0 +1,74 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"google.golang.org/genai"
+)
+
+var (
+	// ErrNotConfigured indica que no hay credenciales para la API de Gemini.
+	ErrNotConfigured = errors.New("falta configurar GEMINI_API_KEY o habilitar VertexAI (GOOGLE_GENAI_USE_VERTEXAI=true)")
+	// ErrUpload indica que falló la subida del archivo a la API de Files.
+	ErrUpload = errors.New("error subiendo el archivo a Gemini")
+)
+
+// TaskError clasifica un error de Gemini en un error de la API con código estable,
+// para guardarlo en la tarea sin exponer el texto original del SDK.
+func TaskError(err error) *apierror.Error {
+	var apiErr *apierror.Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var schemaErr *SchemaValidationError
+	switch {
+	case errors.As(err, &schemaErr):
+		return apierror.Wrap(err, http.StatusUnprocessableEntity, apierror.CodeSchemaValidationFailed, i18n.ErrSchemaValidationFailed).
+			WithDetails(schemaErr.Violations)
+	case errors.Is(err, ErrInvalidJSONResponse):
+		return apierror.Wrap(err, http.StatusUnprocessableEntity, apierror.CodeSchemaValidationFailed, i18n.ErrInvalidJSONResponse).
+			WithDetails([]SchemaViolation{{Path: "/", Keyword: "type", Message: err.Error()}})
+	case errors.Is(err, ErrMaxToolSteps):
+		return apierror.Wrap(err, http.StatusUnprocessableEntity, apierror.CodeGeminiToolStepsExceeded, i18n.ErrGeminiToolSteps)
+	case errors.Is(err, ErrNotConfigured):
+		return apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeGeminiNotConfigured, i18n.ErrGeminiNotConfigured)
+	case errors.Is(err, context.DeadlineExceeded):
+		return apierror.Wrap(err, http.StatusGatewayTimeout, apierror.CodeGeminiTimeout, i18n.ErrGeminiTimeout)
+	}
+
+	if status, ok := apiStatus(err); ok {
+		switch {
+		case status == http.StatusTooManyRequests:
+			return apierror.Wrap(err, status, apierror.CodeGeminiRateLimited, i18n.ErrGeminiRateLimited)
+		case status == http.StatusUnauthorized || status == http.StatusForbidden:
+			return apierror.Wrap(err, status, apierror.CodeGeminiPermissionDenied, i18n.ErrGeminiPermissionDenied)
+		case status >= 400 && status < 500:
+			return apierror.Wrap(err, status, apierror.CodeGeminiInvalidRequest, i18n.ErrGeminiInvalidRequest)
+		case status >= 500:
+			return apierror.Wrap(err, status, apierror.CodeGeminiUnavailable, i18n.ErrGeminiUnavailable)
+		}
+	}
+
+	if errors.Is(err, ErrUpload) {
+		return apierror.Wrap(err, http.StatusBadGateway, apierror.CodeGeminiUploadFailed, i18n.ErrGeminiUploadFailed)
+	}
+	return apierror.Wrap(err, http.StatusBadGateway, apierror.CodeGeminiError, i18n.ErrGeminiError)
+}
+
+// apiStatus obtiene el código HTTP de un error devuelto por la API de Gemini.
+func apiStatus(err error) (int, bool) {
+	var value genai.APIError
+	if errors.As(err, &value) {
+		return value.Code, true
+	}
+	var ptr *genai.APIError
+	if errors.As(err, &ptr) && ptr != nil {
+		return ptr.Code, true
+	}
+	return 0, false
+}
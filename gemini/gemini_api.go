@@ -6,75 +6,165 @@ import (
 	"io"
 	"os"
 
+	"github.com/Efren-Garza-Z/go-api-gemini/telemetry"
 	"github.com/joho/godotenv"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/genai"
 )
 
+// DefaultModel es el modelo de Gemini usado para generar contenido.
+const DefaultModel = "gemini-2.0-flash"
+
 // Service GeminiService es una abstracción del servicio de la API de Gemini.
 type Service struct{}
 
 // newClient inicializa el cliente de Gemini con la configuración estándar.
-func newClient() (*genai.Client, context.Context, error) {
+func newClient(ctx context.Context) (*genai.Client, error) {
 	_ = godotenv.Load() // carga .env si existe
 
-	ctx := context.Background()
-
 	// Validación de configuración
 	if os.Getenv("GOOGLE_GENAI_USE_VERTEXAI") != "true" && os.Getenv("GEMINI_API_KEY") == "" {
-		return nil, nil, fmt.Errorf("falta configurar GOOGLE_API_KEY o habilitar VertexAI (GOOGLE_GENAI_USE_VERTEXAI=true)")
+		return nil, ErrNotConfigured
 	}
 
 	client, err := genai.NewClient(ctx, nil)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error creando cliente genai: %w", err)
+		return nil, fmt.Errorf("error creando cliente genai: %w", err)
 	}
 
-	return client, ctx, nil
+	return client, nil
 }
 
-// GenerateContent genera contenido a partir de un prompt de texto.
-func (s *Service) GenerateContent(prompt string) (string, error) {
-	client, ctx, err := newClient()
+// Options son los parámetros opcionales de una generación.
+type Options struct {
+	// Schema, si no es nil, pide la respuesta en JSON con ese esquema y la valida al recibirla.
+	Schema *ResponseSchema
+	// Tools son las herramientas que el modelo puede llamar durante la generación.
+	Tools []*Tool
+	// SystemInstruction es la instrucción de sistema de la generación (por ejemplo, la de una plantilla).
+	SystemInstruction string
+	// RedactToolResponse, si no es nil, se aplica al resultado de cada herramienta antes de
+	// devolverlo al modelo y de registrarlo en ToolCalls, para reemplazar los datos personales.
+	RedactToolResponse func(map[string]interface{}) map[string]interface{}
+}
+
+// generateConfig arma la configuración de generación a partir de las opciones.
+func (o Options) generateConfig(cfg *genai.GenerateContentConfig) *genai.GenerateContentConfig {
+	if o.Schema == nil && len(o.Tools) == 0 && o.SystemInstruction == "" {
+		return cfg
+	}
+	if cfg == nil {
+		cfg = &genai.GenerateContentConfig{}
+	}
+	if o.Schema != nil {
+		cfg.ResponseMIMEType = JSONMIMEType
+		cfg.ResponseJsonSchema = o.Schema.doc
+	}
+	if len(o.Tools) > 0 {
+		cfg.Tools = toolConfig(o.Tools)
+	}
+	if o.SystemInstruction != "" {
+		cfg.SystemInstruction = genai.NewContentFromText(o.SystemInstruction, genai.RoleUser)
+	}
+	return cfg
+}
+
+// generate envía las partes al chat, ejecuta las herramientas que pida el modelo y, si se
+// pidió un esquema, valida la respuesta. Si Gemini llegó a responder pero la tarea falla
+// (esquema, máximo de pasos) se devuelve también el Result para registrar el consumo.
+func (o Options) generate(ctx context.Context, span trace.Span, chat *genai.Chat, parts ...genai.Part) (*Result, error) {
+	res, usage, calls, err := sendWithTools(ctx, chat, o.Tools, o.RedactToolResponse, parts...)
+	span.SetAttributes(attribute.Int("gemini.tool.calls", len(calls)))
 	if err != nil {
-		return "", err
+		if res == nil && len(calls) == 0 {
+			return nil, err
+		}
+		return &Result{Usage: usage, ToolCalls: calls}, err
 	}
 
-	cfg := &genai.GenerateContentConfig{
-		Temperature: genai.Ptr[float32](0.5),
+	result := &Result{Text: res.Text(), Usage: usage, ToolCalls: calls}
+	if o.Schema == nil {
+		return result, nil
+	}
+	data, err := o.Schema.Validate(result.Text)
+	span.SetAttributes(attribute.Bool("gemini.response.schema_valid", err == nil))
+	if err != nil {
+		return result, err
+	}
+	result.JSON = data
+	return result, nil
+}
+
+// endSpan registra el error (si lo hay) en el span y lo cierra.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 	}
+	span.End()
+}
 
-	chat, err := client.Chats.Create(ctx, "gemini-2.0-flash", cfg, nil)
+// GenerateContent genera contenido a partir de un prompt de texto.
+func (s *Service) GenerateContent(ctx context.Context, prompt string, opts Options) (_ *Result, err error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "gemini.GenerateContent", trace.WithAttributes(
+		attribute.String("gen_ai.request.model", DefaultModel),
+		attribute.Int("gemini.prompt.length", len(prompt)),
+		attribute.Bool("gemini.response.schema", opts.Schema != nil),
+		attribute.Int("gemini.tools", len(opts.Tools)),
+	))
+	defer func() { endSpan(span, err) }()
+
+	client, err := newClient(ctx)
 	if err != nil {
-		return "", fmt.Errorf("error creando chat: %w", err)
+		return nil, err
 	}
 
-	res, err := chat.SendMessage(ctx, genai.Part{Text: prompt})
+	cfg := opts.generateConfig(&genai.GenerateContentConfig{
+		Temperature: genai.Ptr[float32](0.5),
+	})
+
+	chat, err := client.Chats.Create(ctx, DefaultModel, cfg, nil)
 	if err != nil {
-		return "", fmt.Errorf("error enviando mensaje: %w", err)
+		return nil, fmt.Errorf("error creando chat: %w", err)
 	}
 
-	return res.Text(), nil
+	result, err := opts.generate(ctx, span, chat, genai.Part{Text: prompt})
+	if err != nil {
+		return result, fmt.Errorf("error enviando mensaje: %w", err)
+	}
+	return result, nil
 }
 
 // GenerateWithFile genera contenido usando un prompt y un archivo adjunto de cualquier tipo.
-func (s *Service) GenerateWithFile(file io.Reader, filename string, mimeType string, prompt string) (string, error) {
-	client, ctx, err := newClient()
+func (s *Service) GenerateWithFile(ctx context.Context, file io.Reader, filename string, mimeType string, prompt string, opts Options) (_ *Result, err error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "gemini.GenerateWithFile", trace.WithAttributes(
+		attribute.String("gen_ai.request.model", DefaultModel),
+		attribute.String("gemini.file.mime_type", mimeType),
+		attribute.Int("gemini.prompt.length", len(prompt)),
+		attribute.Bool("gemini.response.schema", opts.Schema != nil),
+		attribute.Int("gemini.tools", len(opts.Tools)),
+	))
+	defer func() { endSpan(span, err) }()
+
+	client, err := newClient(ctx)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// Subir el archivo, pasando el MIMEType dinámicamente
-	f, err := client.Files.Upload(ctx, file, &genai.UploadFileConfig{
+	f, err := uploadFile(ctx, client, file, &genai.UploadFileConfig{
 		DisplayName: filename,
 		MIMEType:    mimeType, // Ahora el MIMEType es dinámico
 	})
 	if err != nil {
-		return "", fmt.Errorf("upload: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrUpload, err)
 	}
 
-	chat, err := client.Chats.Create(ctx, "gemini-2.0-flash", nil, nil)
+	chat, err := client.Chats.Create(ctx, DefaultModel, opts.generateConfig(nil), nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	parts := []genai.Part{
@@ -90,9 +180,50 @@ func (s *Service) GenerateWithFile(file io.Reader, filename string, mimeType str
 		}},
 	}
 
+	return opts.generate(ctx, span, chat, parts...)
+}
+
+// uploadFile sube un archivo a la API de Files dentro de su propio span.
+func uploadFile(ctx context.Context, client *genai.Client, file io.Reader, cfg *genai.UploadFileConfig) (_ *genai.File, err error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "gemini.Files.Upload", trace.WithAttributes(
+		attribute.String("gemini.file.mime_type", cfg.MIMEType),
+	))
+	defer func() { endSpan(span, err) }()
+
+	f, err := client.Files.Upload(ctx, file, cfg)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(
+		attribute.String("gemini.file.name", f.Name),
+		attribute.Int64("gemini.file.size_bytes", derefInt64(f.SizeBytes)),
+	)
+	return f, nil
+}
+
+// sendMessage envía las partes al chat dentro de su propio span.
+func sendMessage(ctx context.Context, chat *genai.Chat, parts ...genai.Part) (_ *genai.GenerateContentResponse, err error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "gemini.Chat.SendMessage", trace.WithAttributes(
+		attribute.String("gen_ai.request.model", DefaultModel),
+	))
+	defer func() { endSpan(span, err) }()
+
 	res, err := chat.SendMessage(ctx, parts...)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if md := res.UsageMetadata; md != nil {
+		span.SetAttributes(
+			attribute.Int("gen_ai.usage.input_tokens", int(md.PromptTokenCount)),
+			attribute.Int("gen_ai.usage.output_tokens", int(md.CandidatesTokenCount)),
+		)
+	}
+	return res, nil
+}
+
+func derefInt64(v *int64) int64 {
+	if v == nil {
+		return 0
 	}
-	return res.Text(), nil
+	return *v
 }
@@ -0,0 +1,78 @@
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Price es el precio en USD por millón de tokens de un modelo.
+type Price struct {
+	InputPerMillion  float64 `json:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million"`
+}
+
+// defaultPrices es la tabla de precios usada si no se configura GEMINI_PRICE_TABLE.
+var defaultPrices = map[string]Price{
+	"gemini-2.0-flash":      {InputPerMillion: 0.10, OutputPerMillion: 0.40},
+	"gemini-2.0-flash-lite": {InputPerMillion: 0.075, OutputPerMillion: 0.30},
+	"gemini-2.5-flash":      {InputPerMillion: 0.30, OutputPerMillion: 2.50},
+	"gemini-2.5-pro":        {InputPerMillion: 1.25, OutputPerMillion: 10.00},
+}
+
+var (
+	pricesOnce sync.Once
+	prices     map[string]Price
+)
+
+// loadPrices lee la tabla de precios desde el archivo JSON indicado en GEMINI_PRICE_TABLE,
+// con el formato {"modelo": {"input_per_million": 0.1, "output_per_million": 0.4}}.
+// Los modelos del archivo reemplazan o se agregan a los valores por defecto.
+func loadPrices() (map[string]Price, error) {
+	table := make(map[string]Price, len(defaultPrices))
+	for name, price := range defaultPrices {
+		table[name] = price
+	}
+
+	path := os.Getenv("GEMINI_PRICE_TABLE")
+	if path == "" {
+		return table, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return table, fmt.Errorf("error leyendo la tabla de precios: %w", err)
+	}
+	var custom map[string]Price
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return table, fmt.Errorf("tabla de precios inválida: %w", err)
+	}
+	for name, price := range custom {
+		table[name] = price
+	}
+	return table, nil
+}
+
+// InitPrices carga la tabla de precios. Se llama al iniciar la aplicación para
+// detectar un archivo mal configurado; si no se llama, se carga en el primer uso.
+func InitPrices() error {
+	var err error
+	pricesOnce.Do(func() {
+		prices, err = loadPrices()
+	})
+	return err
+}
+
+// EstimateCost calcula el costo estimado en USD de un consumo de tokens.
+// Devuelve 0 si el modelo no está en la tabla de precios.
+func EstimateCost(usage Usage) float64 {
+	_ = InitPrices()
+
+	price, ok := prices[usage.Model]
+	if !ok {
+		return 0
+	}
+	return float64(usage.PromptTokens)*price.InputPerMillion/1e6 +
+		float64(usage.CandidateTokens)*price.OutputPerMillion/1e6
+}
@@ -0,0 +1,53 @@
+package gemini
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEstimateCost(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage Usage
+		want  float64
+	}{
+		{"modelo con precio", Usage{Model: "gemini-2.0-flash", PromptTokens: 1_000_000, CandidateTokens: 500_000}, 0.10 + 0.20},
+		{"sin tokens", Usage{Model: "gemini-2.5-pro"}, 0},
+		{"modelo sin precio", Usage{Model: "modelo-desconocido", PromptTokens: 1000, CandidateTokens: 1000}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimateCost(tt.usage); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("EstimateCost = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceTableFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "precios.json")
+	os.WriteFile(path, []byte(`{"gemini-2.0-flash": {"input_per_million": 1, "output_per_million": 2}, "interno": {"input_per_million": 3}}`), 0o600)
+	t.Setenv("GEMINI_PRICE_TABLE", path)
+
+	table, err := loadPrices()
+	if err != nil {
+		t.Fatalf("loadPrices: %v", err)
+	}
+	if table["gemini-2.0-flash"].OutputPerMillion != 2 || table["interno"].InputPerMillion != 3 {
+		t.Errorf("el archivo no reemplazó ni agregó precios: %+v", table)
+	}
+	if table["gemini-2.5-pro"] != defaultPrices["gemini-2.5-pro"] {
+		t.Errorf("se perdió un precio por defecto: %+v", table["gemini-2.5-pro"])
+	}
+
+	os.WriteFile(path, []byte(`{"gemini-2.0-flash": 1}`), 0o600)
+	if _, err := loadPrices(); err == nil {
+		t.Error("loadPrices con un archivo inválido no devolvió error")
+	}
+	t.Setenv("GEMINI_PRICE_TABLE", filepath.Join(t.TempDir(), "no-existe.json"))
+	if _, err := loadPrices(); err == nil {
+		t.Error("loadPrices con un archivo inexistente no devolvió error")
+	}
+}
@@ -0,0 +1,200 @@
+package gemini
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// JSONMIMEType es el tipo MIME de respuesta que se pide a Gemini cuando hay un esquema.
+const JSONMIMEType = "application/json"
+
+// schemaURL es la ubicación ficticia con la que se compila cada esquema.
+const schemaURL = "request://response-schema.json"
+
+// ResponseSchema es un JSON Schema compilado que Gemini debe respetar en su respuesta.
+type ResponseSchema struct {
+	// Raw es el esquema tal como lo envió el cliente o como está registrado.
+	Raw json.RawMessage
+	// doc es el esquema decodificado que se envía a Gemini.
+	doc      interface{}
+	compiled *jsonschema.Schema
+}
+
+// SchemaViolation describe un punto de la respuesta que no cumple el esquema.
+type SchemaViolation struct {
+	// Path es la ubicación del valor en la respuesta en formato JSON Pointer.
+	Path string `json:"path" example:"/items/0/price"`
+	// Keyword es la regla del esquema que no se cumple.
+	Keyword string `json:"keyword" example:"type"`
+	Message string `json:"message" example:"got string, want number"`
+}
+
+// SchemaValidationError indica que la respuesta de Gemini no cumple el esquema pedido.
+type SchemaValidationError struct {
+	Violations []SchemaViolation
+}
+
+func (e *SchemaValidationError) Error() string {
+	if len(e.Violations) == 0 {
+		return "la respuesta no cumple el esquema"
+	}
+	return fmt.Sprintf("la respuesta no cumple el esquema: %s: %s", e.Violations[0].Path, e.Violations[0].Message)
+}
+
+// ErrInvalidJSONResponse indica que Gemini no devolvió un documento JSON válido.
+var ErrInvalidJSONResponse = errors.New("la respuesta de Gemini no es JSON válido")
+
+// CompileSchema valida y compila un JSON Schema enviado por el cliente.
+func CompileSchema(raw json.RawMessage) (*ResponseSchema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("el esquema no es JSON válido: %w", err)
+	}
+	if _, ok := doc.(map[string]interface{}); !ok {
+		return nil, errors.New("el esquema debe ser un objeto JSON")
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(schemaURL, doc); err != nil {
+		return nil, err
+	}
+	compiled, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Se guarda una copia compacta para no depender del buffer de la petición.
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return nil, err
+	}
+	return &ResponseSchema{Raw: compact.Bytes(), doc: doc, compiled: compiled}, nil
+}
+
+// Validate decodifica la respuesta de Gemini y la valida contra el esquema.
+// Devuelve el documento compactado si es válido, ErrInvalidJSONResponse si no es JSON,
+// o un *SchemaValidationError con la lista de incumplimientos.
+func (s *ResponseSchema) Validate(text string) (json.RawMessage, error) {
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(text))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJSONResponse, err)
+	}
+
+	if err := s.compiled.Validate(doc); err != nil {
+		var validationErr *jsonschema.ValidationError
+		if !errors.As(err, &validationErr) {
+			return nil, err
+		}
+		return nil, &SchemaValidationError{Violations: violations(validationErr)}
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(text)); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJSONResponse, err)
+	}
+	return compact.Bytes(), nil
+}
+
+// violationPrinter da formato a los mensajes del validador; se dejan en inglés porque
+// describen reglas de JSON Schema y se guardan tal cual en la tarea.
+var violationPrinter = message.NewPrinter(language.English)
+
+// violations aplana el árbol de errores del validador en la lista de incumplimientos concretos.
+func violations(err *jsonschema.ValidationError) []SchemaViolation {
+	if len(err.Causes) > 0 {
+		var list []SchemaViolation
+		for _, cause := range err.Causes {
+			list = append(list, violations(cause)...)
+		}
+		return list
+	}
+
+	path := ""
+	for _, token := range err.InstanceLocation {
+		token = strings.ReplaceAll(token, "~", "~0")
+		path += "/" + strings.ReplaceAll(token, "/", "~1")
+	}
+	if path == "" {
+		path = "/"
+	}
+	keyword := ""
+	if kp := err.ErrorKind.KeywordPath(); len(kp) > 0 {
+		keyword = kp[len(kp)-1]
+	}
+	return []SchemaViolation{{
+		Path:    path,
+		Keyword: keyword,
+		Message: err.ErrorKind.LocalizedString(violationPrinter),
+	}}
+}
+
+var (
+	schemasMu sync.RWMutex
+	schemas   = map[string]*ResponseSchema{}
+)
+
+// RegisterSchema registra un esquema con nombre para usarlo en las tareas con "schema".
+func RegisterSchema(name string, raw json.RawMessage) error {
+	schema, err := CompileSchema(raw)
+	if err != nil {
+		return fmt.Errorf("esquema %q inválido: %w", name, err)
+	}
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+	schemas[name] = schema
+	return nil
+}
+
+// LookupSchema devuelve el esquema registrado con ese nombre.
+func LookupSchema(name string) (*ResponseSchema, bool) {
+	schemasMu.RLock()
+	defer schemasMu.RUnlock()
+	schema, ok := schemas[name]
+	return schema, ok
+}
+
+// SchemaNames devuelve los nombres de los esquemas registrados en orden alfabético.
+func SchemaNames() []string {
+	schemasMu.RLock()
+	defer schemasMu.RUnlock()
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// InitSchemas registra los esquemas del archivo JSON indicado en GEMINI_RESPONSE_SCHEMAS,
+// con el formato {"nombre": { ...JSON Schema... }}. Se llama al iniciar la aplicación.
+func InitSchemas() error {
+	path := os.Getenv("GEMINI_RESPONSE_SCHEMAS")
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error leyendo los esquemas de respuesta: %w", err)
+	}
+	var custom map[string]json.RawMessage
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return fmt.Errorf("archivo de esquemas inválido: %w", err)
+	}
+	for name, raw := range custom {
+		if err := RegisterSchema(name, raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
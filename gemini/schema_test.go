@@ -0,0 +1,88 @@
+package gemini
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+)
+
+const invoiceSchema = `{
+	"type": "object",
+	"required": ["total", "items"],
+	"properties": {
+		"total": {"type": "number"},
+		"items": {"type": "array", "items": {"type": "object", "properties": {"price": {"type": "number"}}}}
+	}
+}`
+
+func TestCompileSchemaRejectsInvalidSchemas(t *testing.T) {
+	for name, raw := range map[string]string{
+		"no es JSON":       `{"type": `,
+		"no es un objeto":  `["object"]`,
+		"tipo inexistente": `{"type": "numero"}`,
+	} {
+		if _, err := CompileSchema(json.RawMessage(raw)); err == nil {
+			t.Errorf("%s: CompileSchema(%s) no devolvió error", name, raw)
+		}
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	schema, err := CompileSchema(json.RawMessage(invoiceSchema))
+	if err != nil {
+		t.Fatalf("CompileSchema: %v", err)
+	}
+
+	doc, err := schema.Validate("{\n  \"total\": 10.5,\n  \"items\": [{\"price\": 10.5}]\n}")
+	if err != nil || string(doc) != `{"total":10.5,"items":[{"price":10.5}]}` {
+		t.Fatalf("respuesta válida: %s, err = %v", doc, err)
+	}
+
+	_, err = schema.Validate(`{"total": "diez", "items": [{"price": "caro"}]}`)
+	var schemaErr *SchemaValidationError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("respuesta inválida: error %v, se esperaba *SchemaValidationError", err)
+	}
+	paths := map[string]string{}
+	for _, v := range schemaErr.Violations {
+		paths[v.Path] = v.Keyword
+	}
+	if paths["/total"] != "type" || paths["/items/0/price"] != "type" || len(paths) != 2 {
+		t.Errorf("incumplimientos = %+v", schemaErr.Violations)
+	}
+
+	// El error guardado en la tarea usa el código schema_validation_failed con el reporte.
+	taskErr := TaskError(err)
+	if taskErr.Code != apierror.CodeSchemaValidationFailed || taskErr.Status != http.StatusUnprocessableEntity {
+		t.Errorf("TaskError = %d %s", taskErr.Status, taskErr.Code)
+	}
+	if details, _ := taskErr.Details.([]SchemaViolation); len(details) != 2 {
+		t.Errorf("details = %+v, se esperaban los 2 incumplimientos", taskErr.Details)
+	}
+
+	_, err = schema.Validate("El total es 10.5")
+	if !errors.Is(err, ErrInvalidJSONResponse) {
+		t.Fatalf("respuesta sin JSON: error %v, se esperaba ErrInvalidJSONResponse", err)
+	}
+	if taskErr := TaskError(err); taskErr.Code != apierror.CodeSchemaValidationFailed {
+		t.Errorf("TaskError de una respuesta sin JSON = %s", taskErr.Code)
+	}
+}
+
+func TestRegisteredSchemas(t *testing.T) {
+	if err := RegisterSchema("factura-prueba", json.RawMessage(invoiceSchema)); err != nil {
+		t.Fatalf("RegisterSchema: %v", err)
+	}
+	if _, ok := LookupSchema("factura-prueba"); !ok {
+		t.Error("LookupSchema no encontró el esquema registrado")
+	}
+	if err := RegisterSchema("roto", json.RawMessage(`{"type": 1}`)); err == nil {
+		t.Error("RegisterSchema aceptó un esquema inválido")
+	}
+	if _, ok := LookupSchema("roto"); ok {
+		t.Error("el esquema inválido quedó registrado")
+	}
+}
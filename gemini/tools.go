@@ -0,0 +1,243 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genai"
+)
+
+// defaultMaxToolSteps es el número máximo de rondas de llamadas a herramientas por tarea
+// si no se define GEMINI_MAX_TOOL_STEPS.
+const defaultMaxToolSteps = 5
+
+// ToolFunc es la función Go que ejecuta una herramienta. Recibe los argumentos ya validados
+// contra el esquema de parámetros y devuelve un objeto JSON que se envía de vuelta al modelo.
+type ToolFunc func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error)
+
+// Tool es una herramienta que Gemini puede llamar durante la generación.
+type Tool struct {
+	Name        string
+	Description string
+	// Parameters es el JSON Schema de los argumentos; debe describir un objeto.
+	Parameters json.RawMessage
+	Func       ToolFunc
+
+	params *ResponseSchema
+}
+
+// ToolCall es el registro de una llamada a herramienta hecha durante una generación.
+type ToolCall struct {
+	Step       int                    `json:"step" example:"1"`
+	Name       string                 `json:"name" example:"get_user"`
+	Args       map[string]interface{} `json:"args,omitempty" swaggertype:"object"`
+	Response   map[string]interface{} `json:"response,omitempty" swaggertype:"object"`
+	Error      string                 `json:"error,omitempty"`
+	DurationMS int64                  `json:"duration_ms" example:"12"`
+}
+
+var (
+	// ErrUnknownTool indica que se pidió una herramienta que no está registrada.
+	ErrUnknownTool = errors.New("herramienta no registrada")
+	// ErrMaxToolSteps indica que el modelo siguió pidiendo herramientas después del máximo de pasos.
+	ErrMaxToolSteps = errors.New("se superó el máximo de pasos con herramientas")
+)
+
+// ToolRegistry guarda las herramientas disponibles para las tareas.
+type ToolRegistry struct {
+	mu    sync.RWMutex
+	tools map[string]*Tool
+}
+
+// NewToolRegistry crea un registro de herramientas vacío.
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{tools: map[string]*Tool{}}
+}
+
+// DefaultTools es el registro de herramientas usado por las tareas.
+var DefaultTools = NewToolRegistry()
+
+// Register agrega una herramienta al registro después de compilar su esquema de parámetros.
+func (r *ToolRegistry) Register(tool Tool) error {
+	if tool.Name == "" || tool.Func == nil {
+		return errors.New("la herramienta necesita nombre y función")
+	}
+	params := tool.Parameters
+	if len(params) == 0 {
+		params = json.RawMessage(`{"type":"object","properties":{}}`)
+	}
+	schema, err := CompileSchema(params)
+	if err != nil {
+		return fmt.Errorf("parámetros de la herramienta %q inválidos: %w", tool.Name, err)
+	}
+	tool.params = schema
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tools[tool.Name] = &tool
+	return nil
+}
+
+// Lookup devuelve la herramienta registrada con ese nombre.
+func (r *ToolRegistry) Lookup(name string) (*Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tool, ok := r.tools[name]
+	return tool, ok
+}
+
+// Names devuelve los nombres de las herramientas registradas en orden alfabético.
+func (r *ToolRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.tools))
+	for name := range r.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Select devuelve las herramientas con los nombres indicados, o ErrUnknownTool si alguna no existe.
+func (r *ToolRegistry) Select(names []string) ([]*Tool, error) {
+	tools := make([]*Tool, 0, len(names))
+	for _, name := range names {
+		tool, ok := r.Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownTool, name)
+		}
+		tools = append(tools, tool)
+	}
+	return tools, nil
+}
+
+// ParametersSchema devuelve el JSON Schema compilado de los argumentos de la herramienta.
+func (t *Tool) ParametersSchema() json.RawMessage {
+	return t.params.Raw
+}
+
+// toolConfig convierte las herramientas en la declaración que se envía a Gemini.
+func toolConfig(tools []*Tool) []*genai.Tool {
+	decls := make([]*genai.FunctionDeclaration, 0, len(tools))
+	for _, tool := range tools {
+		decls = append(decls, &genai.FunctionDeclaration{
+			Name:                 tool.Name,
+			Description:          tool.Description,
+			ParametersJsonSchema: tool.params.doc,
+		})
+	}
+	return []*genai.Tool{{FunctionDeclarations: decls}}
+}
+
+// maxToolSteps devuelve el máximo de rondas con herramientas configurado en GEMINI_MAX_TOOL_STEPS.
+func maxToolSteps() int {
+	if v, err := strconv.Atoi(os.Getenv("GEMINI_MAX_TOOL_STEPS")); err == nil && v > 0 {
+		return v
+	}
+	return defaultMaxToolSteps
+}
+
+// call valida los argumentos y ejecuta la herramienta dentro de su propio span.
+// Los errores de la herramienta se devuelven al modelo como respuesta para que pueda corregirse.
+func (t *Tool) call(ctx context.Context, step int, fc *genai.FunctionCall) ToolCall {
+	ctx, span := telemetry.Tracer().Start(ctx, "gemini.tool."+t.Name, trace.WithAttributes(
+		attribute.String("gemini.tool.name", t.Name),
+		attribute.Int("gemini.tool.step", step),
+	))
+	start := time.Now()
+	record := ToolCall{Step: step, Name: t.Name, Args: fc.Args}
+
+	response, err := t.run(ctx, fc.Args)
+	if err != nil {
+		record.Error = err.Error()
+	} else {
+		record.Response = response
+	}
+	record.DurationMS = time.Since(start).Milliseconds()
+	endSpan(span, err)
+	return record
+}
+
+func (t *Tool) run(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := t.params.Validate(string(encoded)); err != nil {
+		return nil, fmt.Errorf("argumentos inválidos: %w", err)
+	}
+	return t.Func(ctx, args)
+}
+
+// functionResponse arma la parte que devuelve al modelo el resultado de una llamada.
+func (c ToolCall) functionResponse(id string) genai.Part {
+	response := c.Response
+	if c.Error != "" {
+		response = map[string]interface{}{"error": c.Error}
+	}
+	return genai.Part{FunctionResponse: &genai.FunctionResponse{ID: id, Name: c.Name, Response: response}}
+}
+
+// sendWithTools envía el mensaje y ejecuta el ciclo de llamadas a herramientas:
+// modelo → llamada → resultado → modelo, hasta que el modelo responde sin pedir
+// herramientas o se alcanza el máximo de pasos. El consumo de todas las rondas se acumula.
+// Si redact no es nil, los resultados de las herramientas pasan por él antes de enviarse.
+func sendWithTools(ctx context.Context, chat *genai.Chat, tools []*Tool, redact func(map[string]interface{}) map[string]interface{}, parts ...genai.Part) (*genai.GenerateContentResponse, Usage, []ToolCall, error) {
+	usage := Usage{Model: DefaultModel}
+	var calls []ToolCall
+
+	res, err := sendMessage(ctx, chat, parts...)
+	if err != nil {
+		return nil, usage, calls, err
+	}
+	usage.Add(usageOf(DefaultModel, res))
+
+	byName := make(map[string]*Tool, len(tools))
+	for _, tool := range tools {
+		byName[tool.Name] = tool
+	}
+
+	limit := maxToolSteps()
+	for step := 1; ; step++ {
+		requested := res.FunctionCalls()
+		if len(requested) == 0 {
+			return res, usage, calls, nil
+		}
+		if step > limit {
+			return res, usage, calls, fmt.Errorf("%w (%d)", ErrMaxToolSteps, limit)
+		}
+
+		replies := make([]genai.Part, 0, len(requested))
+		for _, fc := range requested {
+			var record ToolCall
+			if tool, ok := byName[fc.Name]; ok {
+				record = tool.call(ctx, step, fc)
+				if redact != nil && record.Response != nil {
+					record.Response = redact(record.Response)
+				}
+			} else {
+				record = ToolCall{Step: step, Name: fc.Name, Args: fc.Args, Error: ErrUnknownTool.Error()}
+			}
+			calls = append(calls, record)
+			replies = append(replies, record.functionResponse(fc.ID))
+		}
+
+		if res, err = sendMessage(ctx, chat, replies...); err != nil {
+			return nil, usage, calls, err
+		}
+		usage.Add(usageOf(DefaultModel, res))
+	}
+}
@@ -0,0 +1,43 @@
+package gemini
+
+import (
+	"encoding/json"
+
+	"google.golang.org/genai"
+)
+
+// Result es la respuesta de Gemini junto con el consumo de tokens de la llamada.
+type Result struct {
+	Text string
+	// JSON es la respuesta ya validada cuando se pidió un esquema de respuesta.
+	JSON  json.RawMessage
+	Usage Usage
+	// ToolCalls son las llamadas a herramientas hechas durante la generación, en orden.
+	ToolCalls []ToolCall
+}
+
+// Usage resume los tokens consumidos por una llamada a Gemini.
+type Usage struct {
+	Model           string
+	PromptTokens    int32
+	CandidateTokens int32
+	TotalTokens     int32
+}
+
+// usageOf obtiene el consumo de tokens de una respuesta del SDK.
+func usageOf(modelName string, res *genai.GenerateContentResponse) Usage {
+	usage := Usage{Model: modelName}
+	if md := res.UsageMetadata; md != nil {
+		usage.PromptTokens = md.PromptTokenCount
+		usage.CandidateTokens = md.CandidatesTokenCount
+		usage.TotalTokens = md.TotalTokenCount
+	}
+	return usage
+}
+
+// Add acumula el consumo de otra llamada (por ejemplo, en conversaciones de varios pasos).
+func (u *Usage) Add(other Usage) {
+	u.PromptTokens += other.PromptTokens
+	u.CandidateTokens += other.CandidateTokens
+	u.TotalTokens += other.TotalTokens
+}
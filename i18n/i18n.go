@@ -0,0 +1,90 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/text/language"
+)
+
+// Lang es un idioma soportado por la API.
+type Lang string
+
+const (
+	// Spanish es el idioma por defecto de la API.
+	Spanish Lang = "es"
+	// English se usa cuando el cliente lo prefiere en Accept-Language.
+	English Lang = "en"
+)
+
+// Default es el idioma usado si Accept-Language no coincide con ninguno soportado.
+const Default = Spanish
+
+// Key identifica un mensaje del catálogo.
+type Key string
+
+type contextKey struct{}
+
+// supported conserva el orden de preferencia del servidor; el primero es el idioma por defecto.
+var supported = []language.Tag{language.Spanish, language.English}
+
+var matcher = language.NewMatcher(supported)
+
+// Parse elige el idioma a partir de la cabecera Accept-Language.
+func Parse(acceptLanguage string) Lang {
+	if acceptLanguage == "" {
+		return Default
+	}
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Default
+	}
+	base, _ := supported[index].Base()
+	return Lang(base.String())
+}
+
+// WithLang guarda el idioma de la petición en el contexto.
+func WithLang(ctx context.Context, lang Lang) context.Context {
+	return context.WithValue(ctx, contextKey{}, lang)
+}
+
+// FromContext devuelve el idioma guardado en el contexto, o Default.
+func FromContext(ctx context.Context) Lang {
+	if lang, ok := ctx.Value(contextKey{}).(Lang); ok {
+		return lang
+	}
+	return Default
+}
+
+// Lookup devuelve el mensaje de la clave en el idioma indicado, con Default como respaldo.
+func Lookup(lang Lang, key Key, args ...interface{}) (string, bool) {
+	translations, ok := catalog[key]
+	if !ok {
+		return "", false
+	}
+	message, ok := translations[lang]
+	if !ok {
+		message = translations[Default]
+	}
+	if len(args) > 0 {
+		message = fmt.Sprintf(message, args...)
+	}
+	return message, true
+}
+
+// T traduce la clave al idioma indicado. Si la clave no está en el catálogo se devuelve tal cual.
+func T(lang Lang, key Key, args ...interface{}) string {
+	if message, ok := Lookup(lang, key, args...); ok {
+		return message
+	}
+	return string(key)
+}
+
+// Tc traduce la clave al idioma de la petición guardado en el contexto.
+func Tc(ctx context.Context, key Key, args ...interface{}) string {
+	return T(FromContext(ctx), key, args...)
+}
@@ -0,0 +1,51 @@
+package i18n
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Lang
+	}{
+		{"", Spanish},
+		{"en", English},
+		{"en-US,en;q=0.9", English},
+		{"es-MX", Spanish},
+		{"fr-FR,en;q=0.5", English},
+		{"fr-FR", Spanish},
+		{"en;q=0.2,es;q=0.8", Spanish},
+		{";;inválido", Spanish},
+	}
+	for _, tt := range tests {
+		if got := Parse(tt.header); got != tt.want {
+			t.Errorf("Parse(%q) = %s, se esperaba %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCatalogHasEveryLanguage(t *testing.T) {
+	for key, translations := range catalog {
+		for _, lang := range []Lang{Spanish, English} {
+			if translations[lang] == "" {
+				t.Errorf("%s no tiene mensaje en %s", key, lang)
+			}
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	if got := T(English, ErrTaskNotFound); got != "Task ID not found" {
+		t.Errorf("T(en) = %q", got)
+	}
+	// Un idioma sin traducción usa el idioma por defecto.
+	if got := T(Lang("fr"), ErrTaskNotFound); got != "ID de proceso no encontrado" {
+		t.Errorf("T(fr) = %q, se esperaba el mensaje en español", got)
+	}
+	// Una clave que no está en el catálogo se devuelve tal cual.
+	if got, ok := Lookup(English, Key("error.no_existe")); ok || got != "" {
+		t.Errorf("Lookup de una clave inexistente = %q, %v", got, ok)
+	}
+	if got := T(English, Key("error.no_existe")); got != "error.no_existe" {
+		t.Errorf("T de una clave inexistente = %q", got)
+	}
+}
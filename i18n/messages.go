@@ -0,0 +1,237 @@
+package i18n
+
+// Claves de los mensajes de error. Las de la forma "error.<código>" corresponden al
+// mensaje por defecto de cada código de apierror.
+const (
+	ErrInvalidRequest        Key = "error.invalid_request"
+	ErrValidationFailed      Key = "error.validation_failed"
+	ErrInternal              Key = "error.internal_error"
+	ErrDatabase              Key = "error.database"
+	ErrInvalidID             Key = "error.invalid_id"
+	ErrInvalidUserHeader     Key = "error.invalid_user_header"
+	ErrUserHeaderNotFound    Key = "error.user_header_not_found"
+	ErrUserHeaderDisabled    Key = "error.user_header_disabled"
+	ErrInvalidCredentials    Key = "error.invalid_credentials"
+	ErrUserNotFound          Key = "error.user_not_found"
+	ErrTaskNotFound          Key = "error.task_not_found"
+	ErrTaskLegalHold         Key = "error.task_legal_hold"
+	ErrEmailTaken            Key = "error.email_taken"
+	ErrUserCreateFailed      Key = "error.user_create_failed"
+	ErrTaskCreateFailed      Key = "error.task_create_failed"
+	ErrPromptRequired        Key = "error.prompt_required"
+	ErrFileRequired          Key = "error.file_required"
+	ErrFileOpenFailed        Key = "error.file_open_failed"
+	ErrFileReadFailed        Key = "error.file_read_failed"
+	ErrUnsupportedFile       Key = "error.unsupported_file_type"
+	ErrInvalidParam          Key = "error.invalid_param"
+	ErrFromBeforeTo          Key = "error.from_before_to"
+	ErrInvalidGroupBy        Key = "error.invalid_group_by"
+	ErrRateLimited           Key = "error.rate_limited"
+	ErrQuotaExceeded         Key = "error.quota_exceeded"
+	ErrServerBusy            Key = "error.server_busy"
+	ErrInvalidSchema         Key = "error.invalid_schema"
+	ErrSchemaNotFound        Key = "error.schema_not_found"
+	ErrSchemaConflict        Key = "error.schema_conflict"
+	ErrToolNotFound          Key = "error.tool_not_found"
+	ErrToolsWithSchema       Key = "error.tools_with_schema"
+	ErrEmbeddingInput        Key = "error.embedding_input"
+	ErrCollectionNotFound    Key = "error.collection_not_found"
+	ErrCollectionTaken       Key = "error.collection_name_taken"
+	ErrCollectionEmpty       Key = "error.collection_empty"
+	ErrDocumentNotFound      Key = "error.document_not_found"
+	ErrTemplateNotFound      Key = "error.template_not_found"
+	ErrTemplateTaken         Key = "error.template_name_taken"
+	ErrInvalidTemplate       Key = "error.invalid_template"
+	ErrTemplateRender        Key = "error.template_render_failed"
+	ErrTemplateConflict      Key = "error.template_conflict"
+	ErrTemplateRequired      Key = "error.template_required"
+	ErrBatchNotFound         Key = "error.batch_not_found"
+	ErrBatchNotFinished      Key = "error.batch_not_finished"
+	ErrBatchTooLarge         Key = "error.batch_too_large"
+	ErrInvalidCSV            Key = "error.invalid_csv"
+	ErrUnauthorized          Key = "error.unauthorized"
+	ErrForbidden             Key = "error.forbidden"
+	ErrOwnAdminRole          Key = "error.own_admin_role"
+	ErrRoleNotAllowed        Key = "error.role_not_allowed"
+	ErrInvalidAPIKey         Key = "error.invalid_api_key"
+	ErrAPIKeyNotFound        Key = "error.api_key_not_found"
+	ErrAPIKeyRevoked         Key = "error.api_key_revoked"
+	ErrExpiresInPast         Key = "error.expires_in_past"
+	ErrInvalidToken          Key = "error.invalid_token"
+	ErrEmailNotVerified      Key = "error.email_not_verified"
+	ErrOIDCNotConfigured     Key = "error.oidc_not_configured"
+	ErrOIDCLoginFailed       Key = "error.oidc_login_failed"
+	ErrOIDCState             Key = "error.oidc_state"
+	ErrOIDCEmailMissing      Key = "error.oidc_email_missing"
+	ErrOIDCEmailUnverified   Key = "error.oidc_email_unverified"
+	ErrOIDCAccountUnverified Key = "error.oidc_account_unverified"
+	ErrUserDeleted           Key = "error.user_deleted"
+	ErrInvalidOrgHeader      Key = "error.invalid_org_header"
+	ErrOrgNotFound           Key = "error.org_not_found"
+	ErrNotOrgMember          Key = "error.not_org_member"
+	ErrNoOrganization        Key = "error.no_organization"
+	ErrOrgKeyMismatch        Key = "error.org_key_mismatch"
+	ErrOrgSlugTaken          Key = "error.org_slug_taken"
+	ErrInvalidOrgSlug        Key = "error.invalid_org_slug"
+	ErrOrgMemberNotFound     Key = "error.org_member_not_found"
+	ErrOrgMemberExists       Key = "error.org_member_exists"
+	ErrOrgLastOwner          Key = "error.org_last_owner"
+
+	ErrSchemaValidationFailed Key = "error.schema_validation_failed"
+	ErrInvalidJSONResponse    Key = "error.invalid_json_response"
+	ErrGeminiToolSteps        Key = "error.gemini_tool_steps_exceeded"
+	ErrDocumentExtraction     Key = "error.document_extraction_failed"
+	ErrDocumentEmpty          Key = "error.document_empty"
+
+	ErrGeminiNotConfigured    Key = "error.gemini_not_configured"
+	ErrGeminiTimeout          Key = "error.gemini_timeout"
+	ErrGeminiInvalidRequest   Key = "error.gemini_invalid_request"
+	ErrGeminiPermissionDenied Key = "error.gemini_permission_denied"
+	ErrGeminiRateLimited      Key = "error.gemini_rate_limited"
+	ErrGeminiUnavailable      Key = "error.gemini_unavailable"
+	ErrGeminiUploadFailed     Key = "error.gemini_upload_failed"
+	ErrGeminiError            Key = "error.gemini_error"
+)
+
+// Claves de las etiquetas de estado de las tareas.
+const (
+	StatusPending    Key = "status.pending"
+	StatusProcessing Key = "status.processing"
+	StatusCompleted  Key = "status.completed"
+	StatusError      Key = "status.error"
+)
+
+// Claves de los correos y de los mensajes que no son errores.
+const (
+	MailVerifySubject Key = "mail.verify_subject"
+	MailVerifyBody    Key = "mail.verify_body"
+	MailResetSubject  Key = "mail.reset_subject"
+	MailResetBody     Key = "mail.reset_body"
+	MsgEmailSent      Key = "message.email_sent"
+)
+
+// catalog contiene todos los mensajes de la API en cada idioma soportado.
+var catalog = map[Key]map[Lang]string{
+	ErrInvalidRequest:     {Spanish: "JSON de solicitud inválido", English: "Invalid request JSON"},
+	ErrValidationFailed:   {Spanish: "Datos de entrada inválidos", English: "Invalid input data"},
+	ErrInternal:           {Spanish: "Error interno del servidor", English: "Internal server error"},
+	ErrDatabase:           {Spanish: "Error en la base de datos", English: "Database error"},
+	ErrInvalidID:          {Spanish: "ID inválido", English: "Invalid ID"},
+	ErrInvalidUserHeader:  {Spanish: "X-User-ID inválido", English: "Invalid X-User-ID"},
+	ErrUserHeaderNotFound: {Spanish: "El usuario de X-User-ID no existe", English: "The X-User-ID user does not exist"},
+	ErrUserHeaderDisabled: {Spanish: "X-User-ID no autentica al usuario; inicie sesión y use una API key", English: "X-User-ID does not authenticate the user; log in and use an API key"},
+	ErrInvalidCredentials: {Spanish: "Email o contraseña incorrectos", English: "Incorrect email or password"},
+	ErrUserNotFound:       {Spanish: "Usuario no encontrado", English: "User not found"},
+	ErrTaskNotFound:       {Spanish: "ID de proceso no encontrado", English: "Task ID not found"},
+	ErrTaskLegalHold:      {Spanish: "La tarea tiene retención legal y no se puede eliminar", English: "The task is under legal hold and cannot be deleted"},
+	ErrServerBusy:         {Spanish: "El servidor está ocupado, intenta de nuevo en unos segundos", English: "The server is busy, try again in a few seconds"},
+	ErrEmailTaken:         {Spanish: "Ya existe un usuario con ese email", English: "A user with that email already exists"},
+	ErrUserCreateFailed:   {Spanish: "No se pudo crear el usuario", English: "Could not create the user"},
+	ErrTaskCreateFailed:   {Spanish: "No se pudo crear la tarea", English: "Could not create the task"},
+	ErrPromptRequired:     {Spanish: "El prompt es obligatorio", English: "The prompt is required"},
+	ErrFileRequired:       {Spanish: "Se requiere un archivo", English: "A file is required"},
+	ErrFileOpenFailed:     {Spanish: "No se pudo abrir el archivo", English: "Could not open the file"},
+	ErrFileReadFailed:     {Spanish: "No se pudo leer el archivo", English: "Could not read the file"},
+	ErrUnsupportedFile:    {Spanish: "Tipo de archivo no soportado: %s", English: "Unsupported file type: %s"},
+	ErrInvalidParam:       {Spanish: "Parámetro '%s' inválido", English: "Invalid '%s' parameter"},
+	ErrFromBeforeTo:       {Spanish: "'from' debe ser anterior a 'to'", English: "'from' must be before 'to'"},
+	ErrInvalidGroupBy:     {Spanish: "group_by debe ser 'day' o 'model'", English: "group_by must be 'day' or 'model'"},
+	ErrRateLimited:        {Spanish: "Demasiadas peticiones, intenta más tarde", English: "Too many requests, try again later"},
+	ErrQuotaExceeded:      {Spanish: "Cuota de uso agotada", English: "Usage quota exhausted"},
+	ErrInvalidSchema:      {Spanish: "El esquema de respuesta no es un JSON Schema válido", English: "The response schema is not a valid JSON Schema"},
+	ErrSchemaNotFound:     {Spanish: "No existe el esquema de respuesta '%s'", English: "Response schema '%s' does not exist"},
+	ErrSchemaConflict:     {Spanish: "Indica 'schema' o 'response_schema', no ambos", English: "Provide either 'schema' or 'response_schema', not both"},
+	ErrToolNotFound:       {Spanish: "Herramienta no registrada", English: "Tool not registered"},
+	ErrToolsWithSchema:    {Spanish: "No se pueden usar herramientas junto con un esquema de respuesta", English: "Tools cannot be combined with a response schema"},
+	ErrEmbeddingInput:     {Spanish: "Indica 'text' o 'items', no ambos", English: "Provide either 'text' or 'items', not both"},
+	ErrCollectionNotFound: {Spanish: "Colección no encontrada", English: "Collection not found"},
+	ErrCollectionTaken:    {Spanish: "Ya existe una colección con ese nombre", English: "A collection with that name already exists"},
+	ErrCollectionEmpty:    {Spanish: "La colección no tiene documentos indexados", English: "The collection has no indexed documents"},
+	ErrDocumentNotFound:   {Spanish: "Documento no encontrado", English: "Document not found"},
+	ErrTemplateNotFound:   {Spanish: "Plantilla de prompt no encontrada", English: "Prompt template not found"},
+	ErrTemplateTaken:      {Spanish: "Ya existe una plantilla con ese nombre", English: "A template with that name already exists"},
+	ErrInvalidTemplate:    {Spanish: "La plantilla no tiene una sintaxis válida", English: "The template syntax is invalid"},
+	ErrTemplateRender:     {Spanish: "No se pudo generar el prompt con las variables enviadas", English: "Could not render the prompt with the given variables"},
+	ErrTemplateConflict:   {Spanish: "Indica 'prompt' o 'template', no ambos", English: "Provide either 'prompt' or 'template', not both"},
+	ErrTemplateRequired:   {Spanish: "Se requiere una plantilla", English: "A template is required"},
+	ErrBatchNotFound:      {Spanish: "Lote no encontrado", English: "Batch not found"},
+	ErrBatchNotFinished:   {Spanish: "El lote aún tiene tareas sin terminar", English: "The batch still has unfinished tasks"},
+	ErrBatchTooLarge:      {Spanish: "El lote supera el máximo de %d tareas", English: "The batch exceeds the maximum of %d tasks"},
+	ErrInvalidCSV:         {Spanish: "No se pudo leer el archivo CSV", English: "Could not read the CSV file"},
+	ErrUnauthorized:       {Spanish: "Debe identificarse con una API key", English: "You must authenticate with an API key"},
+	ErrForbidden:          {Spanish: "No tiene permiso para esta operación", English: "You do not have permission for this operation"},
+	ErrOwnAdminRole:       {Spanish: "No puede quitarse su propio rol de administrador", English: "You cannot remove your own admin role"},
+	ErrInvalidAPIKey:      {Spanish: "API key inválida, revocada o vencida", English: "Invalid, revoked or expired API key"},
+	ErrAPIKeyNotFound:     {Spanish: "API key no encontrada", English: "API key not found"},
+	ErrAPIKeyRevoked:      {Spanish: "La API key está revocada", English: "The API key is revoked"},
+	ErrInvalidToken:       {Spanish: "El enlace no es válido, ya se usó o venció", English: "The link is invalid, already used or expired"},
+	ErrEmailNotVerified:   {Spanish: "Debe confirmar su email antes de usar la API", English: "You must verify your email before using the API"},
+	ErrOIDCNotConfigured:  {Spanish: "El inicio de sesión con OIDC no está configurado", English: "OIDC sign-in is not configured"},
+	ErrOIDCLoginFailed:    {Spanish: "No se pudo iniciar sesión con el proveedor de identidad", English: "Could not sign in with the identity provider"},
+	ErrOIDCState:          {Spanish: "El inicio de sesión no es válido o venció; vuelva a intentarlo", English: "The sign-in is invalid or expired; try again"},
+	ErrOIDCEmailMissing:   {Spanish: "El proveedor de identidad no informó el email del usuario", English: "The identity provider did not return the user's email"},
+	ErrOIDCEmailUnverified: {
+		Spanish: "El proveedor de identidad no verificó el email; no se puede vincular con la cuenta existente",
+		English: "The identity provider did not verify the email; it cannot be linked to the existing account",
+	},
+	ErrOIDCAccountUnverified: {
+		Spanish: "Ya existe una cuenta con este email sin confirmar; confirme el email o restablezca la contraseña antes de iniciar sesión con el proveedor de identidad",
+		English: "An account with this email exists but is not verified; verify the email or reset the password before signing in with the identity provider",
+	},
+	ErrUserDeleted:      {Spanish: "El usuario está eliminado", English: "The user is deleted"},
+	ErrInvalidOrgHeader: {Spanish: "El header X-Org-ID debe ser un ID numérico", English: "The X-Org-ID header must be a numeric ID"},
+	ErrOrgNotFound:      {Spanish: "Organización no encontrada", English: "Organization not found"},
+	ErrNotOrgMember:     {Spanish: "No es miembro de la organización", English: "You are not a member of the organization"},
+	ErrNoOrganization:   {Spanish: "El usuario no pertenece a ninguna organización", English: "The user does not belong to any organization"},
+	ErrOrgKeyMismatch:   {Spanish: "La API key pertenece a otra organización", English: "The API key belongs to another organization"},
+	ErrOrgSlugTaken:     {Spanish: "Ya existe una organización con ese slug", English: "An organization with that slug already exists"},
+	ErrInvalidOrgSlug: {
+		Spanish: "El slug solo puede tener minúsculas, números y guiones entre palabras",
+		English: "The slug may only contain lowercase letters, digits and hyphens between words",
+	},
+	ErrOrgMemberNotFound: {Spanish: "El usuario no es miembro de la organización", English: "The user is not a member of the organization"},
+	ErrOrgMemberExists:   {Spanish: "El usuario ya es miembro de la organización", English: "The user is already a member of the organization"},
+	ErrOrgLastOwner:      {Spanish: "La organización debe tener al menos un owner", English: "The organization must have at least one owner"},
+	ErrExpiresInPast:     {Spanish: "La fecha de vencimiento debe ser futura", English: "The expiration date must be in the future"},
+	ErrRoleNotAllowed:    {Spanish: "Solo un administrador puede asignar el rol '%s'", English: "Only an admin can assign the '%s' role"},
+
+	ErrSchemaValidationFailed: {Spanish: "La respuesta de Gemini no cumple el esquema", English: "The Gemini response does not match the schema"},
+	ErrInvalidJSONResponse:    {Spanish: "La respuesta de Gemini no es JSON válido", English: "The Gemini response is not valid JSON"},
+	ErrGeminiToolSteps:        {Spanish: "Gemini superó el máximo de pasos con herramientas", English: "Gemini exceeded the maximum number of tool steps"},
+	ErrDocumentExtraction:     {Spanish: "No se pudo leer el texto del documento", English: "Could not read the document text"},
+	ErrDocumentEmpty:          {Spanish: "El documento no contiene texto extraíble", English: "The document has no extractable text"},
+
+	ErrGeminiNotConfigured:    {Spanish: "La API de Gemini no está configurada", English: "The Gemini API is not configured"},
+	ErrGeminiTimeout:          {Spanish: "Gemini no respondió a tiempo", English: "Gemini did not respond in time"},
+	ErrGeminiInvalidRequest:   {Spanish: "Gemini rechazó la solicitud", English: "Gemini rejected the request"},
+	ErrGeminiPermissionDenied: {Spanish: "La credencial de Gemini no es válida o no tiene permisos", English: "The Gemini credential is invalid or lacks permissions"},
+	ErrGeminiRateLimited:      {Spanish: "Se agotó la cuota de la API de Gemini", English: "The Gemini API quota is exhausted"},
+	ErrGeminiUnavailable:      {Spanish: "La API de Gemini no está disponible", English: "The Gemini API is unavailable"},
+	ErrGeminiUploadFailed:     {Spanish: "No se pudo subir el archivo a Gemini", English: "Could not upload the file to Gemini"},
+	ErrGeminiError:            {Spanish: "Error al generar contenido con Gemini", English: "Error generating content with Gemini"},
+
+	MailVerifySubject: {Spanish: "Confirma tu email", English: "Verify your email"},
+	MailVerifyBody: {
+		Spanish: "Hola %s,\n\nPara confirmar tu email abre este enlace:\n\n%s\n\nEl enlace vence en %s. Si no creaste una cuenta, ignora este correo.",
+		English: "Hi %s,\n\nTo verify your email open this link:\n\n%s\n\nThe link expires in %s. If you did not create an account, ignore this email.",
+	},
+	MailResetSubject: {Spanish: "Recupera tu contraseña", English: "Reset your password"},
+	MailResetBody: {
+		Spanish: "Hola %s,\n\nPara elegir una contraseña nueva abre este enlace:\n\n%s\n\nEl enlace vence en %s y solo se puede usar una vez. Si no lo pediste, ignora este correo.",
+		English: "Hi %s,\n\nTo choose a new password open this link:\n\n%s\n\nThe link expires in %s and can only be used once. If you did not request it, ignore this email.",
+	},
+	MsgEmailSent: {
+		Spanish: "Si el email está registrado, recibirás un correo con instrucciones",
+		English: "If the email is registered, you will receive an email with instructions",
+	},
+
+	StatusPending:    {Spanish: "pendiente", English: "pending"},
+	StatusProcessing: {Spanish: "en proceso", English: "processing"},
+	StatusCompleted:  {Spanish: "finalizado", English: "completed"},
+	StatusError:      {Spanish: "error", English: "error"},
+}
+
+// CodeKey devuelve la clave del mensaje por defecto de un código de error.
+func CodeKey(code string) Key {
+	return Key("error." + code)
+}
@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey string
+
+const (
+	requestIDKey contextKey = "request_id"
+	taskIDKey    contextKey = "task_id"
+)
+
+// logPrompts indica si el texto de los prompts puede aparecer en los logs (LOG_PROMPTS=true).
+var logPrompts bool
+
+// Setup configura slog con salida JSON como logger por defecto. El nivel se toma de
+// LOG_LEVEL (debug, info, warn, error); por defecto es info.
+func Setup() {
+	logPrompts = os.Getenv("LOG_PROMPTS") == "true"
+
+	level := slog.LevelInfo
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		if err := level.UnmarshalText([]byte(v)); err != nil {
+			fmt.Fprintf(os.Stderr, "LOG_LEVEL inválido %q, se usa info\n", v)
+		}
+	}
+
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})
+	slog.SetDefault(slog.New(contextHandler{Handler: handler}))
+}
+
+// contextHandler agrega a cada registro los identificadores de correlación del contexto.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if id, ok := ctx.Value(taskIDKey).(string); ok && id != "" {
+		r.AddAttrs(slog.String("task_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// WithRequestID guarda el ID de la petición en el contexto.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID devuelve el ID de la petición guardado en el contexto, o "" si no existe.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithTaskID guarda el ID de la tarea en el contexto para que aparezca en cada línea del worker.
+func WithTaskID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, taskIDKey, id)
+}
+
+// Prompt devuelve el atributo de log para un prompt. Por defecto solo se registra su
+// longitud; el texto completo se incluye únicamente con LOG_PROMPTS=true.
+func Prompt(prompt string) slog.Attr {
+	if logPrompts {
+		return slog.String("prompt", prompt)
+	}
+	return slog.String("prompt", fmt.Sprintf("[REDACTED len=%d]", len([]rune(prompt))))
+}
@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestRecordsCarryRequestAndTaskIDs(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(contextHandler{Handler: slog.NewJSONHandler(&out, nil)})
+
+	ctx := WithTaskID(WithRequestID(context.Background(), "req-1"), "task-1")
+	logger.InfoContext(ctx, "tarea procesada")
+	var record map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("la línea no es JSON: %v: %s", err, out.String())
+	}
+	if record["request_id"] != "req-1" || record["task_id"] != "task-1" {
+		t.Errorf("request_id=%v task_id=%v, se esperaban req-1 y task-1", record["request_id"], record["task_id"])
+	}
+
+	// Sin identificadores en el contexto no se agregan atributos vacíos.
+	out.Reset()
+	logger.InfoContext(context.Background(), "sin petición")
+	record = nil
+	json.Unmarshal(out.Bytes(), &record)
+	if _, ok := record["request_id"]; ok {
+		t.Errorf("registro sin petición con request_id: %s", out.String())
+	}
+}
+
+func TestPromptsAreRedactedByDefault(t *testing.T) {
+	t.Cleanup(func() { logPrompts = false })
+
+	if got := Prompt("mi contraseña es ñandú").Value.String(); got != "[REDACTED len=22]" {
+		t.Errorf("Prompt = %q, se esperaba solo la longitud", got)
+	}
+	logPrompts = true
+	if got := Prompt("hola").Value.String(); got != "hola" {
+		t.Errorf("Prompt con LOG_PROMPTS=true = %q, se esperaba el texto", got)
+	}
+}
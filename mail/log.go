@@ -0,0 +1,44 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogMailer registra los correos en los logs en lugar de enviarlos. Incluye el cuerpo, con
+// los enlaces de verificación o recuperación, por lo que solo debe usarse en desarrollo.
+type LogMailer struct{}
+
+// Send registra el correo.
+func (LogMailer) Send(ctx context.Context, msg Message) error {
+	slog.InfoContext(ctx, "correo (no enviado)", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
+
+// FileMailer agrega los correos a un archivo en lugar de enviarlos, para revisarlos en
+// pruebas locales.
+type FileMailer struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// Send agrega el correo al final del archivo.
+func (m *FileMailer) Send(_ context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(f, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n----\n",
+		time.Now().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
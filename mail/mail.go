@@ -0,0 +1,87 @@
+// Package mail envía los correos de la API (verificación de email y recuperación de
+// contraseña). El transporte se elige con MAILER: smtp para producción, y log o file para
+// desarrollo, donde los correos se registran en lugar de enviarse.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Message es un correo de texto plano.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer envía correos.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New crea el Mailer configurado en MAILER:
+//   - "log" (por defecto): registra cada correo en los logs.
+//   - "file": agrega cada correo al archivo MAILER_FILE (por defecto mail.log).
+//   - "smtp": envía por SMTP_HOST:SMTP_PORT (por defecto 587) con SMTP_USERNAME y
+//     SMTP_PASSWORD, desde MAIL_FROM.
+func New() (Mailer, error) {
+	switch transport := os.Getenv("MAILER"); transport {
+	case "", "log":
+		return LogMailer{}, nil
+	case "file":
+		path := os.Getenv("MAILER_FILE")
+		if path == "" {
+			path = "mail.log"
+		}
+		return &FileMailer{Path: path}, nil
+	case "smtp":
+		host := os.Getenv("SMTP_HOST")
+		from := os.Getenv("MAIL_FROM")
+		if host == "" || from == "" {
+			return nil, fmt.Errorf("MAILER=smtp requiere SMTP_HOST y MAIL_FROM")
+		}
+		port := 587
+		if v := os.Getenv("SMTP_PORT"); v != "" {
+			p, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("SMTP_PORT inválido: %q", v)
+			}
+			port = p
+		}
+		return &SMTPMailer{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}, nil
+	default:
+		return nil, fmt.Errorf("MAILER no soportado: %s", transport)
+	}
+}
+
+// Valores por defecto de la cola de correos.
+const (
+	defaultWorkers   = 2
+	defaultQueueSize = 100
+)
+
+// Workers devuelve cuántos correos se envían a la vez, configurado en MAIL_WORKERS.
+func Workers() int {
+	if v, err := strconv.Atoi(os.Getenv("MAIL_WORKERS")); err == nil && v > 0 {
+		return v
+	}
+	return defaultWorkers
+}
+
+// QueueSize devuelve cuántos correos pueden esperar a ser enviados, configurado en
+// MAIL_QUEUE_SIZE. Con la cola llena los correos nuevos se descartan.
+func QueueSize() int {
+	if v, err := strconv.Atoi(os.Getenv("MAIL_QUEUE_SIZE")); err == nil && v >= 0 {
+		return v
+	}
+	return defaultQueueSize
+}
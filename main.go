@@ -1,48 +1,281 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"os"
 
+	"github.com/Efren-Garza-Z/go-api-gemini/audit"
+	"github.com/Efren-Garza-Z/go-api-gemini/auth"
+	"github.com/Efren-Garza-Z/go-api-gemini/cache"
 	"github.com/Efren-Garza-Z/go-api-gemini/controllers"
 	"github.com/Efren-Garza-Z/go-api-gemini/db"
-	_ "github.com/Efren-Garza-Z/go-api-gemini/docs" // Importa la documentación Swagger generada
+	legacydocs "github.com/Efren-Garza-Z/go-api-gemini/docs/legacy" // Documentación Swagger de la API sin versión
+	_ "github.com/Efren-Garza-Z/go-api-gemini/docs/v1"              // Documentación Swagger de /v1
+	"github.com/Efren-Garza-Z/go-api-gemini/encryption"
+	"github.com/Efren-Garza-Z/go-api-gemini/events"
+	"github.com/Efren-Garza-Z/go-api-gemini/gemini"
+	"github.com/Efren-Garza-Z/go-api-gemini/logging"
+	"github.com/Efren-Garza-Z/go-api-gemini/mail"
+	"github.com/Efren-Garza-Z/go-api-gemini/middleware"
 	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/pii"
+	"github.com/Efren-Garza-Z/go-api-gemini/ratelimit"
+	"github.com/Efren-Garza-Z/go-api-gemini/retention"
 	"github.com/Efren-Garza-Z/go-api-gemini/routes"
+	"github.com/Efren-Garza-Z/go-api-gemini/telemetry"
+	"github.com/Efren-Garza-Z/go-api-gemini/tenant"
+	"github.com/Efren-Garza-Z/go-api-gemini/tools"
+	"github.com/Efren-Garza-Z/go-api-gemini/worker"
 	"github.com/gin-gonic/gin"
+	"github.com/joho/godotenv"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
+//go:generate swag init --instanceName v1 --output docs/v1 --tags !legacy
+//go:generate swag init --instanceName legacy --output docs/legacy --tags legacy
+
 // @title API GEMINI
 // @version 1.0
 // @description API RESTful para gestión de usuarios
 // @host localhost:8080
 // @BasePath /
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key del usuario o de sesión (POST /v1/auth/login); también se acepta en Authorization: Bearer.
 func main() {
+	_ = godotenv.Load() // carga .env si existe
+
+	// Logs estructurados en JSON
+	logging.Setup()
+
+	// Trazas de OpenTelemetry (HTTP, base de datos y llamadas a Gemini)
+	shutdownTracing, err := telemetry.Setup(context.Background())
+	if err != nil {
+		slog.Error("Error al configurar OpenTelemetry", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Error("Error al cerrar OpenTelemetry", "error", err)
+		}
+	}()
 
 	// Conexión a PostgreSQL
 	db.Connect()
 
-	// Migración automática del modelo UserDB
-	if err := db.DB.AutoMigrate(&models.UserDB{}, &models.GeminiProcessingDB{}, &models.GeminiProcessingFileDB{}); err != nil {
-		log.Fatalf("Error al migrar modelo UserDB: %v", err)
+	// Clave maestra para cifrar los prompts, resultados y archivos de las tareas
+	keys, err := encryption.FromEnv()
+	if err != nil {
+		slog.Error("Error al cargar las claves de cifrado", "error", err)
+		os.Exit(1)
+	}
+	if keys == nil {
+		slog.Warn("ENCRYPTION_KEY_FILE no está definida: los prompts, resultados y archivos se guardan sin cifrar")
+	} else {
+		encryption.SetKeyProvider(keys)
+	}
+	if err := encryption.RegisterCallbacks(db.DB); err != nil {
+		slog.Error("Error al registrar el cifrado de columnas", "error", err)
+		os.Exit(1)
+	}
+
+	// Migración automática de los modelos; pgvector debe estar instalado antes de crear gemini.embeddings
+	if err := models.EnableVector(db.DB); err != nil {
+		slog.Error("Error al instalar la extensión pgvector", "error", err)
+		os.Exit(1)
+	}
+	if err := db.DB.AutoMigrate(&models.UserDB{}, &models.GeminiProcessingDB{}, &models.GeminiProcessingFileDB{}, &models.RateLimitBucketDB{}, &models.EmbeddingDB{},
+		&models.CollectionDB{}, &models.DocumentDB{}, &models.DocumentChunkDB{}, &models.PromptTemplateDB{}, &models.BatchDB{},
+		&models.ResponseCacheDB{}, &models.APIKeyDB{}, &models.UserTokenDB{},
+		&models.OIDCLoginDB{}, &models.UserIdentityDB{}, &models.OrganizationDB{}, &models.OrgMemberDB{},
+		&models.AuditEventDB{}); err != nil {
+		slog.Error("Error al migrar los modelos", "error", err)
+		os.Exit(1)
+	}
+	// Organización por defecto para los datos previos a las organizaciones y las peticiones anónimas
+	defaultOrg, err := tenant.Bootstrap(context.Background(), db.DB)
+	if err != nil {
+		slog.Error("Error al preparar la organización por defecto", "error", err)
+		os.Exit(1)
+	}
+	middleware.SetDefaultOrg(defaultOrg)
+	// Contraseñas guardadas en texto plano por versiones anteriores
+	if n, err := auth.HashStoredPasswords(context.Background(), db.DB); err != nil {
+		slog.Error("Error al migrar las contraseñas", "error", err)
+		os.Exit(1)
+	} else if n > 0 {
+		slog.Info("contraseñas migradas a bcrypt", "users", n)
+	}
+	if err := models.MigrateUserEmailIndex(db.DB); err != nil {
+		slog.Error("Error al crear el índice de emails", "error", err)
+		os.Exit(1)
+	}
+	if err := models.MigrateEmbeddingIndexes(db.DB); err != nil {
+		slog.Error("Error al crear los índices de embeddings", "error", err)
+		os.Exit(1)
+	}
+	if err := models.MigrateDocumentChunkIndexes(db.DB); err != nil {
+		slog.Error("Error al crear los índices de documentos", "error", err)
+		os.Exit(1)
+	}
+	if err := models.MigrateTaskStatusNotifications(db.DB); err != nil {
+		slog.Error("Error al crear los avisos de cambio de estado", "error", err)
+		os.Exit(1)
+	}
+	if err := models.MigrateLegacyStatuses(db.DB); err != nil {
+		slog.Error("Error al migrar los estados de las tareas", "error", err)
+		os.Exit(1)
+	}
+	if err := models.MigrateAuditEvents(db.DB); err != nil {
+		slog.Error("Error al proteger el registro de auditoría", "error", err)
+		os.Exit(1)
+	}
+	// Los cambios en usuarios, API keys, organizaciones y contenido se añaden al evento de auditoría de la petición
+	if err := audit.RegisterHooks(db.DB); err != nil {
+		slog.Error("Error al registrar los callbacks de auditoría", "error", err)
+		os.Exit(1)
+	}
+
+	// Tabla de precios para estimar el costo de cada tarea
+	if err := gemini.InitPrices(); err != nil {
+		slog.Error("Error al cargar la tabla de precios", "error", err)
+		os.Exit(1)
+	}
+
+	// Esquemas de respuesta con nombre para las tareas con salida JSON
+	if err := gemini.InitSchemas(); err != nil {
+		slog.Error("Error al cargar los esquemas de respuesta", "error", err)
+		os.Exit(1)
+	}
+
+	// Herramientas internas que Gemini puede llamar durante las tareas
+	if err := tools.Register(gemini.DefaultTools, db.DB); err != nil {
+		slog.Error("Error al registrar las herramientas", "error", err)
+		os.Exit(1)
+	}
+
+	// Generador de embeddings (Gemini o uno falso para pruebas sin conexión)
+	embedder, err := gemini.NewEmbedder()
+	if err != nil {
+		slog.Error("Error al configurar los embeddings", "error", err)
+		os.Exit(1)
+	}
+	controllers.SetEmbedder(embedder)
+
+	// Caché de respuestas de Gemini (desactivado si no se configura RESPONSE_CACHE_STORE)
+	responseCache, err := cache.New(db.DB)
+	if err != nil {
+		slog.Error("Error al configurar el caché de respuestas", "error", err)
+		os.Exit(1)
+	}
+	controllers.SetResponseCache(responseCache)
+
+	// Redacción de datos personales en los prompts antes de enviarlos a Gemini
+	redactor, err := pii.FromEnv()
+	if err != nil {
+		slog.Error("Error al configurar la redacción de datos personales", "error", err)
+		os.Exit(1)
+	}
+	if redactor == nil {
+		slog.Info("PII_DETECTORS=none: los prompts se envían a Gemini sin redactar")
+	}
+	controllers.SetRedactor(redactor)
+
+	// Correos de verificación y recuperación de contraseña
+	mailer, err := mail.New()
+	if err != nil {
+		slog.Error("Error al configurar el envío de correos", "error", err)
+		os.Exit(1)
+	}
+	controllers.SetMailer(mailer)
+	controllers.SetMailPool(worker.NewPool(mail.Workers(), mail.QueueSize()))
+
+	// Inicio de sesión con un proveedor OpenID Connect (opcional)
+	oidcConfig, err := auth.LoadOIDCConfig()
+	if err != nil {
+		slog.Error("Error en la configuración de OIDC", "error", err)
+		os.Exit(1)
+	}
+	if oidcConfig != nil {
+		controllers.SetOIDCProvider(auth.NewOIDCProvider(*oidcConfig))
+	}
+
+	// Límites de peticiones por usuario/IP y cuotas de uso
+	rateLimitConfig, err := ratelimit.LoadConfig()
+	if err != nil {
+		slog.Error("Error al cargar los límites de peticiones", "error", err)
+		os.Exit(1)
+	}
+	quotas, err := ratelimit.DefaultQuotas()
+	if err != nil {
+		slog.Error("Error al cargar las cuotas por defecto", "error", err)
+		os.Exit(1)
+	}
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if os.Getenv("RATE_LIMIT_STORE") == "postgres" {
+		rateLimitStore = ratelimit.NewPostgresStore(db.DB)
 	}
 
 	// Inyectar la conexión DB en los controladores
 	controllers.SetDB(db.DB)
 
-	// Crear instancia de Gin
-	r := gin.Default()
+	// Cambios de estado de las tareas (LISTEN/NOTIFY) para long-polling y WebSocket
+	taskEvents := events.NewHub()
+	go taskEvents.Listen(context.Background(), db.DSN())
+	controllers.SetTaskEvents(taskEvents)
+
+	// Pool de workers para las tareas en segundo plano; se reanudan los lotes que quedaron a medias
+	controllers.SetWorkerPool(worker.NewPool(worker.PoolSize(), worker.QueueSize()))
+	if err := controllers.ResumeBatches(context.Background()); err != nil {
+		slog.Error("Error al reanudar los lotes pendientes", "error", err)
+		os.Exit(1)
+	}
+	// Tareas individuales pendientes (las que dejó un reinicio y las que no cupieron en la cola) y
+	// tareas que un worker detenido dejó en proceso
+	go controllers.RequeuePendingTasks(context.Background(), controllers.TaskRequeueInterval(), controllers.TaskClaimTimeout())
+
+	// Purga periódica de archivos, resultados y tareas según la política de retención de cada organización
+	retentionPolicy, err := retention.DefaultPolicy()
+	if err != nil {
+		slog.Error("Error al cargar la política de retención", "error", err)
+		os.Exit(1)
+	}
+	if interval := retention.Interval(); interval > 0 {
+		go retention.NewPurger(db.DB, retentionPolicy).Run(context.Background(), interval)
+	}
+
+	// Crear instancia de Gin con logs de acceso en slog y un ID por petición
+	r := gin.New()
+	if err := r.SetTrustedProxies(middleware.TrustedProxies()); err != nil {
+		slog.Error("TRUSTED_PROXIES inválido", "error", err)
+		os.Exit(1)
+	}
+	r.Use(
+		gin.Recovery(),
+		otelgin.Middleware(telemetry.ServiceName),
+		middleware.RequestID(),
+		middleware.Logger(),
+		middleware.Language(),
+		middleware.CurrentUser(),
+		middleware.RateLimit(rateLimitStore, rateLimitConfig),
+	)
 
-	// Rutas Swagger
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	// Rutas Swagger, una documentación por versión de la API
+	legacydocs.SwaggerInfolegacy.Title += " (sin versión, obsoleta)"
+	r.GET("/swagger/v1/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.InstanceName("v1")))
+	r.GET("/swagger/legacy/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.InstanceName("legacy")))
 
-	// Rutas para usuarios
-	routes.RegisterUserRoutes(r)
+	// Rutas de la API (/v1 y alias obsoletos)
+	routes.RegisterRoutes(r, quotas)
 
 	// Iniciar servidor
-	log.Println("Servidor corriendo en http://localhost:8080")
+	slog.Info("Servidor corriendo en http://localhost:8080")
 	if err := r.Run(":8080"); err != nil {
-		log.Fatalf("Error al iniciar servidor: %v", err)
+		slog.Error("Error al iniciar servidor", "error", err)
+		os.Exit(1)
 	}
 }
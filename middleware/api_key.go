@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/apikey"
+	"github.com/Efren-Garza-Z/go-api-gemini/audit"
+	"github.com/Efren-Garza-Z/go-api-gemini/db"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// APIKeyHeader es la cabecera alternativa a Authorization: Bearer para enviar una API key.
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyKey es la clave del contexto de gin donde se guarda la API key de la petición.
+const apiKeyKey = "current_api_key"
+
+// requestAPIKey devuelve la API key enviada en X-API-Key o en Authorization: Bearer, o ""
+// si no hay ninguna. Los tokens Bearer que no tienen el formato de una API key se ignoran.
+func requestAPIKey(c *gin.Context) string {
+	if key := strings.TrimSpace(c.GetHeader(APIKeyHeader)); key != "" {
+		return key
+	}
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") && apikey.IsKey(strings.TrimSpace(token)) {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// authenticateAPIKey identifica al usuario dueño de la key y registra su uso. Si la key no
+// existe, está revocada o vencida, o su usuario fue eliminado, responde 401 y devuelve false.
+func authenticateAPIKey(c *gin.Context, key string) bool {
+	ctx := c.Request.Context()
+	now := time.Now()
+
+	var stored models.APIKeyDB
+	err := db.DB.WithContext(ctx).Where("hash = ?", apikey.Hash(key)).First(&stored).Error
+	if err == nil && !stored.Active(now) {
+		err = gorm.ErrRecordNotFound
+	}
+	var user models.UserDB
+	if err == nil {
+		err = db.DB.WithContext(ctx).First(&user, stored.UserID).Error
+	}
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+			return false
+		}
+		slog.WarnContext(ctx, "API key inválida", "prefix", keyPrefix(key))
+		apierror.Abort(c, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, i18n.ErrInvalidAPIKey))
+		recordAudit(c, "api_key.reject", audit.NewEntry("api_key", keyPrefix(key)))
+		return false
+	}
+
+	// UpdateColumns no cambia updated_at: el uso no es una modificación de la key.
+	err = db.DB.WithContext(ctx).Model(&stored).UpdateColumns(map[string]interface{}{
+		"last_used_at":  now,
+		"request_count": gorm.Expr("request_count + 1"),
+	}).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "error al registrar el uso de la API key", "api_key_id", stored.ID, "error", err)
+	}
+
+	c.Set(userKey, user)
+	c.Set(apiKeyKey, stored)
+	return true
+}
+
+// keyPrefix devuelve la parte visible de una key para los logs, sin el secreto.
+func keyPrefix(key string) string {
+	if i := strings.LastIndex(key, "_"); i > 0 {
+		return key[:i]
+	}
+	return ""
+}
+
+// APIKey devuelve la API key con la que se autenticó la petición, si la hay.
+func APIKey(c *gin.Context) (models.APIKeyDB, bool) {
+	value, ok := c.Get(apiKeyKey)
+	if !ok {
+		return models.APIKeyDB{}, false
+	}
+	key, ok := value.(models.APIKeyDB)
+	return key, ok
+}
+
+// APIKeyID devuelve el ID de la API key de la petición, o nil si no se usó ninguna.
+func APIKeyID(c *gin.Context) *uint {
+	key, ok := APIKey(c)
+	if !ok {
+		return nil
+	}
+	return &key.ID
+}
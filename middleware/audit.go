@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/audit"
+	"github.com/Efren-Garza-Z/go-api-gemini/db"
+	"github.com/Efren-Garza-Z/go-api-gemini/logging"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/gin-gonic/gin"
+)
+
+// maxUserAgent es la longitud máxima del user agent que se guarda en el registro de auditoría.
+const maxUserAgent = 512
+
+// Audit registra la petición en el registro de auditoría con la acción indicada (de la forma
+// <recurso>.<verbo>), incluso si se rechaza: debe ir antes de la comprobación de permisos.
+// param es el parámetro de la ruta con el ID del recurso; vacío si el recurso se crea en la
+// petición (se toma de la fila creada) o la acción no se refiere a uno solo.
+func Audit(action, param string) gin.HandlerFunc {
+	resourceType, _, _ := strings.Cut(action, ".")
+	return func(c *gin.Context) {
+		entry := audit.NewEntry(resourceType, c.Param(param))
+		c.Request = c.Request.WithContext(audit.WithEntry(c.Request.Context(), entry))
+		c.Next()
+		recordAudit(c, action, entry)
+	}
+}
+
+// recordAudit guarda el evento de la petición con su resultado. El actor es el usuario
+// autenticado con la API key, o el que el handler identificó con una credencial (SetActor);
+// el de X-User-ID no está autenticado y no se registra. La IP es la de la conexión, o la de
+// X-Forwarded-For solo si llega de un proxy de TRUSTED_PROXIES.
+func recordAudit(c *gin.Context, action string, entry *audit.Entry) {
+	resourceType, resourceID := entry.Resource()
+	event := models.AuditEventDB{
+		ActorUserID:  entry.Actor(),
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Status:       c.Writer.Status(),
+		IP:           c.ClientIP(),
+		UserAgent:    truncate(c.Request.UserAgent(), maxUserAgent),
+		RequestID:    logging.RequestID(c.Request.Context()),
+		Changes:      entry.Changes(),
+	}
+	if key, ok := APIKey(c); ok {
+		event.ActorUserID, event.ActorAPIKeyID = &key.UserID, &key.ID
+	}
+	if org, ok := Org(c); ok {
+		event.OrgID = &org.ID
+	}
+	audit.Record(c.Request.Context(), db.DB, event)
+}
+
+// truncate recorta s a n caracteres como máximo.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
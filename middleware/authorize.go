@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/gin-gonic/gin"
+)
+
+// AnonymousRole devuelve el rol de las peticiones sin usuario identificado, configurado en
+// ANONYMOUS_ROLE. Por defecto (o con "none") las peticiones anónimas no tienen ningún permiso.
+func AnonymousRole() models.Role {
+	if role := models.Role(os.Getenv("ANONYMOUS_ROLE")); role.Valid() {
+		return role
+	}
+	return ""
+}
+
+// Role devuelve el rol de la petición: el del usuario identificado o el rol anónimo.
+func Role(c *gin.Context) models.Role {
+	if user, ok := User(c); ok {
+		return user.Role
+	}
+	return AnonymousRole()
+}
+
+// keyAllows indica si la API key de la petición (si la hay) incluye el permiso en sus scopes.
+func keyAllows(c *gin.Context, permission models.Permission) bool {
+	key, ok := APIKey(c)
+	return !ok || key.Allows(permission)
+}
+
+// Authorize rechaza la petición si su rol, o los scopes de su API key, no tienen el
+// permiso: 401 si es anónima (el cliente puede identificarse) y 403 si está identificada.
+func Authorize(permission models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if Role(c).Can(permission) && keyAllows(c, permission) {
+			c.Next()
+			return
+		}
+		abortUnauthorized(c, permission)
+	}
+}
+
+// AuthorizeSelfOr permite la petición si el usuario identificado es el del parámetro :id;
+// si no, exige el permiso como Authorize. Con una API key con scopes, el permiso debe
+// estar en ellos también para los datos propios.
+func AuthorizeSelfOr(permission models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !keyAllows(c, permission) {
+			abortUnauthorized(c, permission)
+			return
+		}
+		if user, ok := User(c); ok && strconv.FormatUint(uint64(user.ID), 10) == c.Param("id") {
+			c.Next()
+			return
+		}
+		if Role(c).Can(permission) {
+			c.Next()
+			return
+		}
+		abortUnauthorized(c, permission)
+	}
+}
+
+// AllowOwnerOr es la comprobación de AuthorizeSelfOr para los recursos con dueño que se
+// cargan en el handler: permite la petición si el usuario identificado es owner y los scopes
+// de su API key incluyen own y, si no, exige el permiso. Si no se permite responde 401 o 403
+// y devuelve false.
+func AllowOwnerOr(c *gin.Context, owner *uint, own, permission models.Permission) bool {
+	if user, ok := User(c); ok && owner != nil && *owner == user.ID {
+		if keyAllows(c, own) {
+			return true
+		}
+		abortUnauthorized(c, own)
+		return false
+	}
+	if Role(c).Can(permission) && keyAllows(c, permission) {
+		return true
+	}
+	abortUnauthorized(c, permission)
+	return false
+}
+
+// abortUnauthorized responde 401 o 403 según la petición esté identificada o no.
+func abortUnauthorized(c *gin.Context, permission models.Permission) {
+	details := gin.H{"permission": permission}
+	user, ok := User(c)
+	if !ok {
+		apierror.Abort(c, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, i18n.ErrUnauthorized).WithDetails(details))
+		return
+	}
+	slog.WarnContext(c.Request.Context(), "operación no permitida para el rol",
+		"user_id", user.ID, "role", user.Role, "permission", permission)
+	apierror.Abort(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, i18n.ErrForbidden).WithDetails(details))
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated marca una ruta como obsoleta: agrega las cabeceras Deprecation (RFC 9745),
+// Sunset (RFC 8594) y un Link a la ruta que la reemplaza. En successor los parámetros
+// de la ruta (":id") se reemplazan por los valores de la petición.
+func Deprecated(deprecatedAt time.Time, sunset time.Time, successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link := successor
+		for _, param := range c.Params {
+			link = strings.ReplaceAll(link, ":"+param.Key, param.Value)
+		}
+
+		c.Header("Deprecation", fmt.Sprintf("@%d", deprecatedAt.Unix()))
+		c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+		c.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", link))
+		c.Next()
+	}
+}
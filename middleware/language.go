@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// Language elige el idioma de los mensajes a partir de Accept-Language y lo guarda en el
+// contexto de la petición. La respuesta indica el idioma usado en Content-Language.
+func Language() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang := i18n.Parse(c.GetHeader("Accept-Language"))
+		c.Request = c.Request.WithContext(i18n.WithLang(c.Request.Context(), lang))
+		c.Header("Content-Language", string(lang))
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Next()
+	}
+}
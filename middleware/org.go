@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/audit"
+	"github.com/Efren-Garza-Z/go-api-gemini/db"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// OrgIDHeader es la cabecera con la que el cliente elige la organización en la que actúa.
+const OrgIDHeader = "X-Org-ID"
+
+// Claves del contexto de gin donde se guardan la organización de la petición y el rol del
+// usuario en ella.
+const (
+	orgKey     = "current_org"
+	orgRoleKey = "current_org_role"
+)
+
+var (
+	defaultOrgMu sync.RWMutex
+	defaultOrg   models.OrganizationDB
+)
+
+// SetDefaultOrg define la organización de las peticiones anónimas cuando ANONYMOUS_ROLE les
+// da permisos.
+func SetDefaultOrg(org models.OrganizationDB) {
+	defaultOrgMu.Lock()
+	defer defaultOrgMu.Unlock()
+	defaultOrg = org
+}
+
+func getDefaultOrg() models.OrganizationDB {
+	defaultOrgMu.RLock()
+	defer defaultOrgMu.RUnlock()
+	return defaultOrg
+}
+
+// CurrentOrg resuelve la organización de la petición, que limita los datos que ve y a la que
+// se cargan sus cuotas. Solo se toma del usuario autenticado (ver CurrentUser), nunca de la
+// cabecera por sí sola:
+//   - con una API key de una organización, esa organización; un X-Org-ID distinto responde 403.
+//   - con una API key sin organización (como las de sesión), la de X-Org-ID si el usuario
+//     es miembro (un administrador puede elegir cualquiera) o, sin cabecera, la primera a
+//     la que se unió.
+//   - sin autenticar, ninguna, salvo que ANONYMOUS_ROLE dé permisos a las peticiones
+//     anónimas, que usan la organización por defecto; cualquier X-Org-ID responde 401.
+func CurrentOrg() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		var requested uint
+		if value := c.GetHeader(OrgIDHeader); value != "" {
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil || id == 0 {
+				apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidOrgHeader))
+				return
+			}
+			requested = uint(id)
+		}
+
+		user, identified := User(c)
+		var orgID uint
+		var role models.OrgRole
+		switch key, ok := APIKey(c); {
+		case ok && key.OrgID != nil:
+			orgID = *key.OrgID
+			if requested != 0 && requested != orgID {
+				apierror.Abort(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, i18n.ErrOrgKeyMismatch))
+				recordOrgDenied(c, requested)
+				return
+			}
+		case !identified:
+			if requested != 0 {
+				apierror.Abort(c, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, i18n.ErrUnauthorized))
+				recordOrgDenied(c, requested)
+				return
+			}
+			if AnonymousRole() == "" {
+				c.Next()
+				return
+			}
+			orgID = getDefaultOrg().ID
+		default:
+			orgID = requested
+		}
+
+		// El rol en la organización se busca también para las API keys, por si el usuario
+		// dejó de ser miembro después de crearla.
+		var member models.OrgMemberDB
+		if identified {
+			query := db.DB.WithContext(ctx).Where("user_id = ?", user.ID)
+			if orgID != 0 {
+				query = query.Where("org_id = ?", orgID)
+			}
+			err := query.Order("created_at, id").First(&member).Error
+			switch {
+			case err == nil:
+				orgID, role = member.OrgID, member.Role
+			case !errors.Is(err, gorm.ErrRecordNotFound):
+				apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+				return
+			case orgID == 0:
+				apierror.Abort(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, i18n.ErrNoOrganization))
+				return
+			case user.Role != models.RoleAdmin:
+				slog.WarnContext(ctx, "acceso a una organización sin ser miembro", "user_id", user.ID, "org_id", orgID)
+				apierror.Abort(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, i18n.ErrNotOrgMember))
+				recordOrgDenied(c, orgID)
+				return
+			}
+		}
+
+		var org models.OrganizationDB
+		if err := db.DB.WithContext(ctx).First(&org, orgID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeOrgNotFound, i18n.ErrOrgNotFound))
+				return
+			}
+			apierror.Abort(c, apierror.Wrap(err, http.StatusInternalServerError, apierror.CodeInternal, i18n.ErrDatabase))
+			return
+		}
+		c.Set(orgKey, org)
+		c.Set(orgRoleKey, role)
+		c.Next()
+	}
+}
+
+// recordOrgDenied registra en la auditoría el intento de actuar en una organización ajena.
+func recordOrgDenied(c *gin.Context, orgID uint) {
+	recordAudit(c, "org.access_denied", audit.NewEntry("org", strconv.FormatUint(uint64(orgID), 10)))
+}
+
+// Org devuelve la organización de la petición, si ya se resolvió.
+func Org(c *gin.Context) (models.OrganizationDB, bool) {
+	value, ok := c.Get(orgKey)
+	if !ok {
+		return models.OrganizationDB{}, false
+	}
+	org, ok := value.(models.OrganizationDB)
+	return org, ok
+}
+
+// OrgID devuelve el ID de la organización de la petición, o 0 si no se resolvió.
+func OrgID(c *gin.Context) uint {
+	org, _ := Org(c)
+	return org.ID
+}
+
+// OrgRole devuelve el rol del usuario en la organización de la petición; vacío si no es
+// miembro (peticiones anónimas o administradores fuera de sus organizaciones).
+func OrgRole(c *gin.Context) models.OrgRole {
+	role, _ := c.Get(orgRoleKey)
+	r, _ := role.(models.OrgRole)
+	return r
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"os"
+	"strings"
+)
+
+// TrustedProxies devuelve las IPs o rangos CIDR de los proxies configurados en TRUSTED_PROXIES
+// (separados por comas), los únicos de los que se aceptan X-Forwarded-For y X-Real-IP para
+// obtener la IP del cliente. Por defecto no se confía en ninguno y la IP es la de la conexión,
+// así que un cliente no puede cambiar su IP en los límites por IP ni en la auditoría.
+func TrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/db"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// quotasKey guarda en el contexto las cuotas por defecto, para ChargeQuota.
+	quotasKey = "quota_defaults"
+	// quotaReservedKey guarda en el contexto las tareas que la petición reservó con ChargeQuota.
+	quotaReservedKey = "quota_reserved"
+)
+
+// quotaReservations son las tareas que las peticiones en curso reservaron en la cuota de su
+// organización y todavía pueden estar creando.
+var quotaReservations ratelimit.Reservations
+
+// Quota rechaza con 429 la creación de tareas de una organización que agotó su cuota diaria
+// o mensual de peticiones o tokens. Debe ir después de CurrentOrg; las peticiones anónimas
+// (si ANONYMOUS_ROLE les da permisos) usan la cuota de la organización por defecto. La
+// petición cuenta como una tarea; los handlers que crean varias (los lotes) deben llamar a
+// ChargeQuota con la cantidad antes de crearlas.
+//
+// Lo reservado se libera cuando termina el handler, que para entonces ya guardó sus tareas.
+// La reserva es de cada réplica: con varias, dos peticiones simultáneas en réplicas distintas
+// aún pueden superar la cuota de peticiones por unas pocas tareas. Las cuotas de tokens se
+// comprueban con el uso de las tareas ya procesadas y siempre pueden superarse por lo que
+// consuman las que están en curso.
+func Quota(defaults ratelimit.Quotas) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(quotasKey, defaults)
+		defer func() {
+			if org, ok := Org(c); ok {
+				if reserved := c.GetInt64(quotaReservedKey); reserved > 0 {
+					quotaReservations.Release(org.ID, reserved)
+				}
+			}
+		}()
+		if !ChargeQuota(c, 1) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// ChargeQuota comprueba que la organización pueda crear requests tareas más sin superar su
+// cuota de peticiones y que no haya agotado la de tokens. Si no puede, responde 429 y devuelve
+// false. Sin el middleware Quota en la ruta no comprueba nada.
+func ChargeQuota(c *gin.Context, requests int64) bool {
+	value, ok := c.Get(quotasKey)
+	if !ok {
+		return true
+	}
+	org, ok := Org(c)
+	if !ok {
+		return true
+	}
+
+	ctx := c.Request.Context()
+	now := time.Now()
+	held := c.GetInt64(quotaReservedKey)
+	exceeded, err := quotaReservations.Charge(ctx, db.DB, org.ID, ratelimit.QuotasFor(org, value.(ratelimit.Quotas)), requests, held, now)
+	if err != nil {
+		// Sin poder consultar el uso se deja pasar la petición: la cuota no debe tirar la API.
+		slog.WarnContext(ctx, "no se pudo consultar la cuota de la organización; se permite la petición sin comprobarla",
+			"org_id", org.ID, "requested", requests, "error", err)
+		return true
+	}
+	if exceeded != nil {
+		slog.WarnContext(ctx, "cuota de organización agotada",
+			"org_id", org.ID, "quota", exceeded.Name, "limit", exceeded.Limit, "used", exceeded.Used, "requested", requests)
+		reset := exceeded.ResetAt.Sub(now)
+		setRateLimitHeaders(c, exceeded.Limit, 0, reset, reset)
+		apierror.Abort(c, apierror.New(http.StatusTooManyRequests, apierror.CodeQuotaExceeded, i18n.ErrQuotaExceeded).
+			WithDetails(gin.H{
+				"quota":     exceeded.Name,
+				"limit":     exceeded.Limit,
+				"used":      exceeded.Used,
+				"requested": requests,
+				"reset_at":  exceeded.ResetAt,
+			}))
+		return false
+	}
+	c.Set(quotaReservedKey, held+requests)
+	return true
+}
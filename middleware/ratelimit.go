@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// setRateLimitHeaders escribe las cabeceras X-RateLimit-* y, si se rechaza, Retry-After.
+func setRateLimitHeaders(c *gin.Context, limit int64, remaining int64, reset time.Duration, retryAfter time.Duration) {
+	c.Header("X-RateLimit-Limit", strconv.FormatInt(limit, 10))
+	c.Header("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(ceilSeconds(reset), 10))
+	if retryAfter > 0 {
+		c.Header("Retry-After", strconv.FormatInt(ceilSeconds(retryAfter), 10))
+	}
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+// RateLimit aplica los límites por usuario y por IP configurados para cada ruta.
+// Debe registrarse después de CurrentUser. Si el almacén falla, la petición se deja pasar.
+func RateLimit(store ratelimit.Store, cfg ratelimit.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		limits := cfg.For(route)
+
+		type check struct {
+			key   string
+			limit ratelimit.Limit
+		}
+		checks := []check{{key: "ip:" + c.ClientIP() + ":" + route, limit: limits.PerIP}}
+		if userID := UserID(c); userID != nil {
+			checks = append(checks, check{key: "user:" + strconv.FormatUint(uint64(*userID), 10) + ":" + route, limit: limits.PerUser})
+		}
+
+		// Las cabeceras informan el límite más restrictivo de los aplicados.
+		var reported *ratelimit.Decision
+		for _, ch := range checks {
+			if !ch.limit.Enabled() {
+				continue
+			}
+			decision, err := store.Take(c.Request.Context(), ch.key, ch.limit)
+			if err != nil {
+				slog.ErrorContext(c.Request.Context(), "error al consultar el límite de peticiones", "key", ch.key, "error", err)
+				continue
+			}
+			if !decision.Allowed {
+				setRateLimitHeaders(c, int64(decision.Limit), 0, decision.Reset, decision.RetryAfter)
+				apierror.Abort(c, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited, i18n.ErrRateLimited).
+					WithDetails(gin.H{"retry_after_seconds": ceilSeconds(decision.RetryAfter)}))
+				return
+			}
+			if reported == nil || decision.Remaining < reported.Remaining {
+				d := decision
+				reported = &d
+			}
+		}
+
+		if reported != nil {
+			setRateLimitHeaders(c, int64(reported.Limit), int64(reported.Remaining), reported.Reset, 0)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/Efren-Garza-Z/go-api-gemini/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader es la cabecera con la que se recibe y devuelve el ID de la petición.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID asigna un ID a cada petición (reutiliza el recibido en X-Request-ID si viene),
+// lo devuelve en la respuesta y lo guarda en el contexto para los logs.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = uuid.New().String()
+		}
+
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/logging"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestIDIsReusedOrGenerated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, logging.RequestID(c.Request.Context()))
+	})
+
+	tests := []struct {
+		name     string
+		received string
+		reused   bool
+	}{
+		{"recibido", "abc-123", true},
+		{"sin cabecera", "", false},
+		{"demasiado largo", strings.Repeat("x", 129), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.received != "" {
+				req.Header.Set(RequestIDHeader, tt.received)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(RequestIDHeader)
+			if id == "" || rec.Body.String() != id {
+				t.Fatalf("cabecera %q, contexto %q: se esperaba el mismo ID", id, rec.Body.String())
+			}
+			if (id == tt.received) != tt.reused {
+				t.Errorf("ID = %q con %q recibido; reutilizado = %v", id, tt.received, tt.reused)
+			}
+		})
+	}
+}
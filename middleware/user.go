@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/db"
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/gin-gonic/gin"
+)
+
+// UserIDHeader es la cabecera con la que, solo en desarrollo (ALLOW_USER_ID_HEADER=true), el
+// cliente indica el usuario que origina la petición sin autenticarse.
+const UserIDHeader = "X-User-ID"
+
+// userKey es la clave del contexto de gin donde se guarda el usuario identificado.
+const userKey = "current_user"
+
+// CurrentUser identifica al usuario de la petición a partir de una API key, ya sea creada
+// por el usuario o de sesión (ver authenticateAPIKey). Sin key la petición sigue como anónima.
+// X-User-ID no autentica a nadie, así que solo se acepta con ALLOW_USER_ID_HEADER=true, para
+// desarrollo; si no, responde 401, y si es inválido o el usuario no existe responde 400.
+// Con REQUIRE_EMAIL_VERIFICATION=true, un usuario que no confirmó su email recibe 403.
+func CurrentUser() gin.HandlerFunc {
+	requireVerified := os.Getenv("REQUIRE_EMAIL_VERIFICATION") == "true"
+	allowUserHeader := os.Getenv("ALLOW_USER_ID_HEADER") == "true"
+	if allowUserHeader {
+		slog.Warn("ALLOW_USER_ID_HEADER=true: X-User-ID identifica usuarios sin autenticarlos; no usar en producción")
+	}
+	return func(c *gin.Context) {
+		if key := requestAPIKey(c); key != "" {
+			if authenticateAPIKey(c, key) && checkVerified(c, requireVerified) {
+				c.Next()
+			}
+			return
+		}
+
+		value := c.GetHeader(UserIDHeader)
+		if value == "" {
+			c.Next()
+			return
+		}
+		if !allowUserHeader {
+			apierror.Abort(c, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, i18n.ErrUserHeaderDisabled))
+			return
+		}
+
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.ErrInvalidUserHeader))
+			return
+		}
+
+		var user models.UserDB
+		if err := db.DB.WithContext(c.Request.Context()).First(&user, id).Error; err != nil {
+			slog.WarnContext(c.Request.Context(), "usuario de X-User-ID no encontrado", "user_id", id, "error", err)
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeUserNotFound, i18n.ErrUserHeaderNotFound))
+			return
+		}
+
+		c.Set(userKey, user)
+		if checkVerified(c, requireVerified) {
+			c.Next()
+		}
+	}
+}
+
+// checkVerified responde 403 y devuelve false si se exige el email confirmado y el usuario
+// identificado no lo confirmó.
+func checkVerified(c *gin.Context, required bool) bool {
+	user, ok := User(c)
+	if !required || !ok || user.EmailVerifiedAt != nil {
+		return true
+	}
+	apierror.Abort(c, apierror.New(http.StatusForbidden, apierror.CodeEmailNotVerified, i18n.ErrEmailNotVerified))
+	return false
+}
+
+// User devuelve el usuario identificado en la petición, si lo hay.
+func User(c *gin.Context) (models.UserDB, bool) {
+	value, ok := c.Get(userKey)
+	if !ok {
+		return models.UserDB{}, false
+	}
+	user, ok := value.(models.UserDB)
+	return user, ok
+}
+
+// UserID devuelve el ID del usuario identificado en la petición, o nil si es anónima.
+func UserID(c *gin.Context) *uint {
+	user, ok := User(c)
+	if !ok {
+		return nil
+	}
+	return &user.ID
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// AdminTask es el resumen de una tarea de cualquier usuario en el listado de administración.
+type AdminTask struct {
+	ID            string                 `json:"id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
+	Kind          string                 `json:"kind" example:"task" enums:"task,file_task"`
+	Status        GeminiProcessingStatus `json:"status" example:"completed"`
+	StatusLabel   string                 `json:"status_label" example:"finalizado"`
+	OrgID         *uint                  `json:"org_id,omitempty" example:"1"`
+	UserID        *uint                  `json:"user_id,omitempty" example:"1"`
+	Model         string                 `json:"model,omitempty" example:"gemini-2.0-flash"`
+	TotalTokens   int32                  `json:"total_tokens" example:"1650"`
+	EstimatedCost float64                `json:"estimated_cost" example:"0.000185"`
+	Cached        bool                   `json:"cached" example:"false"`
+	LegalHold     bool                   `json:"legal_hold" example:"false"`
+	ErrorCode     string                 `json:"error_code,omitempty" example:"gemini_rate_limited"`
+	CreatedAt     time.Time              `json:"created_at" example:"2025-01-15T10:30:00Z"`
+	UpdatedAt     time.Time              `json:"updated_at" example:"2025-01-15T10:30:05Z"`
+}
+
+// AdminTaskList es una página de tareas, de la más reciente a la más antigua. NextCursor se
+// envía en ?cursor= para pedir la siguiente página; está vacío en la última.
+type AdminTaskList struct {
+	Items      []AdminTask `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty" example:"eyJ0IjoiMjAyNS0wMS0xNVQxMDozMDowMFoiLCJpZCI6IjhiOWExZDJlIn0"`
+}
+
+// LegalHoldInput pone o quita la retención legal de una tarea.
+type LegalHoldInput struct {
+	LegalHold *bool `json:"legal_hold" binding:"required" example:"true"`
+}
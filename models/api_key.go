@@ -0,0 +1,103 @@
+package models
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// CreateAPIKeyInput son los datos para crear una API key.
+type CreateAPIKeyInput struct {
+	Name string `json:"name" binding:"required,max=128" example:"jobs-nocturnos"`
+	// Scopes limita los permisos de la key a un subconjunto de los del rol del usuario; vacío usa todos.
+	Scopes []Permission `json:"scopes" binding:"omitempty,dive,required" example:"tasks:create,tasks:read"`
+	// ExpiresAt es la fecha en que la key deja de funcionar; sin valor no vence.
+	ExpiresAt *time.Time `json:"expires_at" example:"2026-12-31T00:00:00Z"`
+}
+
+// APIKey es una API key sin el secreto; Prefix es la parte visible para reconocerla.
+type APIKey struct {
+	ID           uint         `json:"id" example:"3"`
+	UserID       uint         `json:"user_id" example:"1"`
+	OrgID        *uint        `json:"org_id,omitempty" example:"1"`
+	Name         string       `json:"name" example:"jobs-nocturnos"`
+	Prefix       string       `json:"prefix" example:"gak_7f3a9c1e"`
+	Scopes       []Permission `json:"scopes,omitempty" example:"tasks:create,tasks:read"`
+	ExpiresAt    *time.Time   `json:"expires_at,omitempty" example:"2026-12-31T00:00:00Z"`
+	RevokedAt    *time.Time   `json:"revoked_at,omitempty"`
+	LastUsedAt   *time.Time   `json:"last_used_at,omitempty" example:"2025-01-15T10:30:00Z"`
+	RequestCount int64        `json:"request_count" example:"1520"`
+	RotatedAt    *time.Time   `json:"rotated_at,omitempty"`
+	CreatedAt    time.Time    `json:"created_at" example:"2025-01-01T09:00:00Z"`
+}
+
+// APIKeyCreated es la respuesta al crear o rotar una API key. Key es el secreto completo y
+// solo se devuelve esta vez.
+type APIKeyCreated struct {
+	APIKey
+	Key string `json:"key" example:"gak_7f3a9c1e_Qm9vdHN0cmFwIGFuIEFQSSBrZXkgZm9yIGpvYnM"`
+}
+
+// APIKeyDB es una API key guardada. El secreto no se guarda: solo su SHA-256 en Hash.
+type APIKeyDB struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uint   `gorm:"not null;index"`
+	OrgID     *uint  `gorm:"index"` // Organización en la que actúa la key; nil: las del usuario
+	Name      string `gorm:"type:varchar(128);not null"`
+	Prefix    string `gorm:"type:varchar(16);not null;uniqueIndex"`
+	Hash      string `gorm:"type:varchar(64);not null;uniqueIndex"`
+	// Scopes es la lista de permisos ([]Permission); vacío usa los del rol del usuario.
+	Scopes    JSON `gorm:"type:jsonb"`
+	ExpiresAt *time.Time
+	RevokedAt *time.Time
+	RotatedAt *time.Time
+
+	// Uso de la key: última petición autenticada y cantidad de peticiones.
+	LastUsedAt   *time.Time
+	RequestCount int64 `gorm:"not null;default:0"`
+}
+
+// TableName especifica el nombre de la tabla en la DB.
+func (APIKeyDB) TableName() string {
+	return "gemini.api_keys"
+}
+
+// ScopeList devuelve los permisos a los que está limitada la key.
+func (k APIKeyDB) ScopeList() []Permission {
+	var scopes []Permission
+	if len(k.Scopes) > 0 {
+		_ = json.Unmarshal(k.Scopes, &scopes)
+	}
+	return scopes
+}
+
+// Allows indica si la key permite la operación; sin scopes permite todas las del rol.
+func (k APIKeyDB) Allows(p Permission) bool {
+	scopes := k.ScopeList()
+	return len(scopes) == 0 || slices.Contains(scopes, p)
+}
+
+// Active indica si la key se puede usar en el instante indicado: no está revocada ni vencida.
+func (k APIKeyDB) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// ToResponse convierte APIKeyDB en la respuesta sin el secreto.
+func (k APIKeyDB) ToResponse() APIKey {
+	return APIKey{
+		ID:           k.ID,
+		UserID:       k.UserID,
+		OrgID:        k.OrgID,
+		Name:         k.Name,
+		Prefix:       k.Prefix,
+		Scopes:       k.ScopeList(),
+		ExpiresAt:    k.ExpiresAt,
+		RevokedAt:    k.RevokedAt,
+		LastUsedAt:   k.LastUsedAt,
+		RequestCount: k.RequestCount,
+		RotatedAt:    k.RotatedAt,
+		CreatedAt:    k.CreatedAt,
+	}
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditEventDB es un evento del registro de auditoría: quién hizo qué sobre qué recurso,
+// desde dónde y con qué resultado. La tabla solo admite inserciones (ver MigrateAuditEvents).
+type AuditEventDB struct {
+	ID        uint      `gorm:"primaryKey"`
+	CreatedAt time.Time `gorm:"index"`
+
+	// Actor: usuario y API key de la petición (nil si es anónima) y organización en la que actuó.
+	ActorUserID   *uint `gorm:"index"`
+	ActorAPIKeyID *uint
+	OrgID         *uint `gorm:"index"`
+
+	// Action es la operación, de la forma <recurso>.<verbo> (por ejemplo user.create o task.read).
+	Action       string `gorm:"type:varchar(64);not null;index"`
+	ResourceType string `gorm:"type:varchar(32);index:idx_audit_events_resource"`
+	ResourceID   string `gorm:"type:varchar(128);index:idx_audit_events_resource"`
+	// Status es el código HTTP de la respuesta; las operaciones rechazadas también se registran.
+	Status int
+
+	IP        string `gorm:"type:varchar(64)"`
+	UserAgent string `gorm:"type:varchar(512)"`
+	RequestID string `gorm:"type:varchar(128);index"`
+
+	// Changes son los cambios que hizo la operación en la base de datos ([]AuditChange).
+	Changes JSON `gorm:"type:jsonb"`
+}
+
+// TableName especifica el nombre de la tabla en la DB.
+func (AuditEventDB) TableName() string {
+	return "gemini.audit_events"
+}
+
+// MigrateAuditEvents crea el trigger que rechaza modificar o eliminar eventos de auditoría,
+// para que el registro solo crezca aunque alguien tenga acceso a la base de datos con el
+// usuario de la API.
+func MigrateAuditEvents(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`CREATE OR REPLACE FUNCTION gemini.audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'gemini.audit_events solo admite inserciones';
+END;
+$$ LANGUAGE plpgsql`).Error
+		if err != nil {
+			return err
+		}
+		table := AuditEventDB{}.TableName()
+		if err := tx.Exec("DROP TRIGGER IF EXISTS audit_events_append_only ON " + table).Error; err != nil {
+			return err
+		}
+		return tx.Exec("CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON " + table +
+			" FOR EACH STATEMENT EXECUTE FUNCTION gemini.audit_events_append_only()").Error
+	})
+}
+
+// AuditChange es el cambio de una fila: los valores anteriores (Before) y nuevos (After) de
+// las columnas que cambiaron. Una creación solo tiene After y una eliminación solo Before.
+type AuditChange struct {
+	Table     string                 `json:"table" example:"gemini.users"`
+	ID        string                 `json:"id" example:"2"`
+	Operation string                 `json:"operation" example:"update" enums:"create,update,delete"`
+	Before    map[string]interface{} `json:"before,omitempty"`
+	After     map[string]interface{} `json:"after,omitempty"`
+}
+
+// AuditEvent es un evento de auditoría en el listado y la exportación.
+type AuditEvent struct {
+	ID            uint          `json:"id" example:"1542"`
+	CreatedAt     time.Time     `json:"created_at" example:"2025-01-15T10:30:00Z"`
+	ActorUserID   *uint         `json:"actor_user_id,omitempty" example:"1"`
+	ActorAPIKeyID *uint         `json:"actor_api_key_id,omitempty" example:"3"`
+	OrgID         *uint         `json:"org_id,omitempty" example:"1"`
+	Action        string        `json:"action" example:"user.update"`
+	ResourceType  string        `json:"resource_type,omitempty" example:"user"`
+	ResourceID    string        `json:"resource_id,omitempty" example:"2"`
+	Status        int           `json:"status" example:"200"`
+	IP            string        `json:"ip,omitempty" example:"203.0.113.7"`
+	UserAgent     string        `json:"user_agent,omitempty" example:"curl/8.5.0"`
+	RequestID     string        `json:"request_id,omitempty" example:"0f8fad5b-d9cb-469f-a165-70867728950e"`
+	Changes       []AuditChange `json:"changes,omitempty"`
+}
+
+// AuditEventList es una página de eventos, del más reciente al más antiguo. NextCursor se
+// envía en ?cursor= para pedir la siguiente página; está vacío en la última.
+type AuditEventList struct {
+	Items      []AuditEvent `json:"items"`
+	NextCursor string       `json:"next_cursor,omitempty" example:"eyJ0IjoiMjAyNS0wMS0xNVQxMDozMDowMFoiLCJpZCI6MTU0Mn0"`
+}
+
+// ToResponse convierte AuditEventDB en la respuesta, con los cambios decodificados.
+func (e AuditEventDB) ToResponse() AuditEvent {
+	event := AuditEvent{
+		ID:            e.ID,
+		CreatedAt:     e.CreatedAt,
+		ActorUserID:   e.ActorUserID,
+		ActorAPIKeyID: e.ActorAPIKeyID,
+		OrgID:         e.OrgID,
+		Action:        e.Action,
+		ResourceType:  e.ResourceType,
+		ResourceID:    e.ResourceID,
+		Status:        e.Status,
+		IP:            e.IP,
+		UserAgent:     e.UserAgent,
+		RequestID:     e.RequestID,
+	}
+	if len(e.Changes) > 0 {
+		_ = json.Unmarshal(e.Changes, &event.Changes)
+	}
+	return event
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/db/dbtest"
+)
+
+func TestAuditEventsAreAppendOnly(t *testing.T) {
+	conn := dbtest.Postgres(t, &AuditEventDB{})
+	if err := MigrateAuditEvents(conn); err != nil {
+		t.Fatalf("MigrateAuditEvents: %v", err)
+	}
+	event := AuditEventDB{Action: "user.read", ResourceType: "user", ResourceID: "1", Status: 200}
+	if err := conn.Create(&event).Error; err != nil {
+		t.Fatalf("insertar evento: %v", err)
+	}
+
+	table := AuditEventDB{}.TableName()
+	for _, statement := range []string{
+		"UPDATE " + table + " SET action = 'user.delete' WHERE id = ?",
+		"DELETE FROM " + table + " WHERE id = ?",
+	} {
+		err := conn.Exec(statement, event.ID).Error
+		if err == nil || !strings.Contains(err.Error(), "solo admite inserciones") {
+			t.Errorf("%s: error %v, se esperaba el rechazo del trigger", strings.Fields(statement)[0], err)
+		}
+	}
+	if err := conn.Exec("TRUNCATE " + table).Error; err == nil {
+		t.Error("TRUNCATE: se esperaba el rechazo del trigger")
+	}
+
+	var stored AuditEventDB
+	if err := conn.First(&stored, event.ID).Error; err != nil || stored.Action != "user.read" {
+		t.Errorf("el evento cambió: %+v, err = %v", stored, err)
+	}
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Formatos de descarga de los resultados de un lote.
+const (
+	BatchFormatJSONL = "jsonl"
+	BatchFormatCSV   = "csv"
+)
+
+// BatchRequest crea un lote con un prompt por tarea. Todas las tareas usan el mismo esquema de respuesta.
+type BatchRequest struct {
+	Prompts []string `json:"prompts" binding:"required,min=1,dive,required" example:"Resume: ...,Traduce: ..."`
+	// ResponseSchema es un JSON Schema que debe cumplir cada respuesta; no se puede usar junto con Schema.
+	ResponseSchema json.RawMessage `json:"response_schema,omitempty" swaggertype:"object"`
+	// Schema es el nombre de un esquema de respuesta registrado en el servidor.
+	Schema string `json:"schema,omitempty" example:"invoice"`
+}
+
+// Batch es el progreso agregado de un lote de tareas.
+type Batch struct {
+	ID          string                 `json:"id" example:"5f0c2a1e-7b3d-4c8a-9e6f-1a2b3c4d5e6f"`
+	Status      GeminiProcessingStatus `json:"status" example:"processing"`
+	StatusLabel string                 `json:"status_label" example:"en proceso"`
+	Total       int                    `json:"total" example:"1000"`
+	Pending     int                    `json:"pending" example:"600"`
+	Processing  int                    `json:"processing" example:"8"`
+	Completed   int                    `json:"completed" example:"390"`
+	Failed      int                    `json:"failed" example:"2"`
+	// Progress es la fracción de tareas terminadas (completadas o con error), de 0 a 1.
+	Progress      float64       `json:"progress" example:"0.392"`
+	Template      *TaskTemplate `json:"template,omitempty"`
+	TotalTokens   int64         `json:"total_tokens" example:"102400"`
+	EstimatedCost float64       `json:"estimated_cost_usd" example:"0.0412"`
+	// ResultsURL es la URL para descargar los resultados; solo está presente cuando el lote terminó.
+	ResultsURL string    `json:"results_url,omitempty" example:"/v1/gemini/batches/5f0c2a1e-7b3d-4c8a-9e6f-1a2b3c4d5e6f/results"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// BatchCreatedResponse es la respuesta al crear un lote.
+type BatchCreatedResponse struct {
+	ID        string `json:"id" example:"5f0c2a1e-7b3d-4c8a-9e6f-1a2b3c4d5e6f"`
+	Total     int    `json:"total" example:"1000"`
+	StatusURL string `json:"status_url" example:"/v1/gemini/batches/5f0c2a1e-7b3d-4c8a-9e6f-1a2b3c4d5e6f"`
+}
+
+// BatchResult es una línea de los resultados de un lote en formato JSONL.
+type BatchResult struct {
+	Index      int                    `json:"index" example:"1"`
+	TaskID     string                 `json:"task_id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
+	Status     GeminiProcessingStatus `json:"status" example:"completed"`
+	Result     string                 `json:"result,omitempty"`
+	ResultJSON JSON                   `json:"result_json,omitempty" swaggertype:"object"`
+	Error      *TaskError             `json:"error,omitempty"`
+}
+
+// BatchDB es un lote de tareas de texto creadas en una sola petición. Las tareas se
+// guardan en GeminiProcessingDB con BatchID y BatchIndex.
+type BatchDB struct {
+	ID        string `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Source indica el origen de las tareas: "json" (lista de prompts) o "csv" (filas con una plantilla).
+	Source          string `gorm:"type:varchar(10);not null"`
+	Total           int    `gorm:"not null"`
+	TemplateName    string `gorm:"type:varchar(128)"`
+	TemplateVersion *int
+	UserID          *uint `gorm:"index"`
+	OrgID           *uint `gorm:"index"`
+}
+
+// TableName especifica el nombre de la tabla en la DB.
+func (BatchDB) TableName() string {
+	return "gemini.batches"
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CreateCollectionInput son los datos para crear una colección de documentos.
+type CreateCollectionInput struct {
+	Name        string `json:"name" binding:"required,max=128" example:"reglamentos"`
+	Description string `json:"description" binding:"max=1000" example:"Reglamentos internos de becas"`
+}
+
+// Collection es una colección de documentos indexados para hacer preguntas sobre ellos.
+type Collection struct {
+	ID          uint      `json:"id" example:"1"`
+	Name        string    `json:"name" example:"reglamentos"`
+	Description string    `json:"description,omitempty" example:"Reglamentos internos de becas"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Document es un documento de una colección con el estado de su indexación.
+type Document struct {
+	ID           uint                   `json:"id" example:"1"`
+	CollectionID uint                   `json:"collection_id" example:"1"`
+	Filename     string                 `json:"filename" example:"reglamento.pdf"`
+	MIMEType     string                 `json:"mime_type" example:"application/pdf"`
+	Status       GeminiProcessingStatus `json:"status" example:"completed"`
+	StatusLabel  string                 `json:"status_label" example:"finalizado"`
+	Pages        int                    `json:"pages" example:"12"`
+	Chunks       int                    `json:"chunks" example:"34"`
+	Error        *TaskError             `json:"error,omitempty"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+// DocumentCreatedResponse es la respuesta al subir un documento a una colección.
+type DocumentCreatedResponse struct {
+	ID        uint   `json:"id" example:"1"`
+	StatusURL string `json:"status_url" example:"/v1/collections/1/documents/1"`
+}
+
+// CollectionQueryRequest es una pregunta sobre los documentos de una colección.
+type CollectionQueryRequest struct {
+	Question string `json:"question" binding:"required" example:"¿Cuál es el promedio mínimo para renovar la beca?"`
+	TopK     int    `json:"top_k,omitempty" binding:"omitempty,min=1,max=20" example:"5"`
+}
+
+// Citation identifica el fragmento de un documento usado como fuente de una respuesta.
+// Index es el número con el que el modelo cita la fuente en el texto, por ejemplo [1].
+type Citation struct {
+	Index      int     `json:"index" example:"1"`
+	DocumentID uint    `json:"document_id" example:"1"`
+	Filename   string  `json:"filename" example:"reglamento.pdf"`
+	Page       int     `json:"page" example:"3"`
+	ChunkIndex int     `json:"chunk" example:"7"`
+	Score      float64 `json:"score" example:"0.82"`
+	Text       string  `json:"text" example:"El promedio mínimo para renovar la beca es 8.5..."`
+}
+
+// CollectionDB es una colección de documentos.
+type CollectionDB struct {
+	ID          uint `gorm:"primaryKey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Name        string `gorm:"type:varchar(128);not null;uniqueIndex:idx_collections_org_name"`
+	Description string `gorm:"type:text"`
+	UserID      *uint  `gorm:"index"`
+	// OrgID es la organización dueña; el nombre es único dentro de cada organización.
+	OrgID *uint `gorm:"uniqueIndex:idx_collections_org_name,priority:1"`
+}
+
+// TableName especifica el nombre de la tabla en la DB.
+func (CollectionDB) TableName() string {
+	return "gemini.collections"
+}
+
+// ToResponse convierte CollectionDB a Collection.
+func (c CollectionDB) ToResponse() Collection {
+	return Collection{ID: c.ID, Name: c.Name, Description: c.Description, CreatedAt: c.CreatedAt}
+}
+
+// DocumentDB es un documento subido a una colección. El archivo no se guarda: solo su
+// texto, dividido en fragmentos con embeddings (DocumentChunkDB).
+type DocumentDB struct {
+	ID           uint `gorm:"primaryKey"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	CollectionID uint                   `gorm:"index;not null"`
+	Collection   CollectionDB           `gorm:"constraint:OnDelete:CASCADE"`
+	Filename     string                 `gorm:"type:varchar(255);not null"`
+	MIMEType     string                 `gorm:"type:varchar(100);not null"`
+	Status       GeminiProcessingStatus `gorm:"type:varchar(20);not null"`
+	ErrorCode    string                 `gorm:"type:varchar(64)"`
+	Error        string                 `gorm:"type:text"`
+	Pages        int                    `gorm:"not null;default:0"`
+	Chunks       int                    `gorm:"not null;default:0"`
+	UserID       *uint                  `gorm:"index"`
+
+	// ID de la petición HTTP y contexto de traza, para correlacionar la indexación en segundo plano.
+	RequestID   string `gorm:"type:varchar(128)"`
+	TraceParent string `gorm:"type:varchar(55)"`
+	TraceState  string `gorm:"type:text"`
+}
+
+// TableName especifica el nombre de la tabla en la DB.
+func (DocumentDB) TableName() string {
+	return "gemini.documents"
+}
+
+// DocumentChunkDB es un fragmento de texto de un documento con su embedding.
+//
+// Text no se cifra: la búsqueda lo devuelve desde SQL junto con la similitud y el embedding, que
+// no se puede cifrar sin perder la búsqueda, ya deja inferir el contenido. Se protege con los
+// permisos de la base de datos y se borra con el documento.
+type DocumentChunkDB struct {
+	ID           uint       `gorm:"primaryKey"`
+	DocumentID   uint       `gorm:"index;not null"`
+	Document     DocumentDB `gorm:"constraint:OnDelete:CASCADE"`
+	CollectionID uint       `gorm:"index;not null"`
+	ChunkIndex   int        `gorm:"not null"`
+	Page         int        `gorm:"not null"`
+	Text         string     `gorm:"type:text;not null"`
+	Model        string     `gorm:"type:varchar(64);not null"`
+	// Embedding debe tener las mismas dimensiones que gemini.EmbeddingDimensions.
+	Embedding Vector `gorm:"type:vector(768);not null"`
+}
+
+// TableName especifica el nombre de la tabla en la DB.
+func (DocumentChunkDB) TableName() string {
+	return "gemini.document_chunks"
+}
+
+// MigrateDocumentChunkIndexes crea el índice HNSW para buscar fragmentos por distancia coseno.
+func MigrateDocumentChunkIndexes(db *gorm.DB) error {
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_document_chunks_embedding ON gemini.document_chunks USING hnsw (embedding vector_cosine_ops)").Error
+}
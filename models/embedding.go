@@ -0,0 +1,113 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultNamespace es el espacio de nombres de los embeddings si no se indica otro.
+const DefaultNamespace = "default"
+
+// EmbeddingInput es un texto a convertir en embedding.
+type EmbeddingInput struct {
+	Text string `json:"text" binding:"required" example:"Becas para estudiar en Noruega"`
+	// ExternalID identifica el texto en el sistema del cliente; si ya existe en el namespace se reemplaza.
+	ExternalID string `json:"external_id,omitempty" binding:"max=255" example:"doc-42"`
+	Metadata   JSON   `json:"metadata,omitempty" swaggertype:"object"`
+}
+
+// EmbeddingRequest pide los embeddings de un texto (Text) o de varios (Items).
+type EmbeddingRequest struct {
+	Namespace string           `json:"namespace,omitempty" binding:"max=128" example:"becas"`
+	Text      string           `json:"text,omitempty" example:"Becas para estudiar en Noruega"`
+	Items     []EmbeddingInput `json:"items,omitempty" binding:"max=100,dive"`
+	// Store indica si los vectores se guardan para búsquedas; por defecto true.
+	Store *bool `json:"store,omitempty" example:"true"`
+}
+
+// EmbeddingResult es el embedding de un texto.
+type EmbeddingResult struct {
+	// ID es el identificador del embedding guardado; 0 si no se guardó.
+	ID         uint      `json:"id,omitempty" example:"1"`
+	ExternalID string    `json:"external_id,omitempty" example:"doc-42"`
+	Embedding  []float32 `json:"embedding"`
+}
+
+// EmbeddingResponse es la respuesta de POST /v1/gemini/embeddings.
+type EmbeddingResponse struct {
+	Model      string            `json:"model" example:"gemini-embedding-001"`
+	Namespace  string            `json:"namespace" example:"becas"`
+	Dimensions int               `json:"dimensions" example:"768"`
+	Data       []EmbeddingResult `json:"data"`
+}
+
+// SearchRequest es una búsqueda por similitud coseno dentro de un namespace.
+type SearchRequest struct {
+	Namespace string `json:"namespace,omitempty" binding:"max=128" example:"becas"`
+	Query     string `json:"query" binding:"required" example:"estudiar en el extranjero"`
+	TopK      int    `json:"top_k,omitempty" binding:"omitempty,min=1,max=100" example:"5"`
+	// Filter limita la búsqueda a los embeddings cuya metadata contiene este objeto.
+	Filter JSON `json:"filter,omitempty" swaggertype:"object"`
+}
+
+// SearchMatch es un resultado de la búsqueda; Score es la similitud coseno (1 es idéntico).
+type SearchMatch struct {
+	ID         uint    `json:"id" example:"1"`
+	ExternalID string  `json:"external_id,omitempty" example:"doc-42"`
+	Text       string  `json:"text" example:"Becas para estudiar en Noruega"`
+	Metadata   JSON    `json:"metadata,omitempty" swaggertype:"object"`
+	Score      float64 `json:"score" example:"0.87"`
+}
+
+// SearchResponse es la respuesta de POST /v1/gemini/search.
+type SearchResponse struct {
+	Model     string        `json:"model" example:"gemini-embedding-001"`
+	Namespace string        `json:"namespace" example:"becas"`
+	Matches   []SearchMatch `json:"matches"`
+}
+
+// EmbeddingDB es un texto guardado con su embedding para búsquedas semánticas.
+//
+// Text no se cifra, por la misma razón que DocumentChunkDB.Text.
+type EmbeddingDB struct {
+	ID         uint `gorm:"primaryKey"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Namespace  string `gorm:"type:varchar(128);not null;uniqueIndex:idx_embeddings_org_namespace_external_id,where:external_id <> ''"`
+	ExternalID string `gorm:"type:varchar(255);not null;default:'';uniqueIndex:idx_embeddings_org_namespace_external_id,where:external_id <> ''"`
+	Text       string `gorm:"type:text;not null"`
+	Metadata   JSON   `gorm:"type:jsonb"`
+	Model      string `gorm:"type:varchar(64);not null"`
+	// Embedding debe tener las mismas dimensiones que gemini.EmbeddingDimensions.
+	Embedding Vector `gorm:"type:vector(768);not null"`
+	UserID    *uint  `gorm:"index"`
+	// OrgID es la organización dueña; cada organización tiene sus propios namespaces.
+	OrgID *uint `gorm:"uniqueIndex:idx_embeddings_org_namespace_external_id,priority:1,where:external_id <> ''"`
+}
+
+// TableName especifica el nombre de la tabla en la DB.
+func (EmbeddingDB) TableName() string {
+	return "gemini.embeddings"
+}
+
+// EnableVector instala la extensión pgvector; debe ejecutarse antes de migrar EmbeddingDB.
+func EnableVector(db *gorm.DB) error {
+	return db.Exec("CREATE EXTENSION IF NOT EXISTS vector").Error
+}
+
+// MigrateEmbeddingIndexes crea los índices de EmbeddingDB que GORM no puede declarar:
+// HNSW para la distancia coseno y GIN para filtrar por metadata.
+func MigrateEmbeddingIndexes(db *gorm.DB) error {
+	statements := []string{
+		"CREATE INDEX IF NOT EXISTS idx_embeddings_embedding ON gemini.embeddings USING hnsw (embedding vector_cosine_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_embeddings_metadata ON gemini.embeddings USING gin (metadata jsonb_path_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_embeddings_namespace ON gemini.embeddings (namespace)",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
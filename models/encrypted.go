@@ -0,0 +1,13 @@
+package models
+
+import (
+	"github.com/Efren-Garza-Z/go-api-gemini/encryption"
+	"gorm.io/gorm/schema"
+)
+
+// Las columnas con gorm:"serializer:encrypted" (prompts, instrucciones de sistema, resultados,
+// archivos, llamadas a herramientas, citas y detalles de error) se guardan cifradas; ver el
+// paquete encryption.
+func init() {
+	schema.RegisterSerializer(encryption.SerializerName, encryption.Serializer{})
+}
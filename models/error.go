@@ -0,0 +1,26 @@
+package models
+
+// ErrorResponse es el sobre estándar de los errores de la API. Los clientes deben
+// decidir según Code; Message es descriptivo y puede cambiar.
+type ErrorResponse struct {
+	Code      string      `json:"code" example:"validation_failed" enums:"invalid_request,validation_failed,unsupported_file_type,user_not_found,task_not_found,email_taken,invalid_schema,schema_not_found,tool_not_found,collection_not_found,collection_name_taken,collection_empty,document_not_found,template_not_found,template_name_taken,invalid_template,template_render_failed,batch_not_found,batch_not_finished,batch_too_large,invalid_csv,api_key_not_found,api_key_revoked,invalid_token,email_not_verified,oidc_not_configured,oidc_login_failed,org_not_found,org_slug_taken,org_member_not_found,org_member_exists,org_last_owner,unauthorized,forbidden,rate_limited,quota_exceeded,internal_error"`
+	Message   string      `json:"message" example:"Datos de entrada inválidos"`
+	Details   interface{} `json:"details,omitempty" swaggertype:"object"`
+	RequestID string      `json:"request_id,omitempty" example:"0f8fad5b-d9cb-469f-a165-70867728950e"`
+}
+
+// TaskError es el error guardado en una tarea fallida.
+type TaskError struct {
+	Code    string `json:"code" example:"gemini_rate_limited" enums:"schema_validation_failed,gemini_tool_steps_exceeded,document_extraction_failed,document_empty,gemini_not_configured,gemini_invalid_request,gemini_permission_denied,gemini_rate_limited,gemini_timeout,gemini_unavailable,gemini_upload_failed,gemini_error"`
+	Message string `json:"message" example:"Se agotó la cuota de la API de Gemini"`
+	// Details es información adicional, por ejemplo los incumplimientos del esquema de respuesta.
+	Details JSON `json:"details,omitempty" swaggertype:"object"`
+}
+
+// NewTaskError construye el error de una tarea; devuelve nil si la tarea no falló.
+func NewTaskError(code string, message string, details JSON) *TaskError {
+	if code == "" && message == "" {
+		return nil
+	}
+	return &TaskError{Code: code, Message: message, Details: details}
+}
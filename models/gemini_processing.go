@@ -1,37 +1,112 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/i18n"
+	"gorm.io/gorm"
+)
 
 // PromptRequest es la estructura de la solicitud para iniciar una tarea.
 type PromptRequest struct {
 	Prompt string `json:"prompt" example:"Conoces las becas para poder estudiar en finlandia o noruega?"`
+	// Template es el nombre de una plantilla de prompt; no se puede usar junto con Prompt.
+	Template string `json:"template,omitempty" example:"invoice-summary"`
+	// Version es la versión de la plantilla; si se omite se usa la más reciente.
+	Version int `json:"version,omitempty" binding:"omitempty,min=1" example:"3"`
+	// Variables son los valores de las variables de la plantilla; reemplazan a los valores por defecto.
+	Variables map[string]interface{} `json:"variables,omitempty"`
+	// ResponseSchema es un JSON Schema que debe cumplir la respuesta; no se puede usar junto con Schema.
+	ResponseSchema json.RawMessage `json:"response_schema,omitempty" swaggertype:"object"`
+	// Schema es el nombre de un esquema de respuesta registrado en el servidor.
+	Schema string `json:"schema,omitempty" example:"invoice"`
+	// Tools son los nombres de las herramientas registradas que el modelo puede llamar.
+	Tools []string `json:"tools,omitempty" example:"get_user"`
 }
 
-// GeminiProcessingIDResponse es la respuesta que contiene el ID de la tarea.
+// GeminiProcessingIDResponse es la respuesta que contiene el ID de la tarea (API sin versión).
 type GeminiProcessingIDResponse struct {
 	GeminiProcessingID string `json:"task_id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
 }
 
-// GeminiProcessingStatus representa el estado de una tarea asíncrona.
+// TaskCreatedResponse es la respuesta de /v1 al crear una tarea.
+type TaskCreatedResponse struct {
+	ID        string `json:"id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
+	StatusURL string `json:"status_url" example:"/v1/gemini/tasks/8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
+}
+
+// GeminiProcessingStatus representa el estado de una tarea asíncrona. Los valores son
+// estables e independientes del idioma; la etiqueta traducida se obtiene con Label.
 type GeminiProcessingStatus string
 
 const (
 	// StatusPending indica que la tarea está pendiente de iniciar.
-	StatusPending GeminiProcessingStatus = "pendiente"
+	StatusPending GeminiProcessingStatus = "pending"
 	// StatusProcessing indica que la tarea está en curso.
-	StatusProcessing GeminiProcessingStatus = "en_proceso"
+	StatusProcessing GeminiProcessingStatus = "processing"
 	// StatusCompleted indica que la tarea ha finalizado con éxito.
-	StatusCompleted GeminiProcessingStatus = "finalizado"
+	StatusCompleted GeminiProcessingStatus = "completed"
 	// StatusError indica que la tarea ha fallado.
 	StatusError GeminiProcessingStatus = "error"
 )
 
+// Finished indica si la tarea terminó, con éxito o con error.
+func (s GeminiProcessingStatus) Finished() bool {
+	return s == StatusCompleted || s == StatusError
+}
+
+// statusLabels relaciona cada estado con su etiqueta en el catálogo de mensajes.
+var statusLabels = map[GeminiProcessingStatus]i18n.Key{
+	StatusPending:    i18n.StatusPending,
+	StatusProcessing: i18n.StatusProcessing,
+	StatusCompleted:  i18n.StatusCompleted,
+	StatusError:      i18n.StatusError,
+}
+
+// Label devuelve la etiqueta del estado en el idioma indicado.
+func (s GeminiProcessingStatus) Label(lang i18n.Lang) string {
+	if key, ok := statusLabels[s]; ok {
+		return i18n.T(lang, key)
+	}
+	return string(s)
+}
+
+// legacyStatuses son los valores en español que se guardaban antes de usar valores neutros.
+var legacyStatuses = map[string]GeminiProcessingStatus{
+	"pendiente":  StatusPending,
+	"en_proceso": StatusProcessing,
+	"finalizado": StatusCompleted,
+}
+
+// MigrateLegacyStatuses convierte los estados guardados en español a los valores actuales.
+func MigrateLegacyStatuses(db *gorm.DB) error {
+	for _, model := range []interface{}{&GeminiProcessingDB{}, &GeminiProcessingFileDB{}} {
+		for legacy, status := range legacyStatuses {
+			if err := db.Model(model).Where("status = ?", legacy).Update("status", status).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // GeminiProcessingResponse representa el estado y el resultado de una tarea.
 type GeminiProcessingResponse struct {
-	ID     string                 `json:"id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
-	Status GeminiProcessingStatus `json:"status" example:"finalizado"`
-	Result string                 `json:"result,omitempty" example:"Sí, existen varias becas..."`
-	Error  string                 `json:"error,omitempty"`
+	ID          string                 `json:"id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
+	Status      GeminiProcessingStatus `json:"status" example:"completed"`
+	StatusLabel string                 `json:"status_label" example:"finalizado"`
+	Result      string                 `json:"result,omitempty" example:"Sí, existen varias becas..."`
+	ResultJSON  JSON                   `json:"result_json,omitempty" swaggertype:"object"`
+	ToolCalls   JSON                   `json:"tool_calls,omitempty" swaggertype:"array,object"`
+	Redactions  JSON                   `json:"redactions,omitempty" swaggertype:"array,object"`
+	Citations   JSON                   `json:"citations,omitempty" swaggertype:"array,object"`
+	Template    *TaskTemplate          `json:"template,omitempty"`
+	Cached      bool                   `json:"cached" example:"false"`
+	Error       *TaskError             `json:"error,omitempty"`
+	Usage       *TaskUsage             `json:"usage,omitempty"`
+	// ResultPurgedAt indica que el resultado se eliminó por la política de retención.
+	ResultPurgedAt *time.Time `json:"result_purged_at,omitempty" example:"2025-04-15T03:00:00Z"`
 }
 
 // GeminiProcessingDB es el modelo que se guarda en la base de datos.
@@ -40,12 +115,103 @@ type GeminiProcessingDB struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Status    GeminiProcessingStatus `gorm:"type:varchar(20);not null"`
-	Result    string                 `gorm:"type:text"`
+	Result    string                 `gorm:"serializer:encrypted;type:text"`
+	ErrorCode string                 `gorm:"type:varchar(64)"`
 	Error     string                 `gorm:"type:text"`
-	Prompt    string                 `gorm:"type:text;not null"`
+	Prompt    string                 `gorm:"serializer:encrypted;type:text;not null"`
+	UserID    *uint                  `gorm:"index"`
+	OrgID     *uint                  `gorm:"index"`
+
+	// ClaimedAt es cuándo un worker pasó la tarea a en proceso. Si sigue en proceso después
+	// de TASK_CLAIM_TIMEOUT, el worker se detuvo (por ejemplo al reiniciar) y vuelve a pendiente.
+	ClaimedAt *time.Time
+
+	// APIKeyID es la API key con la que se creó la tarea, para reportar el consumo por key.
+	APIKeyID *uint `gorm:"index"`
+
+	// ErrorDetails guarda información adicional del error, por ejemplo el reporte de validación del esquema.
+	ErrorDetails JSON `gorm:"serializer:encrypted;type:jsonb"`
+
+	// Esquema de respuesta pedido (por nombre o enviado en la petición) y la respuesta validada.
+	SchemaName     string `gorm:"type:varchar(128)"`
+	ResponseSchema JSON   `gorm:"type:jsonb"`
+	ResultJSON     JSON   `gorm:"serializer:encrypted;type:jsonb"`
+
+	// Herramientas habilitadas para la tarea y registro de cada llamada con su resultado.
+	Tools     JSON `gorm:"type:jsonb"`
+	ToolCalls JSON `gorm:"serializer:encrypted;type:jsonb"`
+
+	// Datos personales reemplazados por marcadores antes de enviar el prompt a Gemini
+	// ([]pii.Finding, sin los valores originales).
+	Redactions JSON `gorm:"type:jsonb"`
+
+	// Colección consultada y fragmentos incluidos como fuentes en el prompt ([]Citation).
+	CollectionID *uint `gorm:"index"`
+	Citations    JSON  `gorm:"serializer:encrypted;type:jsonb"`
+
+	// Plantilla y versión con las que se generó el prompt, y su instrucción de sistema.
+	TemplateName      string `gorm:"type:varchar(128);index"`
+	TemplateVersion   *int
+	SystemInstruction string `gorm:"serializer:encrypted;type:text"`
+
+	// Lote al que pertenece la tarea y su posición dentro del lote (empezando en 1).
+	BatchID    *string `gorm:"type:varchar(36);index"`
+	BatchIndex *int
+
+	// Caché de respuestas: CacheBypass pide generar la respuesta sin consultar el caché
+	// (Cache-Control: no-cache) y Cached indica que el resultado se tomó del caché.
+	CacheBypass bool `gorm:"not null;default:false"`
+	Cached      bool `gorm:"not null;default:false"`
+
+	// Consumo de tokens y costo estimado de la llamada a Gemini.
+	Model           string  `gorm:"type:varchar(64)"`
+	PromptTokens    int32   `gorm:"not null;default:0"`
+	CandidateTokens int32   `gorm:"not null;default:0"`
+	TotalTokens     int32   `gorm:"not null;default:0"`
+	EstimatedCost   float64 `gorm:"type:numeric(14,8);not null;default:0"`
+
+	// ID de la petición HTTP que creó la tarea, para correlacionar los logs del worker.
+	RequestID string `gorm:"type:varchar(128)"`
+	// Contexto de traza W3C de la petición que originó la tarea, para continuarla en el worker.
+	TraceParent string `gorm:"type:varchar(55)"`
+	TraceState  string `gorm:"type:text"`
+
+	// Retención: LegalHold excluye la tarea de la purga y de la eliminación por el usuario;
+	// ResultPurgedAt es cuándo la purga eliminó el resultado.
+	LegalHold      bool `gorm:"not null;default:false"`
+	ResultPurgedAt *time.Time
+
+	// DeletedAt marca la tarea como eliminada (ver DeletedTaskColumns): las consultas normales
+	// la omiten, pero la fila queda con su consumo para las cuotas y los reportes de uso.
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
 // TableName especifica el nombre de la tabla en la DB.
 func (GeminiProcessingDB) TableName() string {
 	return "gemini.gemini_processing"
 }
+
+// DeletedTaskColumns devuelve los valores con los que se elimina una tarea de model
+// (GeminiProcessingDB o GeminiProcessingFileDB): se borra todo lo que envió el usuario o
+// generó Gemini y se marca deleted_at. El estado, el modelo, los tokens y el costo se
+// conservan, porque las cuotas y los reportes de uso se calculan con ellos.
+func DeletedTaskColumns(model interface{}, now time.Time) map[string]interface{} {
+	columns := map[string]interface{}{
+		"prompt":        "",
+		"result":        "",
+		"result_json":   nil,
+		"error":         "",
+		"error_details": nil,
+		"tool_calls":    nil,
+		"deleted_at":    now,
+	}
+	switch model.(type) {
+	case *GeminiProcessingFileDB:
+		columns["file"] = nil
+		columns["file_name"] = ""
+	default:
+		columns["citations"] = nil
+		columns["system_instruction"] = ""
+	}
+	return columns
+}
@@ -2,19 +2,29 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
-// GeminiProcessingFileIDResponse es la respuesta que contiene el ID de la tarea.
+// GeminiProcessingFileIDResponse es la respuesta que contiene el ID de la tarea (API sin versión).
 type GeminiProcessingFileIDResponse struct {
 	GeminiProcessingFileID string `json:"task_id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
 }
 
 // GeminiProcessingFileResponse representa el estado y el resultado de una tarea.
 type GeminiProcessingFileResponse struct {
-	ID     string                 `json:"id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
-	Status GeminiProcessingStatus `json:"status" example:"finalizado"`
-	Result string                 `json:"result,omitempty" example:"Sí, existen varias becas..."`
-	Error  string                 `json:"error,omitempty"`
+	ID          string                 `json:"id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
+	Status      GeminiProcessingStatus `json:"status" example:"completed"`
+	StatusLabel string                 `json:"status_label" example:"finalizado"`
+	Result      string                 `json:"result,omitempty" example:"Sí, existen varias becas..."`
+	ResultJSON  JSON                   `json:"result_json,omitempty" swaggertype:"object"`
+	ToolCalls   JSON                   `json:"tool_calls,omitempty" swaggertype:"array,object"`
+	Redactions  JSON                   `json:"redactions,omitempty" swaggertype:"array,object"`
+	Cached      bool                   `json:"cached" example:"false"`
+	Error       *TaskError             `json:"error,omitempty"`
+	Usage       *TaskUsage             `json:"usage,omitempty"`
+	// ResultPurgedAt indica que el resultado se eliminó por la política de retención.
+	ResultPurgedAt *time.Time `json:"result_purged_at,omitempty" example:"2025-04-15T03:00:00Z"`
 }
 
 // GeminiProcessingFileDB es el modelo que se guarda en la base de datos.
@@ -23,10 +33,67 @@ type GeminiProcessingFileDB struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Status    GeminiProcessingStatus `gorm:"type:varchar(20);not null"`
-	Result    string                 `gorm:"type:text"`
+	Result    string                 `gorm:"serializer:encrypted;type:text"`
+	ErrorCode string                 `gorm:"type:varchar(64)"`
 	Error     string                 `gorm:"type:text"`
-	Prompt    string                 `gorm:"type:text;not null"`
-	File      []byte                 `gorm:"type:bytea"`
+	Prompt    string                 `gorm:"serializer:encrypted;type:text;not null"`
+	UserID    *uint                  `gorm:"index"`
+	OrgID     *uint                  `gorm:"index"`
+
+	// ClaimedAt es cuándo un worker pasó la tarea a en proceso. Si sigue en proceso después
+	// de TASK_CLAIM_TIMEOUT, el worker se detuvo (por ejemplo al reiniciar) y vuelve a pendiente.
+	ClaimedAt *time.Time
+
+	File []byte `gorm:"serializer:encrypted;type:bytea"`
+	// Nombre y tipo del archivo subido, para procesar la tarea aunque se encole más tarde.
+	FileName string `gorm:"type:varchar(255)"`
+	FileType string `gorm:"type:varchar(64)"`
+
+	// APIKeyID es la API key con la que se creó la tarea, para reportar el consumo por key.
+	APIKeyID *uint `gorm:"index"`
+
+	// ErrorDetails guarda información adicional del error, por ejemplo el reporte de validación del esquema.
+	ErrorDetails JSON `gorm:"serializer:encrypted;type:jsonb"`
+
+	// Esquema de respuesta pedido (por nombre o enviado en la petición) y la respuesta validada.
+	SchemaName     string `gorm:"type:varchar(128)"`
+	ResponseSchema JSON   `gorm:"type:jsonb"`
+	ResultJSON     JSON   `gorm:"serializer:encrypted;type:jsonb"`
+
+	// Herramientas habilitadas para la tarea y registro de cada llamada con su resultado.
+	Tools     JSON `gorm:"type:jsonb"`
+	ToolCalls JSON `gorm:"serializer:encrypted;type:jsonb"`
+
+	// Datos personales reemplazados por marcadores antes de enviar el prompt a Gemini
+	// ([]pii.Finding, sin los valores originales).
+	Redactions JSON `gorm:"type:jsonb"`
+
+	// Caché de respuestas: CacheBypass pide generar la respuesta sin consultar el caché
+	// (Cache-Control: no-cache) y Cached indica que el resultado se tomó del caché.
+	CacheBypass bool `gorm:"not null;default:false"`
+	Cached      bool `gorm:"not null;default:false"`
+
+	// Consumo de tokens y costo estimado de la llamada a Gemini.
+	Model           string  `gorm:"type:varchar(64)"`
+	PromptTokens    int32   `gorm:"not null;default:0"`
+	CandidateTokens int32   `gorm:"not null;default:0"`
+	TotalTokens     int32   `gorm:"not null;default:0"`
+	EstimatedCost   float64 `gorm:"type:numeric(14,8);not null;default:0"`
+
+	// ID de la petición HTTP que creó la tarea, para correlacionar los logs del worker.
+	RequestID string `gorm:"type:varchar(128)"`
+	// Contexto de traza W3C de la petición que originó la tarea, para continuarla en el worker.
+	TraceParent string `gorm:"type:varchar(55)"`
+	TraceState  string `gorm:"type:text"`
+
+	// Retención: LegalHold excluye la tarea de la purga y de la eliminación por el usuario;
+	// FilePurgedAt y ResultPurgedAt son cuándo la purga eliminó el archivo y el resultado.
+	LegalHold      bool `gorm:"not null;default:false"`
+	FilePurgedAt   *time.Time
+	ResultPurgedAt *time.Time
+
+	// DeletedAt marca la tarea como eliminada (ver DeletedTaskColumns).
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
 // TableName especifica el nombre de la tabla en la DB.
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+)
+
+// JSON es un documento JSON guardado en una columna jsonb. Se serializa tal cual en
+// las respuestas y un valor vacío se guarda como NULL.
+type JSON []byte
+
+// Value implementa driver.Valuer.
+func (j JSON) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+// Scan implementa sql.Scanner.
+func (j *JSON) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append((*j)[:0], v...)
+	case string:
+		*j = JSON(v)
+	default:
+		return fmt.Errorf("tipo no soportado para JSON: %T", value)
+	}
+	return nil
+}
+
+// MarshalJSON devuelve el documento sin modificar; un valor vacío se serializa como null.
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+// UnmarshalJSON guarda una copia del documento recibido.
+func (j *JSON) UnmarshalJSON(data []byte) error {
+	if j == nil {
+		return errors.New("models.JSON: UnmarshalJSON sobre un puntero nil")
+	}
+	if string(data) == "null" {
+		*j = nil
+		return nil
+	}
+	*j = append((*j)[:0], data...)
+	return nil
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// OIDCLoginDB es un inicio de sesión con OIDC en curso, entre la redirección al proveedor y
+// el callback. Se busca por el SHA-256 del state y se elimina al usarse.
+type OIDCLoginDB struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	StateHash string `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Nonce     string `gorm:"not null"`
+	// Verifier es el code_verifier de PKCE; el proveedor solo recibe su SHA-256.
+	Verifier  string    `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+// TableName especifica el nombre de la tabla en la DB.
+func (OIDCLoginDB) TableName() string {
+	return "gemini.oidc_logins"
+}
+
+// UserIdentityDB vincula un usuario con su cuenta en un proveedor OIDC (emisor y sub del ID token).
+type UserIdentityDB struct {
+	ID          uint `gorm:"primaryKey"`
+	CreatedAt   time.Time
+	UserID      uint   `gorm:"not null;index"`
+	Issuer      string `gorm:"not null;uniqueIndex:idx_user_identities_subject"`
+	Subject     string `gorm:"not null;uniqueIndex:idx_user_identities_subject"`
+	LastLoginAt time.Time
+}
+
+// TableName especifica el nombre de la tabla en la DB.
+func (UserIdentityDB) TableName() string {
+	return "gemini.user_identities"
+}
+
+// OIDCLoginResponse es la respuesta de un inicio de sesión con OIDC: el usuario y una API key
+// de sesión, que vence a las SESSION_TTL, para identificarse en las demás peticiones.
+type OIDCLoginResponse struct {
+	User   User          `json:"user"`
+	APIKey APIKeyCreated `json:"api_key"`
+	// Created indica si el usuario se creó en este inicio de sesión.
+	Created bool `json:"created" example:"false"`
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// OrgRole es el rol de un usuario dentro de una organización.
+type OrgRole string
+
+const (
+	// OrgRoleOwner administra la organización y sus miembros.
+	OrgRoleOwner OrgRole = "owner"
+	// OrgRoleMember usa los recursos de la organización según su rol de usuario.
+	OrgRoleMember OrgRole = "member"
+)
+
+// Valid indica si el rol es uno de los definidos.
+func (r OrgRole) Valid() bool {
+	return slices.Contains([]OrgRole{OrgRoleOwner, OrgRoleMember}, r)
+}
+
+// OrganizationDB es una organización: agrupa usuarios y aísla sus tareas, lotes, colecciones,
+// plantillas, embeddings, API keys y cuotas de las de otras organizaciones.
+type OrganizationDB struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Name      string `gorm:"type:varchar(128);not null"`
+	Slug      string `gorm:"type:varchar(64);not null;uniqueIndex"`
+
+	// Cuotas de uso de Gemini. nil usa el valor por defecto de la configuración; 0 es sin límite.
+	DailyRequestQuota   *int64
+	MonthlyRequestQuota *int64
+	DailyTokenQuota     *int64
+	MonthlyTokenQuota   *int64
+
+	// Días que se conservan los datos de las tareas terminadas (ver retention.Policy). nil usa
+	// el valor por defecto de la configuración; 0 es sin límite.
+	FileRetentionDays   *int
+	ResultRetentionDays *int
+	TaskRetentionDays   *int
+}
+
+// TableName especifica el nombre de la tabla en la DB.
+func (OrganizationDB) TableName() string {
+	return "gemini.organizations"
+}
+
+// OrgMemberDB es la pertenencia de un usuario a una organización.
+type OrgMemberDB struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	OrgID     uint    `gorm:"not null;uniqueIndex:idx_org_members_org_user"`
+	UserID    uint    `gorm:"not null;uniqueIndex:idx_org_members_org_user;index"`
+	Role      OrgRole `gorm:"type:varchar(20);not null;default:member"`
+}
+
+// TableName especifica el nombre de la tabla en la DB.
+func (OrgMemberDB) TableName() string {
+	return "gemini.org_members"
+}
+
+// OrgQuotas son las cuotas de uso de una organización. Sin valor se usa la cuota por
+// defecto de la configuración; 0 es sin límite.
+type OrgQuotas struct {
+	DailyRequests   *int64 `json:"daily_requests" binding:"omitempty,min=0" example:"1000"`
+	MonthlyRequests *int64 `json:"monthly_requests" binding:"omitempty,min=0" example:"20000"`
+	DailyTokens     *int64 `json:"daily_tokens" binding:"omitempty,min=0" example:"2000000"`
+	MonthlyTokens   *int64 `json:"monthly_tokens" binding:"omitempty,min=0"`
+}
+
+// OrgRetention son los días que se conservan los datos de las tareas terminadas de una
+// organización. Sin valor se usa el valor por defecto de la configuración; 0 es sin límite.
+type OrgRetention struct {
+	// FileDays son los días que se conserva el archivo de las tareas con archivo.
+	FileDays *int `json:"file_days" binding:"omitempty,min=0" example:"7"`
+	// ResultDays son los días que se conservan el resultado y las llamadas a herramientas.
+	ResultDays *int `json:"result_days" binding:"omitempty,min=0" example:"90"`
+	// TaskDays son los días tras los que se elimina la tarea completa.
+	TaskDays *int `json:"task_days" binding:"omitempty,min=0" example:"365"`
+}
+
+// Organization es la respuesta con los datos de una organización.
+type Organization struct {
+	ID        uint         `json:"id" example:"1"`
+	Name      string       `json:"name" example:"Acme"`
+	Slug      string       `json:"slug" example:"acme"`
+	Quotas    OrgQuotas    `json:"quotas"`
+	Retention OrgRetention `json:"retention"`
+	// Role es el rol del usuario de la petición en la organización, si es miembro.
+	Role      OrgRole   `json:"role,omitempty" example:"owner" enums:"owner,member"`
+	CreatedAt time.Time `json:"created_at" example:"2025-01-15T10:30:00Z"`
+}
+
+// ToResponse convierte OrganizationDB en la respuesta, con el rol del usuario de la petición.
+func (o OrganizationDB) ToResponse(role OrgRole) Organization {
+	return Organization{
+		ID:   o.ID,
+		Name: o.Name,
+		Slug: o.Slug,
+		Quotas: OrgQuotas{
+			DailyRequests:   o.DailyRequestQuota,
+			MonthlyRequests: o.MonthlyRequestQuota,
+			DailyTokens:     o.DailyTokenQuota,
+			MonthlyTokens:   o.MonthlyTokenQuota,
+		},
+		Retention: OrgRetention{
+			FileDays:   o.FileRetentionDays,
+			ResultDays: o.ResultRetentionDays,
+			TaskDays:   o.TaskRetentionDays,
+		},
+		Role:      role,
+		CreatedAt: o.CreatedAt,
+	}
+}
+
+// CreateOrganizationInput son los datos para crear una organización.
+type CreateOrganizationInput struct {
+	Name string `json:"name" binding:"required,max=128" example:"Acme"`
+	// Slug identifica a la organización en URLs: minúsculas, números y guiones.
+	Slug string `json:"slug" binding:"required,max=64" example:"acme"`
+}
+
+// UpdateOrganizationInput son los cambios de una organización; solo se modifican los campos
+// enviados. Quotas reemplaza las cuatro cuotas y Retention los tres plazos: los que no se
+// envían vuelven al valor por defecto.
+type UpdateOrganizationInput struct {
+	Name      *string       `json:"name" binding:"omitempty,min=1,max=128" example:"Acme S.A."`
+	Quotas    *OrgQuotas    `json:"quotas"`
+	Retention *OrgRetention `json:"retention"`
+}
+
+// OrgMember es un miembro de una organización.
+type OrgMember struct {
+	UserID    uint      `json:"user_id" example:"1"`
+	FullName  string    `json:"full_name" example:"Efren David"`
+	Email     string    `json:"email" example:"efren@example.com"`
+	Role      OrgRole   `json:"role" example:"member" enums:"owner,member"`
+	CreatedAt time.Time `json:"created_at" example:"2025-01-15T10:30:00Z"`
+}
+
+// AddOrgMemberInput son los datos para agregar un usuario a una organización.
+type AddOrgMemberInput struct {
+	UserID uint    `json:"user_id" binding:"required" example:"2"`
+	Role   OrgRole `json:"role" binding:"omitempty,oneof=owner member" example:"member"`
+}
+
+// UpdateOrgMemberInput es el nuevo rol de un miembro.
+type UpdateOrgMemberInput struct {
+	Role OrgRole `json:"role" binding:"required,oneof=owner member" example:"owner"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// PromptTemplateVersionInput son los datos de una nueva versión de una plantilla de prompt.
+type PromptTemplateVersionInput struct {
+	Description string `json:"description" binding:"max=1000" example:"Resumen de facturas para contabilidad"`
+	// Body es el prompt con variables en sintaxis de text/template, por ejemplo {{.cliente}}.
+	Body string `json:"body" binding:"required" example:"Resume la factura de {{.cliente}} en {{.idioma}}:\n{{.factura}}"`
+	// SystemInstruction es la instrucción de sistema que se envía a Gemini junto con el prompt.
+	SystemInstruction string `json:"system_instruction" example:"Eres un asistente contable. Responde de forma concisa."`
+	// Defaults son los valores por defecto de las variables.
+	Defaults map[string]interface{} `json:"defaults,omitempty" swaggertype:"object"`
+}
+
+// CreatePromptTemplateInput son los datos para crear una plantilla de prompt (versión 1).
+type CreatePromptTemplateInput struct {
+	Name string `json:"name" binding:"required,max=128,excludesall=/?#" example:"invoice-summary"`
+	PromptTemplateVersionInput
+}
+
+// PromptTemplate es una versión de una plantilla de prompt. Variables son las variables
+// que usa el cuerpo de la plantilla.
+type PromptTemplate struct {
+	Name              string    `json:"name" example:"invoice-summary"`
+	Version           int       `json:"version" example:"3"`
+	Description       string    `json:"description,omitempty" example:"Resumen de facturas para contabilidad"`
+	Body              string    `json:"body" example:"Resume la factura de {{.cliente}} en {{.idioma}}:\n{{.factura}}"`
+	SystemInstruction string    `json:"system_instruction,omitempty" example:"Eres un asistente contable. Responde de forma concisa."`
+	Defaults          JSON      `json:"defaults,omitempty" swaggertype:"object"`
+	Variables         []string  `json:"variables" example:"cliente,factura,idioma"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// TaskTemplate identifica la plantilla con la que se creó una tarea.
+type TaskTemplate struct {
+	Name    string `json:"name" example:"invoice-summary"`
+	Version int    `json:"version" example:"3"`
+}
+
+// NewTaskTemplate devuelve la plantilla de una tarea; nil si la tarea no usó plantilla.
+func NewTaskTemplate(name string, version *int) *TaskTemplate {
+	if name == "" || version == nil {
+		return nil
+	}
+	return &TaskTemplate{Name: name, Version: *version}
+}
+
+// PromptTemplateDB es una versión de una plantilla de prompt. Las versiones no se
+// modifican: cada cambio crea una versión nueva con el mismo nombre.
+type PromptTemplateDB struct {
+	ID                uint `gorm:"primaryKey"`
+	CreatedAt         time.Time
+	Name              string `gorm:"type:varchar(128);not null;uniqueIndex:idx_prompt_templates_org_name_version"`
+	Version           int    `gorm:"not null;uniqueIndex:idx_prompt_templates_org_name_version"`
+	Description       string `gorm:"type:text"`
+	Body              string `gorm:"type:text;not null"`
+	SystemInstruction string `gorm:"type:text"`
+	Defaults          JSON   `gorm:"type:jsonb"`
+	UserID            *uint  `gorm:"index"`
+	// OrgID es la organización dueña; nombre y versión son únicos dentro de cada organización.
+	OrgID *uint `gorm:"uniqueIndex:idx_prompt_templates_org_name_version,priority:1"`
+}
+
+// TableName especifica el nombre de la tabla en la DB.
+func (PromptTemplateDB) TableName() string {
+	return "gemini.prompt_templates"
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// RateLimitBucketDB es el estado de un token bucket compartido entre réplicas.
+type RateLimitBucketDB struct {
+	Key       string    `gorm:"primaryKey;type:varchar(255)"`
+	Tokens    float64   `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null;autoUpdateTime:false"`
+}
+
+// TableName especifica el nombre de la tabla en la DB.
+func (RateLimitBucketDB) TableName() string {
+	return "gemini.rate_limit_buckets"
+}
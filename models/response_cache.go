@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// ResponseCacheDB es una respuesta de Gemini guardada para reutilizarla en prompts idénticos.
+type ResponseCacheDB struct {
+	Key        string    `gorm:"primaryKey;type:varchar(64)"`
+	Result     string    `gorm:"serializer:encrypted;type:text;not null"`
+	ResultJSON JSON      `gorm:"serializer:encrypted;type:jsonb"`
+	Model      string    `gorm:"type:varchar(64);not null"`
+	CreatedAt  time.Time `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"not null;index"`
+	LastHitAt  time.Time `gorm:"not null;index"`
+}
+
+// TableName especifica el nombre de la tabla en la DB.
+func (ResponseCacheDB) TableName() string {
+	return "gemini.response_cache"
+}
@@ -0,0 +1,7 @@
+package models
+
+// ResponseSchema es un esquema de respuesta registrado en el servidor.
+type ResponseSchema struct {
+	Name   string `json:"name" example:"invoice"`
+	Schema JSON   `json:"schema" swaggertype:"object"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import "slices"
+
+// Role es el rol de un usuario; determina qué operaciones puede hacer.
+type Role string
+
+const (
+	// RoleAdmin puede hacer todo, incluida la administración de usuarios y ver todas las tareas.
+	RoleAdmin Role = "admin"
+	// RoleMember crea tareas y administra colecciones y plantillas.
+	RoleMember Role = "member"
+	// RoleViewer solo consulta.
+	RoleViewer Role = "viewer"
+	// RoleService es para integraciones: crea y consulta tareas, sin administrar contenido.
+	RoleService Role = "service"
+)
+
+// Roles son los roles válidos.
+var Roles = []Role{RoleAdmin, RoleMember, RoleViewer, RoleService}
+
+// Valid indica si el rol es uno de los definidos.
+func (r Role) Valid() bool {
+	return slices.Contains(Roles, r)
+}
+
+// Permission es una operación que se autoriza por rol.
+type Permission string
+
+const (
+	// PermTasksCreate permite crear tareas, embeddings, lotes y preguntas a colecciones.
+	PermTasksCreate Permission = "tasks:create"
+	// PermTasksRead permite consultar tareas, lotes, esquemas, herramientas y hacer búsquedas.
+	PermTasksRead Permission = "tasks:read"
+	// PermTasksReadAll permite listar las tareas de todos los usuarios.
+	PermTasksReadAll Permission = "tasks:read_all"
+	// PermContentRead permite consultar colecciones, documentos y plantillas.
+	PermContentRead Permission = "content:read"
+	// PermContentWrite permite crear y eliminar colecciones, documentos y plantillas.
+	PermContentWrite Permission = "content:write"
+	// PermUsersRead permite consultar cualquier usuario y su consumo.
+	PermUsersRead Permission = "users:read"
+	// PermUsersManage permite listar, modificar, eliminar y restaurar usuarios y cambiar su rol.
+	PermUsersManage Permission = "users:manage"
+	// PermOrgsManage permite crear organizaciones y administrar aquellas de las que se es owner.
+	PermOrgsManage Permission = "orgs:manage"
+	// PermAuditRead permite consultar y exportar el registro de auditoría.
+	PermAuditRead Permission = "audit:read"
+	// PermTasksLegalHold permite poner y quitar la retención legal de cualquier tarea.
+	PermTasksLegalHold Permission = "tasks:legal_hold"
+	// PermTasksDeleteAll permite eliminar las tareas de otros usuarios de la organización.
+	PermTasksDeleteAll Permission = "tasks:delete_all"
+)
+
+// Permissions son todos los permisos, en el orden en que se documentan.
+var Permissions = []Permission{PermTasksCreate, PermTasksRead, PermTasksReadAll, PermContentRead, PermContentWrite,
+	PermUsersRead, PermUsersManage, PermOrgsManage, PermAuditRead, PermTasksLegalHold, PermTasksDeleteAll}
+
+// Valid indica si el permiso es uno de los definidos.
+func (p Permission) Valid() bool {
+	return slices.Contains(Permissions, p)
+}
+
+// rolePermissions son los permisos de cada rol.
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {PermTasksCreate, PermTasksRead, PermTasksReadAll, PermContentRead, PermContentWrite,
+		PermUsersRead, PermUsersManage, PermOrgsManage, PermAuditRead, PermTasksLegalHold, PermTasksDeleteAll},
+	RoleMember:  {PermTasksCreate, PermTasksRead, PermContentRead, PermContentWrite, PermOrgsManage},
+	RoleViewer:  {PermTasksRead, PermContentRead},
+	RoleService: {PermTasksCreate, PermTasksRead, PermContentRead},
+}
+
+// Can indica si el rol tiene el permiso.
+func (r Role) Can(p Permission) bool {
+	return slices.Contains(rolePermissions[r], p)
+}
@@ -0,0 +1,35 @@
+package models
+
+import "testing"
+
+func TestRolePermissions(t *testing.T) {
+	for _, p := range Permissions {
+		if !RoleAdmin.Can(p) {
+			t.Errorf("admin no tiene el permiso %s", p)
+		}
+	}
+	cases := []struct {
+		role Role
+		perm Permission
+		want bool
+	}{
+		{RoleMember, PermTasksCreate, true},
+		{RoleMember, PermTasksReadAll, false},
+		{RoleMember, PermUsersManage, false},
+		{RoleViewer, PermTasksRead, true},
+		{RoleViewer, PermTasksCreate, false},
+		{RoleViewer, PermContentWrite, false},
+		{RoleService, PermTasksCreate, true},
+		{RoleService, PermContentWrite, false},
+		{Role(""), PermTasksRead, false},
+		{Role("root"), PermTasksRead, false},
+	}
+	for _, c := range cases {
+		if got := c.role.Can(c.perm); got != c.want {
+			t.Errorf("%q.Can(%s) = %v, se esperaba %v", c.role, c.perm, got, c.want)
+		}
+	}
+	if Role("root").Valid() || !RoleService.Valid() {
+		t.Error("Valid no distingue los roles definidos")
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import "gorm.io/gorm"
+
+// TaskStatusChannel es el canal de PostgreSQL (LISTEN/NOTIFY) por el que se avisan los
+// cambios de estado de las tareas a todas las réplicas.
+const TaskStatusChannel = "task_status"
+
+// Tipos de tarea en los eventos de cambio de estado.
+const (
+	TaskKindPrompt = "task"
+	TaskKindFile   = "file_task"
+)
+
+// TaskStatusEvent es el aviso de que una tarea cambió de estado.
+type TaskStatusEvent struct {
+	ID          string                 `json:"id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
+	Kind        string                 `json:"kind" example:"task" enums:"task,file_task"`
+	Status      GeminiProcessingStatus `json:"status" example:"completed"`
+	StatusLabel string                 `json:"status_label,omitempty" example:"finalizado"`
+}
+
+// MigrateTaskStatusNotifications crea los triggers que publican en TaskStatusChannel cada
+// cambio de estado de gemini_processing y gemini_processing_file, sin importar qué réplica
+// o proceso haga la actualización.
+func MigrateTaskStatusNotifications(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`CREATE OR REPLACE FUNCTION gemini.notify_task_status() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_notify('` + TaskStatusChannel + `', json_build_object('id', NEW.id, 'kind', TG_ARGV[0], 'status', NEW.status)::text);
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql`).Error
+		if err != nil {
+			return err
+		}
+		triggers := []struct{ table, kind string }{
+			{GeminiProcessingDB{}.TableName(), TaskKindPrompt},
+			{GeminiProcessingFileDB{}.TableName(), TaskKindFile},
+		}
+		for _, t := range triggers {
+			if err := tx.Exec("DROP TRIGGER IF EXISTS task_status_notify ON " + t.table).Error; err != nil {
+				return err
+			}
+			err := tx.Exec("CREATE TRIGGER task_status_notify AFTER UPDATE OF status ON " + t.table +
+				" FOR EACH ROW WHEN (OLD.status IS DISTINCT FROM NEW.status)" +
+				" EXECUTE FUNCTION gemini.notify_task_status('" + t.kind + "')").Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,8 @@
+package models
+
+// ToolInfo describe una herramienta que Gemini puede llamar durante una tarea.
+type ToolInfo struct {
+	Name        string `json:"name" example:"get_user"`
+	Description string `json:"description" example:"Busca un usuario de la aplicación por su ID"`
+	Parameters  JSON   `json:"parameters" swaggertype:"object"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// TaskUsage es el consumo de tokens de una tarea que se devuelve al consultar su estado.
+type TaskUsage struct {
+	Model           string  `json:"model" example:"gemini-2.0-flash"`
+	PromptTokens    int32   `json:"prompt_tokens" example:"12"`
+	CandidateTokens int32   `json:"candidate_tokens" example:"250"`
+	TotalTokens     int32   `json:"total_tokens" example:"262"`
+	EstimatedCost   float64 `json:"estimated_cost_usd" example:"0.0001012"`
+}
+
+// UsageGroupBy indica cómo se agrupa el reporte de consumo.
+type UsageGroupBy string
+
+const (
+	// UsageGroupByDay agrupa el consumo por día (UTC).
+	UsageGroupByDay UsageGroupBy = "day"
+	// UsageGroupByModel agrupa el consumo por modelo de Gemini.
+	UsageGroupByModel UsageGroupBy = "model"
+)
+
+// UsageItem es una fila del reporte de consumo.
+type UsageItem struct {
+	Key             string  `json:"key" example:"2025-01-31"`
+	Requests        int64   `json:"requests" example:"42"`
+	PromptTokens    int64   `json:"prompt_tokens" example:"1200"`
+	CandidateTokens int64   `json:"candidate_tokens" example:"8000"`
+	TotalTokens     int64   `json:"total_tokens" example:"9200"`
+	EstimatedCost   float64 `json:"estimated_cost_usd" example:"0.00332"`
+}
+
+// UsageReport es la respuesta de GET /users/{id}/usage.
+type UsageReport struct {
+	UserID   uint         `json:"user_id" example:"1"`
+	APIKeyID *uint        `json:"api_key_id,omitempty" example:"3"`
+	From     time.Time    `json:"from" example:"2025-01-01T00:00:00Z"`
+	To       time.Time    `json:"to" example:"2025-02-01T00:00:00Z"`
+	GroupBy  UsageGroupBy `json:"group_by" example:"day"`
+	Items    []UsageItem  `json:"items"`
+	Total    UsageItem    `json:"total"`
+}
+
+// NewTaskUsage construye el consumo de una tarea; devuelve nil si aún no hay consumo registrado.
+func NewTaskUsage(model string, promptTokens, candidateTokens, totalTokens int32, estimatedCost float64) *TaskUsage {
+	if totalTokens == 0 {
+		return nil
+	}
+	return &TaskUsage{
+		Model:           model,
+		PromptTokens:    promptTokens,
+		CandidateTokens: candidateTokens,
+		TotalTokens:     totalTokens,
+		EstimatedCost:   estimatedCost,
+	}
+}
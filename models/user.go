@@ -1,7 +1,10 @@
 package models
 
 import (
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Modelo para la base de datos (GORM)
@@ -9,35 +12,85 @@ type UserDB struct {
 	ID        uint `gorm:"primaryKey"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// DeletedAt marca al usuario como eliminado; las consultas normales lo omiten y se puede restaurar.
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 
 	FullName string `gorm:"not null"`
 	Password string `gorm:"not null"`
 	Email    string `gorm:"uniqueIndex;not null"`
+	Role     Role   `gorm:"type:varchar(20);not null;default:member"`
+	// EmailVerifiedAt es cuándo el usuario confirmó su email actual; nil si aún no lo hizo.
+	EmailVerifiedAt *time.Time
 }
 
 func (UserDB) TableName() string {
 	return "gemini.users"
 }
 
+// NormalizeEmail devuelve el email sin espacios y en minúsculas. Los emails se guardan así y
+// se buscan comparando con lower(email), para que escribirlo con otras mayúsculas (o un usuario
+// guardado antes de normalizarlos) no dé lugar a una segunda cuenta de la misma persona.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// MigrateUserEmailIndex crea el índice sobre lower(email) que usan las búsquedas por email.
+func MigrateUserEmailIndex(db *gorm.DB) error {
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_users_email_lower ON gemini.users (lower(email))").Error
+}
+
 // Modelo para respuesta JSON y Swagger (no expone password)
 type User struct {
-	ID       uint   `json:"id" example:"1"`
-	FullName string `json:"full_name" example:"Efren David"`
-	Email    string `json:"email" example:"efren@example.com"`
+	ID            uint       `json:"id" example:"1"`
+	FullName      string     `json:"full_name" example:"Efren David"`
+	Email         string     `json:"email" example:"efren@example.com"`
+	Role          Role       `json:"role" example:"member" enums:"admin,member,viewer,service"`
+	EmailVerified bool       `json:"email_verified" example:"true"`
+	CreatedAt     time.Time  `json:"created_at" example:"2025-01-15T10:30:00Z"`
+	UpdatedAt     time.Time  `json:"updated_at" example:"2025-01-15T10:30:00Z"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty" example:"2025-02-01T08:00:00Z"`
+}
+
+// UserList es una página de usuarios. NextCursor se envía en ?cursor= para pedir la
+// siguiente página; está vacío en la última.
+type UserList struct {
+	Items      []User `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJzIjoiaWQiLCJpZCI6MjB9"`
 }
 
 // Modelo para recibir creación de usuario (input con password)
 type CreateUserInput struct {
 	FullName string `json:"full_name" binding:"required" example:"Efren David"`
 	Email    string `json:"email" binding:"required,email" example:"efren@example.com"`
-	Password string `json:"password" binding:"required" example:"miPasswordSeguro123"`
+	Password string `json:"password" binding:"required,min=8,max=72" example:"miPasswordSeguro123"`
+	// Role solo lo puede indicar un administrador; por defecto es member.
+	Role Role `json:"role" binding:"omitempty,oneof=admin member viewer service" example:"member"`
+}
+
+// Modelo para cambiar el rol de un usuario
+type UpdateUserRoleInput struct {
+	Role Role `json:"role" binding:"required,oneof=admin member viewer service" example:"viewer"`
+}
+
+// Modelo para actualizar el perfil de un usuario; solo se cambian los campos enviados.
+type UpdateUserInput struct {
+	FullName *string `json:"full_name" binding:"omitempty,min=1" example:"Efren David Garza"`
+	Email    *string `json:"email" binding:"omitempty,email" example:"efren.garza@example.com"`
 }
 
 // Convierte UserDB a User para la respuesta
 func (u *UserDB) ToSwagger() User {
-	return User{
-		ID:       u.ID,
-		FullName: u.FullName,
-		Email:    u.Email,
+	user := User{
+		ID:            u.ID,
+		FullName:      u.FullName,
+		Email:         u.Email,
+		Role:          u.Role,
+		EmailVerified: u.EmailVerifiedAt != nil,
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
+	}
+	if u.DeletedAt.Valid {
+		user.DeletedAt = &u.DeletedAt.Time
 	}
+	return user
 }
@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// TokenPurpose indica para qué sirve un token enviado por correo.
+type TokenPurpose string
+
+const (
+	// TokenEmailVerification confirma que el usuario controla el email.
+	TokenEmailVerification TokenPurpose = "email_verification"
+	// TokenPasswordReset permite elegir una contraseña nueva.
+	TokenPasswordReset TokenPurpose = "password_reset"
+)
+
+// UserTokenDB es un token de un solo uso enviado por correo. Solo se guarda su SHA-256.
+type UserTokenDB struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UserID    uint         `gorm:"not null;index"`
+	Purpose   TokenPurpose `gorm:"type:varchar(32);not null"`
+	Hash      string       `gorm:"type:varchar(64);not null;uniqueIndex"`
+	// Email es la dirección a la que se envió el token; la verificación solo vale si el
+	// usuario no la cambió después.
+	Email     string    `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+}
+
+// TableName especifica el nombre de la tabla en la DB.
+func (UserTokenDB) TableName() string {
+	return "gemini.user_tokens"
+}
+
+// VerifyEmailInput es la petición para confirmar un email con el token recibido.
+type VerifyEmailInput struct {
+	Token string `json:"token" binding:"required" example:"q0v3Yb6m1N2kZ8xR4tH7aLw9cE5sJ2pD0fG3uI6oK1M"`
+}
+
+// EmailInput es la petición que solo indica un email, por ejemplo para recuperar la contraseña.
+type EmailInput struct {
+	Email string `json:"email" binding:"required,email" example:"efren@example.com"`
+}
+
+// LoginInput es la petición para iniciar sesión con email y contraseña.
+type LoginInput struct {
+	Email    string `json:"email" binding:"required,email" example:"efren@example.com"`
+	Password string `json:"password" binding:"required,max=72" example:"miPasswordSeguro123"`
+}
+
+// LoginResponse es la respuesta de un inicio de sesión con contraseña: el usuario y una API
+// key de sesión, que vence a las SESSION_TTL, para identificarse en las demás peticiones.
+type LoginResponse struct {
+	User   User          `json:"user"`
+	APIKey APIKeyCreated `json:"api_key"`
+}
+
+// ResetPasswordInput es la petición para elegir una contraseña nueva con el token recibido.
+type ResetPasswordInput struct {
+	Token    string `json:"token" binding:"required" example:"q0v3Yb6m1N2kZ8xR4tH7aLw9cE5sJ2pD0fG3uI6oK1M"`
+	Password string `json:"password" binding:"required,min=8,max=72" example:"otraPasswordSegura456"`
+}
+
+// AcceptedResponse es la respuesta de las operaciones que se aceptan sin revelar su resultado.
+type AcceptedResponse struct {
+	Message string `json:"message" example:"Si el email está registrado, recibirás un correo con instrucciones"`
+}
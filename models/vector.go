@@ -0,0 +1,66 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Vector es un vector de pgvector. Se guarda con el formato de texto "[1,2,3]".
+type Vector []float32
+
+// Value implementa driver.Valuer.
+func (v Vector) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return v.String(), nil
+}
+
+// String devuelve el vector en el formato de texto de pgvector.
+func (v Vector) String() string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, x := range v {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatFloat(float64(x), 'f', -1, 32))
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+// Scan implementa sql.Scanner.
+func (v *Vector) Scan(value interface{}) error {
+	var text string
+	switch s := value.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case []byte:
+		text = string(s)
+	case string:
+		text = s
+	default:
+		return fmt.Errorf("tipo no soportado para Vector: %T", value)
+	}
+
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "["), "]")
+	if text == "" {
+		*v = Vector{}
+		return nil
+	}
+	parts := strings.Split(text, ",")
+	vector := make(Vector, len(parts))
+	for i, part := range parts {
+		x, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return fmt.Errorf("vector inválido: %w", err)
+		}
+		vector[i] = float32(x)
+	}
+	*v = vector
+	return nil
+}
@@ -0,0 +1,169 @@
+package pii
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Detector encuentra un tipo de dato personal: una expresión regular y, si el tipo tiene
+// dígito verificador, una validación que descarta las coincidencias que no lo cumplen.
+type Detector struct {
+	// Type es el nombre del tipo (email, phone, …); da nombre también al marcador.
+	Type    string
+	pattern *regexp.Regexp
+	valid   func(match string) bool
+	// formatted, si no es nil, indica si la coincidencia tiene el formato propio del tipo; las
+	// que no lo tienen solo se aceptan si context aparece en los contextWindow bytes anteriores.
+	formatted func(match string) bool
+	context   *regexp.Regexp
+}
+
+// contextWindow es cuánto texto antes de una coincidencia se busca el contexto del detector.
+const contextWindow = 32
+
+// Detectors son los detectores disponibles, en el orden en que se aplican: los más
+// específicos primero, para que por ejemplo una CLABE no se tome por un teléfono.
+var Detectors = []*Detector{
+	{Type: "email", pattern: regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9-]+(?:\.[a-z0-9-]+)*\.[a-z]{2,}\b`)},
+	{Type: "curp", pattern: regexp.MustCompile(`(?i)\b[A-Z][AEIOUX][A-Z]{2}\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01])[HMX][A-Z]{2}[B-DF-HJ-NP-TV-Z]{3}[A-Z\d]\d\b`), valid: validCURP},
+	{Type: "rfc", pattern: regexp.MustCompile(`(?i)\b[A-Z&]{3,4}\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01])[A-Z\d]{2}[A\d]\b`), valid: validRFC},
+	{Type: "clabe", pattern: regexp.MustCompile(`\b\d{18}\b`), valid: validCLABE},
+	{Type: "credit_card", pattern: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), valid: validCard},
+	// Diez dígitos seguidos también pueden ser un folio o un número de pedido: sin el formato de
+	// un teléfono, solo se toman como tal después de palabras como "tel." o "celular".
+	{Type: "phone", pattern: regexp.MustCompile(`(?:\+\d{1,3}[ .-]?(?:\(\d{2,3}\) ?|\d{2,3}[ .-]?)|\(\d{2,3}\) ?|\b\d{2,3}[ .-]?)\d{3,4}[ .-]?\d{4}\b`), valid: validPhone,
+		formatted: formattedPhone,
+		context:   regexp.MustCompile(`(?i)\b(?:tel|tels|tel[eé]fonos?|cel|celular|m[oó]vil|whats ?app|phone|mobile|llamar?|marcar?|contacto)\b`)},
+}
+
+// accepts indica si la coincidencia match, que empieza en start dentro de text, es del tipo.
+func (d *Detector) accepts(text string, start int, match string) bool {
+	if d.valid != nil && !d.valid(match) {
+		return false
+	}
+	if d.formatted == nil || d.formatted(match) {
+		return true
+	}
+	return d.context.MatchString(text[max(0, start-contextWindow):start])
+}
+
+// replace reemplaza en text las coincidencias aceptadas por lo que devuelve replacement.
+func (d *Detector) replace(text string, replacement func(match string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range d.pattern.FindAllStringIndex(text, -1) {
+		match := text[loc[0]:loc[1]]
+		if !d.accepts(text, loc[0], match) {
+			continue
+		}
+		b.WriteString(text[last:loc[0]])
+		b.WriteString(replacement(match))
+		last = loc[1]
+	}
+	if last == 0 {
+		return text
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// digits devuelve solo los dígitos de s.
+func digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// validCard aplica el algoritmo de Luhn a los números de 13 a 19 dígitos.
+func validCard(match string) bool {
+	d := digits(match)
+	if len(d) < 13 || len(d) > 19 {
+		return false
+	}
+	sum := 0
+	for i := len(d) - 1; i >= 0; i-- {
+		n := int(d[i] - '0')
+		if (len(d)-1-i)%2 == 1 {
+			if n *= 2; n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+	}
+	return sum%10 == 0
+}
+
+// validPhone acepta números de 10 dígitos, o de 11 a 13 con el código de país.
+func validPhone(match string) bool {
+	n := len(digits(match))
+	return n == 10 || (n > 10 && n <= 13 && strings.HasPrefix(match, "+"))
+}
+
+// formattedPhone indica si el número se escribió como teléfono: con código de país, con la
+// lada entre paréntesis o separado en grupos (55 1234 5678).
+func formattedPhone(match string) bool {
+	separators := strings.Count(match, " ") + strings.Count(match, ".") + strings.Count(match, "-")
+	return strings.HasPrefix(match, "+") || strings.Contains(match, "(") || separators >= 2
+}
+
+// validCLABE comprueba el dígito de control de una CLABE interbancaria (pesos 3, 7, 1).
+func validCLABE(match string) bool {
+	weights := [3]int{3, 7, 1}
+	sum := 0
+	for i := 0; i < 17; i++ {
+		sum += int(match[i]-'0') * weights[i%3] % 10
+	}
+	return (10-sum%10)%10 == int(match[17]-'0')
+}
+
+// curpAlphabet da el valor de cada carácter en el dígito verificador de la CURP.
+const curpAlphabet = "0123456789ABCDEFGHIJKLMNÑOPQRSTUVWXYZ"
+
+// validCURP comprueba el dígito verificador de la CURP (el último carácter).
+func validCURP(match string) bool {
+	chars := []rune(strings.ToUpper(match))
+	sum := 0
+	for i, c := range chars[:17] {
+		v := strings.IndexRune(curpAlphabet, c)
+		if v < 0 {
+			return false
+		}
+		// Ñ ocupa dos bytes: se cuenta su posición en runas.
+		v = len([]rune(curpAlphabet[:v]))
+		sum += v * (18 - i)
+	}
+	return (10-sum%10)%10 == int(chars[17]-'0')
+}
+
+// rfcAlphabet da el valor de cada carácter en el dígito verificador del RFC.
+const rfcAlphabet = "0123456789ABCDEFGHIJKLMN&OPQRSTUVWXYZ Ñ"
+
+// validRFC comprueba el dígito verificador del RFC de personas físicas (13 caracteres) y
+// morales (12, que se completan con un espacio al inicio).
+func validRFC(match string) bool {
+	chars := []rune(strings.ToUpper(match))
+	if len(chars) == 12 {
+		chars = append([]rune{' '}, chars...)
+	}
+	sum := 0
+	for i, c := range chars[:12] {
+		v := strings.IndexRune(rfcAlphabet, c)
+		if v < 0 {
+			return false
+		}
+		v = len([]rune(rfcAlphabet[:v]))
+		sum += v * (13 - i)
+	}
+	expected := byte('0')
+	switch rem := sum % 11; {
+	case rem == 1:
+		expected = 'A'
+	case rem > 1:
+		expected = byte('0' + 11 - rem)
+	}
+	return chars[12] == rune(expected)
+}
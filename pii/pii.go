@@ -0,0 +1,210 @@
+// Package pii detecta datos personales (emails, teléfonos, tarjetas, CURP, RFC, CLABE) en los
+// textos que se envían a Gemini y los reemplaza por marcadores como [EMAIL_1], para que no
+// salgan de nuestra red. Los marcadores de la respuesta se pueden restaurar con los valores
+// originales, que nunca se envían.
+package pii
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Redactor reemplaza los datos personales que encuentran sus detectores.
+type Redactor struct {
+	detectors []*Detector
+	restore   bool
+}
+
+// New crea un Redactor con los detectores indicados por tipo. Con restore, Restore devuelve
+// los valores originales en la respuesta; sin él, la respuesta conserva los marcadores.
+func New(types []string, restore bool) (*Redactor, error) {
+	r := &Redactor{restore: restore}
+	for _, t := range types {
+		d := detector(t)
+		if d == nil {
+			return nil, fmt.Errorf("detector de datos personales desconocido: %q", t)
+		}
+		r.detectors = append(r.detectors, d)
+	}
+	return r, nil
+}
+
+func detector(t string) *Detector {
+	for _, d := range Detectors {
+		if d.Type == t {
+			return d
+		}
+	}
+	return nil
+}
+
+// FromEnv crea el Redactor configurado en PII_DETECTORS (tipos separados por comas; por
+// defecto todos, "none" desactiva la redacción y devuelve nil) y PII_RESTORE (por defecto true).
+func FromEnv() (*Redactor, error) {
+	value := strings.TrimSpace(os.Getenv("PII_DETECTORS"))
+	if value == "none" {
+		return nil, nil
+	}
+	var types []string
+	if value == "" {
+		for _, d := range Detectors {
+			types = append(types, d.Type)
+		}
+	} else {
+		for _, t := range strings.Split(value, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				types = append(types, t)
+			}
+		}
+	}
+	return New(types, os.Getenv("PII_RESTORE") != "false")
+}
+
+// Finding es un dato personal reemplazado: su tipo, el marcador y cuántas veces aparecía. El
+// valor original no se incluye.
+type Finding struct {
+	Type        string `json:"type" example:"email"`
+	Placeholder string `json:"placeholder" example:"[EMAIL_1]"`
+	Count       int    `json:"count" example:"1"`
+}
+
+// Redaction reemplaza los datos personales de los textos de una tarea. El mismo valor recibe
+// siempre el mismo marcador, también entre el prompt y la instrucción de sistema, para que el
+// modelo pueda relacionarlos.
+type Redaction struct {
+	redactor     *Redactor
+	placeholders map[string]string // valor original → marcador
+	originals    map[string]string // marcador → valor original
+	findings     []Finding
+	perType      map[string]int
+}
+
+// Start empieza la redacción de una tarea. Un Redactor nil no reemplaza nada.
+func (r *Redactor) Start() *Redaction {
+	return &Redaction{
+		redactor:     r,
+		placeholders: map[string]string{},
+		originals:    map[string]string{},
+		perType:      map[string]int{},
+	}
+}
+
+// Redact devuelve el texto con los datos personales reemplazados por marcadores.
+func (x *Redaction) Redact(text string) string {
+	if x.redactor == nil || text == "" {
+		return text
+	}
+	for _, d := range x.redactor.detectors {
+		text = d.replace(text, func(match string) string {
+			return x.placeholder(d.Type, match)
+		})
+	}
+	return text
+}
+
+// RedactMap devuelve una copia del resultado de una herramienta con los datos personales de sus
+// strings reemplazados por marcadores, para devolverlo al modelo. El resultado se revisa como el
+// JSON en que se envía, así que también se cubren structs y tipos propios; si no se puede
+// convertir a JSON se devuelve vacío.
+func (x *Redaction) RedactMap(value map[string]interface{}) map[string]interface{} {
+	if x.redactor == nil || len(value) == 0 {
+		return value
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var copied map[string]interface{}
+	if err := decoder.Decode(&copied); err != nil {
+		return map[string]interface{}{}
+	}
+	return replaceStrings(copied, x.Redact).(map[string]interface{})
+}
+
+// placeholder devuelve el marcador del valor, creándolo si es la primera vez que aparece.
+func (x *Redaction) placeholder(t, value string) string {
+	if p, ok := x.placeholders[value]; ok {
+		for i := range x.findings {
+			if x.findings[i].Placeholder == p {
+				x.findings[i].Count++
+			}
+		}
+		return p
+	}
+	x.perType[t]++
+	p := fmt.Sprintf("[%s_%d]", strings.ToUpper(t), x.perType[t])
+	x.placeholders[value] = p
+	x.originals[p] = value
+	x.findings = append(x.findings, Finding{Type: t, Placeholder: p, Count: 1})
+	return p
+}
+
+// Findings devuelve los datos reemplazados, en el orden en que aparecieron.
+func (x *Redaction) Findings() []Finding {
+	return x.findings
+}
+
+// Restore devuelve el texto de la respuesta con los marcadores reemplazados por los valores
+// originales. Solo se restauran los marcadores que se generaron en esta redacción y que
+// aparecen sin cambios; si la restauración está desactivada, el texto no cambia.
+func (x *Redaction) Restore(text string) string {
+	if len(x.originals) == 0 || !x.redactor.restore {
+		return text
+	}
+	pairs := make([]string, 0, 2*len(x.originals))
+	for p, v := range x.originals {
+		pairs = append(pairs, p, v)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// RestoreJSON restaura los marcadores solo dentro de los strings del documento, para que los
+// valores originales queden bien escapados y el documento siga siendo válido.
+func (x *Redaction) RestoreJSON(doc json.RawMessage) json.RawMessage {
+	if len(x.originals) == 0 || !x.redactor.restore || !x.containsPlaceholder(doc) {
+		return doc
+	}
+	// UseNumber conserva los números tal cual; json.Marshal ordena las claves de los objetos.
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return doc
+	}
+	restored, err := json.Marshal(replaceStrings(value, x.Restore))
+	if err != nil {
+		return doc
+	}
+	return restored
+}
+
+func (x *Redaction) containsPlaceholder(doc []byte) bool {
+	for p := range x.originals {
+		if bytes.Contains(doc, []byte(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceStrings aplica replace a cada string de un valor decodificado de JSON.
+func replaceStrings(value interface{}, replace func(string) string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return replace(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = replaceStrings(v[i], replace)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = replaceStrings(item, replace)
+		}
+	}
+	return value
+}
@@ -0,0 +1,99 @@
+package pii
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string // tipo detectado, o "" si no debe detectarse nada
+	}{
+		{"tarjeta válida", "pago con 4111111111111111", "credit_card"},
+		{"tarjeta con espacios", "pago con 5500 0055 5555 5559", "credit_card"},
+		{"American Express", "pago con 378282246310005", "credit_card"},
+		{"tarjeta con Luhn inválido", "pago con 4111111111111112", ""},
+		{"CURP válida", "mi CURP es GODE561231HDFRRN00", "curp"},
+		{"CURP en minúsculas", "mi curp es mapa900101mdfrrn04", "curp"},
+		{"CURP con dígito inválido", "mi CURP es GODE561231HDFRRN01", ""},
+		{"RFC de persona física", "RFC GODE561231GR8", "rfc"},
+		{"RFC de persona moral", "RFC ABC680524P73", "rfc"},
+		{"RFC con dígito inválido", "RFC GODE561231GR9", ""},
+		{"CLABE válida", "CLABE 032180000118359719", "clabe"},
+		{"CLABE con dígito inválido", "CLABE 032180000118359718", ""},
+		{"teléfono con lada entre paréntesis", "llama al (55) 1234 5678", "phone"},
+		{"teléfono separado en grupos", "oficina 55-1234-5678", "phone"},
+		{"teléfono con código de país", "+52 5512345678", "phone"},
+		{"teléfono con código de país y lada", "+52 (55) 1234 5678", "phone"},
+		{"teléfono con código de país sin separador", "+525512345678", "phone"},
+		{"teléfono sin formato después de tel.", "Tel. 5512345678", "phone"},
+		{"teléfono sin formato después de celular", "mi celular: 5512345678", "phone"},
+		{"diez dígitos sin contexto", "pedido 5512345678 enviado", ""},
+		{"folio sin contexto", "folio 1234567890", ""},
+		{"email", "escribe a ana@example.com", "email"},
+	}
+	redactor, err := New([]string{"email", "curp", "rfc", "clabe", "credit_card", "phone"}, true)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redaction := redactor.Start()
+			redacted := redaction.Redact(tt.text)
+			findings := redaction.Findings()
+			if tt.want == "" {
+				if len(findings) != 0 {
+					t.Fatalf("Redact(%q) = %q, no se esperaba ningún dato personal", tt.text, redacted)
+				}
+				return
+			}
+			if len(findings) != 1 || findings[0].Type != tt.want {
+				t.Fatalf("Redact(%q) = %q con %+v, se esperaba un %s", tt.text, redacted, findings, tt.want)
+			}
+			if got := redaction.Restore(redacted); got != tt.text {
+				t.Errorf("Restore = %q, se esperaba %q", got, tt.text)
+			}
+		})
+	}
+}
+
+func TestRedactMap(t *testing.T) {
+	redactor, err := New([]string{"email"}, true)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	type user struct {
+		Email string `json:"email"`
+	}
+	original := map[string]interface{}{
+		"found": true,
+		"id":    7,
+		"user":  user{Email: "ana@example.com"},
+		"cc":    []interface{}{"beto@example.com"},
+	}
+	redacted := redactor.Start().RedactMap(original)
+
+	email := redacted["user"].(map[string]interface{})["email"]
+	cc := redacted["cc"].([]interface{})[0]
+	for _, value := range []interface{}{email, cc} {
+		if s, _ := value.(string); !strings.HasPrefix(s, "[EMAIL_") {
+			t.Errorf("valor %v sin redactar, se esperaba un marcador [EMAIL_n]", value)
+		}
+	}
+	if email == cc {
+		t.Errorf("dos emails distintos con el mismo marcador %v", email)
+	}
+	if redacted["found"] != true || redacted["id"].(interface{ String() string }).String() != "7" {
+		t.Errorf("valores que no son texto cambiados: %v", redacted)
+	}
+	if original["cc"].([]interface{})[0] != "beto@example.com" {
+		t.Error("RedactMap modificó el resultado original")
+	}
+
+	var disabled *Redactor
+	if got := disabled.Start().RedactMap(original); !strings.Contains(got["cc"].([]interface{})[0].(string), "@") {
+		t.Error("sin Redactor el resultado no debe cambiar")
+	}
+}
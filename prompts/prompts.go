@@ -0,0 +1,92 @@
+// Package prompts compila y ejecuta las plantillas de prompt. Las plantillas usan la
+// sintaxis de text/template: las variables se escriben {{.cliente}} y se pueden usar
+// condiciones, rangos y funciones como {{if .nota}}...{{end}}.
+package prompts
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Parse compila el cuerpo de una plantilla. Al ejecutarla, una variable sin valor es un error.
+func Parse(name string, body string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=error").Parse(body)
+}
+
+// Render ejecuta la plantilla con los valores por defecto combinados con variables;
+// los valores de variables tienen prioridad.
+func Render(name string, body string, defaults map[string]interface{}, variables map[string]interface{}) (string, error) {
+	tmpl, err := Parse(name, body)
+	if err != nil {
+		return "", err
+	}
+	data := make(map[string]interface{}, len(defaults)+len(variables))
+	maps.Copy(data, defaults)
+	maps.Copy(data, variables)
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// Variables devuelve, ordenados, los nombres de las variables de primer nivel que usa la
+// plantilla ({{.cliente}} o {{.cliente.nombre}} → "cliente"). Dentro de range y with el
+// punto cambia de valor, por lo que solo se consideran sus expresiones de control.
+func Variables(tmpl *template.Template) []string {
+	names := map[string]bool{}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			collectNode(t.Tree.Root, names)
+		}
+	}
+	return slices.Sorted(maps.Keys(names))
+}
+
+// collectNode agrega a names las variables usadas en el nodo y sus hijos.
+func collectNode(node parse.Node, names map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectNode(child, names)
+		}
+	case *parse.ActionNode:
+		collectPipe(n.Pipe, names)
+	case *parse.IfNode:
+		collectPipe(n.Pipe, names)
+		collectNode(n.List, names)
+		collectNode(n.ElseList, names)
+	case *parse.RangeNode:
+		collectPipe(n.Pipe, names)
+		collectNode(n.ElseList, names)
+	case *parse.WithNode:
+		collectPipe(n.Pipe, names)
+		collectNode(n.ElseList, names)
+	case *parse.TemplateNode:
+		collectPipe(n.Pipe, names)
+	}
+}
+
+// collectPipe agrega a names las variables usadas en los argumentos del pipeline.
+func collectPipe(pipe *parse.PipeNode, names map[string]bool) {
+	if pipe == nil {
+		return
+	}
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			switch a := arg.(type) {
+			case *parse.FieldNode:
+				names[a.Ident[0]] = true
+			case *parse.PipeNode:
+				collectPipe(a, names)
+			}
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package prompts
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	body := "Resume la factura de {{.cliente}} en {{.idioma}}.{{if .nota}} Nota: {{.nota}}{{end}}"
+	defaults := map[string]interface{}{"idioma": "español", "nota": ""}
+
+	got, err := Render("factura", body, defaults, map[string]interface{}{"cliente": "Acme", "idioma": "inglés"})
+	if err != nil || got != "Resume la factura de Acme en inglés." {
+		t.Errorf("Render = %q, err = %v", got, err)
+	}
+	got, err = Render("factura", body, defaults, map[string]interface{}{"cliente": "Acme", "nota": "urgente"})
+	if err != nil || got != "Resume la factura de Acme en español. Nota: urgente" {
+		t.Errorf("Render con valores por defecto = %q, err = %v", got, err)
+	}
+
+	// Una variable sin valor ni valor por defecto es un error, no "<no value>".
+	if got, err := Render("factura", body, defaults, nil); err == nil {
+		t.Errorf("Render sin cliente = %q, se esperaba un error", got)
+	}
+	if _, err := Render("rota", "{{.cliente", nil, nil); err == nil {
+		t.Error("Render con una plantilla mal formada no devolvió error")
+	}
+}
+
+func TestVariables(t *testing.T) {
+	tmpl, err := Parse("p", `{{.cliente.nombre}} {{if .nota}}{{.nota}}{{end}} {{range .items}}{{.precio}}{{end}} {{with .pie}}{{.texto}}{{end}}`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := []string{"cliente", "items", "nota", "pie"}
+	if got := Variables(tmpl); !slices.Equal(got, want) {
+		t.Errorf("Variables = %v, se esperaba %v", got, want)
+	}
+}
@@ -0,0 +1,80 @@
+package rag
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Tamaños por defecto de los fragmentos, en caracteres.
+const (
+	defaultChunkSize    = 1500
+	defaultChunkOverlap = 200
+)
+
+// Chunk es un fragmento del documento que se indexa por separado.
+type Chunk struct {
+	// Index es la posición del fragmento dentro del documento, empezando en 0.
+	Index int
+	Page  int
+	Text  string
+}
+
+// ChunkSize devuelve el tamaño máximo de los fragmentos configurado en RAG_CHUNK_SIZE.
+func ChunkSize() int {
+	if v, err := strconv.Atoi(os.Getenv("RAG_CHUNK_SIZE")); err == nil && v > 0 {
+		return v
+	}
+	return defaultChunkSize
+}
+
+// ChunkOverlap devuelve los caracteres que se repiten entre fragmentos consecutivos
+// (RAG_CHUNK_OVERLAP), para no cortar ideas a la mitad.
+func ChunkOverlap() int {
+	if v, err := strconv.Atoi(os.Getenv("RAG_CHUNK_OVERLAP")); err == nil && v >= 0 {
+		return v
+	}
+	return defaultChunkOverlap
+}
+
+// Split divide cada página en fragmentos de hasta size caracteres sin cortar palabras.
+// Los fragmentos no cruzan páginas para poder citar la página exacta; cada fragmento
+// empieza con las últimas palabras del anterior hasta sumar overlap caracteres.
+func Split(pages []Page, size int, overlap int) []Chunk {
+	if overlap >= size {
+		overlap = size / 2
+	}
+
+	var chunks []Chunk
+	for _, page := range pages {
+		words := strings.Fields(page.Text)
+		start := 0
+		for start < len(words) {
+			end, length := start, 0
+			for end < len(words) && (end == start || length+1+len(words[end]) <= size) {
+				if end > start {
+					length++
+				}
+				length += len(words[end])
+				end++
+			}
+			chunks = append(chunks, Chunk{
+				Index: len(chunks),
+				Page:  page.Number,
+				Text:  strings.Join(words[start:end], " "),
+			})
+			if end == len(words) {
+				break
+			}
+
+			// Retroceder desde el final hasta cubrir el solapamiento, avanzando al menos una palabra.
+			next, kept := end, 0
+			for next-1 > start && kept+len(words[next-1])+1 <= overlap {
+				next--
+				kept += len(words[next]) + 1
+			}
+			start = next
+		}
+	}
+	return chunks
+}
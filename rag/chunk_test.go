@@ -0,0 +1,78 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name    string
+		pages   []Page
+		size    int
+		overlap int
+		want    []Chunk
+	}{
+		{
+			name:  "sin solapamiento",
+			pages: []Page{{Number: 1, Text: "uno dos tres cuatro cinco"}},
+			size:  10, overlap: 0,
+			want: []Chunk{{0, 1, "uno dos"}, {1, 1, "tres"}, {2, 1, "cuatro"}, {3, 1, "cinco"}},
+		},
+		{
+			name:  "repite las últimas palabras del fragmento anterior",
+			pages: []Page{{Number: 1, Text: "uno dos tres cuatro cinco seis siete ocho"}},
+			size:  15, overlap: 5,
+			want: []Chunk{{0, 1, "uno dos tres"}, {1, 1, "tres cuatro"}, {2, 1, "cinco seis"}, {3, 1, "seis siete ocho"}},
+		},
+		{
+			name:  "no cruza páginas y numera en todo el documento",
+			pages: []Page{{Number: 1, Text: "uno dos"}, {Number: 2, Text: "  "}, {Number: 3, Text: "tres\ncuatro"}},
+			size:  100, overlap: 10,
+			want: []Chunk{{0, 1, "uno dos"}, {1, 3, "tres cuatro"}},
+		},
+		{
+			name:  "una palabra más larga que el tamaño no se corta",
+			pages: []Page{{Number: 1, Text: "a supercalifragilístico b"}},
+			size:  5, overlap: 0,
+			want: []Chunk{{0, 1, "a"}, {1, 1, "supercalifragilístico"}, {2, 1, "b"}},
+		},
+		{
+			name:  "el solapamiento no pasa de la mitad del tamaño",
+			pages: []Page{{Number: 1, Text: "aa bb cc dd"}},
+			size:  7, overlap: 50,
+			want: []Chunk{{0, 1, "aa bb"}, {1, 1, "bb cc"}, {2, 1, "cc dd"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Split(tt.pages, tt.size, tt.overlap)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Split = %+v, se esperaba %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Split = %+v, se esperaba %+v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildPromptNumbersTheSources(t *testing.T) {
+	prompt := BuildPrompt("¿Cuál es el promedio mínimo?", []Source{
+		{Filename: "reglamento.pdf", Page: 3, Text: "El promedio mínimo es 8.5."},
+		{Filename: "faq.md", Page: 1, Text: "La beca se renueva cada semestre."},
+	})
+	for _, want := range []string{
+		"[1] reglamento.pdf, página 3:\nEl promedio mínimo es 8.5.",
+		"[2] faq.md, página 1:\nLa beca se renueva cada semestre.",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("el prompt no incluye la fuente %q:\n%s", want, prompt)
+		}
+	}
+	if !strings.HasSuffix(prompt, "Pregunta: ¿Cuál es el promedio mínimo?") {
+		t.Errorf("el prompt no termina con la pregunta:\n%s", prompt)
+	}
+}
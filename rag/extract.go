@@ -0,0 +1,81 @@
+package rag
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+var (
+	// ErrUnsupportedType indica que no se sabe extraer texto de ese tipo de archivo.
+	ErrUnsupportedType = errors.New("tipo de documento no soportado")
+	// ErrNoText indica que el documento no contiene texto extraíble (por ejemplo, un PDF escaneado).
+	ErrNoText = errors.New("el documento no contiene texto")
+)
+
+// SupportedTypes son los tipos MIME de los documentos que se pueden indexar.
+var SupportedTypes = []string{"application/pdf", "text/plain", "text/markdown"}
+
+// Page es el texto de una página del documento; los documentos de texto tienen una sola página.
+type Page struct {
+	Number int
+	Text   string
+}
+
+// Extract obtiene el texto de cada página del documento.
+func Extract(data []byte, mimeType string) ([]Page, error) {
+	var pages []Page
+	var err error
+	switch mimeType {
+	case "application/pdf":
+		pages, err = extractPDF(data)
+	case "text/plain", "text/markdown":
+		if !utf8.Valid(data) {
+			return nil, fmt.Errorf("%w: el texto no es UTF-8", ErrUnsupportedType)
+		}
+		pages = []Page{{Number: 1, Text: string(data)}}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, mimeType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, page := range pages {
+		if strings.TrimSpace(page.Text) != "" {
+			return pages, nil
+		}
+	}
+	return nil, ErrNoText
+}
+
+// extractPDF lee el texto de cada página. La librería puede entrar en pánico con PDFs
+// mal formados, por lo que el pánico se convierte en error.
+func extractPDF(data []byte) (pages []Page, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("PDF inválido: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("PDF inválido: %w", err)
+	}
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		text, err := page.GetPlainText(nil)
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo la página %d: %w", i, err)
+		}
+		pages = append(pages, Page{Number: i, Text: text})
+	}
+	return pages, nil
+}
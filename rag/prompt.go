@@ -0,0 +1,32 @@
+package rag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Source es un fragmento recuperado que se incluye en el prompt como contexto.
+type Source struct {
+	Filename string
+	Page     int
+	Text     string
+}
+
+// BuildPrompt arma el prompt con los fragmentos numerados como contexto. Se pide al
+// modelo que cite las fuentes con su número entre corchetes, que coincide con el
+// índice de la cita devuelta al cliente (empezando en 1).
+func BuildPrompt(question string, sources []Source) string {
+	var sb strings.Builder
+	sb.WriteString("Responde la pregunta usando únicamente la información de las fuentes siguientes. ")
+	sb.WriteString("Cita las fuentes que uses con su número entre corchetes, por ejemplo [1]. ")
+	sb.WriteString("Si las fuentes no contienen la respuesta, dilo claramente. ")
+	sb.WriteString("Responde en el mismo idioma de la pregunta.\n\n")
+
+	for i, source := range sources {
+		fmt.Fprintf(&sb, "[%d] %s, página %d:\n%s\n\n", i+1, source.Filename, source.Page, source.Text)
+	}
+
+	sb.WriteString("Pregunta: ")
+	sb.WriteString(question)
+	return sb.String()
+}
@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostgresStore guarda los buckets en PostgreSQL para compartirlos entre réplicas.
+// Cada consumo bloquea la fila del bucket dentro de una transacción.
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresStore crea un Store respaldado por la tabla gemini.rate_limit_buckets.
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Take consume un token del bucket indicado.
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Decision, error) {
+	var decision Decision
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// La hora la da la base de datos para que todas las réplicas usen el mismo reloj.
+		var now time.Time
+		if err := tx.Raw("SELECT now()").Scan(&now).Error; err != nil {
+			return err
+		}
+
+		bucket := models.RateLimitBucketDB{Key: key, Tokens: float64(limit.Burst), UpdatedAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&bucket).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&bucket, "key = ?", key).Error; err != nil {
+			return err
+		}
+
+		var tokens float64
+		tokens, decision = refill(bucket.Tokens, bucket.UpdatedAt, now, limit)
+		return tx.Model(&bucket).Updates(map[string]interface{}{
+			"tokens":     tokens,
+			"updated_at": now,
+		}).Error
+	})
+	return decision, err
+}
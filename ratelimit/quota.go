@@ -0,0 +1,173 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"gorm.io/gorm"
+)
+
+// Quotas son los límites de uso de Gemini de una organización. 0 significa sin límite.
+type Quotas struct {
+	DailyRequests   int64
+	MonthlyRequests int64
+	DailyTokens     int64
+	MonthlyTokens   int64
+}
+
+// DefaultQuotas lee las cuotas por defecto de QUOTA_DAILY_REQUESTS, QUOTA_MONTHLY_REQUESTS,
+// QUOTA_DAILY_TOKENS y QUOTA_MONTHLY_TOKENS. Las variables no definidas valen 0 (sin límite).
+func DefaultQuotas() (Quotas, error) {
+	var q Quotas
+	vars := []struct {
+		name string
+		dest *int64
+	}{
+		{"QUOTA_DAILY_REQUESTS", &q.DailyRequests},
+		{"QUOTA_MONTHLY_REQUESTS", &q.MonthlyRequests},
+		{"QUOTA_DAILY_TOKENS", &q.DailyTokens},
+		{"QUOTA_MONTHLY_TOKENS", &q.MonthlyTokens},
+	}
+	for _, v := range vars {
+		value := os.Getenv(v.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			return q, fmt.Errorf("%s inválido: %q", v.name, value)
+		}
+		*v.dest = n
+	}
+	return q, nil
+}
+
+// QuotasFor combina las cuotas propias de la organización con las cuotas por defecto.
+func QuotasFor(org models.OrganizationDB, defaults Quotas) Quotas {
+	pick := func(own *int64, def int64) int64 {
+		if own != nil {
+			return *own
+		}
+		return def
+	}
+	return Quotas{
+		DailyRequests:   pick(org.DailyRequestQuota, defaults.DailyRequests),
+		MonthlyRequests: pick(org.MonthlyRequestQuota, defaults.MonthlyRequests),
+		DailyTokens:     pick(org.DailyTokenQuota, defaults.DailyTokens),
+		MonthlyTokens:   pick(org.MonthlyTokenQuota, defaults.MonthlyTokens),
+	}
+}
+
+// quotaUsage es el uso acumulado de la organización en el día y el mes en curso.
+type quotaUsage struct {
+	DayRequests   int64
+	DayTokens     int64
+	MonthRequests int64
+	MonthTokens   int64
+}
+
+// QuotaExceeded describe la cuota que se superó.
+type QuotaExceeded struct {
+	// Name identifica la cuota: daily_requests, monthly_requests, daily_tokens o monthly_tokens.
+	Name  string
+	Limit int64
+	Used  int64
+	// ResetAt es el inicio del siguiente día o mes (UTC).
+	ResetAt time.Time
+}
+
+// Reservations lleva las tareas que las peticiones en curso de cada organización ya pasaron
+// por la cuota pero todavía no crearon. CheckQuota solo cuenta las tareas guardadas, así que
+// sin reservarlas dos peticiones simultáneas podrían ocupar el último lugar de la cuota.
+// Las reservas son de este proceso: con varias réplicas la cuota de peticiones puede
+// superarse en las tareas que se crean a la vez en las demás.
+type Reservations struct {
+	mu     sync.Mutex
+	counts map[uint]int64
+}
+
+// Charge comprueba la cuota como CheckQuota contando también las tareas reservadas por otras
+// peticiones y, si la organización puede crear requests tareas más, se las reserva. held son
+// las que la misma petición ya reservó, que no se cuentan. La reserva se libera con Release
+// cuando la petición terminó de crear sus tareas.
+func (r *Reservations) Charge(ctx context.Context, db *gorm.DB, orgID uint, quotas Quotas, requests, held int64, now time.Time) (*QuotaExceeded, error) {
+	r.mu.Lock()
+	if r.counts == nil {
+		r.counts = map[uint]int64{}
+	}
+	reserved := r.counts[orgID] - held
+	r.counts[orgID] += requests
+	r.mu.Unlock()
+
+	exceeded, err := CheckQuota(ctx, db, orgID, quotas, requests+reserved, now)
+	if err != nil || exceeded != nil {
+		r.Release(orgID, requests)
+	}
+	return exceeded, err
+}
+
+// Release libera requests tareas reservadas por Charge.
+func (r *Reservations) Release(orgID uint, requests int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.counts[orgID] -= requests; r.counts[orgID] <= 0 {
+		delete(r.counts, orgID)
+	}
+}
+
+// CheckQuota compara el uso de la organización en el día y el mes en curso (UTC) con sus
+// cuotas. Devuelve nil si la organización aún puede crear requests tareas: las cuotas de
+// peticiones se superan si el uso más requests pasa del límite; las de tokens, que no se
+// conocen hasta procesar las tareas, si el uso ya llegó al límite.
+func CheckQuota(ctx context.Context, db *gorm.DB, orgID uint, quotas Quotas, requests int64, now time.Time) (*QuotaExceeded, error) {
+	if quotas == (Quotas{}) {
+		return nil, nil
+	}
+
+	now = now.UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	query := fmt.Sprintf(`
+		SELECT
+			COUNT(*) FILTER (WHERE created_at >= @day) AS day_requests,
+			COALESCE(SUM(total_tokens) FILTER (WHERE created_at >= @day), 0) AS day_tokens,
+			COUNT(*) AS month_requests,
+			COALESCE(SUM(total_tokens), 0) AS month_tokens
+		FROM (
+			SELECT created_at, total_tokens FROM %s WHERE org_id = @org AND created_at >= @month
+			UNION ALL
+			SELECT created_at, total_tokens FROM %s WHERE org_id = @org AND created_at >= @month
+		) AS usage`,
+		models.GeminiProcessingDB{}.TableName(), models.GeminiProcessingFileDB{}.TableName())
+
+	var used quotaUsage
+	params := map[string]interface{}{"org": orgID, "day": dayStart, "month": monthStart}
+	if err := db.WithContext(ctx).Raw(query, params).Scan(&used).Error; err != nil {
+		return nil, err
+	}
+
+	nextDay := dayStart.AddDate(0, 0, 1)
+	nextMonth := monthStart.AddDate(0, 1, 0)
+	checks := []struct {
+		QuotaExceeded
+		charge int64
+	}{
+		{QuotaExceeded{Name: "daily_requests", Limit: quotas.DailyRequests, Used: used.DayRequests, ResetAt: nextDay}, requests},
+		{QuotaExceeded{Name: "daily_tokens", Limit: quotas.DailyTokens, Used: used.DayTokens, ResetAt: nextDay}, 1},
+		{QuotaExceeded{Name: "monthly_requests", Limit: quotas.MonthlyRequests, Used: used.MonthRequests, ResetAt: nextMonth}, requests},
+		{QuotaExceeded{Name: "monthly_tokens", Limit: quotas.MonthlyTokens, Used: used.MonthTokens, ResetAt: nextMonth}, 1},
+	}
+	for _, check := range checks {
+		if check.Limit > 0 && check.Used+check.charge > check.Limit {
+			exceeded := check.QuotaExceeded
+			return &exceeded, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/db/dbtest"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+)
+
+func TestCheckQuotaChargesRequestedTasks(t *testing.T) {
+	conn := dbtest.Open(t, &models.GeminiProcessingDB{}, &models.GeminiProcessingFileDB{})
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	orgID := uint(1)
+	for _, id := range []string{"t1", "t2"} {
+		task := models.GeminiProcessingDB{ID: id, Prompt: "hola", Status: models.StatusCompleted, OrgID: &orgID, TotalTokens: 100, CreatedAt: now.Add(-time.Hour)}
+		if err := conn.Create(&task).Error; err != nil {
+			t.Fatalf("crear tarea: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		quotas   Quotas
+		requests int64
+		want     string // cuota superada, o "" si se aceptan
+	}{
+		{"una tarea que cabe", Quotas{DailyRequests: 3}, 1, ""},
+		{"lote que llena la cuota", Quotas{DailyRequests: 5}, 3, ""},
+		{"lote que no cabe", Quotas{DailyRequests: 5}, 4, "daily_requests"},
+		{"lote que no cabe en el mes", Quotas{MonthlyRequests: 10}, 9, "monthly_requests"},
+		{"cuota de peticiones agotada", Quotas{DailyRequests: 2}, 1, "daily_requests"},
+		{"los tokens no dependen del lote", Quotas{DailyTokens: 300}, 50, ""},
+		{"tokens agotados", Quotas{DailyTokens: 200}, 1, "daily_tokens"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exceeded, err := CheckQuota(context.Background(), conn, orgID, tt.quotas, tt.requests, now)
+			if err != nil {
+				t.Fatalf("CheckQuota: %v", err)
+			}
+			got := ""
+			if exceeded != nil {
+				got = exceeded.Name
+			}
+			if got != tt.want {
+				t.Errorf("cuota superada = %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReservationsCountConcurrentRequests(t *testing.T) {
+	conn := dbtest.Open(t, &models.GeminiProcessingDB{}, &models.GeminiProcessingFileDB{})
+	now := time.Now()
+	orgID := uint(1)
+	quotas := Quotas{DailyRequests: 5}
+	var r Reservations
+
+	// Diez peticiones a la vez que todavía no guardaron su tarea: solo cinco caben.
+	var wg sync.WaitGroup
+	var accepted atomic.Int64
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			exceeded, err := r.Charge(context.Background(), conn, orgID, quotas, 1, 0, now)
+			if err != nil {
+				t.Errorf("Charge: %v", err)
+			} else if exceeded == nil {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if accepted.Load() != 5 {
+		t.Fatalf("peticiones aceptadas = %d, se esperaban 5", accepted.Load())
+	}
+
+	// Lo que la misma petición ya reservó no se cuenta otra vez.
+	r.Release(orgID, 4)
+	if exceeded, err := r.Charge(context.Background(), conn, orgID, quotas, 4, 1, now); err != nil || exceeded != nil {
+		t.Errorf("lote de la petición que reservó 1: exceeded=%+v err=%v", exceeded, err)
+	}
+	r.Release(orgID, 5)
+	if exceeded, _ := r.Charge(context.Background(), conn, orgID, quotas, 5, 0, now); exceeded != nil {
+		t.Errorf("con las reservas liberadas: cuota %s superada", exceeded.Name)
+	}
+}
@@ -0,0 +1,140 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+// Limit define un token bucket: se recargan PerMinute tokens por minuto hasta un máximo de Burst.
+type Limit struct {
+	PerMinute float64 `json:"per_minute"`
+	Burst     int     `json:"burst"`
+}
+
+// Enabled indica si el límite está configurado.
+func (l Limit) Enabled() bool {
+	return l.PerMinute > 0 && l.Burst > 0
+}
+
+// Decision es el resultado de consumir un token de un bucket.
+type Decision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter es el tiempo que falta para tener un token disponible (0 si se permitió).
+	RetryAfter time.Duration
+	// Reset es el tiempo que falta para que el bucket vuelva a estar lleno.
+	Reset time.Duration
+}
+
+// Store guarda el estado de los buckets. MemoryStore sirve para una sola instancia;
+// PostgresStore comparte los buckets entre réplicas.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Decision, error)
+}
+
+// refill aplica el algoritmo de token bucket: recarga los tokens según el tiempo
+// transcurrido e intenta consumir uno. Devuelve los tokens restantes y la decisión.
+func refill(tokens float64, last time.Time, now time.Time, limit Limit) (float64, Decision) {
+	perSecond := limit.PerMinute / 60
+	burst := float64(limit.Burst)
+
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens = math.Min(burst, tokens+elapsed*perSecond)
+	}
+
+	decision := Decision{Limit: limit.Burst}
+	if tokens >= 1 {
+		tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = time.Duration((1 - tokens) / perSecond * float64(time.Second))
+	}
+	decision.Remaining = int(math.Floor(tokens))
+	decision.Reset = time.Duration((burst - tokens) / perSecond * float64(time.Second))
+	return tokens, decision
+}
+
+// RouteLimits son los límites aplicados a una ruta, por usuario y por IP.
+type RouteLimits struct {
+	PerUser Limit `json:"per_user"`
+	PerIP   Limit `json:"per_ip"`
+}
+
+// Config es la configuración de límites. Las rutas se identifican como "MÉTODO /ruta"
+// (por ejemplo "POST /v1/gemini/tasks"); las que no aparecen usan Default.
+type Config struct {
+	Default RouteLimits            `json:"default"`
+	Routes  map[string]RouteLimits `json:"routes"`
+}
+
+var (
+	taskLimits = RouteLimits{
+		PerUser: Limit{PerMinute: 10, Burst: 5},
+		PerIP:   Limit{PerMinute: 30, Burst: 10},
+	}
+	fileTaskLimits = RouteLimits{
+		PerUser: Limit{PerMinute: 5, Burst: 3},
+		PerIP:   Limit{PerMinute: 15, Burst: 5},
+	}
+)
+
+// DefaultConfig son los límites usados si no se configura RATE_LIMIT_CONFIG.
+func DefaultConfig() Config {
+	return Config{
+		Default: RouteLimits{
+			PerUser: Limit{PerMinute: 120, Burst: 30},
+			PerIP:   Limit{PerMinute: 300, Burst: 60},
+		},
+		Routes: map[string]RouteLimits{
+			"POST /v1/gemini/tasks":              taskLimits,
+			"POST /v1/gemini/file-tasks":         fileTaskLimits,
+			"POST /v1/gemini/embeddings":         taskLimits,
+			"POST /v1/gemini/search":             taskLimits,
+			"POST /v1/gemini/batches":            fileTaskLimits,
+			"POST /v1/collections/:id/documents": fileTaskLimits,
+			"POST /v1/collections/:id/query":     taskLimits,
+			"POST /gemini/process":               taskLimits,
+			"POST /gemini/process/file":          fileTaskLimits,
+		},
+	}
+}
+
+// LoadConfig lee la configuración desde el archivo JSON indicado en RATE_LIMIT_CONFIG.
+// Las rutas del archivo reemplazan a las de DefaultConfig.
+func LoadConfig() (Config, error) {
+	cfg := DefaultConfig()
+
+	path := os.Getenv("RATE_LIMIT_CONFIG")
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("error leyendo la configuración de límites: %w", err)
+	}
+	var custom Config
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return cfg, fmt.Errorf("configuración de límites inválida: %w", err)
+	}
+	if custom.Default.PerUser.Enabled() || custom.Default.PerIP.Enabled() {
+		cfg.Default = custom.Default
+	}
+	for route, limits := range custom.Routes {
+		cfg.Routes[route] = limits
+	}
+	return cfg, nil
+}
+
+// For devuelve los límites de una ruta.
+func (c Config) For(route string) RouteLimits {
+	if limits, ok := c.Routes[route]; ok {
+		return limits
+	}
+	return c.Default
+}
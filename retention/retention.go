@@ -0,0 +1,228 @@
+// Package retention purga los datos de las tareas terminadas según la política de
+// retención de cada organización: los archivos, los resultados y el resto del contenido de
+// las tareas, de las que solo queda el consumo para las cuotas y los reportes de uso.
+package retention
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultInterval  = time.Hour
+	defaultBatchSize = 500
+)
+
+// Policy son los días que se conservan los datos de una tarea terminada; 0 es sin límite.
+type Policy struct {
+	// FileDays son los días que se conserva el archivo de las tareas con archivo.
+	FileDays int
+	// ResultDays son los días que se conservan el resultado, el JSON y las llamadas a herramientas.
+	ResultDays int
+	// TaskDays son los días que se conserva la tarea; después se elimina todo su contenido y
+	// queda solo su consumo (ver models.DeletedTaskColumns).
+	TaskDays int
+}
+
+// DefaultPolicy lee la política por defecto de RETENTION_FILE_DAYS, RETENTION_RESULT_DAYS y
+// RETENTION_TASK_DAYS. Las variables no definidas valen 0 (sin límite).
+func DefaultPolicy() (Policy, error) {
+	var p Policy
+	vars := []struct {
+		name string
+		dest *int
+	}{
+		{"RETENTION_FILE_DAYS", &p.FileDays},
+		{"RETENTION_RESULT_DAYS", &p.ResultDays},
+		{"RETENTION_TASK_DAYS", &p.TaskDays},
+	}
+	for _, v := range vars {
+		value := os.Getenv(v.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return p, fmt.Errorf("%s inválido: %q", v.name, value)
+		}
+		*v.dest = n
+	}
+	return p, nil
+}
+
+// PolicyFor combina la política propia de la organización con la política por defecto.
+func PolicyFor(org models.OrganizationDB, defaults Policy) Policy {
+	pick := func(own *int, def int) int {
+		if own != nil {
+			return *own
+		}
+		return def
+	}
+	return Policy{
+		FileDays:   pick(org.FileRetentionDays, defaults.FileDays),
+		ResultDays: pick(org.ResultRetentionDays, defaults.ResultDays),
+		TaskDays:   pick(org.TaskRetentionDays, defaults.TaskDays),
+	}
+}
+
+// Interval devuelve cada cuánto se ejecuta la purga, configurado en RETENTION_PURGE_INTERVAL
+// (por ejemplo 30m). Con 0 la purga está desactivada, por ejemplo en las réplicas que no la
+// deben ejecutar.
+func Interval() time.Duration {
+	value := os.Getenv("RETENTION_PURGE_INTERVAL")
+	if value == "0" {
+		return 0
+	}
+	if v, err := time.ParseDuration(value); err == nil && v >= 0 {
+		return v
+	}
+	return defaultInterval
+}
+
+// batchSize devuelve cuántas filas se modifican por sentencia, configurado en RETENTION_PURGE_BATCH_SIZE.
+func batchSize() int {
+	if v, err := strconv.Atoi(os.Getenv("RETENTION_PURGE_BATCH_SIZE")); err == nil && v > 0 {
+		return v
+	}
+	return defaultBatchSize
+}
+
+// Stats son las filas afectadas por una purga.
+type Stats struct {
+	Files   int64
+	Results int64
+	Tasks   int64
+}
+
+// Purger aplica las políticas de retención.
+type Purger struct {
+	db        *gorm.DB
+	defaults  Policy
+	batchSize int
+}
+
+// NewPurger crea un Purger con la política por defecto para las organizaciones sin política propia.
+func NewPurger(db *gorm.DB, defaults Policy) *Purger {
+	return &Purger{db: db, defaults: defaults, batchSize: batchSize()}
+}
+
+// Run ejecuta la purga al iniciar y luego cada interval, hasta que se cancele ctx.
+func (p *Purger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		start := time.Now()
+		stats, err := p.Purge(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "error en la purga de retención", "error", err)
+		} else if stats != (Stats{}) {
+			slog.InfoContext(ctx, "purga de retención finalizada",
+				"files", stats.Files,
+				"results", stats.Results,
+				"tasks", stats.Tasks,
+				"duration", time.Since(start))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge aplica una vez la política de cada organización. Solo se purgan las tareas terminadas
+// que no tienen retención legal (legal_hold); las filas se modifican en lotes de batchSize
+// para no bloquear las tablas.
+func (p *Purger) Purge(ctx context.Context) (Stats, error) {
+	var stats Stats
+	var orgs []models.OrganizationDB
+	if err := p.db.WithContext(ctx).Order("id").Find(&orgs).Error; err != nil {
+		return stats, err
+	}
+	now := time.Now()
+	for _, org := range orgs {
+		if err := p.purgeOrg(ctx, org.ID, PolicyFor(org, p.defaults), now, &stats); err != nil {
+			return stats, fmt.Errorf("organización %d: %w", org.ID, err)
+		}
+	}
+	return stats, nil
+}
+
+// purgeOrg aplica la política a las tareas de una organización.
+func (p *Purger) purgeOrg(ctx context.Context, orgID uint, policy Policy, now time.Time, stats *Stats) error {
+	cutoff := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+
+	if policy.FileDays > 0 {
+		n, err := p.update(ctx, &models.GeminiProcessingFileDB{}, orgID, cutoff(policy.FileDays), "file IS NOT NULL",
+			map[string]interface{}{"file": nil, "file_purged_at": now})
+		stats.Files += n
+		if err != nil {
+			return err
+		}
+	}
+	if policy.ResultDays > 0 {
+		results := []struct {
+			model   interface{}
+			columns map[string]interface{}
+		}{
+			{&models.GeminiProcessingDB{}, map[string]interface{}{
+				"result": "", "result_json": nil, "error_details": nil, "tool_calls": nil, "citations": nil, "result_purged_at": now}},
+			{&models.GeminiProcessingFileDB{}, map[string]interface{}{
+				"result": "", "result_json": nil, "error_details": nil, "tool_calls": nil, "result_purged_at": now}},
+		}
+		for _, r := range results {
+			n, err := p.update(ctx, r.model, orgID, cutoff(policy.ResultDays), "result_purged_at IS NULL", r.columns)
+			stats.Results += n
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if policy.TaskDays > 0 {
+		for _, model := range []interface{}{&models.GeminiProcessingDB{}, &models.GeminiProcessingFileDB{}} {
+			n, err := p.update(ctx, model, orgID, cutoff(policy.TaskDays), "", models.DeletedTaskColumns(model, now))
+			stats.Tasks += n
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expired devuelve las tareas terminadas de la organización creadas antes de cutoff y sin
+// retención legal, hasta batchSize.
+func (p *Purger) expired(tx *gorm.DB, model interface{}, orgID uint, cutoff time.Time) *gorm.DB {
+	return tx.Session(&gorm.Session{NewDB: true}).Model(model).Select("id").
+		Where("org_id = ? AND created_at < ? AND NOT legal_hold", orgID, cutoff).
+		Where("status IN ?", []models.GeminiProcessingStatus{models.StatusCompleted, models.StatusError}).
+		Limit(p.batchSize)
+}
+
+// update modifica columns en las tareas vencidas que cumplen pending (todas si es vacío), por
+// lotes, hasta que no quede ninguna. Las tareas eliminadas no se vuelven a tomar.
+func (p *Purger) update(ctx context.Context, model interface{}, orgID uint, cutoff time.Time, pending string, columns map[string]interface{}) (int64, error) {
+	var total int64
+	for {
+		tx := p.db.WithContext(ctx)
+		expired := p.expired(tx, model, orgID, cutoff)
+		if pending != "" {
+			expired = expired.Where(pending)
+		}
+		// UpdateColumns no modifica updated_at: la purga no es un cambio de la tarea.
+		result := tx.Model(model).
+			Where("id IN (?)", expired).
+			UpdateColumns(columns)
+		total += result.RowsAffected
+		if result.Error != nil || result.RowsAffected < int64(p.batchSize) {
+			return total, result.Error
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/db/dbtest"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+)
+
+func TestPurgeKeepsUsageOfDeletedTasks(t *testing.T) {
+	conn := dbtest.Open(t, &models.OrganizationDB{}, &models.GeminiProcessingDB{}, &models.GeminiProcessingFileDB{})
+	org := models.OrganizationDB{Name: "Acme", Slug: "acme"}
+	if err := conn.Create(&org).Error; err != nil {
+		t.Fatalf("crear organización: %v", err)
+	}
+
+	old := time.Now().AddDate(0, 0, -40)
+	tasks := []models.GeminiProcessingDB{
+		{ID: "old-1", Status: models.StatusCompleted, Prompt: "secreto 1", Result: "respuesta", SystemInstruction: "sé breve"},
+		{ID: "old-2", Status: models.StatusError, Prompt: "secreto 2", Error: "falló"},
+		{ID: "old-3", Status: models.StatusCompleted, Prompt: "secreto 3"},
+		{ID: "held", Status: models.StatusCompleted, Prompt: "en juicio", LegalHold: true},
+		{ID: "running", Status: models.StatusProcessing, Prompt: "en curso"},
+	}
+	for i := range tasks {
+		tasks[i].OrgID = &org.ID
+		tasks[i].CreatedAt = old
+		tasks[i].TotalTokens = 100
+		tasks[i].EstimatedCost = 0.5
+	}
+	tasks = append(tasks, models.GeminiProcessingDB{ID: "recent", OrgID: &org.ID, Status: models.StatusCompleted, Prompt: "nuevo", TotalTokens: 100})
+	if err := conn.Create(&tasks).Error; err != nil {
+		t.Fatalf("crear tareas: %v", err)
+	}
+	file := models.GeminiProcessingFileDB{ID: "file-1", OrgID: &org.ID, Status: models.StatusCompleted, Prompt: "resume",
+		File: []byte("%PDF"), FileName: "contrato.pdf", CreatedAt: old, TotalTokens: 50}
+	if err := conn.Create(&file).Error; err != nil {
+		t.Fatalf("crear tarea con archivo: %v", err)
+	}
+
+	// Lotes de 2 para que la purga necesite varias vueltas.
+	purger := &Purger{db: conn, defaults: Policy{TaskDays: 30}, batchSize: 2}
+	stats, err := purger.Purge(context.Background())
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if stats.Tasks != 4 {
+		t.Errorf("stats.Tasks = %d, se esperaban 4", stats.Tasks)
+	}
+
+	var visible []string
+	conn.Model(&models.GeminiProcessingDB{}).Order("id").Pluck("id", &visible)
+	want := []string{"held", "recent", "running"}
+	if len(visible) != len(want) {
+		t.Fatalf("tareas visibles = %v, se esperaban %v", visible, want)
+	}
+	for i := range want {
+		if visible[i] != want[i] {
+			t.Fatalf("tareas visibles = %v, se esperaban %v", visible, want)
+		}
+	}
+
+	var deleted models.GeminiProcessingDB
+	if err := conn.Unscoped().First(&deleted, "id = ?", "old-1").Error; err != nil {
+		t.Fatalf("la tarea eliminada no quedó como tombstone: %v", err)
+	}
+	if !deleted.DeletedAt.Valid || deleted.Prompt != "" || deleted.Result != "" || deleted.SystemInstruction != "" {
+		t.Errorf("tombstone con contenido: deleted_at=%v prompt=%q result=%q system=%q",
+			deleted.DeletedAt.Valid, deleted.Prompt, deleted.Result, deleted.SystemInstruction)
+	}
+	if deleted.TotalTokens != 100 || deleted.EstimatedCost != 0.5 || deleted.Status != models.StatusCompleted {
+		t.Errorf("el tombstone perdió el consumo: tokens=%d costo=%v estado=%s", deleted.TotalTokens, deleted.EstimatedCost, deleted.Status)
+	}
+
+	var deletedFile models.GeminiProcessingFileDB
+	if err := conn.Unscoped().First(&deletedFile, "id = ?", "file-1").Error; err != nil {
+		t.Fatalf("la tarea con archivo eliminada no quedó como tombstone: %v", err)
+	}
+	if !deletedFile.DeletedAt.Valid || deletedFile.File != nil || deletedFile.FileName != "" || deletedFile.TotalTokens != 50 {
+		t.Errorf("tombstone de archivo: deleted_at=%v file=%q name=%q tokens=%d",
+			deletedFile.DeletedAt.Valid, deletedFile.File, deletedFile.FileName, deletedFile.TotalTokens)
+	}
+
+	// Las cuotas y los reportes de uso suman directamente sobre la tabla, incluidas las eliminadas.
+	var tokens int64
+	conn.Raw("SELECT SUM(total_tokens) FROM " + models.GeminiProcessingDB{}.TableName()).Scan(&tokens)
+	if tokens != 600 {
+		t.Errorf("tokens de la organización = %d, se esperaban 600", tokens)
+	}
+
+	// Una segunda purga no vuelve a tomar las tareas eliminadas.
+	stats, err = purger.Purge(context.Background())
+	if err != nil || stats.Tasks != 0 {
+		t.Errorf("segunda purga: stats=%+v err=%v", stats, err)
+	}
+}
+
+func TestPurgeResultsClearsErrorDetails(t *testing.T) {
+	conn := dbtest.Open(t, &models.OrganizationDB{}, &models.GeminiProcessingDB{}, &models.GeminiProcessingFileDB{})
+	org := models.OrganizationDB{Name: "Acme", Slug: "acme"}
+	if err := conn.Create(&org).Error; err != nil {
+		t.Fatalf("crear organización: %v", err)
+	}
+	old := time.Now().AddDate(0, 0, -40)
+	// El reporte de validación del esquema cita la respuesta del modelo.
+	details := models.JSON(`{"response": "datos del cliente"}`)
+	task := models.GeminiProcessingDB{ID: "old-1", OrgID: &org.ID, Status: models.StatusError, Prompt: "p",
+		Error: "no cumple el esquema", ErrorDetails: details, CreatedAt: old}
+	file := models.GeminiProcessingFileDB{ID: "file-1", OrgID: &org.ID, Status: models.StatusError, Prompt: "p",
+		Error: "no cumple el esquema", ErrorDetails: details, CreatedAt: old}
+	if err := conn.Create(&task).Error; err != nil {
+		t.Fatalf("crear tarea: %v", err)
+	}
+	if err := conn.Create(&file).Error; err != nil {
+		t.Fatalf("crear tarea con archivo: %v", err)
+	}
+
+	purger := &Purger{db: conn, defaults: Policy{ResultDays: 30}, batchSize: 10}
+	if stats, err := purger.Purge(context.Background()); err != nil || stats.Results != 2 {
+		t.Fatalf("Purge: stats=%+v err=%v", stats, err)
+	}
+
+	var purged models.GeminiProcessingDB
+	conn.First(&purged, "id = ?", "old-1")
+	if purged.ErrorDetails != nil || purged.ResultPurgedAt == nil {
+		t.Errorf("tarea purgada: error_details=%s result_purged_at=%v", purged.ErrorDetails, purged.ResultPurgedAt)
+	}
+	var purgedFile models.GeminiProcessingFileDB
+	conn.First(&purgedFile, "id = ?", "file-1")
+	if purgedFile.ErrorDetails != nil || purgedFile.ResultPurgedAt == nil {
+		t.Errorf("tarea con archivo purgada: error_details=%s result_purged_at=%v", purgedFile.ErrorDetails, purgedFile.ResultPurgedAt)
+	}
+}
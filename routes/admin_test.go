@@ -0,0 +1,95 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+)
+
+func TestAdminListsTheTasksOfEveryOrganization(t *testing.T) {
+	f := newTenantFixture(t)
+	taskA := "2b3c4d5e-6f7a-4b8c-9d0e-1f2a3b4c5d6e"
+	mustCreate(t, f.conn, &models.GeminiProcessingDB{ID: taskA, Prompt: "prompt de A", Status: models.StatusError, UserID: &f.userA.ID, OrgID: &f.orgA})
+
+	// Un member no tiene acceso a la administración.
+	if code := f.do(http.MethodGet, "/v1/admin/tasks", bearer(f.keyA)); code != http.StatusForbidden {
+		t.Fatalf("listar todas las tareas como member: código %d, se esperaba 403", code)
+	}
+	if code := f.do(http.MethodGet, "/v1/admin/tasks", nil); code != http.StatusUnauthorized {
+		t.Errorf("listar todas las tareas sin API key: código %d, se esperaba 401", code)
+	}
+
+	makeAdmin(t, f)
+	rec := f.get("/v1/admin/tasks", bearer(f.keyA))
+	var list models.AdminTaskList
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); rec.Code != http.StatusOK || err != nil || len(list.Items) != 2 {
+		t.Fatalf("listar todas las tareas: código %d: %s", rec.Code, rec.Body.String())
+	}
+	if containsKey(rec.Body.Bytes(), "prompt") {
+		t.Errorf("el listado expone el prompt: %s", rec.Body.String())
+	}
+
+	rec = f.get(fmt.Sprintf("/v1/admin/tasks?status=completed&org_id=%d", f.orgB), bearer(f.keyA))
+	list = models.AdminTaskList{}
+	json.Unmarshal(rec.Body.Bytes(), &list)
+	if len(list.Items) != 1 || list.Items[0].ID != f.taskB || list.Items[0].Kind != models.TaskKindPrompt {
+		t.Errorf("filtrar por estado y organización: %s", rec.Body.String())
+	}
+	for _, query := range []string{"kind=video", "status=perdida", "user_id=uno", "limit=500"} {
+		if code := f.do(http.MethodGet, "/v1/admin/tasks?"+query, bearer(f.keyA)); code != http.StatusBadRequest {
+			t.Errorf("?%s: código %d, se esperaba 400", query, code)
+		}
+	}
+}
+
+// containsKey indica si algún elemento de items en el JSON tiene la clave.
+func containsKey(body []byte, key string) bool {
+	var list struct {
+		Items []map[string]interface{} `json:"items"`
+	}
+	json.Unmarshal(body, &list)
+	for _, item := range list.Items {
+		if _, ok := item[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAdminChangesUserRoles(t *testing.T) {
+	f := newTenantFixture(t)
+	pathB := fmt.Sprintf("/v1/admin/users/%d/role", f.userB.ID)
+
+	if code := f.sendJSON(http.MethodPut, pathB, `{"role": "viewer"}`, bearer(f.keyA)).Code; code != http.StatusForbidden {
+		t.Fatalf("cambiar un rol como member: código %d, se esperaba 403", code)
+	}
+
+	makeAdmin(t, f)
+	rec := f.sendJSON(http.MethodPut, pathB, `{"role": "viewer"}`, bearer(f.keyA))
+	var user models.User
+	if json.Unmarshal(rec.Body.Bytes(), &user); rec.Code != http.StatusOK || user.Role != models.RoleViewer {
+		t.Fatalf("cambiar el rol de B: código %d: %s", rec.Code, rec.Body.String())
+	}
+	// Como viewer, B consulta pero ya no crea tareas.
+	if code := f.do(http.MethodGet, "/v1/gemini/tasks/"+f.taskB, bearer(f.keyB)); code != http.StatusOK {
+		t.Errorf("consultar una tarea como viewer: código %d, se esperaba 200", code)
+	}
+	if code := f.postJSON("/v1/gemini/tasks", `{"prompt": "hola"}`, bearer(f.keyB)).Code; code != http.StatusForbidden {
+		t.Errorf("crear una tarea como viewer: código %d, se esperaba 403", code)
+	}
+
+	if code := f.sendJSON(http.MethodPut, pathB, `{"role": "superusuario"}`, bearer(f.keyA)).Code; code != http.StatusBadRequest {
+		t.Errorf("rol inexistente: código %d, se esperaba 400", code)
+	}
+	// Un administrador no puede quitarse el rol a sí mismo.
+	pathA := fmt.Sprintf("/v1/admin/users/%d/role", f.userA.ID)
+	if code := f.sendJSON(http.MethodPut, pathA, `{"role": "member"}`, bearer(f.keyA)).Code; code != http.StatusBadRequest {
+		t.Errorf("quitarse el rol admin: código %d, se esperaba 400", code)
+	}
+	if code := f.sendJSON(http.MethodPut, "/v1/admin/users/999/role", `{"role": "viewer"}`, bearer(f.keyA)).Code; code != http.StatusNotFound {
+		t.Errorf("usuario inexistente: código %d, se esperaba 404", code)
+	}
+}
@@ -0,0 +1,118 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/middleware"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+)
+
+// createAPIKey crea una API key de A con el cuerpo indicado y devuelve la respuesta.
+func createAPIKey(t *testing.T, f tenantFixture, body string) models.APIKeyCreated {
+	t.Helper()
+	rec := f.postJSON(fmt.Sprintf("/v1/users/%d/api-keys", f.userA.ID), body, bearer(f.keyA))
+	var created models.APIKeyCreated
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); rec.Code != http.StatusCreated || err != nil {
+		t.Fatalf("crear la API key: código %d: %s", rec.Code, rec.Body.String())
+	}
+	return created
+}
+
+func TestScopedAPIKeysRecordTheirUsage(t *testing.T) {
+	f := newTenantFixture(t)
+	taskA := "2b3c4d5e-6f7a-4b8c-9d0e-1f2a3b4c5d6e"
+	mustCreate(t, f.conn, &models.GeminiProcessingDB{ID: taskA, Prompt: "p", Status: models.StatusCompleted, UserID: &f.userA.ID, OrgID: &f.orgA})
+
+	created := createAPIKey(t, f, `{"name": "lectura", "scopes": ["tasks:read"]}`)
+	if !strings.HasPrefix(created.Key, created.Prefix+"_") || len(created.Scopes) != 1 {
+		t.Fatalf("key creada: %+v", created)
+	}
+
+	// La key se acepta en X-API-Key y en Authorization: Bearer, solo con sus scopes.
+	if code := f.do(http.MethodGet, "/v1/gemini/tasks/"+taskA, map[string]string{middleware.APIKeyHeader: created.Key}); code != http.StatusOK {
+		t.Errorf("consultar con X-API-Key: código %d, se esperaba 200", code)
+	}
+	if code := f.do(http.MethodGet, "/v1/gemini/tasks/"+taskA, bearer(created.Key)); code != http.StatusOK {
+		t.Errorf("consultar con Bearer: código %d, se esperaba 200", code)
+	}
+	if code := f.postJSON("/v1/gemini/tasks", `{"prompt": "hola"}`, bearer(created.Key)).Code; code != http.StatusForbidden {
+		t.Errorf("crear una tarea fuera de los scopes: código %d, se esperaba 403", code)
+	}
+
+	// El listado trae el uso de la key, pero nunca el secreto.
+	rec := f.get(fmt.Sprintf("/v1/users/%d/api-keys", f.userA.ID), bearer(f.keyA))
+	var keys []models.APIKey
+	json.Unmarshal(rec.Body.Bytes(), &keys)
+	if strings.Contains(rec.Body.String(), created.Key) {
+		t.Error("el listado devuelve el secreto de la key")
+	}
+	var listed *models.APIKey
+	for i := range keys {
+		if keys[i].ID == created.ID {
+			listed = &keys[i]
+		}
+	}
+	if listed == nil || listed.RequestCount != 3 || listed.LastUsedAt == nil {
+		t.Errorf("uso de la key: %+v; se esperaban 3 peticiones", listed)
+	}
+
+	for name, body := range map[string]string{
+		"scope inexistente":  `{"name": "x", "scopes": ["tasks:everything"]}`,
+		"vencimiento pasado": `{"name": "x", "expires_at": "2020-01-01T00:00:00Z"}`,
+		"sin nombre":         `{"scopes": ["tasks:read"]}`,
+	} {
+		if code := f.postJSON(fmt.Sprintf("/v1/users/%d/api-keys", f.userA.ID), body, bearer(f.keyA)).Code; code != http.StatusBadRequest {
+			t.Errorf("%s: código %d, se esperaba 400", name, code)
+		}
+	}
+	if code := f.postJSON(fmt.Sprintf("/v1/users/%d/api-keys", f.userB.ID), `{"name": "x"}`, bearer(f.keyA)).Code; code != http.StatusForbidden {
+		t.Errorf("crear una key para otro usuario: código %d, se esperaba 403", code)
+	}
+}
+
+func TestRevokedRotatedAndExpiredKeysAreRejected(t *testing.T) {
+	f := newTenantFixture(t)
+	self := fmt.Sprintf("/v1/users/%d", f.userA.ID)
+	created := createAPIKey(t, f, `{"name": "jobs"}`)
+	keyPath := fmt.Sprintf("%s/api-keys/%d", self, created.ID)
+
+	// Al rotar, el secreto anterior deja de funcionar y el nuevo conserva la key.
+	rec := f.postJSON(keyPath+"/rotate", "", bearer(f.keyA))
+	var rotated models.APIKeyCreated
+	if err := json.Unmarshal(rec.Body.Bytes(), &rotated); rec.Code != http.StatusOK || err != nil || rotated.ID != created.ID || rotated.RotatedAt == nil {
+		t.Fatalf("rotar la key: código %d: %s", rec.Code, rec.Body.String())
+	}
+	if code := f.do(http.MethodGet, self, bearer(created.Key)); code != http.StatusUnauthorized {
+		t.Errorf("secreto anterior a la rotación: código %d, se esperaba 401", code)
+	}
+	if code := f.do(http.MethodGet, self, bearer(rotated.Key)); code != http.StatusOK {
+		t.Errorf("secreto rotado: código %d, se esperaba 200", code)
+	}
+
+	if code := f.do(http.MethodDelete, keyPath, bearer(f.keyA)); code != http.StatusNoContent {
+		t.Fatalf("revocar la key: código %d", code)
+	}
+	if code := f.do(http.MethodGet, self, bearer(rotated.Key)); code != http.StatusUnauthorized {
+		t.Errorf("key revocada: código %d, se esperaba 401", code)
+	}
+	if code := f.postJSON(keyPath+"/rotate", "", bearer(f.keyA)).Code; code != http.StatusConflict {
+		t.Errorf("rotar una key revocada: código %d, se esperaba 409", code)
+	}
+	if code := f.do(http.MethodDelete, fmt.Sprintf("%s/api-keys/999", self), bearer(f.keyA)); code != http.StatusNotFound {
+		t.Errorf("revocar una key inexistente: código %d, se esperaba 404", code)
+	}
+
+	expiring := createAPIKey(t, f, fmt.Sprintf(`{"name": "temporal", "expires_at": %q}`, time.Now().Add(time.Hour).Format(time.RFC3339)))
+	if code := f.do(http.MethodGet, self, bearer(expiring.Key)); code != http.StatusOK {
+		t.Fatalf("key vigente: código %d, se esperaba 200", code)
+	}
+	f.conn.Model(&models.APIKeyDB{}).Where("id = ?", expiring.ID).Update("expires_at", time.Now().Add(-time.Minute))
+	if code := f.do(http.MethodGet, self, bearer(expiring.Key)); code != http.StatusUnauthorized {
+		t.Errorf("key vencida: código %d, se esperaba 401", code)
+	}
+}
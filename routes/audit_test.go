@@ -0,0 +1,79 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/middleware"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// lastAuditEvent devuelve el último evento de auditoría registrado.
+func (f tenantFixture) lastAuditEvent(t *testing.T) models.AuditEventDB {
+	t.Helper()
+	var event models.AuditEventDB
+	if err := f.conn.Order("id DESC").First(&event).Error; err != nil {
+		t.Fatalf("leer el evento de auditoría: %v", err)
+	}
+	return event
+}
+
+func TestAuditRecordsTheAuthenticatedActorAndTrustedIP(t *testing.T) {
+	f := newTenantFixture(t)
+	if err := f.router.SetTrustedProxies(middleware.TrustedProxies()); err != nil {
+		t.Fatalf("SetTrustedProxies: %v", err)
+	}
+	path := "/v1/gemini/tasks/" + f.taskB
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = "192.0.2.10:40000"
+	req.Header.Set("Authorization", "Bearer "+f.keyB)
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	f.router.ServeHTTP(httptest.NewRecorder(), req)
+
+	event := f.lastAuditEvent(t)
+	if event.ActorUserID == nil || *event.ActorUserID != f.userB.ID || event.ActorAPIKeyID == nil {
+		t.Errorf("actor = %v (key %v), se esperaba el usuario %d con su API key", event.ActorUserID, event.ActorAPIKeyID, f.userB.ID)
+	}
+	if event.IP != "192.0.2.10" {
+		t.Errorf("IP = %q, se esperaba la de la conexión sin proxies de confianza", event.IP)
+	}
+
+	// Detrás de un proxy de confianza se toma la IP de X-Forwarded-For.
+	t.Setenv("TRUSTED_PROXIES", "192.0.2.0/24")
+	if err := f.router.SetTrustedProxies(middleware.TrustedProxies()); err != nil {
+		t.Fatalf("SetTrustedProxies: %v", err)
+	}
+	f.router.ServeHTTP(httptest.NewRecorder(), req)
+	if event := f.lastAuditEvent(t); event.IP != "203.0.113.7" {
+		t.Errorf("IP = %q, se esperaba la de X-Forwarded-For del proxy de confianza", event.IP)
+	}
+}
+
+func TestAuditDoesNotTrustUserIDHeader(t *testing.T) {
+	f := newTenantFixture(t)
+	t.Setenv("ALLOW_USER_ID_HEADER", "true")
+	router := gin.New()
+	router.Use(middleware.CurrentUser())
+	RegisterRoutes(router, ratelimit.Quotas{})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/gemini/tasks/"+f.taskB, nil)
+	req.Header.Set(middleware.UserIDHeader, strconv.FormatUint(uint64(f.userB.ID), 10))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("X-User-ID en desarrollo: código %d, se esperaba 200", rec.Code)
+	}
+
+	event := f.lastAuditEvent(t)
+	if event.Action != "task.read" {
+		t.Fatalf("acción = %q, se esperaba task.read", event.Action)
+	}
+	if event.ActorUserID != nil || event.ActorAPIKeyID != nil {
+		t.Errorf("actor = %v (key %v), X-User-ID no autentica y no debe registrarse como actor", event.ActorUserID, event.ActorAPIKeyID)
+	}
+}
@@ -0,0 +1,99 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/auth"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+)
+
+// postJSON envía body como JSON con los encabezados indicados.
+func (f tenantFixture) postJSON(path string, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestEmailsIgnoreCase(t *testing.T) {
+	f := newTenantFixture(t)
+
+	rec := f.postJSON("/v1/users", `{"full_name": "Ana", "email": "Ana@Example.com", "password": "contraseña1"}`, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("registrarse: código %d: %s", rec.Code, rec.Body.String())
+	}
+	var created models.User
+	json.Unmarshal(rec.Body.Bytes(), &created)
+	if created.Email != "ana@example.com" {
+		t.Errorf("email guardado = %q, se esperaba en minúsculas", created.Email)
+	}
+	if rec := f.postJSON("/v1/users", `{"full_name": "Ana", "email": "ANA@example.com", "password": "contraseña1"}`, nil); rec.Code != http.StatusConflict {
+		t.Errorf("registrar el mismo email en mayúsculas: código %d, se esperaba 409", rec.Code)
+	}
+
+	// Un usuario guardado antes de normalizar los emails.
+	hash, _ := auth.HashPassword("contraseña1")
+	mustCreate(t, f.conn, &models.UserDB{FullName: "Beto", Email: "Beto@Example.com", Password: hash, Role: models.RoleMember})
+	if rec := f.postJSON("/v1/users", `{"full_name": "Beto", "email": "beto@example.com", "password": "contraseña1"}`, nil); rec.Code != http.StatusConflict {
+		t.Errorf("registrar el email de un usuario anterior: código %d, se esperaba 409", rec.Code)
+	}
+	if rec := f.postJSON("/v1/auth/login", `{"email": "beto@example.com", "password": "contraseña1"}`, nil); rec.Code != http.StatusOK {
+		t.Errorf("iniciar sesión con otras mayúsculas: código %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestPasswordsNeedEightCharacters(t *testing.T) {
+	f := newTenantFixture(t)
+
+	rec := f.postJSON("/v1/users", `{"full_name": "Ana", "email": "ana@example.com", "password": "corta"}`, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("registrarse con una contraseña de 5 caracteres: código %d, se esperaba 400", rec.Code)
+	}
+}
+
+func TestResetPasswordRevokesAPIKeys(t *testing.T) {
+	f := newTenantFixture(t)
+	short := f.postJSON("/v1/auth/password/reset", `{"token": "x", "password": "corta"}`, nil)
+	if short.Code != http.StatusBadRequest {
+		t.Errorf("restablecer con una contraseña de 5 caracteres: código %d, se esperaba 400", short.Code)
+	}
+
+	token := "token-de-recuperacion"
+	mustCreate(t, f.conn, &models.UserTokenDB{UserID: f.userA.ID, Purpose: models.TokenPasswordReset,
+		Hash: auth.HashToken(token), Email: f.userA.Email, ExpiresAt: time.Now().Add(time.Hour)})
+	rec := f.postJSON("/v1/auth/password/reset", `{"token": "`+token+`", "password": "contraseña-nueva"}`, nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("restablecer la contraseña: código %d: %s", rec.Code, rec.Body.String())
+	}
+
+	pathA := fmt.Sprintf("/v1/users/%d", f.userA.ID)
+	for name, key := range map[string]string{"key con nombre": f.keyA, "key de la organización": f.orgKeyA} {
+		if code := f.do(http.MethodGet, pathA, bearer(key)); code != http.StatusUnauthorized {
+			t.Errorf("%s después de restablecer: código %d, se esperaba 401", name, code)
+		}
+	}
+	if code := f.do(http.MethodGet, fmt.Sprintf("/v1/users/%d", f.userB.ID), bearer(f.keyB)); code != http.StatusOK {
+		t.Errorf("key de otro usuario después de restablecer: código %d, se esperaba 200", code)
+	}
+
+	// La sesión iniciada con la contraseña nueva funciona.
+	rec = f.postJSON("/v1/auth/login", `{"email": "a@example.com", "password": "contraseña-nueva"}`, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("iniciar sesión con la contraseña nueva: código %d: %s", rec.Code, rec.Body.String())
+	}
+	var session models.LoginResponse
+	json.Unmarshal(rec.Body.Bytes(), &session)
+	if code := f.do(http.MethodGet, pathA, bearer(session.APIKey.Key)); code != http.StatusOK {
+		t.Errorf("key de la sesión nueva: código %d, se esperaba 200", code)
+	}
+}
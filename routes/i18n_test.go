@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/middleware"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMessagesFollowAcceptLanguage(t *testing.T) {
+	f := newTenantFixture(t)
+	router := gin.New()
+	router.Use(middleware.Language(), middleware.CurrentUser())
+	RegisterRoutes(router, ratelimit.Quotas{})
+
+	get := func(path, lang string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", bearer(f.keyB)["Authorization"])
+		if lang != "" {
+			req.Header.Set("Accept-Language", lang)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		lang, label, message, contentLanguage string
+	}{
+		{"en-US,en;q=0.9", "completed", "Task ID not found", "en"},
+		{"", "finalizado", "ID de proceso no encontrado", "es"},
+		{"de", "finalizado", "ID de proceso no encontrado", "es"},
+	}
+	for _, tt := range tests {
+		rec := get("/v1/gemini/tasks/"+f.taskB, tt.lang)
+		var task models.GeminiProcessingResponse
+		json.Unmarshal(rec.Body.Bytes(), &task)
+		if rec.Code != http.StatusOK || task.Status != models.StatusCompleted || task.StatusLabel != tt.label {
+			t.Errorf("Accept-Language %q: código %d, status %q, status_label %q; se esperaba %q",
+				tt.lang, rec.Code, task.Status, task.StatusLabel, tt.label)
+		}
+		if got := rec.Header().Get("Content-Language"); got != tt.contentLanguage {
+			t.Errorf("Accept-Language %q: Content-Language %q, se esperaba %q", tt.lang, got, tt.contentLanguage)
+		}
+
+		rec = get("/v1/gemini/tasks/no-existe", tt.lang)
+		var body models.ErrorResponse
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		if rec.Code != http.StatusNotFound || body.Code != "task_not_found" || body.Message != tt.message {
+			t.Errorf("Accept-Language %q: código %d, %s %q; se esperaba %q", tt.lang, rec.Code, body.Code, body.Message, tt.message)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLegacyRoutesAnnounceTheirSuccessor(t *testing.T) {
+	t.Setenv("LEGACY_API_SUNSET", "2027-01-31")
+	f := newTenantFixture(t)
+
+	get := func(path string) *httptest.ResponseRecorder { return f.get(path, bearer(f.keyB)) }
+
+	rec := get("/gemini/status/" + f.taskB)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ruta anterior: código %d: %s", rec.Code, rec.Body.String())
+	}
+	headers := map[string]string{
+		"Deprecation": fmt.Sprintf("@%d", legacyDeprecatedAt.Unix()),
+		"Sunset":      "Sun, 31 Jan 2027 00:00:00 GMT",
+		"Link":        `</v1/gemini/tasks/` + f.taskB + `>; rel="successor-version"`,
+	}
+	for name, want := range headers {
+		if got := rec.Header().Get(name); got != want {
+			t.Errorf("%s = %q, se esperaba %q", name, got, want)
+		}
+	}
+
+	// Los errores de la ruta anterior también la marcan como obsoleta.
+	if rec := get("/gemini/status/no-existe"); rec.Code != http.StatusNotFound || rec.Header().Get("Deprecation") == "" {
+		t.Errorf("tarea inexistente en la ruta anterior: código %d, Deprecation %q", rec.Code, rec.Header().Get("Deprecation"))
+	}
+	if rec := get(fmt.Sprintf("/users/%d/usage", f.userB.ID)); rec.Header().Get("Link") != fmt.Sprintf(`</v1/users/%d/usage>; rel="successor-version"`, f.userB.ID) {
+		t.Errorf("consumo en la ruta anterior: Link %q", rec.Header().Get("Link"))
+	}
+
+	if rec := get("/v1/gemini/tasks/" + f.taskB); rec.Code != http.StatusOK || rec.Header().Get("Deprecation") != "" || rec.Header().Get("Sunset") != "" {
+		t.Errorf("ruta /v1: código %d, Deprecation %q, Sunset %q", rec.Code, rec.Header().Get("Deprecation"), rec.Header().Get("Sunset"))
+	}
+}
+
+func TestLegacySunsetFallsBackToTheDefault(t *testing.T) {
+	t.Setenv("LEGACY_API_SUNSET", "31/01/2027")
+	if got := legacySunset(); !got.Equal(defaultLegacySunset) {
+		t.Errorf("LEGACY_API_SUNSET inválido: %v, se esperaba %v", got, defaultLegacySunset)
+	}
+}
@@ -0,0 +1,288 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apierror"
+	"github.com/Efren-Garza-Z/go-api-gemini/auth"
+	"github.com/Efren-Garza-Z/go-api-gemini/auth/oidctest"
+	"github.com/Efren-Garza-Z/go-api-gemini/controllers"
+	"github.com/Efren-Garza-Z/go-api-gemini/db/dbtest"
+	"github.com/Efren-Garza-Z/go-api-gemini/middleware"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/ratelimit"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// oidcFixture es la API configurada con el proveedor OIDC de prueba.
+type oidcFixture struct {
+	router   *gin.Engine
+	conn     *gorm.DB
+	provider *oidctest.Provider
+	client   *http.Client
+}
+
+func newOIDCFixture(t *testing.T) oidcFixture {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	conn := dbtest.Open(t, &models.UserDB{}, &models.OrganizationDB{}, &models.OrgMemberDB{}, &models.APIKeyDB{},
+		&models.OIDCLoginDB{}, &models.UserIdentityDB{}, &models.AuditEventDB{})
+	controllers.SetDB(conn)
+	t.Cleanup(func() { controllers.SetDB(nil) })
+
+	provider, err := oidctest.NewProvider("")
+	if err != nil {
+		t.Fatalf("oidctest.NewProvider: %v", err)
+	}
+	server := httptest.NewServer(provider)
+	t.Cleanup(server.Close)
+	provider.Issuer = server.URL
+	controllers.SetOIDCProvider(auth.NewOIDCProvider(auth.OIDCConfig{
+		Issuer:      server.URL,
+		ClientID:    "gemini-api",
+		RedirectURL: "http://localhost:8080/v1/auth/oidc/callback",
+		Scopes:      []string{"openid", "email", "profile"},
+		GroupsClaim: "groups",
+		GroupRoles:  map[string]models.Role{},
+		DefaultRole: models.RoleMember,
+	}))
+	t.Cleanup(func() { controllers.SetOIDCProvider(nil) })
+
+	f := oidcFixture{conn: conn, provider: provider, router: gin.New()}
+	f.router.Use(middleware.CurrentUser())
+	RegisterRoutes(f.router, ratelimit.Quotas{})
+	// El callback vuelve a la API, no al proveedor: las redirecciones se siguen a mano.
+	f.client = &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	return f
+}
+
+// login inicia sesión en la API, aprueba el inicio de sesión en el proveedor con el email
+// indicado y devuelve la ruta del callback y la cookie con el state.
+func (f oidcFixture) login(t *testing.T, email string) (string, *http.Cookie) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/login", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("iniciar sesión: código %d: %s", rec.Code, rec.Body.String())
+	}
+	var cookie *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "oidc_state" {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.Value == "" {
+		t.Fatal("el inicio de sesión no guardó el state en una cookie")
+	}
+
+	authorize, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("redirección al proveedor inválida: %v", err)
+	}
+	query := authorize.Query()
+	query.Set("login_hint", email)
+	authorize.RawQuery = query.Encode()
+	resp, err := f.client.Get(authorize.String())
+	if err != nil {
+		t.Fatalf("autorizar en el proveedor: %v", err)
+	}
+	resp.Body.Close()
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if resp.StatusCode != http.StatusFound || err != nil {
+		t.Fatalf("autorizar en el proveedor: código %d, redirección %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	return callback.RequestURI(), cookie
+}
+
+// callback completa el inicio de sesión en la API con la cookie indicada (o sin cookie).
+func (f oidcFixture) callback(target string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeOIDCLogin(t *testing.T, rec *httptest.ResponseRecorder) models.OIDCLoginResponse {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("callback: código %d: %s", rec.Code, rec.Body.String())
+	}
+	var response models.OIDCLoginResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("respuesta inválida: %v", err)
+	}
+	return response
+}
+
+// wantOIDCError comprueba el código HTTP y el código de error de la respuesta.
+func wantOIDCError(t *testing.T, rec *httptest.ResponseRecorder, status int, code apierror.Code) {
+	t.Helper()
+	var response models.ErrorResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &response)
+	if rec.Code != status || response.Code != string(code) {
+		t.Errorf("código %d (%s), se esperaba %d (%s): %s", rec.Code, response.Code, status, code, rec.Body.String())
+	}
+}
+
+func TestOIDCLoginCreatesTheUserOnceAndReusesTheIdentity(t *testing.T) {
+	f := newOIDCFixture(t)
+
+	target, cookie := f.login(t, "nueva@example.com")
+	first := decodeOIDCLogin(t, f.callback(target, cookie))
+	if !first.Created || first.User.Email != "nueva@example.com" || first.APIKey.Key == "" {
+		t.Fatalf("primer inicio de sesión: %+v", first)
+	}
+	if code := (tenantFixture{router: f.router}).do(http.MethodGet, "/v1/users/"+strconv.FormatUint(uint64(first.User.ID), 10), bearer(first.APIKey.Key)); code != http.StatusOK {
+		t.Errorf("la API key de sesión no identifica al usuario: código %d", code)
+	}
+
+	target, cookie = f.login(t, "nueva@example.com")
+	second := decodeOIDCLogin(t, f.callback(target, cookie))
+	if second.Created || second.User.ID != first.User.ID {
+		t.Errorf("segundo inicio de sesión: created=%v usuario %d, se esperaba el usuario %d", second.Created, second.User.ID, first.User.ID)
+	}
+	var users int64
+	f.conn.Model(&models.UserDB{}).Count(&users)
+	if users != 1 {
+		t.Errorf("%d usuarios, se esperaba 1", users)
+	}
+}
+
+// createVerifiedUser crea un usuario con el email ya confirmado.
+func createVerifiedUser(t *testing.T, conn *gorm.DB, email string) models.UserDB {
+	t.Helper()
+	user, _ := createTenantUser(t, conn, email)
+	now := time.Now()
+	if err := conn.Model(&user).Update("email_verified_at", now).Error; err != nil {
+		t.Fatalf("confirmar el email de %s: %v", email, err)
+	}
+	return user
+}
+
+func TestOIDCLoginLinksTheAccountWithTheSameVerifiedEmail(t *testing.T) {
+	f := newOIDCFixture(t)
+	existing := createVerifiedUser(t, f.conn, "ana@example.com")
+
+	target, cookie := f.login(t, "Ana@Example.com")
+	response := decodeOIDCLogin(t, f.callback(target, cookie))
+	if response.Created || response.User.ID != existing.ID {
+		t.Fatalf("created=%v usuario %d, se esperaba vincular el usuario %d", response.Created, response.User.ID, existing.ID)
+	}
+	var identity models.UserIdentityDB
+	if err := f.conn.Where("user_id = ?", existing.ID).First(&identity).Error; err != nil {
+		t.Fatalf("la identidad no quedó vinculada: %v", err)
+	}
+	if identity.Issuer != f.provider.Issuer || identity.Subject != "mock|Ana@Example.com" {
+		t.Errorf("identidad = %+v", identity)
+	}
+}
+
+// Una cuenta local sin confirmar pudo registrarla cualquiera con el email de otro: vincularla
+// dejaría entrar a quien la registró con su contraseña y sus API keys.
+func TestOIDCLoginDoesNotLinkAnUnverifiedAccount(t *testing.T) {
+	f := newOIDCFixture(t)
+	existing, _ := createTenantUser(t, f.conn, "ana@example.com")
+
+	target, cookie := f.login(t, "ana@example.com")
+	wantOIDCError(t, f.callback(target, cookie), http.StatusConflict, apierror.CodeEmailNotVerified)
+	var identities, users int64
+	f.conn.Model(&models.UserIdentityDB{}).Count(&identities)
+	f.conn.Model(&models.UserDB{}).Count(&users)
+	if identities != 0 || users != 1 {
+		t.Errorf("%d identidades y %d usuarios, se esperaba no vincular ni crear nada", identities, users)
+	}
+	var user models.UserDB
+	f.conn.First(&user, existing.ID)
+	if user.EmailVerifiedAt != nil {
+		t.Error("el inicio de sesión con OIDC confirmó el email de la cuenta sin vincularla")
+	}
+}
+
+func TestOIDCLoginDoesNotLinkAnUnverifiedEmail(t *testing.T) {
+	f := newOIDCFixture(t)
+	existing := createVerifiedUser(t, f.conn, "ana@example.com")
+	f.provider.EmailVerified = false
+
+	target, cookie := f.login(t, "ana@example.com")
+	wantOIDCError(t, f.callback(target, cookie), http.StatusUnauthorized, apierror.CodeOIDCLoginFailed)
+	var identities int64
+	f.conn.Model(&models.UserIdentityDB{}).Where("user_id = ?", existing.ID).Count(&identities)
+	if identities != 0 {
+		t.Error("se vinculó la cuenta con un email sin verificar")
+	}
+
+	// Un email sin verificar sí crea un usuario nuevo, sin marcarlo como verificado.
+	target, cookie = f.login(t, "otra@example.com")
+	response := decodeOIDCLogin(t, f.callback(target, cookie))
+	var user models.UserDB
+	f.conn.First(&user, response.User.ID)
+	if !response.Created || user.EmailVerifiedAt != nil {
+		t.Errorf("usuario nuevo con email sin verificar: created=%v verificado=%v", response.Created, user.EmailVerifiedAt)
+	}
+}
+
+func TestOIDCCallbackRequiresTheStateOfTheBrowser(t *testing.T) {
+	f := newOIDCFixture(t)
+	target, cookie := f.login(t, "ana@example.com")
+
+	// Otro navegador: sin la cookie o con la de otro inicio de sesión.
+	wantOIDCError(t, f.callback(target, nil), http.StatusBadRequest, apierror.CodeInvalidToken)
+	_, other := f.login(t, "ana@example.com")
+	wantOIDCError(t, f.callback(target, other), http.StatusBadRequest, apierror.CodeInvalidToken)
+
+	// Los intentos rechazados no consumen el inicio de sesión.
+	decodeOIDCLogin(t, f.callback(target, cookie))
+}
+
+func TestOIDCStateIsSingleUse(t *testing.T) {
+	f := newOIDCFixture(t)
+	target, cookie := f.login(t, "ana@example.com")
+	decodeOIDCLogin(t, f.callback(target, cookie))
+
+	wantOIDCError(t, f.callback(target, cookie), http.StatusBadRequest, apierror.CodeInvalidToken)
+	var keys int64
+	f.conn.Model(&models.APIKeyDB{}).Count(&keys)
+	if keys != 1 {
+		t.Errorf("%d API keys de sesión, se esperaba 1", keys)
+	}
+}
+
+func TestOIDCCallbackRejectsAWrongPKCEVerifier(t *testing.T) {
+	f := newOIDCFixture(t)
+	target, cookie := f.login(t, "ana@example.com")
+	// El code_verifier guardado ya no corresponde al code_challenge enviado al proveedor.
+	if err := f.conn.Model(&models.OIDCLoginDB{}).Where("1 = 1").Update("verifier", "otro-verifier-de-43-caracteres-como-minimo-0000").Error; err != nil {
+		t.Fatalf("cambiar el verifier: %v", err)
+	}
+
+	wantOIDCError(t, f.callback(target, cookie), http.StatusUnauthorized, apierror.CodeOIDCLoginFailed)
+	var users int64
+	f.conn.Model(&models.UserDB{}).Count(&users)
+	if users != 0 {
+		t.Errorf("se creó un usuario sin canjear el código")
+	}
+}
+
+func TestOIDCLoginFindsTheAccountWhateverTheCaseOfTheEmail(t *testing.T) {
+	f := newOIDCFixture(t)
+	// Un usuario guardado antes de normalizar los emails.
+	now := time.Now()
+	existing := models.UserDB{FullName: "Ana", Email: "Ana@Example.com", Role: models.RoleMember, EmailVerifiedAt: &now}
+	mustCreate(t, f.conn, &existing)
+
+	target, cookie := f.login(t, "ana@example.com")
+	response := decodeOIDCLogin(t, f.callback(target, cookie))
+	if response.Created || response.User.ID != existing.ID {
+		t.Errorf("created=%v usuario %d, se esperaba vincular el usuario %d", response.Created, response.User.ID, existing.ID)
+	}
+}
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+)
+
+func TestBatchChargesEveryTaskToTheQuota(t *testing.T) {
+	f := newTenantFixture(t)
+	if err := f.conn.Model(&models.OrganizationDB{}).Where("id = ?", f.orgA).Update("daily_request_quota", 2).Error; err != nil {
+		t.Fatalf("configurar la cuota: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/gemini/batches", strings.NewReader(`{"prompts": ["uno", "dos", "tres"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+f.keyA)
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("lote de 3 tareas con cuota de 2: código %d, se esperaba 429", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"quota":"daily_requests"`) {
+		t.Errorf("respuesta sin la cuota superada: %s", rec.Body.String())
+	}
+	var tasks int64
+	f.conn.Model(&models.GeminiProcessingDB{}).Where("org_id = ?", f.orgA).Count(&tasks)
+	if tasks != 0 {
+		t.Errorf("se crearon %d tareas del lote rechazado", tasks)
+	}
+}
@@ -0,0 +1,120 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/controllers"
+	"github.com/Efren-Garza-Z/go-api-gemini/gemini"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/pii"
+)
+
+// recordingEmbedder es un gemini.FakeEmbedder que guarda los textos que recibe.
+type recordingEmbedder struct {
+	gemini.FakeEmbedder
+	mu    sync.Mutex
+	texts []string
+}
+
+func (e *recordingEmbedder) Embed(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	e.mu.Lock()
+	e.texts = append(e.texts, texts...)
+	e.mu.Unlock()
+	return e.FakeEmbedder.Embed(ctx, texts, taskType)
+}
+
+func TestUploadedDocumentIsIndexedWithRedactedEmbeddings(t *testing.T) {
+	t.Setenv("RAG_CHUNK_SIZE", "40")
+	t.Setenv("RAG_CHUNK_OVERLAP", "0")
+	f := newTenantFixture(t)
+	embedder := &recordingEmbedder{}
+	controllers.SetEmbedder(embedder)
+	t.Cleanup(func() { controllers.SetEmbedder(&gemini.Service{}) })
+	redactor, err := pii.New([]string{"email"}, true)
+	if err != nil {
+		t.Fatalf("pii.New: %v", err)
+	}
+	controllers.SetRedactor(redactor)
+	t.Cleanup(func() { controllers.SetRedactor(nil) })
+
+	text := "La beca se renueva con promedio mínimo de 8.5. Dudas al correo becas@example.com cada semestre."
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="file"; filename="reglamento.txt"`)
+	header.Set("Content-Type", "text/plain")
+	part, _ := form.CreatePart(header)
+	part.Write([]byte(text))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/collections/"+strconv.Itoa(int(f.collectionB))+"/documents", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+f.keyB)
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("subir el documento: código %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// La indexación corre en segundo plano.
+	var document models.DocumentDB
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if err := f.conn.Where("collection_id = ?", f.collectionB).First(&document).Error; err != nil {
+			t.Fatalf("buscar el documento: %v", err)
+		}
+		if document.Status == models.StatusCompleted || document.Status == models.StatusError {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("el documento no se indexó: estado %s", document.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if document.Status != models.StatusCompleted {
+		t.Fatalf("indexar el documento: %s: %s", document.ErrorCode, document.Error)
+	}
+
+	var chunks []models.DocumentChunkDB
+	f.conn.Where("document_id = ?", document.ID).Order("chunk_index").Find(&chunks)
+	if len(chunks) < 2 || document.Chunks != len(chunks) || document.Pages != 1 {
+		t.Fatalf("documento con %d páginas y %d fragmentos, %d guardados", document.Pages, document.Chunks, len(chunks))
+	}
+	var stored []string
+	for i, chunk := range chunks {
+		if chunk.ChunkIndex != i || chunk.Page != 1 || chunk.CollectionID != f.collectionB || chunk.Model != "fake-embedding" {
+			t.Errorf("fragmento %d: %+v", i, chunk)
+		}
+		if len(chunk.Embedding) != gemini.EmbeddingDimensions {
+			t.Errorf("fragmento %d con un embedding de %d dimensiones", i, len(chunk.Embedding))
+		}
+		stored = append(stored, chunk.Text)
+	}
+	// En la base queda el texto original para citarlo; a Gemini solo llega redactado.
+	if got := strings.Join(stored, " "); got != text {
+		t.Errorf("texto guardado = %q, se esperaba %q", got, text)
+	}
+	embedder.mu.Lock()
+	defer embedder.mu.Unlock()
+	if len(embedder.texts) != len(chunks) {
+		t.Fatalf("se calcularon %d embeddings para %d fragmentos", len(embedder.texts), len(chunks))
+	}
+	for _, sent := range embedder.texts {
+		if strings.Contains(sent, "becas@example.com") {
+			t.Errorf("el embedder recibió el email sin redactar: %q", sent)
+		}
+	}
+	if !strings.Contains(strings.Join(embedder.texts, " "), "[EMAIL_") {
+		t.Errorf("el embedder no recibió el marcador del email: %q", embedder.texts)
+	}
+}
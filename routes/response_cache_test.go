@@ -0,0 +1,71 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/cache"
+	"github.com/Efren-Garza-Z/go-api-gemini/controllers"
+	"github.com/Efren-Garza-Z/go-api-gemini/gemini"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+)
+
+// createFinishedTask crea una tarea de texto y espera a que el worker la termine.
+func createFinishedTask(t *testing.T, f tenantFixture, body string, headers map[string]string) models.GeminiProcessingDB {
+	t.Helper()
+	rec := f.postJSON("/v1/gemini/tasks", body, headers)
+	var created models.TaskCreatedResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); rec.Code != http.StatusAccepted || err != nil {
+		t.Fatalf("crear la tarea: código %d: %s", rec.Code, rec.Body.String())
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var task models.GeminiProcessingDB
+		if err := f.conn.First(&task, "id = ?", created.ID).Error; err != nil {
+			t.Fatalf("buscar la tarea: %v", err)
+		}
+		if task.Status == models.StatusCompleted || task.Status == models.StatusError {
+			return task
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("el worker no terminó la tarea: estado %s", task.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestIdenticalPromptsAreAnsweredFromTheCache(t *testing.T) {
+	f := newTenantFixture(t)
+	store := cache.NewMemoryStore(10, time.Hour)
+	controllers.SetResponseCache(store)
+	t.Cleanup(func() { controllers.SetResponseCache(nil) })
+
+	// La respuesta guardada para el mismo prompt de la organización A.
+	key := cache.Key(cache.KeyParts{OrgID: &f.orgA, Model: gemini.DefaultModel, Prompt: "Resume el contrato"})
+	store.Set(context.Background(), key, cache.Entry{Text: "Resumen guardado", Model: gemini.DefaultModel})
+
+	task := createFinishedTask(t, f, `{"prompt": "  Resume   el contrato "}`, bearer(f.keyA))
+	if task.Status != models.StatusCompleted || !task.Cached || task.Result != "Resumen guardado" || task.TotalTokens != 0 {
+		t.Fatalf("tarea con el prompt en caché: estado %s, cached %v, resultado %q, tokens %d",
+			task.Status, task.Cached, task.Result, task.TotalTokens)
+	}
+	rec := f.get("/v1/gemini/tasks/"+task.ID, bearer(f.keyA))
+	var response models.GeminiProcessingResponse
+	if json.Unmarshal(rec.Body.Bytes(), &response); !response.Cached {
+		t.Errorf("la respuesta no indica cached=true: %s", rec.Body.String())
+	}
+
+	// Cache-Control: no-cache pide a Gemini (que no está configurado en las pruebas) una
+	// respuesta nueva, y otra organización nunca recibe la respuesta guardada.
+	headers := bearer(f.keyA)
+	headers["Cache-Control"] = "no-cache"
+	if task := createFinishedTask(t, f, `{"prompt": "Resume el contrato"}`, headers); task.Cached || task.Status != models.StatusError {
+		t.Errorf("tarea con no-cache: estado %s, cached %v", task.Status, task.Cached)
+	}
+	if task := createFinishedTask(t, f, `{"prompt": "Resume el contrato"}`, bearer(f.keyB)); task.Cached || task.Result == "Resumen guardado" {
+		t.Errorf("otra organización recibió la respuesta en caché")
+	}
+}
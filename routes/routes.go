@@ -1,21 +1,157 @@
 package routes
 
 import (
+	"os"
+	"time"
+
 	"github.com/Efren-Garza-Z/go-api-gemini/controllers"
+	"github.com/Efren-Garza-Z/go-api-gemini/middleware"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/ratelimit"
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterUserRoutes(r *gin.Engine) {
-	users := r.Group("/users")
-	gemini := r.Group("/gemini")
+// legacyDeprecatedAt es la fecha en que la API sin versión quedó obsoleta al publicarse /v1.
+var legacyDeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// defaultLegacySunset es la fecha de retiro de la API sin versión si no se define LEGACY_API_SUNSET.
+var defaultLegacySunset = time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC)
+
+// legacySunset devuelve la fecha de retiro configurada en LEGACY_API_SUNSET (YYYY-MM-DD).
+func legacySunset() time.Time {
+	if v := os.Getenv("LEGACY_API_SUNSET"); v != "" {
+		if t, err := time.Parse(time.DateOnly, v); err == nil {
+			return t
+		}
+	}
+	return defaultLegacySunset
+}
+
+// RegisterRoutes registra la API /v1 y los alias obsoletos sin versión.
+func RegisterRoutes(r *gin.Engine, quotas ratelimit.Quotas) {
+	quota := middleware.Quota(quotas)
+
+	registerV1(r.Group("/v1"), quota)
+	registerLegacy(r, quota)
+}
+
+func registerV1(v1 *gin.RouterGroup, quota gin.HandlerFunc) {
+	// Cada ruta exige un permiso del rol de la petición (ver models.Role); self permite además
+	// que un usuario consulte o modifique sus propios datos.
+	can := middleware.Authorize
+	self := middleware.AuthorizeSelfOr
+	create, read := can(models.PermTasksCreate), can(models.PermTasksRead)
+	readContent, writeContent := can(models.PermContentRead), can(models.PermContentWrite)
+	manageUsers, manageOrgs := can(models.PermUsersManage), can(models.PermOrgsManage)
+	readAudit := can(models.PermAuditRead)
+	// Las tareas, lotes, colecciones, plantillas y API keys son de la organización de la
+	// petición (ver middleware.CurrentOrg); la cuota también se cuenta por organización.
+	org := middleware.CurrentOrg()
+	// Los accesos a datos y las operaciones de seguridad quedan en el registro de auditoría
+	// (ver middleware.Audit), también cuando se rechazan por falta de permisos.
+	audit := middleware.Audit
+
+	users := v1.Group("/users")
+	gemini := v1.Group("/gemini", org)
+	collections := v1.Group("/collections", org)
+	templates := v1.Group("/templates", org)
+	orgs := v1.Group("/orgs")
+	admin := v1.Group("/admin")
+	// Las rutas de auth son para usuarios que aún no pueden identificarse: no exigen permisos.
+	auth := v1.Group("/auth")
 	{
-		users.GET("/:id", controllers.GetUserByID)
-		users.POST("", controllers.CreateUser)
+		users.GET("", audit("user.list", ""), manageUsers, controllers.ListUsers)
+		users.GET("/:id", audit("user.read", "id"), self(models.PermUsersRead), controllers.GetUserByID)
+		users.POST("", audit("user.create", ""), controllers.CreateUser)
+		users.PATCH("/:id", audit("user.update", "id"), self(models.PermUsersManage), controllers.UpdateUser)
+		users.DELETE("/:id", audit("user.delete", "id"), manageUsers, controllers.DeleteUser)
+		users.POST("/:id/restore", audit("user.restore", "id"), manageUsers, controllers.RestoreUser)
+		users.GET("/:id/usage", audit("user.usage_read", "id"), self(models.PermUsersRead), controllers.GetUserUsage)
+		users.POST("/:id/api-keys", audit("api_key.create", ""), self(models.PermUsersManage), org, controllers.CreateAPIKey)
+		users.GET("/:id/api-keys", audit("api_key.list", ""), self(models.PermUsersManage), controllers.ListAPIKeys)
+		users.GET("/:id/api-keys/:key_id", audit("api_key.read", "key_id"), self(models.PermUsersManage), controllers.GetAPIKey)
+		users.DELETE("/:id/api-keys/:key_id", audit("api_key.revoke", "key_id"), self(models.PermUsersManage), controllers.RevokeAPIKey)
+		users.POST("/:id/api-keys/:key_id/rotate", audit("api_key.rotate", "key_id"), self(models.PermUsersManage), controllers.RotateAPIKey)
+
+		gemini.POST("/tasks", audit("task.create", ""), create, quota, controllers.CreateTask)
+		gemini.GET("/tasks/events", read, controllers.TaskEvents)
+		gemini.GET("/tasks/:id", audit("task.read", "id"), read, controllers.GetTask)
+		gemini.DELETE("/tasks/:id", audit("task.delete", "id"), create, controllers.DeleteTask)
+		gemini.POST("/file-tasks", audit("file_task.create", ""), create, quota, controllers.CreateFileTask)
+		gemini.GET("/file-tasks/:id", audit("file_task.read", "id"), read, controllers.GetFileTask)
+		gemini.DELETE("/file-tasks/:id", audit("file_task.delete", "id"), create, controllers.DeleteFileTask)
+		gemini.GET("/schemas", read, controllers.ListSchemas)
+		gemini.GET("/tools", read, controllers.ListTools)
+		gemini.POST("/embeddings", create, quota, controllers.CreateEmbeddings)
+		gemini.POST("/search", audit("embedding.search", ""), read, controllers.SearchEmbeddings)
+		gemini.POST("/batches", audit("batch.create", ""), create, quota, controllers.CreateBatch)
+		gemini.GET("/batches/:id", audit("batch.read", "id"), read, controllers.GetBatch)
+		gemini.GET("/batches/:id/results", audit("batch.results_read", "id"), read, controllers.GetBatchResults)
 
-		gemini.POST("/process", controllers.ProcessPrompt)
-		gemini.POST("/process/file", controllers.GenerateWithFileController)
-		gemini.GET("/status/:gemini_processing_id", controllers.GetTaskStatus)
-		gemini.GET("/status-file/:gemini_processing_id", controllers.GetGeminiProcessStatus)
+		collections.POST("", audit("collection.create", ""), writeContent, controllers.CreateCollection)
+		collections.GET("", audit("collection.list", ""), readContent, controllers.ListCollections)
+		collections.GET("/:id", audit("collection.read", "id"), readContent, controllers.GetCollection)
+		collections.DELETE("/:id", audit("collection.delete", "id"), writeContent, controllers.DeleteCollection)
+		collections.POST("/:id/documents", audit("document.create", ""), writeContent, quota, controllers.UploadDocument)
+		collections.GET("/:id/documents", audit("document.list", ""), readContent, controllers.ListDocuments)
+		collections.GET("/:id/documents/:document_id", audit("document.read", "document_id"), readContent, controllers.GetDocument)
+		collections.DELETE("/:id/documents/:document_id", audit("document.delete", "document_id"), writeContent, controllers.DeleteDocument)
+		collections.POST("/:id/query", audit("collection.query", "id"), create, quota, controllers.QueryCollection)
+
+		templates.POST("", audit("template.create", ""), writeContent, controllers.CreatePromptTemplate)
+		templates.GET("", readContent, controllers.ListPromptTemplates)
+		templates.GET("/:name", readContent, controllers.GetPromptTemplate)
+		templates.DELETE("/:name", audit("template.delete", "name"), writeContent, controllers.DeletePromptTemplate)
+		templates.POST("/:name/versions", audit("template.version_create", "name"), writeContent, controllers.CreatePromptTemplateVersion)
+		templates.GET("/:name/versions", readContent, controllers.ListPromptTemplateVersions)
+		templates.GET("/:name/versions/:version", readContent, controllers.GetPromptTemplateVersion)
+
+		orgs.POST("", audit("org.create", ""), manageOrgs, controllers.CreateOrganization)
+		orgs.GET("", controllers.ListOrganizations)
+		orgs.GET("/:org_id", audit("org.read", "org_id"), controllers.GetOrganization)
+		orgs.PATCH("/:org_id", audit("org.update", "org_id"), manageOrgs, controllers.UpdateOrganization)
+		orgs.GET("/:org_id/members", audit("org_member.list", ""), controllers.ListOrgMembers)
+		orgs.POST("/:org_id/members", audit("org_member.add", ""), manageOrgs, controllers.AddOrgMember)
+		orgs.PATCH("/:org_id/members/:user_id", audit("org_member.update", "user_id"), manageOrgs, controllers.UpdateOrgMember)
+		orgs.DELETE("/:org_id/members/:user_id", audit("org_member.remove", "user_id"), controllers.RemoveOrgMember)
+
+		admin.GET("/tasks", audit("task.list_all", ""), can(models.PermTasksReadAll), controllers.ListAllTasks)
+		admin.PUT("/tasks/:id/legal-hold", audit("task.legal_hold", "id"), can(models.PermTasksLegalHold), controllers.SetTaskLegalHold)
+		admin.PUT("/users/:id/role", audit("user.role_update", "id"), manageUsers, controllers.UpdateUserRole)
+		admin.GET("/audit-events", audit("audit.list", ""), readAudit, controllers.ListAuditEvents)
+		admin.GET("/audit-events/export", audit("audit.export", ""), readAudit, controllers.ExportAuditEvents)
+
+		auth.POST("/login", audit("user.login", ""), controllers.Login)
+		auth.POST("/email/verify", audit("user.email_verify", ""), controllers.VerifyEmail)
+		auth.POST("/email/verification", audit("user.verification_resend", ""), controllers.ResendVerification)
+		auth.POST("/password/forgot", audit("user.password_forgot", ""), controllers.ForgotPassword)
+		auth.POST("/password/reset", audit("user.password_reset", ""), controllers.ResetPassword)
+		auth.GET("/oidc/login", controllers.OIDCLogin)
+		auth.GET("/oidc/callback", audit("user.oidc_login", ""), controllers.OIDCCallback)
+	}
+}
+
+// registerLegacy mantiene las rutas originales; cada respuesta indica la ruta de /v1 que la reemplaza.
+func registerLegacy(r *gin.Engine, quota gin.HandlerFunc) {
+	sunset := legacySunset()
+	deprecated := func(successor string) gin.HandlerFunc {
+		return middleware.Deprecated(legacyDeprecatedAt, sunset, successor)
+	}
+	// Los mismos permisos que las rutas /v1 equivalentes.
+	self := middleware.AuthorizeSelfOr
+	create, read := middleware.Authorize(models.PermTasksCreate), middleware.Authorize(models.PermTasksRead)
+	audit := middleware.Audit
+
+	users := r.Group("/users")
+	gemini := r.Group("/gemini", middleware.CurrentOrg())
+	{
+		users.GET("/:id", deprecated("/v1/users/:id"), audit("user.read", "id"), self(models.PermUsersRead), controllers.GetUserByIDLegacy)
+		users.POST("", deprecated("/v1/users"), audit("user.create", ""), controllers.CreateUserLegacy)
+		users.GET("/:id/usage", deprecated("/v1/users/:id/usage"), audit("user.usage_read", "id"), self(models.PermUsersRead), controllers.GetUserUsageLegacy)
 
+		gemini.POST("/process", deprecated("/v1/gemini/tasks"), audit("task.create", ""), create, quota, controllers.ProcessPrompt)
+		gemini.POST("/process/file", deprecated("/v1/gemini/file-tasks"), audit("file_task.create", ""), create, quota, controllers.GenerateWithFileController)
+		gemini.GET("/status/:id", deprecated("/v1/gemini/tasks/:id"), audit("task.read", "id"), read, controllers.GetTaskStatus)
+		gemini.GET("/status-file/:id", deprecated("/v1/gemini/file-tasks/:id"), audit("file_task.read", "id"), read, controllers.GetGeminiProcessStatus)
 	}
 }
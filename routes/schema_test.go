@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+)
+
+func TestTaskSchemasAreValidatedBeforeCreatingTheTask(t *testing.T) {
+	f := newTenantFixture(t)
+	tests := []struct {
+		name, body, code string
+		status           int
+	}{
+		{"esquema inválido", `{"prompt": "hola", "response_schema": {"type": "numero"}}`, "invalid_schema", http.StatusBadRequest},
+		{"esquema sin registrar", `{"prompt": "hola", "schema": "no-existe"}`, "schema_not_found", http.StatusBadRequest},
+		{"esquema y nombre a la vez", `{"prompt": "hola", "schema": "factura", "response_schema": {"type": "object"}}`, "invalid_request", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := f.postJSON("/v1/gemini/tasks", tt.body, bearer(f.keyA))
+		var body models.ErrorResponse
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		if rec.Code != tt.status || body.Code != tt.code {
+			t.Errorf("%s: código %d %s, se esperaba %d %s", tt.name, rec.Code, body.Code, tt.status, tt.code)
+		}
+	}
+	var count int64
+	f.conn.Model(&models.GeminiProcessingDB{}).Where("user_id = ?", f.userA.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("se crearon %d tareas con esquemas rechazados", count)
+	}
+}
+
+func TestSchemaValidationFailureIsReportedOnTheTask(t *testing.T) {
+	f := newTenantFixture(t)
+	id := "5e6f7a8b-9c0d-4e1f-8a2b-3c4d5e6f7a8b"
+	mustCreate(t, f.conn, &models.GeminiProcessingDB{ID: id, Prompt: "p", Status: models.StatusError, UserID: &f.userA.ID, OrgID: &f.orgA,
+		ErrorCode: "schema_validation_failed", Error: "la respuesta no cumple el esquema",
+		ErrorDetails: models.JSON(`[{"path": "/total", "keyword": "type", "message": "got string, want number"}]`)})
+
+	rec := f.get("/v1/gemini/tasks/"+id, bearer(f.keyA))
+	var task models.GeminiProcessingResponse
+	json.Unmarshal(rec.Body.Bytes(), &task)
+	if rec.Code != http.StatusOK || task.Error == nil || task.Error.Code != "schema_validation_failed" {
+		t.Fatalf("tarea: código %d: %s", rec.Code, rec.Body.String())
+	}
+	var violations []map[string]string
+	json.Unmarshal(task.Error.Details, &violations)
+	if len(violations) != 1 || violations[0]["path"] != "/total" {
+		t.Errorf("details = %s, se esperaba el reporte de validación", task.Error.Details)
+	}
+}
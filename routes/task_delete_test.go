@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apikey"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+)
+
+// createScopedKey crea una API key de userID limitada a scopes.
+func createScopedKey(t *testing.T, f tenantFixture, userID uint, scopes string) string {
+	t.Helper()
+	secret, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		t.Fatalf("apikey.Generate: %v", err)
+	}
+	mustCreate(t, f.conn, &models.APIKeyDB{UserID: userID, Name: "scoped", Prefix: prefix, Hash: hash, Scopes: models.JSON(scopes)})
+	return secret
+}
+
+func TestDeleteTaskPermissions(t *testing.T) {
+	f := newTenantFixture(t)
+	path := "/v1/gemini/tasks/" + f.taskB
+	orgB := map[string]string{"X-Org-ID": strconv.FormatUint(uint64(f.orgB), 10)}
+	withOrg := func(key string) map[string]string {
+		headers := bearer(key)
+		headers["X-Org-ID"] = orgB["X-Org-ID"]
+		return headers
+	}
+
+	// Otro miembro de la organización de B puede leer las tareas, pero no eliminar las de B.
+	mustCreate(t, f.conn, &models.OrgMemberDB{OrgID: f.orgB, UserID: f.userA.ID, Role: models.OrgRoleMember})
+	if code := f.do(http.MethodDelete, path, withOrg(f.keyA)); code != http.StatusForbidden {
+		t.Errorf("eliminar la tarea de otro miembro: código %d, se esperaba 403", code)
+	}
+
+	// El dueño necesita tasks:create en los scopes de su key también para sus propias tareas.
+	readOnly := createScopedKey(t, f, f.userB.ID, `["tasks:read"]`)
+	if code := f.do(http.MethodDelete, path, bearer(readOnly)); code != http.StatusForbidden {
+		t.Errorf("eliminar la tarea propia con una key de solo lectura: código %d, se esperaba 403", code)
+	}
+
+	// Un administrador necesita tasks:delete_all, también en los scopes de su key.
+	admin, _ := createTenantUser(t, f.conn, "admin@example.com")
+	f.conn.Model(&admin).Update("role", models.RoleAdmin)
+	if code := f.do(http.MethodDelete, path, withOrg(createScopedKey(t, f, admin.ID, `["tasks:create", "tasks:read_all"]`))); code != http.StatusForbidden {
+		t.Errorf("administrador con una key sin tasks:delete_all: código %d, se esperaba 403", code)
+	}
+	if code := f.do(http.MethodDelete, path, withOrg(createTenantKey(t, f.conn, admin.ID, nil))); code != http.StatusNoContent {
+		t.Errorf("administrador: código %d, se esperaba 204", code)
+	}
+	if code := f.do(http.MethodDelete, path, bearer(f.keyB)); code != http.StatusNotFound {
+		t.Errorf("eliminar una tarea ya eliminada: código %d, se esperaba 404", code)
+	}
+}
@@ -0,0 +1,93 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/controllers"
+	"github.com/Efren-Garza-Z/go-api-gemini/events"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/gorilla/websocket"
+)
+
+// useTaskEvents configura un Hub de eventos mientras dure la prueba.
+func useTaskEvents(t *testing.T) *events.Hub {
+	t.Helper()
+	hub := events.NewHub()
+	controllers.SetTaskEvents(hub)
+	t.Cleanup(func() { controllers.SetTaskEvents(nil) })
+	return hub
+}
+
+func TestLongPollingReturnsWhenTheTaskChanges(t *testing.T) {
+	f := newTenantFixture(t)
+	hub := useTaskEvents(t)
+	id := "1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"
+	mustCreate(t, f.conn, &models.GeminiProcessingDB{ID: id, Prompt: "p", Status: models.StatusProcessing, UserID: &f.userA.ID, OrgID: &f.orgA})
+
+	// El worker termina la tarea mientras el cliente espera.
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		f.conn.Model(&models.GeminiProcessingDB{}).Where("id = ?", id).Update("status", models.StatusCompleted)
+		hub.Publish(models.TaskStatusEvent{ID: id, Kind: models.TaskKindPrompt, Status: models.StatusCompleted})
+	}()
+	start := time.Now()
+	rec := f.get("/v1/gemini/tasks/"+id+"?wait=10s", bearer(f.keyA))
+	var task models.GeminiProcessingResponse
+	json.Unmarshal(rec.Body.Bytes(), &task)
+	if rec.Code != http.StatusOK || task.Status != models.StatusCompleted {
+		t.Fatalf("long-polling: código %d, estado %q", rec.Code, task.Status)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("long-polling respondió después de %v, no al cambiar el estado", elapsed)
+	}
+
+	// Una tarea terminada se responde sin esperar.
+	start = time.Now()
+	if code := f.do(http.MethodGet, "/v1/gemini/tasks/"+id+"?wait=10s", bearer(f.keyA)); code != http.StatusOK || time.Since(start) > 5*time.Second {
+		t.Errorf("tarea terminada: código %d en %v", code, time.Since(start))
+	}
+	for _, wait := range []string{"pronto", "-5s"} {
+		if code := f.do(http.MethodGet, "/v1/gemini/tasks/"+id+"?wait="+wait, bearer(f.keyA)); code != http.StatusBadRequest {
+			t.Errorf("wait=%s: código %d, se esperaba 400", wait, code)
+		}
+	}
+}
+
+func TestTaskEventsWebSocket(t *testing.T) {
+	f := newTenantFixture(t)
+	hub := useTaskEvents(t)
+	id := "1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"
+	mustCreate(t, f.conn, &models.GeminiProcessingDB{ID: id, Prompt: "p", Status: models.StatusPending, UserID: &f.userA.ID, OrgID: &f.orgA})
+
+	server := httptest.NewServer(f.router)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/v1/gemini/tasks/events?ids=" + id + "," + f.taskB
+	header := http.Header{"Authorization": {bearer(f.keyA)["Authorization"]}}
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatalf("abrir el WebSocket: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	// Primero llega el estado actual de las tareas propias; la de otra organización se ignora.
+	var event models.TaskStatusEvent
+	if err := conn.ReadJSON(&event); err != nil || event.ID != id || event.Status != models.StatusPending {
+		t.Fatalf("estado inicial: %+v, err = %v", event, err)
+	}
+	hub.Publish(models.TaskStatusEvent{ID: f.taskB, Kind: models.TaskKindPrompt, Status: models.StatusError})
+	hub.Publish(models.TaskStatusEvent{ID: id, Kind: models.TaskKindPrompt, Status: models.StatusCompleted})
+	if err := conn.ReadJSON(&event); err != nil || event.ID != id || event.Status != models.StatusCompleted || event.StatusLabel == "" {
+		t.Fatalf("cambio de estado: %+v, err = %v", event, err)
+	}
+
+	// Sin API key no se abre.
+	if _, resp, err := websocket.DefaultDialer.Dial(url, nil); err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("WebSocket anónimo: err = %v, respuesta %v", err, resp)
+	}
+}
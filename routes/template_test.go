@@ -0,0 +1,85 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+)
+
+func TestPromptTemplateVersionsAndTasks(t *testing.T) {
+	f := newTenantFixture(t)
+	headers := bearer(f.keyA)
+
+	rec := f.postJSON("/v1/templates", `{"name": "factura", "body": "Resume {{.factura}} en {{.idioma}}", "defaults": {"idioma": "español"}}`, headers)
+	var created models.PromptTemplate
+	json.Unmarshal(rec.Body.Bytes(), &created)
+	if rec.Code != http.StatusCreated || created.Version != 1 || !slices.Equal(created.Variables, []string{"factura", "idioma"}) {
+		t.Fatalf("crear plantilla: código %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := f.postJSON("/v1/templates", `{"name": "factura", "body": "otra"}`, headers); rec.Code != http.StatusConflict {
+		t.Errorf("crear una plantilla con el mismo nombre: código %d, se esperaba 409", rec.Code)
+	}
+	rec = f.postJSON("/v1/templates/factura/versions", `{"body": "Resume {{.factura}} en {{.idioma}}, breve", "system_instruction": "Eres contador", "defaults": {"idioma": "español"}}`, headers)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("crear la versión 2: código %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = f.get("/v1/templates/factura", headers)
+	var latest models.PromptTemplate
+	json.Unmarshal(rec.Body.Bytes(), &latest)
+	if latest.Version != 2 {
+		t.Errorf("versión más reciente = %d, se esperaba 2", latest.Version)
+	}
+
+	// Las tareas con plantilla guardan el prompt generado y la versión usada.
+	rec = f.postJSON("/v1/gemini/tasks", `{"template": "factura", "version": 1, "variables": {"factura": "F-1", "idioma": "inglés"}}`, headers)
+	var task models.TaskCreatedResponse
+	json.Unmarshal(rec.Body.Bytes(), &task)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("crear tarea con plantilla: código %d: %s", rec.Code, rec.Body.String())
+	}
+	var stored models.GeminiProcessingDB
+	f.conn.Select("id", "prompt", "template_name", "template_version", "system_instruction").First(&stored, "id = ?", task.ID)
+	if stored.Prompt != "Resume F-1 en inglés" || stored.TemplateName != "factura" || stored.TemplateVersion == nil || *stored.TemplateVersion != 1 {
+		t.Errorf("tarea: prompt %q, plantilla %q versión %v", stored.Prompt, stored.TemplateName, stored.TemplateVersion)
+	}
+	rec = f.postJSON("/v1/gemini/tasks", `{"template": "factura", "variables": {"factura": "F-2"}}`, headers)
+	json.Unmarshal(rec.Body.Bytes(), &task)
+	var latestTask models.GeminiProcessingDB
+	f.conn.Select("id", "prompt", "template_version", "system_instruction").First(&latestTask, "id = ?", task.ID)
+	if latestTask.Prompt != "Resume F-2 en español, breve" || latestTask.TemplateVersion == nil || *latestTask.TemplateVersion != 2 ||
+		latestTask.SystemInstruction != "Eres contador" {
+		t.Errorf("tarea con la última versión: prompt %q, versión %v, instrucción %q",
+			latestTask.Prompt, latestTask.TemplateVersion, latestTask.SystemInstruction)
+	}
+}
+
+func TestPromptTemplateErrors(t *testing.T) {
+	f := newTenantFixture(t)
+	headers := bearer(f.keyA)
+	mustCreate(t, f.conn, &models.PromptTemplateDB{Name: "factura", Version: 1, Body: "Resume {{.factura}}", OrgID: &f.orgA})
+
+	tests := []struct {
+		name, path, body, code string
+		status                 int
+	}{
+		{"plantilla mal formada", "/v1/templates", `{"name": "rota", "body": "{{.factura"}`, "invalid_template", http.StatusBadRequest},
+		{"falta una variable", "/v1/gemini/tasks", `{"template": "factura"}`, "template_render_failed", http.StatusBadRequest},
+		{"versión inexistente", "/v1/gemini/tasks", `{"template": "factura", "version": 9, "variables": {"factura": "F"}}`, "template_not_found", http.StatusNotFound},
+		{"plantilla de otra organización", "/v1/gemini/tasks", `{"template": "factura", "variables": {"factura": "F"}}`, "template_not_found", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		h := headers
+		if tt.name == "plantilla de otra organización" {
+			h = bearer(f.keyB)
+		}
+		rec := f.postJSON(tt.path, tt.body, h)
+		var body models.ErrorResponse
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		if rec.Code != tt.status || body.Code != tt.code {
+			t.Errorf("%s: código %d %s, se esperaba %d %s", tt.name, rec.Code, body.Code, tt.status, tt.code)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/apikey"
+	"github.com/Efren-Garza-Z/go-api-gemini/controllers"
+	"github.com/Efren-Garza-Z/go-api-gemini/db/dbtest"
+	"github.com/Efren-Garza-Z/go-api-gemini/middleware"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/ratelimit"
+	"github.com/Efren-Garza-Z/go-api-gemini/tenant"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// tenantFixture son dos usuarios, cada uno con su organización personal y una API key de
+// sesión, y datos de la organización de B.
+type tenantFixture struct {
+	router       *gin.Engine
+	conn         *gorm.DB
+	userA, userB models.UserDB
+	orgA, orgB   uint
+	keyA, keyB   string
+	orgKeyA      string // key de A limitada a su organización
+	taskB        string
+	collectionB  uint
+	batchB       string
+}
+
+func newTenantFixture(t *testing.T) tenantFixture {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	conn := dbtest.Open(t, &models.UserDB{}, &models.OrganizationDB{}, &models.OrgMemberDB{}, &models.APIKeyDB{},
+		&models.GeminiProcessingDB{}, &models.GeminiProcessingFileDB{}, &models.CollectionDB{}, &models.DocumentDB{},
+		&models.DocumentChunkDB{}, &models.BatchDB{}, &models.PromptTemplateDB{}, &models.AuditEventDB{}, &models.UserTokenDB{})
+	controllers.SetDB(conn)
+	t.Cleanup(func() { controllers.SetDB(nil) })
+
+	f := tenantFixture{conn: conn}
+	f.userA, f.orgA = createTenantUser(t, conn, "a@example.com")
+	f.userB, f.orgB = createTenantUser(t, conn, "b@example.com")
+	f.keyA = createTenantKey(t, conn, f.userA.ID, nil)
+	f.keyB = createTenantKey(t, conn, f.userB.ID, nil)
+	f.orgKeyA = createTenantKey(t, conn, f.userA.ID, &f.orgA)
+
+	f.taskB = "6f1c2d3e-4a5b-4c6d-8e7f-9a0b1c2d3e4f"
+	mustCreate(t, conn, &models.GeminiProcessingDB{ID: f.taskB, Prompt: "prompt de B", Status: models.StatusCompleted, UserID: &f.userB.ID, OrgID: &f.orgB})
+	collection := models.CollectionDB{Name: "docs", UserID: &f.userB.ID, OrgID: &f.orgB}
+	mustCreate(t, conn, &collection)
+	f.collectionB = collection.ID
+	f.batchB = "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"
+	mustCreate(t, conn, &models.BatchDB{ID: f.batchB, Source: "json", Total: 1, UserID: &f.userB.ID, OrgID: &f.orgB})
+
+	f.router = gin.New()
+	f.router.Use(middleware.CurrentUser())
+	RegisterRoutes(f.router, ratelimit.Quotas{})
+	return f
+}
+
+func createTenantUser(t *testing.T, conn *gorm.DB, email string) (models.UserDB, uint) {
+	t.Helper()
+	user := models.UserDB{FullName: email, Email: email, Role: models.RoleMember}
+	mustCreate(t, conn, &user)
+	if err := tenant.CreatePersonalOrg(conn, user); err != nil {
+		t.Fatalf("CreatePersonalOrg: %v", err)
+	}
+	var member models.OrgMemberDB
+	if err := conn.Where("user_id = ?", user.ID).First(&member).Error; err != nil {
+		t.Fatalf("buscar la organización de %s: %v", email, err)
+	}
+	return user, member.OrgID
+}
+
+func createTenantKey(t *testing.T, conn *gorm.DB, userID uint, orgID *uint) string {
+	t.Helper()
+	secret, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		t.Fatalf("apikey.Generate: %v", err)
+	}
+	mustCreate(t, conn, &models.APIKeyDB{UserID: userID, OrgID: orgID, Name: "test", Prefix: prefix, Hash: hash})
+	return secret
+}
+
+func mustCreate(t *testing.T, conn *gorm.DB, value interface{}) {
+	t.Helper()
+	if err := conn.Create(value).Error; err != nil {
+		t.Fatalf("crear %T: %v", value, err)
+	}
+}
+
+// do envía la petición con los encabezados indicados y devuelve el código de respuesta.
+func (f tenantFixture) do(method, path string, headers map[string]string) int {
+	return f.request(method, path, headers).Code
+}
+
+// get envía un GET con los encabezados indicados y devuelve la respuesta.
+func (f tenantFixture) get(path string, headers map[string]string) *httptest.ResponseRecorder {
+	return f.request(http.MethodGet, path, headers)
+}
+
+func (f tenantFixture) request(method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func bearer(key string) map[string]string {
+	return map[string]string{"Authorization": "Bearer " + key}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	f := newTenantFixture(t)
+	orgB := strconv.FormatUint(uint64(f.orgB), 10)
+	userB := strconv.FormatUint(uint64(f.userB.ID), 10)
+	collectionB := strconv.FormatUint(uint64(f.collectionB), 10)
+
+	// Control: B ve sus propios datos, así que los rechazos de abajo se deben a la organización.
+	if code := f.do(http.MethodGet, "/v1/gemini/tasks/"+f.taskB, bearer(f.keyB)); code != http.StatusOK {
+		t.Fatalf("B leyendo su tarea: código %d, se esperaba 200", code)
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		want    []int
+	}{
+		{"tarea de B desde la organización de A", http.MethodGet, "/v1/gemini/tasks/" + f.taskB, bearer(f.keyA), []int{http.StatusNotFound}},
+		{"eliminar la tarea de B", http.MethodDelete, "/v1/gemini/tasks/" + f.taskB, bearer(f.keyA), []int{http.StatusNotFound}},
+		{"lote de B", http.MethodGet, "/v1/gemini/batches/" + f.batchB, bearer(f.keyA), []int{http.StatusNotFound}},
+		{"colección de B", http.MethodGet, "/v1/collections/" + collectionB, bearer(f.keyA), []int{http.StatusNotFound}},
+		{"documentos de la colección de B", http.MethodGet, "/v1/collections/" + collectionB + "/documents", bearer(f.keyA), []int{http.StatusNotFound}},
+		{"elegir la organización de B con X-Org-ID", http.MethodGet, "/v1/gemini/tasks/" + f.taskB,
+			map[string]string{"Authorization": "Bearer " + f.keyA, middleware.OrgIDHeader: orgB}, []int{http.StatusForbidden}},
+		{"key de la organización de A con X-Org-ID de B", http.MethodGet, "/v1/gemini/tasks/" + f.taskB,
+			map[string]string{"Authorization": "Bearer " + f.orgKeyA, middleware.OrgIDHeader: orgB}, []int{http.StatusForbidden}},
+		{"organización de B", http.MethodGet, "/v1/orgs/" + orgB, bearer(f.keyA), []int{http.StatusForbidden, http.StatusNotFound}},
+		{"miembros de la organización de B", http.MethodGet, "/v1/orgs/" + orgB + "/members", bearer(f.keyA), []int{http.StatusForbidden, http.StatusNotFound}},
+		{"consumo de B", http.MethodGet, "/v1/users/" + userB + "/usage", bearer(f.keyA), []int{http.StatusForbidden}},
+		{"API keys de B", http.MethodGet, "/v1/users/" + userB + "/api-keys", bearer(f.keyA), []int{http.StatusForbidden}},
+		{"anónimo con X-Org-ID de B", http.MethodGet, "/v1/gemini/tasks/" + f.taskB,
+			map[string]string{middleware.OrgIDHeader: orgB}, []int{http.StatusUnauthorized}},
+		{"anónimo con X-User-ID de B", http.MethodGet, "/v1/gemini/tasks/" + f.taskB,
+			map[string]string{middleware.UserIDHeader: userB}, []int{http.StatusUnauthorized}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := f.do(tt.method, tt.path, tt.headers)
+			for _, want := range tt.want {
+				if code == want {
+					return
+				}
+			}
+			t.Errorf("código %d, se esperaba %v", code, tt.want)
+		})
+	}
+
+	var count int64
+	f.conn.Model(&models.GeminiProcessingDB{}).Where("id = ?", f.taskB).Count(&count)
+	if count != 1 {
+		t.Errorf("la tarea de B ya no existe después del DELETE desde la organización de A")
+	}
+}
@@ -0,0 +1,125 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/middleware"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/ratelimit"
+	"github.com/Efren-Garza-Z/go-api-gemini/telemetry"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/plugin/opentelemetry/tracing"
+)
+
+// La traza de una tarea empieza en la petición HTTP que la crea y sigue en el worker, con las
+// consultas a la base de datos como hijas del span que las ejecuta.
+func TestTaskTraceFollowsTheRequestIntoTheWorker(t *testing.T) {
+	// Sin credenciales de Gemini la tarea falla enseguida, después de pasar por el worker.
+	t.Setenv("GEMINI_API_KEY", "")
+	t.Setenv("GOOGLE_GENAI_USE_VERTEXAI", "")
+	f := newTenantFixture(t)
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	if err := f.conn.Use(tracing.NewPlugin(tracing.WithTracerProvider(provider), tracing.WithoutQueryVariables(), tracing.WithoutMetrics())); err != nil {
+		t.Fatalf("registrar el plugin de trazas: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(otelgin.Middleware(telemetry.ServiceName, otelgin.WithTracerProvider(provider)), middleware.CurrentUser())
+	RegisterRoutes(router, ratelimit.Quotas{})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/gemini/tasks", strings.NewReader(`{"prompt": "hola"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+f.keyA)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("crear la tarea: código %d: %s", rec.Code, rec.Body.String())
+	}
+	var created models.TaskCreatedResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("respuesta inválida: %v", err)
+	}
+
+	// El span del worker termina después de guardar el resultado de la tarea.
+	var spans tracetest.SpanStubs
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		spans = exporter.GetSpans()
+		if findSpan(spans, "gemini.task.process") != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("el worker no terminó la tarea; spans: %v", spanNames(spans))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	request := findSpan(spans, "/v1/gemini/tasks")
+	if request == nil {
+		t.Fatalf("falta el span de la petición HTTP; spans: %v", spanNames(spans))
+	}
+	task := findSpan(spans, "gemini.task.process")
+	if task.Parent.SpanID() != request.SpanContext.SpanID() || task.SpanContext.TraceID() != request.SpanContext.TraceID() {
+		t.Errorf("el span del worker no es hijo del de la petición")
+	}
+
+	if generate := findSpan(spans, "gemini.GenerateContent"); generate == nil || generate.Parent.SpanID() != task.SpanContext.SpanID() {
+		t.Errorf("la llamada a Gemini no es hija del span del worker")
+	}
+
+	// La tarea se guarda desde la petición y se toma y actualiza desde el worker.
+	var requestQueries, workerQueries int
+	for _, span := range spans {
+		if !strings.HasPrefix(span.Name, "gorm.") || span.SpanContext.TraceID() != request.SpanContext.TraceID() {
+			continue
+		}
+		switch span.Parent.SpanID() {
+		case request.SpanContext.SpanID():
+			requestQueries++
+		case task.SpanContext.SpanID():
+			workerQueries++
+		}
+	}
+	if requestQueries == 0 {
+		t.Errorf("no hay consultas hijas del span de la petición; spans: %v", spanNames(spans))
+	}
+	if workerQueries < 2 {
+		t.Errorf("%d consultas hijas del span del worker, se esperaban al menos 2 (tomar y actualizar la tarea)", workerQueries)
+	}
+
+	var stored models.GeminiProcessingDB
+	if err := f.conn.First(&stored, "id = ?", created.ID).Error; err != nil || stored.Status != models.StatusError {
+		t.Errorf("tarea = %+v, err = %v; se esperaba que el worker la terminara con error", stored.Status, err)
+	}
+}
+
+func findSpan(spans tracetest.SpanStubs, name string) *tracetest.SpanStub {
+	for i := range spans {
+		if strings.HasSuffix(spans[i].Name, name) {
+			return &spans[i]
+		}
+	}
+	return nil
+}
+
+func spanNames(spans tracetest.SpanStubs) []string {
+	names := make([]string, len(spans))
+	for i, span := range spans {
+		names[i] = span.Name
+	}
+	return names
+}
@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+)
+
+func TestUserUsageByModel(t *testing.T) {
+	f := newTenantFixture(t)
+	tasks := []models.GeminiProcessingDB{
+		{ID: "u1", Model: "gemini-2.0-flash", PromptTokens: 10, CandidateTokens: 20, TotalTokens: 30, EstimatedCost: 0.5},
+		{ID: "u2", Model: "gemini-2.0-flash", PromptTokens: 1, CandidateTokens: 2, TotalTokens: 3, EstimatedCost: 0.25},
+		{ID: "u3", PromptTokens: 5, TotalTokens: 5},
+	}
+	for i := range tasks {
+		tasks[i].Prompt, tasks[i].Status = "p", models.StatusCompleted
+		tasks[i].UserID, tasks[i].OrgID = &f.userA.ID, &f.orgA
+		mustCreate(t, f.conn, &tasks[i])
+	}
+	mustCreate(t, f.conn, &models.GeminiProcessingFileDB{ID: "uf", Prompt: "p", Status: models.StatusCompleted,
+		UserID: &f.userA.ID, OrgID: &f.orgA, Model: "gemini-2.5-pro", TotalTokens: 100, EstimatedCost: 1})
+
+	rec := f.get(fmt.Sprintf("/v1/users/%d/usage?group_by=model", f.userA.ID), bearer(f.keyA))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("consumo: código %d: %s", rec.Code, rec.Body.String())
+	}
+	var report models.UsageReport
+	json.Unmarshal(rec.Body.Bytes(), &report)
+
+	want := map[string]models.UsageItem{
+		"gemini-2.0-flash": {Requests: 2, PromptTokens: 11, CandidateTokens: 22, TotalTokens: 33, EstimatedCost: 0.75},
+		"gemini-2.5-pro":   {Requests: 1, TotalTokens: 100, EstimatedCost: 1},
+		"unknown":          {Requests: 1, PromptTokens: 5, TotalTokens: 5},
+	}
+	if len(report.Items) != len(want) {
+		t.Fatalf("items = %+v, se esperaban %d modelos", report.Items, len(want))
+	}
+	for _, item := range report.Items {
+		expected := want[item.Key]
+		expected.Key = item.Key
+		if item != expected {
+			t.Errorf("item %s = %+v, se esperaba %+v", item.Key, item, expected)
+		}
+	}
+	if report.Total.Requests != 4 || report.Total.TotalTokens != 138 || report.Total.EstimatedCost != 1.75 {
+		t.Errorf("total = %+v", report.Total)
+	}
+}
+
+func TestUserUsageRejectsInvalidParameters(t *testing.T) {
+	f := newTenantFixture(t)
+	base := fmt.Sprintf("/v1/users/%d/usage", f.userA.ID)
+	for _, query := range []string{"?group_by=hora", "?from=ayer", "?from=2025-02-01&to=2025-01-01"} {
+		if code := f.do(http.MethodGet, base+query, bearer(f.keyA)); code != http.StatusBadRequest {
+			t.Errorf("%s: código %d, se esperaba 400", query, code)
+		}
+	}
+	if code := f.do(http.MethodGet, fmt.Sprintf("/v1/users/%d/usage", f.userB.ID), bearer(f.keyA)); code != http.StatusForbidden {
+		t.Errorf("consumo de otro usuario: código %d, se esperaba 403", code)
+	}
+}
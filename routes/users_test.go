@@ -0,0 +1,154 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+)
+
+// sendJSON envía body como JSON con el método y los encabezados indicados.
+func (f tenantFixture) sendJSON(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// makeAdmin da a A el rol de administrador.
+func makeAdmin(t *testing.T, f tenantFixture) {
+	t.Helper()
+	if err := f.conn.Model(&f.userA).Update("role", models.RoleAdmin).Error; err != nil {
+		t.Fatalf("dar el rol admin: %v", err)
+	}
+}
+
+// listUserEmails recorre todas las páginas del listado y devuelve los emails en orden.
+func listUserEmails(t *testing.T, f tenantFixture, query url.Values) []string {
+	t.Helper()
+	var emails []string
+	for page := 0; ; page++ {
+		rec := f.get("/v1/users?"+query.Encode(), bearer(f.keyA))
+		var list models.UserList
+		if err := json.Unmarshal(rec.Body.Bytes(), &list); rec.Code != http.StatusOK || err != nil {
+			t.Fatalf("listar usuarios: código %d: %s", rec.Code, rec.Body.String())
+		}
+		for _, user := range list.Items {
+			emails = append(emails, user.Email)
+		}
+		if list.NextCursor == "" || page > 10 {
+			return emails
+		}
+		query.Set("cursor", list.NextCursor)
+	}
+}
+
+func TestListUsersPagesWithACursor(t *testing.T) {
+	f := newTenantFixture(t)
+	makeAdmin(t, f)
+	for _, name := range []string{"Carla", "Beto", "Ana"} {
+		mustCreate(t, f.conn, &models.UserDB{FullName: name, Email: strings.ToLower(name) + "@example.org"})
+	}
+
+	byID := listUserEmails(t, f, url.Values{"limit": {"2"}})
+	if want := []string{"a@example.com", "b@example.com", "carla@example.org", "beto@example.org", "ana@example.org"}; fmt.Sprint(byID) != fmt.Sprint(want) {
+		t.Errorf("orden por ID = %v, se esperaba %v", byID, want)
+	}
+	// Los nombres de A y B son sus emails en minúsculas, que van después de las mayúsculas.
+	byName := listUserEmails(t, f, url.Values{"limit": {"2"}, "sort": {"-full_name"}})
+	if want := []string{"b@example.com", "a@example.com", "carla@example.org", "beto@example.org", "ana@example.org"}; fmt.Sprint(byName) != fmt.Sprint(want) {
+		t.Errorf("orden por nombre descendente = %v, se esperaba %v", byName, want)
+	}
+
+	// El cursor de un orden no sirve para otro.
+	rec := f.get("/v1/users?limit=1&sort=email", bearer(f.keyA))
+	var list models.UserList
+	json.Unmarshal(rec.Body.Bytes(), &list)
+	for _, query := range []string{
+		"sort=id&cursor=" + list.NextCursor,
+		"cursor=no-es-un-cursor",
+		"sort=password",
+		"limit=0",
+		"limit=101",
+		"deleted=quizas",
+	} {
+		if code := f.do(http.MethodGet, "/v1/users?"+query, bearer(f.keyA)); code != http.StatusBadRequest {
+			t.Errorf("?%s: código %d, se esperaba 400", query, code)
+		}
+	}
+
+	// Solo los administradores listan usuarios.
+	if code := f.do(http.MethodGet, "/v1/users", bearer(f.keyB)); code != http.StatusForbidden {
+		t.Errorf("listar usuarios como member: código %d, se esperaba 403", code)
+	}
+}
+
+func TestUpdateUserRejectsAnEmailInUse(t *testing.T) {
+	f := newTenantFixture(t)
+	path := fmt.Sprintf("/v1/users/%d", f.userA.ID)
+
+	rec := f.sendJSON(http.MethodPatch, path, `{"full_name": "Ana Pérez", "email": "Ana@Example.net"}`, bearer(f.keyA))
+	var user models.User
+	json.Unmarshal(rec.Body.Bytes(), &user)
+	if rec.Code != http.StatusOK || user.FullName != "Ana Pérez" || user.Email != "ana@example.net" {
+		t.Fatalf("actualizar el perfil: código %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// El email de otro usuario, aunque esté eliminado, no se puede usar.
+	if rec := f.sendJSON(http.MethodPatch, path, `{"email": "B@example.com"}`, bearer(f.keyA)); rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "email_taken") {
+		t.Errorf("email de otro usuario: código %d: %s", rec.Code, rec.Body.String())
+	}
+	f.conn.Delete(&f.userB)
+	if code := f.sendJSON(http.MethodPatch, path, `{"email": "b@example.com"}`, bearer(f.keyA)).Code; code != http.StatusConflict {
+		t.Errorf("email de un usuario eliminado: código %d, se esperaba 409", code)
+	}
+	if code := f.sendJSON(http.MethodPatch, path, `{"email": "no-es-un-email"}`, bearer(f.keyA)).Code; code != http.StatusBadRequest {
+		t.Errorf("email inválido: código %d, se esperaba 400", code)
+	}
+}
+
+func TestDeletedUsersCanBeRestored(t *testing.T) {
+	f := newTenantFixture(t)
+	makeAdmin(t, f)
+	path := fmt.Sprintf("/v1/users/%d", f.userB.ID)
+
+	if code := f.do(http.MethodDelete, path, bearer(f.keyA)); code != http.StatusNoContent {
+		t.Fatalf("eliminar el usuario: código %d", code)
+	}
+	if code := f.do(http.MethodGet, path, bearer(f.keyA)); code != http.StatusNotFound {
+		t.Errorf("consultar un usuario eliminado: código %d, se esperaba 404", code)
+	}
+	// Sus datos se conservan y sus API keys dejan de funcionar.
+	var stored models.UserDB
+	if err := f.conn.Unscoped().First(&stored, f.userB.ID).Error; err != nil || !stored.DeletedAt.Valid {
+		t.Fatalf("el usuario no quedó marcado como eliminado: %v", err)
+	}
+	if code := f.do(http.MethodGet, path, bearer(f.keyB)); code != http.StatusUnauthorized {
+		t.Errorf("API key de un usuario eliminado: código %d, se esperaba 401", code)
+	}
+	if emails := listUserEmails(t, f, url.Values{"deleted": {"true"}}); fmt.Sprint(emails) != "[b@example.com]" {
+		t.Errorf("usuarios eliminados = %v", emails)
+	}
+
+	if code := f.do(http.MethodPost, path+"/restore", bearer(f.keyA)); code != http.StatusOK {
+		t.Fatalf("restaurar el usuario: código %d", code)
+	}
+	if code := f.do(http.MethodGet, path, bearer(f.keyB)); code != http.StatusOK {
+		t.Errorf("API key del usuario restaurado: código %d, se esperaba 200", code)
+	}
+	if code := f.do(http.MethodPost, "/v1/users/999/restore", bearer(f.keyA)); code != http.StatusNotFound {
+		t.Errorf("restaurar un usuario inexistente: código %d, se esperaba 404", code)
+	}
+	if code := f.do(http.MethodDelete, fmt.Sprintf("/v1/users/%d", f.userA.ID), bearer(f.keyB)); code != http.StatusForbidden {
+		t.Errorf("eliminar a otro usuario como member: código %d, se esperaba 403", code)
+	}
+}
@@ -0,0 +1,98 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName es el nombre con el que se reportan las trazas si no se define OTEL_SERVICE_NAME.
+const ServiceName = "go-api-gemini"
+
+// tracerName identifica al instrumentador propio de la aplicación.
+const tracerName = "github.com/Efren-Garza-Z/go-api-gemini"
+
+// Tracer devuelve el tracer de la aplicación a partir del proveedor global.
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Setup configura el proveedor de trazas global según OTEL_TRACES_EXPORTER:
+// "otlp" (por defecto, usa las variables OTEL_EXPORTER_OTLP_*), "console" o "none".
+// Devuelve una función para vaciar y cerrar el exportador al apagar el servidor.
+func Setup(ctx context.Context) (func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch os.Getenv("OTEL_TRACES_EXPORTER") {
+	case "", "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	case "console":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "none":
+		return func(context.Context) error { return nil }, nil
+	default:
+		return nil, fmt.Errorf("OTEL_TRACES_EXPORTER no soportado: %s", os.Getenv("OTEL_TRACES_EXPORTER"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creando exportador de trazas: %w", err)
+	}
+
+	return SetupWithExporter(ctx, exporter)
+}
+
+// SetupWithExporter registra un proveedor de trazas que envía los spans al exportador dado.
+// Permite usar, por ejemplo, tracetest.NewInMemoryExporter() en pruebas.
+func SetupWithExporter(ctx context.Context, exporter sdktrace.SpanExporter) (func(context.Context) error, error) {
+	// OTEL_SERVICE_NAME y OTEL_RESOURCE_ATTRIBUTES tienen prioridad sobre el nombre por defecto.
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(ServiceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creando recurso de telemetría: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return provider.Shutdown, nil
+}
+
+// InjectTraceContext serializa el contexto de traza activo en formato W3C para
+// guardarlo junto a la tarea y continuar la traza desde el worker.
+func InjectTraceContext(ctx context.Context) (traceParent string, traceState string) {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get("traceparent"), carrier.Get("tracestate")
+}
+
+// ExtractTraceContext reconstruye un contexto a partir de los valores guardados en la tarea.
+// Si los valores están vacíos devuelve el contexto recibido sin cambios.
+func ExtractTraceContext(ctx context.Context, traceParent string, traceState string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+	carrier := propagation.MapCarrier{"traceparent": traceParent}
+	if traceState != "" {
+		carrier.Set("tracestate", traceState)
+	}
+	return propagation.TraceContext{}.Extract(ctx, carrier)
+}
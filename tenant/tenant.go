@@ -0,0 +1,96 @@
+// Package tenant prepara la base de datos para varias organizaciones: crea la organización
+// por defecto y le asigna los datos y los usuarios creados antes de que existieran las
+// organizaciones, para que sigan disponibles. También crea la organización personal de cada
+// usuario nuevo.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"gorm.io/gorm"
+)
+
+// DefaultSlug es el slug de la organización por defecto.
+const DefaultSlug = "default"
+
+// personalSlugPattern son los slugs reservados para las organizaciones personales.
+var personalSlugPattern = regexp.MustCompile(`^user-[0-9]+$`)
+
+// IsPersonalSlug indica si el slug tiene la forma reservada para las organizaciones personales.
+func IsPersonalSlug(slug string) bool {
+	return personalSlugPattern.MatchString(slug)
+}
+
+// CreatePersonalOrg crea la organización personal de un usuario nuevo (slug user-{id}), con el
+// usuario como owner.
+func CreatePersonalOrg(tx *gorm.DB, user models.UserDB) error {
+	org := models.OrganizationDB{Name: user.FullName, Slug: fmt.Sprintf("user-%d", user.ID)}
+	if err := tx.Create(&org).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.OrgMemberDB{OrgID: org.ID, UserID: user.ID, Role: models.OrgRoleOwner}).Error
+}
+
+// scopedTables son las tablas con datos de una organización (columna org_id).
+var scopedTables = []string{
+	models.GeminiProcessingDB{}.TableName(),
+	models.GeminiProcessingFileDB{}.TableName(),
+	models.BatchDB{}.TableName(),
+	models.CollectionDB{}.TableName(),
+	models.PromptTemplateDB{}.TableName(),
+	models.EmbeddingDB{}.TableName(),
+}
+
+// legacyIndexes son los índices únicos globales que reemplazaron los índices por organización.
+var legacyIndexes = []string{
+	"gemini.idx_gemini_collections_name",
+	"gemini.idx_prompt_templates_name_version",
+	"gemini.idx_embeddings_namespace_external_id",
+}
+
+// Bootstrap crea la organización por defecto si no existe y le asigna las filas sin
+// organización. Al crearla agrega como miembros a todos los usuarios existentes (los
+// administradores como owner); los usuarios posteriores se agregan a sus organizaciones desde
+// la API. Se ejecuta al arrancar, después de migrar los modelos.
+func Bootstrap(ctx context.Context, conn *gorm.DB) (models.OrganizationDB, error) {
+	var org models.OrganizationDB
+	err := conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		created := false
+		err := tx.Where("slug = ?", DefaultSlug).First(&org).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			org = models.OrganizationDB{Name: "Default", Slug: DefaultSlug}
+			err = tx.Create(&org).Error
+			created = true
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, table := range scopedTables {
+			if err := tx.Exec(fmt.Sprintf("UPDATE %s SET org_id = ? WHERE org_id IS NULL", table), org.ID).Error; err != nil {
+				return err
+			}
+		}
+		for _, index := range legacyIndexes {
+			if err := tx.Exec("DROP INDEX IF EXISTS " + index).Error; err != nil {
+				return err
+			}
+		}
+		if !created {
+			return nil
+		}
+
+		members := models.OrgMemberDB{}.TableName()
+		return tx.Exec(fmt.Sprintf(`
+			INSERT INTO %s (org_id, user_id, role, created_at)
+			SELECT ?, u.id, CASE WHEN u.role = ? THEN ? ELSE ? END, NOW()
+			FROM %s u
+			ON CONFLICT DO NOTHING`, members, models.UserDB{}.TableName()),
+			org.ID, models.RoleAdmin, models.OrgRoleOwner, models.OrgRoleMember).Error
+	})
+	return org, err
+}
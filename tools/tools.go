@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/gemini"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+	"gorm.io/gorm"
+)
+
+// Caller es quien creó la tarea que usa una herramienta: su organización y su usuario. Las
+// herramientas solo devuelven los datos que ese usuario podría consultar en la API.
+type Caller struct {
+	OrgID  *uint
+	UserID *uint
+}
+
+// callerKey es la clave del contexto donde se guarda el Caller.
+type callerKey struct{}
+
+// WithCaller devuelve un contexto con el creador de la tarea, para las herramientas que
+// Gemini llame mientras la procesa.
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// callerFrom devuelve el creador de la tarea guardado con WithCaller.
+func callerFrom(ctx context.Context) (Caller, bool) {
+	caller, ok := ctx.Value(callerKey{}).(Caller)
+	return caller, ok
+}
+
+// Register agrega al registro las herramientas internas que Gemini puede usar en las tareas.
+func Register(registry *gemini.ToolRegistry, db *gorm.DB) error {
+	return registry.Register(gemini.Tool{
+		Name:        "get_user",
+		Description: "Busca un usuario de la organización por su ID y devuelve su nombre completo y email.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {"id": {"type": "integer", "minimum": 1, "description": "ID del usuario"}},
+			"required": ["id"]
+		}`),
+		Func: getUser(db),
+	})
+}
+
+// getUser devuelve la herramienta get_user. Con los mismos permisos que la API, el creador de
+// la tarea ve a los miembros de la organización de la tarea si él también lo es, y a
+// cualquier usuario si su rol tiene users:read. Si el usuario no existe o no se puede ver
+// responde found=false, para que el modelo lo explique en lugar de fallar la tarea.
+func getUser(db *gorm.DB) gemini.ToolFunc {
+	return func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+		id, ok := args["id"].(float64)
+		if !ok {
+			return nil, fmt.Errorf("id inválido: %v", args["id"])
+		}
+		notFound := map[string]interface{}{"found": false}
+
+		caller, ok := callerFrom(ctx)
+		if !ok || caller.OrgID == nil || caller.UserID == nil {
+			return notFound, nil
+		}
+		var creator models.UserDB
+		err := db.WithContext(ctx).First(&creator, *caller.UserID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return notFound, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		query := db.WithContext(ctx)
+		if !creator.Role.Can(models.PermUsersRead) {
+			var memberships int64
+			err := db.WithContext(ctx).Model(&models.OrgMemberDB{}).
+				Where("org_id = ? AND user_id = ?", *caller.OrgID, creator.ID).
+				Count(&memberships).Error
+			if err != nil {
+				return nil, err
+			}
+			if memberships == 0 {
+				return notFound, nil
+			}
+			query = query.Where("id IN (?)", db.Model(&models.OrgMemberDB{}).Select("user_id").Where("org_id = ?", *caller.OrgID))
+		}
+
+		var user models.UserDB
+		err = query.First(&user, uint(id)).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return notFound, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"found":     true,
+			"id":        user.ID,
+			"full_name": user.FullName,
+			"email":     user.Email,
+		}, nil
+	}
+}
@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/db/dbtest"
+	"github.com/Efren-Garza-Z/go-api-gemini/models"
+)
+
+func TestGetUserScope(t *testing.T) {
+	conn := dbtest.Open(t, &models.UserDB{}, &models.OrgMemberDB{})
+	users := map[string]*models.UserDB{
+		"admin": {FullName: "Admin", Email: "admin@example.com", Role: models.RoleAdmin},
+		"ana":   {FullName: "Ana", Email: "ana@example.com", Role: models.RoleMember},
+		"beto":  {FullName: "Beto", Email: "beto@example.com", Role: models.RoleMember},
+		"caro":  {FullName: "Caro", Email: "caro@example.com", Role: models.RoleMember},
+	}
+	for _, name := range []string{"admin", "ana", "beto", "caro"} {
+		if err := conn.Create(users[name]).Error; err != nil {
+			t.Fatalf("crear %s: %v", name, err)
+		}
+	}
+	org1, org2 := uint(1), uint(2)
+	for _, m := range []models.OrgMemberDB{
+		{OrgID: org1, UserID: users["ana"].ID, Role: models.OrgRoleMember},
+		{OrgID: org1, UserID: users["beto"].ID, Role: models.OrgRoleMember},
+		{OrgID: org2, UserID: users["caro"].ID, Role: models.OrgRoleMember},
+	} {
+		if err := conn.Create(&m).Error; err != nil {
+			t.Fatalf("crear miembro: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		caller *Caller
+		target string
+		found  bool
+	}{
+		{"miembro de la misma organización", &Caller{OrgID: &org1, UserID: &users["ana"].ID}, "beto", true},
+		{"a sí mismo", &Caller{OrgID: &org1, UserID: &users["ana"].ID}, "ana", true},
+		{"miembro de otra organización", &Caller{OrgID: &org1, UserID: &users["ana"].ID}, "caro", false},
+		{"tarea de una organización ajena", &Caller{OrgID: &org2, UserID: &users["ana"].ID}, "caro", false},
+		{"administrador", &Caller{OrgID: &org1, UserID: &users["admin"].ID}, "caro", true},
+		{"tarea anónima", &Caller{OrgID: &org1}, "beto", false},
+		{"sin creador en el contexto", nil, "beto", false},
+	}
+	tool := getUser(conn)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.caller != nil {
+				ctx = WithCaller(ctx, *tt.caller)
+			}
+			result, err := tool(ctx, map[string]interface{}{"id": float64(users[tt.target].ID)})
+			if err != nil {
+				t.Fatalf("get_user: %v", err)
+			}
+			if result["found"] != tt.found {
+				t.Errorf("found = %v, se esperaba %v", result["found"], tt.found)
+			}
+			if tt.found && result["email"] != users[tt.target].Email {
+				t.Errorf("email = %v, se esperaba %s", result["email"], users[tt.target].Email)
+			}
+		})
+	}
+}
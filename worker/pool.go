@@ -0,0 +1,86 @@
+// Package worker ejecuta los trabajos en segundo plano (tareas de Gemini, lotes,
+// indexación de documentos) con una cantidad fija de goroutines, para no saturar la
+// API de Gemini ni la base de datos cuando llegan muchas tareas a la vez.
+package worker
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+)
+
+// Valores por defecto del pool.
+const (
+	defaultPoolSize  = 8
+	defaultQueueSize = 100
+)
+
+// PoolSize devuelve la cantidad de workers configurada en WORKER_POOL_SIZE.
+func PoolSize() int {
+	if v, err := strconv.Atoi(os.Getenv("WORKER_POOL_SIZE")); err == nil && v > 0 {
+		return v
+	}
+	return defaultPoolSize
+}
+
+// QueueSize devuelve la cantidad de trabajos en espera configurada en WORKER_QUEUE_SIZE.
+func QueueSize() int {
+	if v, err := strconv.Atoi(os.Getenv("WORKER_QUEUE_SIZE")); err == nil && v >= 0 {
+		return v
+	}
+	return defaultQueueSize
+}
+
+// Pool ejecuta trabajos con una cantidad fija de workers.
+type Pool struct {
+	jobs chan func()
+}
+
+// NewPool inicia size workers que toman trabajos de una cola de queueSize elementos.
+func NewPool(size int, queueSize int) *Pool {
+	p := &Pool{jobs: make(chan func(), queueSize)}
+	for range size {
+		go p.run()
+	}
+	return p
+}
+
+// Submit encola un trabajo; si la cola está llena espera a que se libere un lugar.
+// Con un Pool nil el trabajo se ejecuta en una goroutine propia.
+func (p *Pool) Submit(job func()) {
+	if p == nil {
+		go job()
+		return
+	}
+	p.jobs <- job
+}
+
+// TrySubmit encola un trabajo sin esperar; devuelve false si la cola está llena. Los
+// handlers HTTP lo usan para no quedar bloqueados cuando un lote grande llena la cola.
+// Con un Pool nil el trabajo se ejecuta en una goroutine propia.
+func (p *Pool) TrySubmit(job func()) bool {
+	if p == nil {
+		go job()
+		return true
+	}
+	select {
+	case p.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// run ejecuta los trabajos de la cola. Un pánico en un trabajo se registra y no detiene al worker.
+func (p *Pool) run() {
+	for job := range p.jobs {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					slog.Error("pánico en un trabajo en segundo plano", "panic", r)
+				}
+			}()
+			job()
+		}()
+	}
+}